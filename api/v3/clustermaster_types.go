@@ -50,6 +50,10 @@ type ClusterMasterStatus struct {
 	// current phase of the cluster manager
 	Phase Phase `json:"phase"`
 
+	// Stage of the ordered upgrade of the Splunk stack: the stage the custom resource is waiting for, or its own stage while its
+	// statefulset is upgraded
+	UpgradeStage string `json:"upgradeStage,omitempty"`
+
 	// selector for pods, used by HorizontalPodAutoscaler
 	Selector string `json:"selector"`
 
@@ -64,6 +68,16 @@ type ClusterMasterStatus struct {
 
 	// App Framework status
 	AppContext AppDeploymentContext `json:"appContext"`
+
+	// Objects exposing the Splunk Web and HEC ports
+	Expose []ExposedEndpoint `json:"expose,omitempty"`
+
+	// NetworkPolicies generated for the Splunk pods
+	NetworkPolicies []string `json:"networkPolicies,omitempty"`
+
+	// Conditions of the custom resource, such as DriftDetected
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // BundlePushInfo Indicates if bundle push required
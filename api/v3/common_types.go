@@ -17,6 +17,7 @@ package v3
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 )
 
 const (
@@ -125,6 +126,20 @@ const (
 	PhaseError Phase = "Error"
 )
 
+// DriftPolicy is the policy applied to the changes of the Kubernetes objects managed by the operator made by someone else
+type DriftPolicy string
+
+const (
+	// DriftPolicyEnforce reverts the changes to the desired state of the objects
+	DriftPolicyEnforce DriftPolicy = "Enforce"
+
+	// DriftPolicyReportOnly keeps the changes, and only reports them
+	DriftPolicyReportOnly DriftPolicy = "ReportOnly"
+)
+
+// ConditionDriftDetected is the type of the status condition reporting the drift of the Kubernetes objects managed by the operator
+const ConditionDriftDetected = "DriftDetected"
+
 // CommonSplunkSpec defines the desired state of parameters that are common across all Splunk Enterprise CRD types
 type CommonSplunkSpec struct {
 	Spec `json:",inline"`
@@ -159,7 +174,8 @@ type CommonSplunkSpec struct {
 	// ClusterMasterRef refers to a Splunk Enterprise indexer cluster managed by the operator within Kubernetes
 	ClusterMasterRef corev1.ObjectReference `json:"clusterMasterRef"`
 
-	// MonitoringConsoleRef refers to a Splunk Enterprise monitoring console managed by the operator within Kubernetes
+	// MonitoringConsoleRef refers to a Splunk Enterprise monitoring console managed by the operator within Kubernetes.
+	// The monitoring console can be in another namespace, the namespace defaults to the namespace of the custom resource
 	MonitoringConsoleRef corev1.ObjectReference `json:"monitoringConsoleRef"`
 
 	// Mock to differentiate between UTs and actual reconcile
@@ -187,6 +203,187 @@ type CommonSplunkSpec struct {
 	// Sets imagePullSecrets if image is being pulled from a private registry.
 	// See https://kubernetes.io/docs/tasks/configure-pod-container/pull-image-private-registry/
 	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// Expose Splunk Web and HEC outside of the Kubernetes cluster, with an Ingress, OpenShift Route or Gateway API routes
+	// +optional
+	Expose ExposeSpec `json:"expose,omitempty"`
+
+	// Generate NetworkPolicies restricting the traffic to the Splunk pods, based on the topology of the custom resources
+	// +optional
+	NetworkPolicy NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// Additional ports of the Splunk instances, or overrides of the default ports (http-splunkweb, https-splunkd, http-hec and tcp-s2s).
+	// The ports are added to the containers, the services, the Istio annotations and the generated expose objects
+	// +optional
+	Ports []SplunkPort `json:"ports,omitempty"`
+
+	// Upgrade the Splunk stack in the supported order when the image changes: license manager, cluster manager, search heads,
+	// indexers and monitoring console. The statefulset is only updated to the new image once the custom resources of the
+	// previous stages are ready on it
+	// +optional
+	OrderedUpgrade bool `json:"orderedUpgrade,omitempty"`
+
+	// Policy applied when the Kubernetes objects managed by the operator were modified by someone else. Enforce (default)
+	// reverts the changes, ReportOnly keeps them. In both cases the drift is reported with events and a DriftDetected condition
+	// +kubebuilder:validation:Enum="";Enforce;ReportOnly
+	// +optional
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+}
+
+// SplunkPort defines an additional port of the Splunk instances, or overrides a default port with the same name
+type SplunkPort struct {
+	// Name of the port, prefixed with its application protocol like tcp-syslog
+	// +kubebuilder:validation:MaxLength=15
+	Name string `json:"name"`
+
+	// Port number, required for the additional ports. The port must also be configured in Splunk, for instance with the defaults
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Protocol of the port, TCP or UDP. Defaults to TCP
+	// +kubebuilder:validation:Enum="";TCP;UDP
+	// +optional
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+
+	// Remove a default port from the containers and services, like http-splunkweb on indexers. The splunkd port can't be disabled
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// NetworkPolicySpec defines the NetworkPolicies generated for the Splunk pods
+type NetworkPolicySpec struct {
+	// Generate the NetworkPolicies, the traffic to the Splunk pods isn't restricted by the operator when false
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Sources allowed to send data over S2S (port 9997) and the additional ports, in addition to the Splunk pods of the namespace
+	// +optional
+	S2SFrom []networkingv1.NetworkPolicyPeer `json:"s2sFrom,omitempty"`
+
+	// Sources allowed to send data to HEC (port 8088), HEC isn't reachable when empty
+	// +optional
+	HECFrom []networkingv1.NetworkPolicyPeer `json:"hecFrom,omitempty"`
+
+	// Sources allowed to access Splunk Web (port 8000), Splunk Web is reachable from anywhere when empty
+	// +optional
+	WebFrom []networkingv1.NetworkPolicyPeer `json:"webFrom,omitempty"`
+}
+
+// ExposeSpec defines the Ingress, OpenShift Route or Gateway API routes generated for Splunk Web and HEC
+type ExposeSpec struct {
+	// Type of the generated objects: Ingress, Route (OpenShift) or Gateway (Gateway API HTTPRoute/TLSRoute).
+	// Nothing is exposed when empty
+	// +kubebuilder:validation:Enum="";Ingress;Route;Gateway
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Name of the IngressClass used by the generated Ingresses, defaults to the cluster default IngressClass
+	// +optional
+	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// Gateway the generated Gateway API routes are attached to
+	// +optional
+	GatewayRef ExposeGatewayRef `json:"gatewayRef,omitempty"`
+
+	// Additional annotations for the generated objects
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Splunk Web endpoint
+	// +optional
+	Web ExposeEndpointSpec `json:"web,omitempty"`
+
+	// HTTP Event Collector endpoint
+	// +optional
+	HEC ExposeEndpointSpec `json:"hec,omitempty"`
+}
+
+// ExposeEndpointSpec defines how a Splunk endpoint is exposed
+type ExposeEndpointSpec struct {
+	// Host name of the endpoint. The endpoint is not exposed when empty
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// Secret holding the TLS certificate and key for the host, used by the Ingress and the Route to terminate TLS
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+
+	// Pass the TLS connections through to Splunk, with a passthrough Route or a TLSRoute
+	// +optional
+	TLSPassthrough bool `json:"tlsPassthrough,omitempty"`
+}
+
+// ExposedEndpoint defines an object generated to expose a Splunk port
+type ExposedEndpoint struct {
+	// Exposed Splunk port name
+	Port string `json:"port"`
+
+	// Host name of the exposed port
+	Host string `json:"host"`
+
+	// API version of the object
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the object
+	Kind string `json:"kind"`
+
+	// Name of the object
+	Name string `json:"name"`
+}
+
+// ExposeGatewayRef refers to a Gateway API Gateway
+type ExposeGatewayRef struct {
+	// Name of the Gateway
+	Name string `json:"name,omitempty"`
+
+	// Namespace of the Gateway, defaults to the namespace of the custom resource
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Listener of the Gateway the routes are attached to
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// AutoscalingSpec defines the bounds and the cooldown periods of the autoscaling of Splunk instances
+type AutoscalingSpec struct {
+	// Scale the instances from the metrics reported by Splunk, replicas is ignored when true.
+	// Must not be used together with a HorizontalPodAutoscaler
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Minimum number of replicas, defaults to 1
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas int32 `json:"minReplicas,omitempty"`
+
+	// Maximum number of replicas
+	// +kubebuilder:validation:Minimum=0
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+
+	// Minimum time between a scaling event and a scale up, in seconds. Defaults to 300
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ScaleUpCooldownSeconds int32 `json:"scaleUpCooldownSeconds,omitempty"`
+
+	// Minimum time between a scaling event and a scale down, in seconds. Defaults to 1800
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ScaleDownCooldownSeconds int32 `json:"scaleDownCooldownSeconds,omitempty"`
+}
+
+// AutoscalingStatus defines the observed state of the autoscaling of Splunk instances
+type AutoscalingStatus struct {
+	// Number of replicas decided by the autoscaling
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+
+	// Time of the last scaling event, in epoch seconds
+	LastScaleTime int64 `json:"lastScaleTime,omitempty"`
+
+	// Reason of the last scaling event
+	LastScaleReason string `json:"lastScaleReason,omitempty"`
 }
 
 // StorageClassSpec defines storage class configuration
@@ -257,10 +454,10 @@ type VolumeSpec struct {
 	// Secret object name
 	SecretRef string `json:"secretRef"`
 
-	// Remote Storage type. Supported values: s3
+	// Remote Storage type. Supported values: s3, http, oci
 	Type string `json:"storageType"`
 
-	// App Package Remote Store provider. Supported values: aws, minio
+	// App Package Remote Store provider. Supported values: aws, minio for s3, http for http, oci for oci
 	Provider string `json:"provider"`
 
 	// Region of the remote storage volume where apps reside
@@ -364,6 +561,10 @@ type AppFrameworkSpec struct {
 
 	// Maximum number of apps that can be downloaded at same time
 	MaxConcurrentAppDownloads uint64 `json:"maxConcurrentAppDownloads,omitempty"`
+
+	// Compute the App changes from the remote storage, without installing them.
+	// The plan is reported in the CR status and in a configMap.
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // AppDeploymentInfo represents a single App deployment information
@@ -383,6 +584,15 @@ type AppDeploymentInfo struct {
 	// Each Pod's phase info is mapped to its ordinal value.
 	// Ignored, once the DeployStatus is marked as Complete
 	AuxPhaseInfo []PhaseInfo `json:"auxPhaseInfo,omitempty"`
+
+	// App directory name inside the app package, used to resolve the app dependencies
+	AppID string `json:"appID,omitempty"`
+
+	// Apps to be installed before this app, as declared in the app.manifest or app.conf of the app package
+	Dependencies []string `json:"dependencies,omitempty"`
+
+	// Reason the app can not be installed due to its dependencies, such as a dependency cycle or a missing dependency
+	DependencyError string `json:"dependencyError,omitempty"`
 }
 
 // AppSrcDeployInfo represents deployment info for list of Apps
@@ -413,6 +623,11 @@ type BundlePushTracker struct {
 	RetryCount int32 `json:"retryCount,omitempty"`
 }
 
+const (
+	// AppFrameworkDryRunAnnotation enables the App Framework dry-run mode on a CR. Changing its value recomputes the plan
+	AppFrameworkDryRunAnnotation = "enterprise.splunk.com/app-framework-dry-run"
+)
+
 const (
 	// AfwPhase2 represents Phase-2 app framework
 	AfwPhase2 uint16 = iota
@@ -435,9 +650,16 @@ type AppDeploymentContext struct {
 	// List of App package (*.spl, *.tgz) locations on remote volume
 	AppFrameworkConfig AppFrameworkSpec `json:"appRepo,omitempty"`
 
-	// Represents the Apps deployment status
+	// Represents the Apps deployment status. Kept in the App deployment status configMap,
+	// and only reported here by the operators not having the configMap
 	AppsSrcDeployStatus map[string]AppSrcDeployInfo `json:"appSrcDeployStatus,omitempty"`
 
+	// Name of the configMap holding the Apps deployment status
+	AppDeployStatusConfigMap string `json:"appDeployStatusConfigMap,omitempty"`
+
+	// Summary of the Apps deployment status
+	Summary AppDeploymentSummary `json:"summary,omitempty"`
+
 	// This is set to the time when we get the list of apps from remote storage.
 	LastAppInfoCheckTime int64 `json:"lastAppInfoCheckTime"`
 
@@ -451,6 +673,96 @@ type AppDeploymentContext struct {
 
 	// Internal to the App framework. Used in case of CM(IDXC) and deployer(SHC)
 	BundlePushStatus BundlePushTracker `json:"bundlePushStatus,omitempty"`
+
+	// App changes computed in the dry-run mode
+	DryRunPlan *AppFrameworkPlan `json:"dryRunPlan,omitempty"`
+}
+
+// AppDeploymentSummary represents the summary of the Apps deployment status
+type AppDeploymentSummary struct {
+	// Number of active Apps across all the App sources
+	TotalApps int32 `json:"totalApps"`
+
+	// Number of Apps with the deployment complete
+	CompletedApps int32 `json:"completedApps"`
+
+	// Number of Apps with the deployment yet to complete
+	PendingApps int32 `json:"pendingApps"`
+
+	// Number of Apps failed to deploy
+	FailedApps int32 `json:"failedApps"`
+
+	// Details of the failed Apps
+	Failures []AppDeploymentFailure `json:"failures,omitempty"`
+}
+
+// AppDeploymentFailure represents the details of an App failed to deploy
+type AppDeploymentFailure struct {
+	// App source name
+	AppSource string `json:"appSource"`
+
+	// App package name
+	AppName string `json:"appName"`
+
+	// Phase where the App deployment failed
+	Phase AppPhaseType `json:"phase,omitempty"`
+
+	// Pod where the App deployment failed, if applicable to the phase
+	Pod string `json:"pod,omitempty"`
+
+	// Status of the phase
+	Status string `json:"status,omitempty"`
+
+	// Number of failed attempts
+	FailCount uint32 `json:"failCount,omitempty"`
+
+	// Last error message
+	Message string `json:"message,omitempty"`
+}
+
+// AppSourcePlan represents the App changes for an App source
+type AppSourcePlan struct {
+	// App source name
+	Name string `json:"name"`
+
+	// Scope of the App source
+	Scope string `json:"scope,omitempty"`
+
+	// Apps to be installed
+	AppsToAdd []string `json:"appsToAdd,omitempty"`
+
+	// Apps to be updated with a newer package
+	AppsToUpdate []string `json:"appsToUpdate,omitempty"`
+
+	// Apps to be deleted/disabled, as they are missing on the remote storage
+	AppsToDelete []string `json:"appsToDelete,omitempty"`
+}
+
+// AppFrameworkPlan represents the App changes the App framework would apply, as computed in the dry-run mode
+type AppFrameworkPlan struct {
+	// Time when the plan was computed
+	PlanTime int64 `json:"planTime"`
+
+	// CR generation and dry-run annotation value, the plan was computed for
+	Trigger string `json:"trigger,omitempty"`
+
+	// App changes for each App source
+	AppSources []AppSourcePlan `json:"appSources,omitempty"`
+
+	// Pods where the App packages will be copied and installed
+	AffectedPods []string `json:"affectedPods,omitempty"`
+
+	// Indicates if a bundle push is needed for the cluster scoped apps
+	BundlePushRequired bool `json:"bundlePushRequired"`
+
+	// Indicates if the changes may need a Splunk restart
+	RestartRequired bool `json:"restartRequired"`
+
+	// ConfigMap with the detailed plan
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// Error found while computing the plan
+	Error string `json:"error,omitempty"`
 }
 
 // AppPhaseStatusType defines the Phase status
@@ -478,6 +790,8 @@ type PhaseInfo struct {
 	Status AppPhaseStatusType `json:"status,omitempty"`
 	// represents number of failures
 	FailCount uint32 `json:"failCount,omitempty"`
+	// last error message of the phase
+	LastError string `json:"lastError,omitempty"`
 }
 
 const (
@@ -511,6 +825,8 @@ const (
 	AppPkgInstallInProgress = 302
 	// AppPkgInstallComplete indicates complete
 	AppPkgInstallComplete = 303
+	// AppPkgDependencyError indicates app pkg can not be installed due to its dependencies
+	AppPkgDependencyError = 397
 	// AppPkgMissingOnPodError indicates app pkg is not available on Pod for install
 	AppPkgMissingOnPodError = 398
 	// AppPkgInstallError indicates error after retries
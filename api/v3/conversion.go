@@ -0,0 +1,128 @@
+/*
+Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v3
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// v4SpecFields maps the fields of the spec renamed in v4 to their v4 name
+var v4SpecFields = map[string]string{
+	"clusterMasterRef": "clusterManagerRef",
+	"licenseMasterRef": "licenseManagerRef",
+}
+
+// ConvertToV4 converts a v3 custom resource to its v4 counterpart. Both versions share the same representation, except the
+// references to the cluster manager and the license manager renamed in v4. The group, version and kind of dst are kept
+func ConvertToV4(src runtime.Object, dst runtime.Object) error {
+	return convertObject(src, dst, v4SpecFields)
+}
+
+// ConvertFromV4 converts a v4 custom resource to its v3 counterpart. The group, version and kind of dst are kept
+func ConvertFromV4(src runtime.Object, dst runtime.Object) error {
+	v3SpecFields := make(map[string]string, len(v4SpecFields))
+	for v3Field, v4Field := range v4SpecFields {
+		v3SpecFields[v4Field] = v3Field
+	}
+	return convertObject(src, dst, v3SpecFields)
+}
+
+// convertObject copies src into dst through their JSON representation, renaming the fields of the spec
+func convertObject(src runtime.Object, dst runtime.Object, specFields map[string]string) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+
+	// numbers are kept as is, int64 values don't fit in a float64
+	var object map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&object)
+	if err != nil {
+		return err
+	}
+
+	if spec, ok := object["spec"].(map[string]interface{}); ok {
+		for from, to := range specFields {
+			if value, ok := spec[from]; ok {
+				delete(spec, from)
+				spec[to] = value
+			}
+		}
+	}
+
+	data, err = json.Marshal(object)
+	if err != nil {
+		return err
+	}
+
+	gvk := dst.GetObjectKind().GroupVersionKind()
+	dstValue := reflect.ValueOf(dst).Elem()
+	dstValue.Set(reflect.Zero(dstValue.Type()))
+	err = json.Unmarshal(data, dst)
+	if err != nil {
+		return err
+	}
+	dst.GetObjectKind().SetGroupVersionKind(gvk)
+	return nil
+}
+
+// ConvertTo converts this IndexerCluster to the hub version (v4)
+func (src *IndexerCluster) ConvertTo(dst conversion.Hub) error {
+	return ConvertToV4(src, dst)
+}
+
+// ConvertFrom converts from the hub version (v4) to this IndexerCluster
+func (dst *IndexerCluster) ConvertFrom(src conversion.Hub) error {
+	return ConvertFromV4(src, dst)
+}
+
+// ConvertTo converts this SearchHeadCluster to the hub version (v4)
+func (src *SearchHeadCluster) ConvertTo(dst conversion.Hub) error {
+	return ConvertToV4(src, dst)
+}
+
+// ConvertFrom converts from the hub version (v4) to this SearchHeadCluster
+func (dst *SearchHeadCluster) ConvertFrom(src conversion.Hub) error {
+	return ConvertFromV4(src, dst)
+}
+
+// ConvertTo converts this Standalone to the hub version (v4)
+func (src *Standalone) ConvertTo(dst conversion.Hub) error {
+	return ConvertToV4(src, dst)
+}
+
+// ConvertFrom converts from the hub version (v4) to this Standalone
+func (dst *Standalone) ConvertFrom(src conversion.Hub) error {
+	return ConvertFromV4(src, dst)
+}
+
+// ConvertTo converts this MonitoringConsole to the hub version (v4)
+func (src *MonitoringConsole) ConvertTo(dst conversion.Hub) error {
+	return ConvertToV4(src, dst)
+}
+
+// ConvertFrom converts from the hub version (v4) to this MonitoringConsole
+func (dst *MonitoringConsole) ConvertFrom(src conversion.Hub) error {
+	return ConvertFromV4(src, dst)
+}
@@ -0,0 +1,227 @@
+/*
+Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v3_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+)
+
+// v3CommonSpec returns a common spec setting the references renamed in v4 and the fields added since v3
+func v3CommonSpec() enterpriseApi.CommonSplunkSpec {
+	spec := enterpriseApi.CommonSplunkSpec{
+		LicenseMasterRef: corev1.ObjectReference{Name: "lm1", Namespace: "test"},
+		ClusterMasterRef: corev1.ObjectReference{Name: "cm1"},
+		Expose: enterpriseApi.ExposeSpec{
+			Type:        "Ingress",
+			Annotations: map[string]string{"example.com/tls": "true"},
+			Web:         enterpriseApi.ExposeEndpointSpec{Host: "splunk.example.com", TLSSecretName: "web-tls"},
+			HEC:         enterpriseApi.ExposeEndpointSpec{Host: "hec.example.com", TLSPassthrough: true},
+		},
+		NetworkPolicy: enterpriseApi.NetworkPolicySpec{
+			Enabled: true,
+			HECFrom: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "forwarders"}}}},
+		},
+		Ports: []enterpriseApi.SplunkPort{
+			{Name: "hec", Disabled: true},
+			{Name: "syslog", Port: 1514, Protocol: corev1.ProtocolUDP},
+		},
+		OrderedUpgrade: true,
+		DriftPolicy:    enterpriseApi.DriftPolicyReportOnly,
+	}
+	spec.Image = "splunk/splunk:9.0.0"
+	return spec
+}
+
+// checkCommonSpec checks the references renamed in v4 and the fields added since v3 of a converted common spec
+func checkCommonSpec(t *testing.T, want enterpriseApi.CommonSplunkSpec, got enterpriseApiV4.CommonSplunkSpec) {
+	if got.LicenseManagerRef != want.LicenseMasterRef {
+		t.Errorf("licenseManagerRef = %v; want %v", got.LicenseManagerRef, want.LicenseMasterRef)
+	}
+	if got.ClusterManagerRef != want.ClusterMasterRef {
+		t.Errorf("clusterManagerRef = %v; want %v", got.ClusterManagerRef, want.ClusterMasterRef)
+	}
+	if got.Image != want.Image {
+		t.Errorf("image = %s; want %s", got.Image, want.Image)
+	}
+	if !reflect.DeepEqual(got.Expose, want.Expose) {
+		t.Errorf("expose = %+v; want %+v", got.Expose, want.Expose)
+	}
+	if !reflect.DeepEqual(got.NetworkPolicy, want.NetworkPolicy) {
+		t.Errorf("networkPolicy = %+v; want %+v", got.NetworkPolicy, want.NetworkPolicy)
+	}
+	if !reflect.DeepEqual(got.Ports, want.Ports) {
+		t.Errorf("ports = %+v; want %+v", got.Ports, want.Ports)
+	}
+	if got.OrderedUpgrade != want.OrderedUpgrade {
+		t.Errorf("orderedUpgrade = %t; want %t", got.OrderedUpgrade, want.OrderedUpgrade)
+	}
+	if got.DriftPolicy != want.DriftPolicy {
+		t.Errorf("driftPolicy = %s; want %s", got.DriftPolicy, want.DriftPolicy)
+	}
+}
+
+// roundTrip converts src to hub, and hub back to dst
+func roundTrip(t *testing.T, src conversion.Convertible, hub conversion.Hub, dst conversion.Convertible) {
+	err := src.ConvertTo(hub)
+	if err != nil {
+		t.Fatalf("ConvertTo() returned error: %v", err)
+	}
+	err = dst.ConvertFrom(hub)
+	if err != nil {
+		t.Fatalf("ConvertFrom() returned error: %v", err)
+	}
+}
+
+func TestIndexerClusterConversion(t *testing.T) {
+	src := &enterpriseApi.IndexerCluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: enterpriseApi.APIVersion, Kind: "IndexerCluster"},
+		ObjectMeta: metav1.ObjectMeta{Name: "idxc", Namespace: "test"},
+	}
+	src.Spec.CommonSplunkSpec = v3CommonSpec()
+	src.Spec.Replicas = 3
+	src.Spec.Autoscaling.Enabled = true
+	src.Spec.Autoscaling.MaxReplicas = 10
+	src.Spec.Autoscaling.ScaleUpQueueFillPercent = 70
+	src.Spec.Rebalance = enterpriseApi.IndexerClusterRebalanceSpec{Enabled: true, MaxRuntimeMinutes: 30}
+
+	hub := &enterpriseApiV4.IndexerCluster{}
+	dst := &enterpriseApi.IndexerCluster{}
+	roundTrip(t, src, hub, dst)
+
+	checkCommonSpec(t, src.Spec.CommonSplunkSpec, hub.Spec.CommonSplunkSpec)
+	if hub.Name != "idxc" || hub.Spec.Replicas != 3 {
+		t.Errorf("ConvertTo() = %+v; want the name and replicas of the v3 indexer cluster", hub)
+	}
+	if hub.Spec.Autoscaling != src.Spec.Autoscaling || hub.Spec.Rebalance != src.Spec.Rebalance {
+		t.Errorf("ConvertTo() autoscaling = %+v, rebalance = %+v; want %+v, %+v", hub.Spec.Autoscaling, hub.Spec.Rebalance, src.Spec.Autoscaling, src.Spec.Rebalance)
+	}
+	if !reflect.DeepEqual(dst.ObjectMeta, src.ObjectMeta) || !reflect.DeepEqual(dst.Spec, src.Spec) {
+		t.Errorf("ConvertFrom(ConvertTo()) spec = %+v; want %+v", dst.Spec, src.Spec)
+	}
+}
+
+func TestSearchHeadClusterConversion(t *testing.T) {
+	src := &enterpriseApi.SearchHeadCluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: enterpriseApi.APIVersion, Kind: "SearchHeadCluster"},
+		ObjectMeta: metav1.ObjectMeta{Name: "shc", Namespace: "test"},
+	}
+	src.Spec.CommonSplunkSpec = v3CommonSpec()
+	src.Spec.Replicas = 3
+	src.Spec.Autoscaling.Enabled = true
+	src.Spec.Autoscaling.MinReplicas = 3
+	src.Spec.Autoscaling.ScaleUpConcurrencyPercent = 80
+
+	hub := &enterpriseApiV4.SearchHeadCluster{}
+	dst := &enterpriseApi.SearchHeadCluster{}
+	roundTrip(t, src, hub, dst)
+
+	checkCommonSpec(t, src.Spec.CommonSplunkSpec, hub.Spec.CommonSplunkSpec)
+	if hub.Spec.Autoscaling != src.Spec.Autoscaling {
+		t.Errorf("ConvertTo() autoscaling = %+v; want %+v", hub.Spec.Autoscaling, src.Spec.Autoscaling)
+	}
+	if !reflect.DeepEqual(dst.ObjectMeta, src.ObjectMeta) || !reflect.DeepEqual(dst.Spec, src.Spec) {
+		t.Errorf("ConvertFrom(ConvertTo()) spec = %+v; want %+v", dst.Spec, src.Spec)
+	}
+}
+
+func TestStandaloneConversion(t *testing.T) {
+	src := &enterpriseApi.Standalone{
+		TypeMeta:   metav1.TypeMeta{APIVersion: enterpriseApi.APIVersion, Kind: "Standalone"},
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "test"},
+	}
+	src.Spec.CommonSplunkSpec = v3CommonSpec()
+	src.Spec.Replicas = 1
+
+	hub := &enterpriseApiV4.Standalone{}
+	dst := &enterpriseApi.Standalone{}
+	roundTrip(t, src, hub, dst)
+
+	checkCommonSpec(t, src.Spec.CommonSplunkSpec, hub.Spec.CommonSplunkSpec)
+	if !reflect.DeepEqual(dst.ObjectMeta, src.ObjectMeta) || !reflect.DeepEqual(dst.Spec, src.Spec) {
+		t.Errorf("ConvertFrom(ConvertTo()) spec = %+v; want %+v", dst.Spec, src.Spec)
+	}
+}
+
+func TestMonitoringConsoleConversion(t *testing.T) {
+	src := &enterpriseApi.MonitoringConsole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: enterpriseApi.APIVersion, Kind: "MonitoringConsole"},
+		ObjectMeta: metav1.ObjectMeta{Name: "mc", Namespace: "test"},
+	}
+	src.Spec.CommonSplunkSpec = v3CommonSpec()
+
+	hub := &enterpriseApiV4.MonitoringConsole{}
+	dst := &enterpriseApi.MonitoringConsole{}
+	roundTrip(t, src, hub, dst)
+
+	checkCommonSpec(t, src.Spec.CommonSplunkSpec, hub.Spec.CommonSplunkSpec)
+	if !reflect.DeepEqual(dst.ObjectMeta, src.ObjectMeta) || !reflect.DeepEqual(dst.Spec, src.Spec) {
+		t.Errorf("ConvertFrom(ConvertTo()) spec = %+v; want %+v", dst.Spec, src.Spec)
+	}
+}
+
+// addJSONFields adds the JSON fields of a struct type to fields, with the fields of its inlined structs, renaming them with
+// renames
+func addJSONFields(structType reflect.Type, renames map[string]string, fields map[string]reflect.Type) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && name == "" {
+			addJSONFields(field.Type, renames, fields)
+			continue
+		}
+		if renamed, ok := renames[name]; ok {
+			name = renamed
+		}
+		fields[name] = field.Type
+	}
+}
+
+// TestV4SpecFields checks that the v4 specs keep the fields of their v3 counterparts, as the v4 CommonSplunkSpec can't embed
+// the v3 one which carries the renamed references
+func TestV4SpecFields(t *testing.T) {
+	renames := map[string]string{
+		"clusterMasterRef": "clusterManagerRef",
+		"licenseMasterRef": "licenseManagerRef",
+	}
+	specs := map[string][2]interface{}{
+		"IndexerCluster":    {enterpriseApi.IndexerClusterSpec{}, enterpriseApiV4.IndexerClusterSpec{}},
+		"SearchHeadCluster": {enterpriseApi.SearchHeadClusterSpec{}, enterpriseApiV4.SearchHeadClusterSpec{}},
+		"Standalone":        {enterpriseApi.StandaloneSpec{}, enterpriseApiV4.StandaloneSpec{}},
+		"MonitoringConsole": {enterpriseApi.MonitoringConsoleSpec{}, enterpriseApiV4.MonitoringConsoleSpec{}},
+		"ClusterManager":    {enterpriseApi.ClusterMasterSpec{}, enterpriseApiV4.ClusterManagerSpec{}},
+		"LicenseManager":    {enterpriseApi.LicenseMasterSpec{}, enterpriseApiV4.LicenseManagerSpec{}},
+	}
+	for kind, spec := range specs {
+		v3Fields := map[string]reflect.Type{}
+		addJSONFields(reflect.TypeOf(spec[0]), renames, v3Fields)
+		v4Fields := map[string]reflect.Type{}
+		addJSONFields(reflect.TypeOf(spec[1]), nil, v4Fields)
+		if !reflect.DeepEqual(v3Fields, v4Fields) {
+			t.Errorf("the %s v4 spec fields %v don't match the v3 spec fields %v", kind, v4Fields, v3Fields)
+		}
+	}
+}
@@ -40,6 +40,93 @@ type IndexerClusterSpec struct {
 
 	// Number of search head pods; a search head cluster will be created if > 1
 	Replicas int32 `json:"replicas"`
+
+	// Autoscaling of the indexers from the ingestion queues and the indexing throughput reported by the cluster manager
+	// +optional
+	Autoscaling IndexerClusterAutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// Automatic rebalance of the buckets once the peers added to the indexer cluster are searchable
+	// +optional
+	Rebalance IndexerClusterRebalanceSpec `json:"rebalance,omitempty"`
+
+	// Maximum time, in seconds, the maintenance mode enabled by the operator around a disruptive operation is kept before
+	// it is cleared by the watchdog. Defaults to 7200
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaintenanceModeTimeoutSeconds int32 `json:"maintenanceModeTimeoutSeconds,omitempty"`
+}
+
+// IndexerClusterMaintenanceStatus defines the maintenance mode enabled by the operator around a disruptive operation
+type IndexerClusterMaintenanceStatus struct {
+	// Operation for which the operator enabled the maintenance mode
+	Reason string `json:"reason,omitempty"`
+
+	// Time when the operator enabled the maintenance mode, in seconds since epoch
+	StartTime int64 `json:"startTime,omitempty"`
+}
+
+// IndexerClusterRebalanceSpec defines the automatic rebalance of the buckets after a scale up of the indexers
+type IndexerClusterRebalanceSpec struct {
+	// Starts a data rebalance through the cluster manager once the peers added to the indexer cluster are searchable.
+	// The rebalance is deferred while the cluster is in maintenance mode or a rolling restart
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Maximum run time of the rebalance, in minutes. Not limited when 0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRuntimeMinutes int32 `json:"maxRuntimeMinutes,omitempty"`
+}
+
+// IndexerClusterRebalanceStatus defines the progress of the automatic rebalance of the buckets
+type IndexerClusterRebalanceStatus struct {
+	// Phase of the rebalance: Pending, Deferred, Running, Completed or Stopped
+	Phase string `json:"phase,omitempty"`
+
+	// Number of peers of the indexer cluster when the buckets were last balanced
+	BalancedReplicas int32 `json:"balancedReplicas,omitempty"`
+
+	// Completion of the running rebalance, in percent
+	PercentComplete int32 `json:"percentComplete,omitempty"`
+
+	// Time when the last rebalance was started, in seconds since epoch
+	StartTime int64 `json:"startTime,omitempty"`
+
+	// Last message reported by the cluster manager, or reason why the rebalance is deferred
+	Message string `json:"message,omitempty"`
+}
+
+// IndexerClusterAutoscalingSpec defines the autoscaling of the indexers. The indexers are scaled up when the fill ratio
+// of the ingestion queues or the indexing throughput are above the thresholds, and scaled down when both are below
+type IndexerClusterAutoscalingSpec struct {
+	AutoscalingSpec `json:",inline"`
+
+	// Fill ratio of the fullest ingestion queue of an indexer, in percent, above which the indexers are scaled up. Defaults to 70
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ScaleUpQueueFillPercent int32 `json:"scaleUpQueueFillPercent,omitempty"`
+
+	// Fill ratio of the fullest ingestion queue of all the indexers, in percent, below which the indexers are scaled down. Defaults to 20
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ScaleDownQueueFillPercent int32 `json:"scaleDownQueueFillPercent,omitempty"`
+
+	// Average indexing throughput per indexer, in KB per second, above which the indexers are scaled up. Not used when 0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ScaleUpThroughputKBps int32 `json:"scaleUpThroughputKBps,omitempty"`
+}
+
+// IndexerClusterAutoscalingStatus defines the observed state of the autoscaling of the indexers
+type IndexerClusterAutoscalingStatus struct {
+	AutoscalingStatus `json:",inline"`
+
+	// Fill ratio of the fullest ingestion queue of all the indexers, in percent
+	QueueFillPercent int32 `json:"queueFillPercent,omitempty"`
+
+	// Average indexing throughput per indexer, in KB per second
+	ThroughputKBps int32 `json:"throughputKBps,omitempty"`
 }
 
 // IndexerClusterMemberStatus is used to track the status of each indexer cluster peer.
@@ -68,6 +155,10 @@ type IndexerClusterStatus struct {
 	// current phase of the indexer cluster
 	Phase Phase `json:"phase"`
 
+	// Stage of the ordered upgrade of the Splunk stack: the stage the custom resource is waiting for, or its own stage while its
+	// statefulset is upgraded
+	UpgradeStage string `json:"upgradeStage,omitempty"`
+
 	// current phase of the cluster manager
 	ClusterMasterPhase Phase `json:"clusterMasterPhase"`
 
@@ -101,8 +192,30 @@ type IndexerClusterStatus struct {
 	// Indicates if the cluster is in maintenance mode.
 	MaintenanceMode bool `json:"maintenance_mode"`
 
+	// Indicates whether the manager is restarting the peers of the cluster.
+	RollingRestart bool `json:"rolling_restart_flag,omitempty"`
+
+	// Maintenance mode enabled by the operator, cleared once the operation is over
+	OperatorMaintenance IndexerClusterMaintenanceStatus `json:"operatorMaintenance,omitempty"`
+
 	// status of each indexer cluster peer
 	Peers []IndexerClusterMemberStatus `json:"peers"`
+
+	// Objects exposing the Splunk Web and HEC ports
+	Expose []ExposedEndpoint `json:"expose,omitempty"`
+
+	// NetworkPolicies generated for the Splunk pods
+	NetworkPolicies []string `json:"networkPolicies,omitempty"`
+
+	// Conditions of the custom resource, such as DriftDetected
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Autoscaling of the indexers
+	Autoscaling IndexerClusterAutoscalingStatus `json:"autoscaling,omitempty"`
+
+	// Automatic rebalance of the buckets
+	Rebalance IndexerClusterRebalanceStatus `json:"rebalance,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -117,7 +230,6 @@ type IndexerClusterStatus struct {
 // +kubebuilder:printcolumn:name="Desired",type="integer",JSONPath=".status.replicas",description="Desired number of indexer peers"
 // +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas",description="Current number of ready indexer peers"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age of indexer cluster"
-// +kubebuilder:storageversion
 type IndexerCluster struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
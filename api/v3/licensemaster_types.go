@@ -40,6 +40,73 @@ type LicenseMasterSpec struct {
 
 	// Splunk enterprise App repository. Specifies remote App location and scope for Splunk App management
 	AppFrameworkConfig AppFrameworkSpec `json:"appRepo,omitempty"`
+
+	// Thresholds for the license usage and expiry warnings
+	LicenseReporting LicenseReportingSpec `json:"licenseReporting,omitempty"`
+
+	// Secrets containing the license files. Each key of a secret is a license file, installed on the license manager
+	// without a restart. Licenses are removed from the license manager when their secret or key is deleted
+	LicenseSecrets []corev1.LocalObjectReference `json:"licenseSecrets,omitempty"`
+}
+
+// InstalledLicense represents a license installed on the license manager from a license secret
+type InstalledLicense struct {
+	// Name of the license secret
+	SecretName string `json:"secretName"`
+
+	// Key of the license file in the secret
+	Key string `json:"key"`
+
+	// SHA256 hash of the license file
+	Hash string `json:"hash"`
+
+	// Name of the license on the license manager
+	LicenseName string `json:"licenseName"`
+}
+
+// LicenseReportingSpec defines the thresholds for the license usage and expiry warnings
+type LicenseReportingSpec struct {
+	// Percentage of the daily license quota, over which the license usage warning is raised. Defaults to 80
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	UsageWarningPercent int32 `json:"usageWarningPercent,omitempty"`
+
+	// Number of days before the license expiry, from which the license expiry warning is raised. Defaults to 30
+	// +kubebuilder:validation:Minimum=1
+	ExpiryWarningDays int32 `json:"expiryWarningDays,omitempty"`
+}
+
+// LicensePoolUsage represents today's license usage of a license pool
+type LicensePoolUsage struct {
+	// Name of the license pool
+	Name string `json:"name"`
+
+	// Daily indexing quota of the pool, in bytes
+	Quota int64 `json:"quota"`
+
+	// Today's usage of the pool, in bytes
+	UsedBytes int64 `json:"usedBytes"`
+}
+
+// LicenseStatus represents the license usage and expiry reported by the license manager
+type LicenseStatus struct {
+	// Earliest expiration time of the valid licenses, in epoch seconds
+	ExpirationTime int64 `json:"expirationTime,omitempty"`
+
+	// Daily indexing quota of all the valid licenses, in bytes
+	Quota int64 `json:"quota,omitempty"`
+
+	// Today's license usage, in bytes
+	UsedBytes int64 `json:"usedBytes,omitempty"`
+
+	// Today's license usage per pool
+	Pools []LicensePoolUsage `json:"pools,omitempty"`
+
+	// Number of license violation messages reported by the license manager
+	ViolationCount int32 `json:"violationCount,omitempty"`
+
+	// Last time the license status was checked, in epoch seconds
+	LastCheckTime int64 `json:"lastCheckTime,omitempty"`
 }
 
 // LicenseMasterStatus defines the observed state of a Splunk Enterprise license manager.
@@ -47,8 +114,28 @@ type LicenseMasterStatus struct {
 	// current phase of the license manager
 	Phase Phase `json:"phase"`
 
+	// Stage of the ordered upgrade of the Splunk stack: the stage the custom resource is waiting for, or its own stage while its
+	// statefulset is upgraded
+	UpgradeStage string `json:"upgradeStage,omitempty"`
+
 	// App Framework Context
 	AppContext AppDeploymentContext `json:"appContext"`
+
+	// License usage and expiry reported by the license manager
+	License LicenseStatus `json:"license,omitempty"`
+
+	// Licenses installed from the license secrets
+	InstalledLicenses []InstalledLicense `json:"installedLicenses,omitempty"`
+
+	// Objects exposing the Splunk Web and HEC ports
+	Expose []ExposedEndpoint `json:"expose,omitempty"`
+
+	// NetworkPolicies generated for the Splunk pods
+	NetworkPolicies []string `json:"networkPolicies,omitempty"`
+
+	// Conditions of the custom resource, such as DriftDetected
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -47,6 +47,10 @@ type MonitoringConsoleStatus struct {
 	// current phase of the monitoring console
 	Phase Phase `json:"phase"`
 
+	// Stage of the ordered upgrade of the Splunk stack: the stage the custom resource is waiting for, or its own stage while its
+	// statefulset is upgraded
+	UpgradeStage string `json:"upgradeStage,omitempty"`
+
 	// selector for pods, used by HorizontalPodAutoscaler
 	Selector string `json:"selector"`
 
@@ -58,6 +62,16 @@ type MonitoringConsoleStatus struct {
 
 	// App Framework status
 	AppContext AppDeploymentContext `json:"appContext,omitempty"`
+
+	// Objects exposing the Splunk Web and HEC ports
+	Expose []ExposedEndpoint `json:"expose,omitempty"`
+
+	// NetworkPolicies generated for the Splunk pods
+	NetworkPolicies []string `json:"networkPolicies,omitempty"`
+
+	// Conditions of the custom resource, such as DriftDetected
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -70,7 +84,6 @@ type MonitoringConsoleStatus struct {
 // +kubebuilder:printcolumn:name="Desired",type="integer",JSONPath=".status.replicas",description="Desired number of monitoring console members"
 // +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas",description="Current number of ready monitoring console members"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age of monitoring console"
-// +kubebuilder:storageversion
 type MonitoringConsole struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -44,6 +44,56 @@ type SearchHeadClusterSpec struct {
 
 	// Splunk Enterprise App repository. Specifies remote App location and scope for Splunk App management
 	AppFrameworkConfig AppFrameworkSpec `json:"appRepo,omitempty"`
+
+	// Autoscaling of the search head cluster members from the search concurrency and the skipped scheduled searches
+	// +optional
+	Autoscaling SearchHeadClusterAutoscalingSpec `json:"autoscaling,omitempty"`
+}
+
+// SearchHeadClusterAutoscalingSpec defines the autoscaling of the search head cluster members. The members are scaled up when
+// the search concurrency approaches the search limits or when scheduled searches are skipped, and scaled down during quiet periods
+type SearchHeadClusterAutoscalingSpec struct {
+	AutoscalingSpec `json:",inline"`
+
+	// Maximum number of concurrent searches of a member. Read from the search concurrency limits of the members when 0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSearchesPerMember int32 `json:"maxSearchesPerMember,omitempty"`
+
+	// Active searches, in percent of the maximum number of concurrent searches of the members, above which the members
+	// are scaled up. Defaults to 80
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ScaleUpConcurrencyPercent int32 `json:"scaleUpConcurrencyPercent,omitempty"`
+
+	// Active searches, in percent of the maximum number of concurrent searches of the members, below which the members
+	// are scaled down once the scale down cooldown period has passed without activity above it. Defaults to 20
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ScaleDownConcurrencyPercent int32 `json:"scaleDownConcurrencyPercent,omitempty"`
+
+	// Skipped scheduled searches of the last 15 minutes, in percent of the scheduled searches, above which the members
+	// are scaled up. Defaults to 5
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ScaleUpSkippedSearchPercent int32 `json:"scaleUpSkippedSearchPercent,omitempty"`
+}
+
+// SearchHeadClusterAutoscalingStatus defines the observed state of the autoscaling of the search head cluster members
+type SearchHeadClusterAutoscalingStatus struct {
+	AutoscalingStatus `json:",inline"`
+
+	// Active searches of all the members, in percent of the maximum number of concurrent searches
+	ConcurrencyPercent int32 `json:"concurrencyPercent,omitempty"`
+
+	// Skipped scheduled searches of the last 15 minutes, in percent of the scheduled searches
+	SkippedSearchPercent int32 `json:"skippedSearchPercent,omitempty"`
+
+	// Time of the last evaluation with the search concurrency above the scale down threshold, in seconds since epoch
+	LastBusyTime int64 `json:"lastBusyTime,omitempty"`
 }
 
 // SearchHeadClusterMemberStatus is used to track the status of each search head cluster member
@@ -72,6 +122,10 @@ type SearchHeadClusterStatus struct {
 	// current phase of the search head cluster
 	Phase Phase `json:"phase"`
 
+	// Stage of the ordered upgrade of the Splunk stack: the stage the custom resource is waiting for, or its own stage while its
+	// statefulset is upgraded
+	UpgradeStage string `json:"upgradeStage,omitempty"`
+
 	// current phase of the deployer
 	DeployerPhase Phase `json:"deployerPhase"`
 
@@ -116,6 +170,19 @@ type SearchHeadClusterStatus struct {
 
 	// App Framework Context
 	AppContext AppDeploymentContext `json:"appContext"`
+
+	// Objects exposing the Splunk Web and HEC ports
+	Expose []ExposedEndpoint `json:"expose,omitempty"`
+
+	// NetworkPolicies generated for the Splunk pods
+	NetworkPolicies []string `json:"networkPolicies,omitempty"`
+
+	// Conditions of the custom resource, such as DriftDetected
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Autoscaling of the search head cluster members
+	Autoscaling SearchHeadClusterAutoscalingStatus `json:"autoscaling,omitempty"`
 }
 
 // SearchHeadCluster is the Schema for a Splunk Enterprise search head cluster
@@ -129,7 +196,6 @@ type SearchHeadClusterStatus struct {
 // +kubebuilder:printcolumn:name="Desired",type="integer",JSONPath=".status.replicas",description="Desired number of search head cluster members"
 // +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas",description="Current number of ready search head cluster members"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age of search head cluster"
-// +kubebuilder:storageversion
 type SearchHeadCluster struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -22,8 +22,10 @@ limitations under the License.
 package v3
 
 import (
-	"k8s.io/api/core/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -37,7 +39,13 @@ func (in *AppDeploymentContext) DeepCopyInto(out *AppDeploymentContext) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	in.Summary.DeepCopyInto(&out.Summary)
 	out.BundlePushStatus = in.BundlePushStatus
+	if in.DryRunPlan != nil {
+		in, out := &in.DryRunPlan, &out.DryRunPlan
+		*out = new(AppFrameworkPlan)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppDeploymentContext.
@@ -50,6 +58,21 @@ func (in *AppDeploymentContext) DeepCopy() *AppDeploymentContext {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppDeploymentFailure) DeepCopyInto(out *AppDeploymentFailure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppDeploymentFailure.
+func (in *AppDeploymentFailure) DeepCopy() *AppDeploymentFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(AppDeploymentFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AppDeploymentInfo) DeepCopyInto(out *AppDeploymentInfo) {
 	*out = *in
@@ -59,6 +82,11 @@ func (in *AppDeploymentInfo) DeepCopyInto(out *AppDeploymentInfo) {
 		*out = make([]PhaseInfo, len(*in))
 		copy(*out, *in)
 	}
+	if in.Dependencies != nil {
+		in, out := &in.Dependencies, &out.Dependencies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppDeploymentInfo.
@@ -71,6 +99,53 @@ func (in *AppDeploymentInfo) DeepCopy() *AppDeploymentInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppDeploymentSummary) DeepCopyInto(out *AppDeploymentSummary) {
+	*out = *in
+	if in.Failures != nil {
+		in, out := &in.Failures, &out.Failures
+		*out = make([]AppDeploymentFailure, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppDeploymentSummary.
+func (in *AppDeploymentSummary) DeepCopy() *AppDeploymentSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(AppDeploymentSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppFrameworkPlan) DeepCopyInto(out *AppFrameworkPlan) {
+	*out = *in
+	if in.AppSources != nil {
+		in, out := &in.AppSources, &out.AppSources
+		*out = make([]AppSourcePlan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AffectedPods != nil {
+		in, out := &in.AffectedPods, &out.AffectedPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppFrameworkPlan.
+func (in *AppFrameworkPlan) DeepCopy() *AppFrameworkPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(AppFrameworkPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AppFrameworkSpec) DeepCopyInto(out *AppFrameworkSpec) {
 	*out = *in
@@ -112,6 +187,36 @@ func (in *AppSourceDefaultSpec) DeepCopy() *AppSourceDefaultSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppSourcePlan) DeepCopyInto(out *AppSourcePlan) {
+	*out = *in
+	if in.AppsToAdd != nil {
+		in, out := &in.AppsToAdd, &out.AppsToAdd
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppsToUpdate != nil {
+		in, out := &in.AppsToUpdate, &out.AppsToUpdate
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppsToDelete != nil {
+		in, out := &in.AppsToDelete, &out.AppsToDelete
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppSourcePlan.
+func (in *AppSourcePlan) DeepCopy() *AppSourcePlan {
+	if in == nil {
+		return nil
+	}
+	out := new(AppSourcePlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AppSourceSpec) DeepCopyInto(out *AppSourceSpec) {
 	*out = *in
@@ -150,6 +255,36 @@ func (in *AppSrcDeployInfo) DeepCopy() *AppSrcDeployInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalingSpec) DeepCopyInto(out *AutoscalingSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalingSpec.
+func (in *AutoscalingSpec) DeepCopy() *AutoscalingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalingStatus) DeepCopyInto(out *AutoscalingStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalingStatus.
+func (in *AutoscalingStatus) DeepCopy() *AutoscalingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BundlePushInfo) DeepCopyInto(out *BundlePushInfo) {
 	*out = *in
@@ -286,6 +421,23 @@ func (in *ClusterMasterStatus) DeepCopyInto(out *ClusterMasterStatus) {
 		}
 	}
 	in.AppContext.DeepCopyInto(&out.AppContext)
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = make([]ExposedEndpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.NetworkPolicies != nil {
+		in, out := &in.NetworkPolicies, &out.NetworkPolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterMasterStatus.
@@ -306,7 +458,7 @@ func (in *CommonSplunkSpec) DeepCopyInto(out *CommonSplunkSpec) {
 	out.VarVolumeStorageConfig = in.VarVolumeStorageConfig
 	if in.Volumes != nil {
 		in, out := &in.Volumes, &out.Volumes
-		*out = make([]v1.Volume, len(*in))
+		*out = make([]corev1.Volume, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -316,14 +468,21 @@ func (in *CommonSplunkSpec) DeepCopyInto(out *CommonSplunkSpec) {
 	out.MonitoringConsoleRef = in.MonitoringConsoleRef
 	if in.ExtraEnv != nil {
 		in, out := &in.ExtraEnv, &out.ExtraEnv
-		*out = make([]v1.EnvVar, len(*in))
+		*out = make([]corev1.EnvVar, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.ImagePullSecrets != nil {
 		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
-		*out = make([]v1.LocalObjectReference, len(*in))
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	in.Expose.DeepCopyInto(&out.Expose)
+	in.NetworkPolicy.DeepCopyInto(&out.NetworkPolicy)
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]SplunkPort, len(*in))
 		copy(*out, *in)
 	}
 }
@@ -338,6 +497,76 @@ func (in *CommonSplunkSpec) DeepCopy() *CommonSplunkSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExposeEndpointSpec) DeepCopyInto(out *ExposeEndpointSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExposeEndpointSpec.
+func (in *ExposeEndpointSpec) DeepCopy() *ExposeEndpointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExposeEndpointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExposeGatewayRef) DeepCopyInto(out *ExposeGatewayRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExposeGatewayRef.
+func (in *ExposeGatewayRef) DeepCopy() *ExposeGatewayRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ExposeGatewayRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExposeSpec) DeepCopyInto(out *ExposeSpec) {
+	*out = *in
+	out.GatewayRef = in.GatewayRef
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.Web = in.Web
+	out.HEC = in.HEC
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExposeSpec.
+func (in *ExposeSpec) DeepCopy() *ExposeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExposeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExposedEndpoint) DeepCopyInto(out *ExposedEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExposedEndpoint.
+func (in *ExposedEndpoint) DeepCopy() *ExposedEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(ExposedEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IndexAndCacheManagerCommonSpec) DeepCopyInto(out *IndexAndCacheManagerCommonSpec) {
 	*out = *in
@@ -428,6 +657,38 @@ func (in *IndexerCluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IndexerClusterAutoscalingSpec) DeepCopyInto(out *IndexerClusterAutoscalingSpec) {
+	*out = *in
+	out.AutoscalingSpec = in.AutoscalingSpec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexerClusterAutoscalingSpec.
+func (in *IndexerClusterAutoscalingSpec) DeepCopy() *IndexerClusterAutoscalingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IndexerClusterAutoscalingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IndexerClusterAutoscalingStatus) DeepCopyInto(out *IndexerClusterAutoscalingStatus) {
+	*out = *in
+	out.AutoscalingStatus = in.AutoscalingStatus
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexerClusterAutoscalingStatus.
+func (in *IndexerClusterAutoscalingStatus) DeepCopy() *IndexerClusterAutoscalingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IndexerClusterAutoscalingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IndexerClusterList) DeepCopyInto(out *IndexerClusterList) {
 	*out = *in
@@ -460,6 +721,21 @@ func (in *IndexerClusterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IndexerClusterMaintenanceStatus) DeepCopyInto(out *IndexerClusterMaintenanceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexerClusterMaintenanceStatus.
+func (in *IndexerClusterMaintenanceStatus) DeepCopy() *IndexerClusterMaintenanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IndexerClusterMaintenanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IndexerClusterMemberStatus) DeepCopyInto(out *IndexerClusterMemberStatus) {
 	*out = *in
@@ -475,10 +751,42 @@ func (in *IndexerClusterMemberStatus) DeepCopy() *IndexerClusterMemberStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IndexerClusterRebalanceSpec) DeepCopyInto(out *IndexerClusterRebalanceSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexerClusterRebalanceSpec.
+func (in *IndexerClusterRebalanceSpec) DeepCopy() *IndexerClusterRebalanceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IndexerClusterRebalanceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IndexerClusterRebalanceStatus) DeepCopyInto(out *IndexerClusterRebalanceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexerClusterRebalanceStatus.
+func (in *IndexerClusterRebalanceStatus) DeepCopy() *IndexerClusterRebalanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IndexerClusterRebalanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IndexerClusterSpec) DeepCopyInto(out *IndexerClusterSpec) {
 	*out = *in
 	in.CommonSplunkSpec.DeepCopyInto(&out.CommonSplunkSpec)
+	out.Autoscaling = in.Autoscaling
+	out.Rebalance = in.Rebalance
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexerClusterSpec.
@@ -506,11 +814,31 @@ func (in *IndexerClusterStatus) DeepCopyInto(out *IndexerClusterStatus) {
 			(*out)[key] = val
 		}
 	}
+	out.OperatorMaintenance = in.OperatorMaintenance
 	if in.Peers != nil {
 		in, out := &in.Peers, &out.Peers
 		*out = make([]IndexerClusterMemberStatus, len(*in))
 		copy(*out, *in)
 	}
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = make([]ExposedEndpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.NetworkPolicies != nil {
+		in, out := &in.NetworkPolicies, &out.NetworkPolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.Autoscaling = in.Autoscaling
+	out.Rebalance = in.Rebalance
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexerClusterStatus.
@@ -523,6 +851,21 @@ func (in *IndexerClusterStatus) DeepCopy() *IndexerClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstalledLicense) DeepCopyInto(out *InstalledLicense) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstalledLicense.
+func (in *InstalledLicense) DeepCopy() *InstalledLicense {
+	if in == nil {
+		return nil
+	}
+	out := new(InstalledLicense)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LicenseMaster) DeepCopyInto(out *LicenseMaster) {
 	*out = *in
@@ -587,6 +930,12 @@ func (in *LicenseMasterSpec) DeepCopyInto(out *LicenseMasterSpec) {
 	*out = *in
 	in.CommonSplunkSpec.DeepCopyInto(&out.CommonSplunkSpec)
 	in.AppFrameworkConfig.DeepCopyInto(&out.AppFrameworkConfig)
+	out.LicenseReporting = in.LicenseReporting
+	if in.LicenseSecrets != nil {
+		in, out := &in.LicenseSecrets, &out.LicenseSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LicenseMasterSpec.
@@ -603,6 +952,29 @@ func (in *LicenseMasterSpec) DeepCopy() *LicenseMasterSpec {
 func (in *LicenseMasterStatus) DeepCopyInto(out *LicenseMasterStatus) {
 	*out = *in
 	in.AppContext.DeepCopyInto(&out.AppContext)
+	in.License.DeepCopyInto(&out.License)
+	if in.InstalledLicenses != nil {
+		in, out := &in.InstalledLicenses, &out.InstalledLicenses
+		*out = make([]InstalledLicense, len(*in))
+		copy(*out, *in)
+	}
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = make([]ExposedEndpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.NetworkPolicies != nil {
+		in, out := &in.NetworkPolicies, &out.NetworkPolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LicenseMasterStatus.
@@ -615,6 +987,56 @@ func (in *LicenseMasterStatus) DeepCopy() *LicenseMasterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LicensePoolUsage) DeepCopyInto(out *LicensePoolUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LicensePoolUsage.
+func (in *LicensePoolUsage) DeepCopy() *LicensePoolUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(LicensePoolUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LicenseReportingSpec) DeepCopyInto(out *LicenseReportingSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LicenseReportingSpec.
+func (in *LicenseReportingSpec) DeepCopy() *LicenseReportingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LicenseReportingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LicenseStatus) DeepCopyInto(out *LicenseStatus) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]LicensePoolUsage, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LicenseStatus.
+func (in *LicenseStatus) DeepCopy() *LicenseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LicenseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MonitoringConsole) DeepCopyInto(out *MonitoringConsole) {
 	*out = *in
@@ -703,6 +1125,23 @@ func (in *MonitoringConsoleStatus) DeepCopyInto(out *MonitoringConsoleStatus) {
 		}
 	}
 	in.AppContext.DeepCopyInto(&out.AppContext)
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = make([]ExposedEndpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.NetworkPolicies != nil {
+		in, out := &in.NetworkPolicies, &out.NetworkPolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringConsoleStatus.
@@ -715,6 +1154,42 @@ func (in *MonitoringConsoleStatus) DeepCopy() *MonitoringConsoleStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicySpec) DeepCopyInto(out *NetworkPolicySpec) {
+	*out = *in
+	if in.S2SFrom != nil {
+		in, out := &in.S2SFrom, &out.S2SFrom
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HECFrom != nil {
+		in, out := &in.HECFrom, &out.HECFrom
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.WebFrom != nil {
+		in, out := &in.WebFrom, &out.WebFrom
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicySpec.
+func (in *NetworkPolicySpec) DeepCopy() *NetworkPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PhaseInfo) DeepCopyInto(out *PhaseInfo) {
 	*out = *in
@@ -757,6 +1232,38 @@ func (in *SearchHeadCluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SearchHeadClusterAutoscalingSpec) DeepCopyInto(out *SearchHeadClusterAutoscalingSpec) {
+	*out = *in
+	out.AutoscalingSpec = in.AutoscalingSpec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SearchHeadClusterAutoscalingSpec.
+func (in *SearchHeadClusterAutoscalingSpec) DeepCopy() *SearchHeadClusterAutoscalingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SearchHeadClusterAutoscalingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SearchHeadClusterAutoscalingStatus) DeepCopyInto(out *SearchHeadClusterAutoscalingStatus) {
+	*out = *in
+	out.AutoscalingStatus = in.AutoscalingStatus
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SearchHeadClusterAutoscalingStatus.
+func (in *SearchHeadClusterAutoscalingStatus) DeepCopy() *SearchHeadClusterAutoscalingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SearchHeadClusterAutoscalingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SearchHeadClusterList) DeepCopyInto(out *SearchHeadClusterList) {
 	*out = *in
@@ -809,6 +1316,7 @@ func (in *SearchHeadClusterSpec) DeepCopyInto(out *SearchHeadClusterSpec) {
 	*out = *in
 	in.CommonSplunkSpec.DeepCopyInto(&out.CommonSplunkSpec)
 	in.AppFrameworkConfig.DeepCopyInto(&out.AppFrameworkConfig)
+	out.Autoscaling = in.Autoscaling
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SearchHeadClusterSpec.
@@ -847,6 +1355,24 @@ func (in *SearchHeadClusterStatus) DeepCopyInto(out *SearchHeadClusterStatus) {
 		copy(*out, *in)
 	}
 	in.AppContext.DeepCopyInto(&out.AppContext)
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = make([]ExposedEndpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.NetworkPolicies != nil {
+		in, out := &in.NetworkPolicies, &out.NetworkPolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.Autoscaling = in.Autoscaling
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SearchHeadClusterStatus.
@@ -892,7 +1418,7 @@ func (in *Spec) DeepCopyInto(out *Spec) {
 	in.Affinity.DeepCopyInto(&out.Affinity)
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
+		*out = make([]corev1.Toleration, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -911,6 +1437,21 @@ func (in *Spec) DeepCopy() *Spec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkPort) DeepCopyInto(out *SplunkPort) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkPort.
+func (in *SplunkPort) DeepCopy() *SplunkPort {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Standalone) DeepCopyInto(out *Standalone) {
 	*out = *in
@@ -1000,6 +1541,23 @@ func (in *StandaloneStatus) DeepCopyInto(out *StandaloneStatus) {
 		}
 	}
 	in.AppContext.DeepCopyInto(&out.AppContext)
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = make([]ExposedEndpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.NetworkPolicies != nil {
+		in, out := &in.NetworkPolicies, &out.NetworkPolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StandaloneStatus.
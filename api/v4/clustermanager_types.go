@@ -0,0 +1,64 @@
+/*
+Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v4
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterpriseApiV3 "github.com/splunk/splunk-operator/api/v3"
+)
+
+// ClusterManagerSpec defines the desired state of ClusterManager
+type ClusterManagerSpec struct {
+	CommonSplunkSpec `json:",inline"`
+
+	// Splunk Smartstore configuration. Refer to indexes.conf.spec and server.conf.spec on docs.splunk.com
+	SmartStore enterpriseApiV3.SmartStoreSpec `json:"smartstore,omitempty"`
+
+	// Splunk Enterprise App repository. Specifies remote App location and scope for Splunk App management
+	AppFrameworkConfig enterpriseApiV3.AppFrameworkSpec `json:"appRepo,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterManager is the Schema for the cluster manager API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=clustermanagers,scope=Namespaced,shortName=cmanager-idxc
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Status of cluster manager"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age of cluster manager"
+// +kubebuilder:storageversion
+type ClusterManager struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterManagerSpec                  `json:"spec,omitempty"`
+	Status enterpriseApiV3.ClusterMasterStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterManagerList contains a list of ClusterManager
+type ClusterManagerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterManager `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterManager{}, &ClusterManagerList{})
+}
@@ -0,0 +1,131 @@
+/*
+Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v4
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	enterpriseApiV3 "github.com/splunk/splunk-operator/api/v3"
+)
+
+const (
+	// APIVersion is a string representation of this API
+	APIVersion = "enterprise.splunk.com/v4"
+)
+
+// default all fields to being optional
+// +kubebuilder:validation:Optional
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
+// see also https://book.kubebuilder.io/reference/markers/crd.html
+
+// The v4 API only differs from the v3 API by the references to the cluster manager and the license manager, which are
+// named after the ClusterManager and LicenseManager kinds. The types that do not carry these references are shared with v3,
+// and the fields of the specs that carry them are kept in sync with v3 by TestV4SpecFields
+
+// CommonSplunkSpec defines the desired state of parameters that are common across all Splunk Enterprise CRD types
+type CommonSplunkSpec struct {
+	enterpriseApiV3.Spec `json:",inline"`
+
+	// Storage configuration for /opt/splunk/etc volume
+	EtcVolumeStorageConfig enterpriseApiV3.StorageClassSpec `json:"etcVolumeStorageConfig"`
+
+	// Storage configuration for /opt/splunk/var volume
+	VarVolumeStorageConfig enterpriseApiV3.StorageClassSpec `json:"varVolumeStorageConfig"`
+
+	// List of one or more Kubernetes volumes. These will be mounted in all pod containers as as /mnt/<name>
+	Volumes []corev1.Volume `json:"volumes"`
+
+	// Inline map of default.yml overrides used to initialize the environment
+	Defaults string `json:"defaults"`
+
+	// Full path or URL for one or more default.yml files, separated by commas
+	DefaultsURL string `json:"defaultsUrl"`
+
+	// Full path or URL for one or more defaults.yml files specific
+	// to App install, separated by commas.  The defaults listed here
+	// will be installed on the CM, standalone, search head deployer
+	// or license manager instance.
+	DefaultsURLApps string `json:"defaultsUrlApps"`
+
+	// Full path or URL for a Splunk Enterprise license file
+	LicenseURL string `json:"licenseUrl"`
+
+	// LicenseManagerRef refers to a Splunk Enterprise license manager managed by the operator within Kubernetes.
+	// Both the LicenseManager and the LicenseMaster kinds are accepted
+	LicenseManagerRef corev1.ObjectReference `json:"licenseManagerRef"`
+
+	// ClusterManagerRef refers to a Splunk Enterprise indexer cluster managed by the operator within Kubernetes.
+	// Both the ClusterManager and the ClusterMaster kinds are accepted
+	ClusterManagerRef corev1.ObjectReference `json:"clusterManagerRef"`
+
+	// MonitoringConsoleRef refers to a Splunk Enterprise monitoring console managed by the operator within Kubernetes.
+	// The monitoring console can be in another namespace, the namespace defaults to the namespace of the custom resource
+	MonitoringConsoleRef corev1.ObjectReference `json:"monitoringConsoleRef"`
+
+	// Mock to differentiate between UTs and actual reconcile
+	Mock bool `json:"Mock"`
+
+	// ServiceAccount is the service account used by the pods deployed by the CRD.
+	// If not specified uses the default serviceAccount for the namespace as per
+	// https://kubernetes.io/docs/tasks/configure-pod-container/configure-service-account/#use-the-default-service-account-to-access-the-api-server
+	ServiceAccount string `json:"serviceAccount"`
+
+	// ExtraEnv refers to extra environment variables to be passed to the Splunk instance containers
+	// WARNING: Setting environment variables used by Splunk or Ansible will affect Splunk installation and operation
+	ExtraEnv []corev1.EnvVar `json:"extraEnv,omitempty"`
+
+	// ReadinessInitialDelaySeconds defines initialDelaySeconds(See https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-startup-probes/#define-readiness-probes) for Readiness probe
+	// Note: If needed, Operator overrides with a higher value
+	// +kubebuilder:validation:Minimum=0
+	ReadinessInitialDelaySeconds int32 `json:"readinessInitialDelaySeconds"`
+
+	// LivenessInitialDelaySeconds defines initialDelaySeconds(See https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-startup-probes/#define-a-liveness-command) for the Liveness probe
+	// Note: If needed, Operator overrides with a higher value
+	// +kubebuilder:validation:Minimum=0
+	LivenessInitialDelaySeconds int32 `json:"livenessInitialDelaySeconds"`
+
+	// Sets imagePullSecrets if image is being pulled from a private registry.
+	// See https://kubernetes.io/docs/tasks/configure-pod-container/pull-image-private-registry/
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// Expose Splunk Web and HEC outside of the Kubernetes cluster, with an Ingress, OpenShift Route or Gateway API routes
+	// +optional
+	Expose enterpriseApiV3.ExposeSpec `json:"expose,omitempty"`
+
+	// Generate NetworkPolicies restricting the traffic to the Splunk pods, based on the topology of the custom resources
+	// +optional
+	NetworkPolicy enterpriseApiV3.NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// Additional ports of the Splunk instances, or overrides of the default ports (http-splunkweb, https-splunkd, http-hec and tcp-s2s).
+	// The ports are added to the containers, the services, the Istio annotations and the generated expose objects
+	// +optional
+	Ports []enterpriseApiV3.SplunkPort `json:"ports,omitempty"`
+
+	// Upgrade the Splunk stack in the supported order when the image changes: license manager, cluster manager, search heads,
+	// indexers and monitoring console. The statefulset is only updated to the new image once the custom resources of the
+	// previous stages are ready on it
+	// +optional
+	OrderedUpgrade bool `json:"orderedUpgrade,omitempty"`
+
+	// Policy applied when the Kubernetes objects managed by the operator were modified by someone else. Enforce (default)
+	// reverts the changes, ReportOnly keeps them. In both cases the drift is reported with events and a DriftDetected condition
+	// +kubebuilder:validation:Enum="";Enforce;ReportOnly
+	// +optional
+	DriftPolicy enterpriseApiV3.DriftPolicy `json:"driftPolicy,omitempty"`
+}
@@ -0,0 +1,55 @@
+/*
+Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v4
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// The v4 kinds are the hub of the conversions, and the storage version of the custom resources also served in v3
+
+// Hub marks IndexerCluster as the conversion hub
+func (*IndexerCluster) Hub() {}
+
+// Hub marks SearchHeadCluster as the conversion hub
+func (*SearchHeadCluster) Hub() {}
+
+// Hub marks Standalone as the conversion hub
+func (*Standalone) Hub() {}
+
+// Hub marks MonitoringConsole as the conversion hub
+func (*MonitoringConsole) Hub() {}
+
+// SetupWebhookWithManager registers the conversion webhook of IndexerCluster
+func (r *IndexerCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(r).Complete()
+}
+
+// SetupWebhookWithManager registers the conversion webhook of SearchHeadCluster
+func (r *SearchHeadCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(r).Complete()
+}
+
+// SetupWebhookWithManager registers the conversion webhook of Standalone
+func (r *Standalone) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(r).Complete()
+}
+
+// SetupWebhookWithManager registers the conversion webhook of MonitoringConsole
+func (r *MonitoringConsole) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(r).Complete()
+}
@@ -0,0 +1,79 @@
+/*
+Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v4
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterpriseApiV3 "github.com/splunk/splunk-operator/api/v3"
+)
+
+// IndexerClusterSpec defines the desired state of a Splunk Enterprise indexer cluster
+type IndexerClusterSpec struct {
+	CommonSplunkSpec `json:",inline"`
+
+	// Number of search head pods; a search head cluster will be created if > 1
+	Replicas int32 `json:"replicas"`
+
+	// Autoscaling of the indexers from the ingestion queues and the indexing throughput reported by the cluster manager
+	// +optional
+	Autoscaling enterpriseApiV3.IndexerClusterAutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// Automatic rebalance of the buckets once the peers added to the indexer cluster are searchable
+	// +optional
+	Rebalance enterpriseApiV3.IndexerClusterRebalanceSpec `json:"rebalance,omitempty"`
+
+	// Maximum time, in seconds, the maintenance mode enabled by the operator around a disruptive operation is kept before
+	// it is cleared by the watchdog. Defaults to 7200
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaintenanceModeTimeoutSeconds int32 `json:"maintenanceModeTimeoutSeconds,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// IndexerCluster is the Schema for a Splunk Enterprise indexer cluster
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:resource:path=indexerclusters,scope=Namespaced,shortName=idc;idxc
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Status of indexer cluster"
+// +kubebuilder:printcolumn:name="Master",type="string",JSONPath=".status.clusterMasterPhase",description="Status of cluster manager"
+// +kubebuilder:printcolumn:name="Desired",type="integer",JSONPath=".status.replicas",description="Desired number of indexer peers"
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas",description="Current number of ready indexer peers"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age of indexer cluster"
+// +kubebuilder:storageversion
+type IndexerCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IndexerClusterSpec                   `json:"spec,omitempty"`
+	Status enterpriseApiV3.IndexerClusterStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// IndexerClusterList contains a list of IndexerCluster
+type IndexerClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IndexerCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IndexerCluster{}, &IndexerClusterList{})
+}
@@ -0,0 +1,69 @@
+/*
+Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v4
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterpriseApiV3 "github.com/splunk/splunk-operator/api/v3"
+)
+
+// LicenseManagerSpec defines the desired state of a Splunk Enterprise license manager.
+type LicenseManagerSpec struct {
+	CommonSplunkSpec `json:",inline"`
+
+	// Splunk enterprise App repository. Specifies remote App location and scope for Splunk App management
+	AppFrameworkConfig enterpriseApiV3.AppFrameworkSpec `json:"appRepo,omitempty"`
+
+	// Thresholds for the license usage and expiry warnings
+	LicenseReporting enterpriseApiV3.LicenseReportingSpec `json:"licenseReporting,omitempty"`
+
+	// Secrets containing the license files. Each key of a secret is a license file, installed on the license manager
+	// without a restart. Licenses are removed from the license manager when their secret or key is deleted
+	LicenseSecrets []corev1.LocalObjectReference `json:"licenseSecrets,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LicenseManager is the Schema for a Splunk Enterprise license manager.
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=licensemanagers,scope=Namespaced,shortName=lmanager
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Status of license manager"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age of license manager"
+// +kubebuilder:storageversion
+type LicenseManager struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LicenseManagerSpec                  `json:"spec,omitempty"`
+	Status enterpriseApiV3.LicenseMasterStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// LicenseManagerList contains a list of LicenseManager
+type LicenseManagerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LicenseManager `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LicenseManager{}, &LicenseManagerList{})
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+)
+
+// appStatus is the App Framework deployment status of an app, as shown by the apps command
+type appStatus struct {
+	AppSource string                     `json:"appSource"`
+	AppName   string                     `json:"appName"`
+	Phase     enterpriseApi.AppPhaseType `json:"phase"`
+	Status    string                     `json:"status"`
+	FailCount uint32                     `json:"failCount"`
+	LastError string                     `json:"lastError,omitempty"`
+}
+
+// appsStatus is the App Framework deployment status of the apps of a custom resource
+type appsStatus struct {
+	Summary enterpriseApi.AppDeploymentSummary `json:"summary"`
+	Apps    []appStatus                        `json:"apps"`
+}
+
+// apps shows the App Framework deployment status of the apps of a custom resource
+func (p *plugin) apps(ctx context.Context, kindName string, name string) error {
+	kind, cr, err := p.getCR(ctx, kindName, name)
+	if err != nil {
+		return err
+	}
+	appContext := getAppContext(cr)
+	if appContext == nil {
+		return fmt.Errorf("%s doesn't deploy apps with the App Framework", kind.kind)
+	}
+
+	appsSrcDeployStatus, err := enterprise.GetAppDeployStatus(ctx, p.client, cr, appContext)
+	if err != nil {
+		return err
+	}
+
+	status := appsStatus{
+		Summary: appContext.Summary,
+		Apps:    []appStatus{},
+	}
+	for appSrc, appSrcDeployInfo := range appsSrcDeployStatus {
+		for _, deployInfo := range appSrcDeployInfo.AppDeploymentInfoList {
+			// deleted apps are no longer deployed
+			if deployInfo.RepoState != enterpriseApi.RepoStateActive {
+				continue
+			}
+			status.Apps = append(status.Apps, appStatus{
+				AppSource: appSrc,
+				AppName:   deployInfo.AppName,
+				Phase:     deployInfo.PhaseInfo.Phase,
+				Status:    enterprise.AppPhaseStatusAsStr(deployInfo.PhaseInfo.Status),
+				FailCount: deployInfo.PhaseInfo.FailCount,
+				LastError: deployInfo.PhaseInfo.LastError,
+			})
+		}
+	}
+	sort.Slice(status.Apps, func(i, j int) bool {
+		if status.Apps[i].AppSource != status.Apps[j].AppSource {
+			return status.Apps[i].AppSource < status.Apps[j].AppSource
+		}
+		return status.Apps[i].AppName < status.Apps[j].AppName
+	})
+
+	if p.output == outputJSON {
+		return printJSON(p.out, status)
+	}
+	if len(status.Apps) == 0 {
+		fmt.Fprintf(p.out, "No apps deployed by %s %s\n", kind.kind, name)
+		return nil
+	}
+	rows := make([][]string, 0, len(status.Apps))
+	for _, app := range status.Apps {
+		rows = append(rows, []string{
+			app.AppSource,
+			app.AppName,
+			string(app.Phase),
+			app.Status,
+			strconv.FormatUint(uint64(app.FailCount), 10),
+			formatOptional(app.LastError),
+		})
+	}
+	if err := printTable(p.out, []string{"APP SOURCE", "APP", "PHASE", "STATUS", "FAILURES", "LAST ERROR"}, rows); err != nil {
+		return err
+	}
+	fmt.Fprintf(p.out, "\n%d apps: %d completed, %d pending, %d failed\n",
+		status.Summary.TotalApps, status.Summary.CompletedApps, status.Summary.PendingApps, status.Summary.FailedApps)
+	return nil
+}
+
+// triggerAppPoll turns on the manual app update of a kind, which makes the operator check the app repositories of all
+// the custom resources of the kind on their next reconcile
+func (p *plugin) triggerAppPoll(ctx context.Context, kindName string) error {
+	kind, err := getCRKind(kindName)
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{}
+	configMapName := enterprise.GetSplunkManualAppUpdateConfigMapName(p.namespace)
+	err = p.client.Get(ctx, types.NamespacedName{Namespace: p.namespace, Name: configMapName}, configMap)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return fmt.Errorf("no custom resource of namespace %s uses the App Framework", p.namespace)
+		}
+		return err
+	}
+	if _, ok := configMap.Data[kind.kind]; !ok {
+		return fmt.Errorf("no %s of namespace %s uses the App Framework", kind.kind, p.namespace)
+	}
+
+	// each custom resource of the kind decrements refCount once it checked its app repositories, and the operator
+	// turns the status off when refCount gets to zero. The custom resources of the kind own the configMap
+	refCount := 0
+	for _, ownerRef := range configMap.GetOwnerReferences() {
+		if ownerRef.Kind == kind.kind {
+			refCount++
+		}
+	}
+	if refCount == 0 {
+		return fmt.Errorf("no %s of namespace %s uses the App Framework", kind.kind, p.namespace)
+	}
+	patch := client.MergeFrom(configMap.DeepCopy())
+	configMap.Data[kind.kind] = fmt.Sprintf(`status: on
+refCount: %d`, refCount)
+	err = p.client.Patch(ctx, configMap, patch)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(p.out, "App repository check triggered for %d %s custom resource(s) in namespace %s\n", refCount, kind.kind, p.namespace)
+	return nil
+}
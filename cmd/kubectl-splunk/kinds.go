@@ -0,0 +1,191 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+)
+
+// crKind is a kind of custom resource of the Splunk operator
+type crKind struct {
+	// Kind of the custom resources
+	kind string
+
+	// short names of the kind accepted on the command line, besides the kind and its plural
+	shortNames []string
+
+	// annotation which pauses the reconciliation of the custom resources
+	pausedAnnotation string
+
+	// instance types of the statefulsets of the custom resources
+	instanceTypes []enterprise.InstanceType
+
+	// newObject returns an empty custom resource
+	newObject func() client.Object
+
+	// newList returns an empty list of custom resources
+	newList func() client.ObjectList
+}
+
+// crKinds are the kinds of custom resources handled by the plugin, in the order of the status command
+var crKinds = []crKind{
+	{
+		kind:             "LicenseMaster",
+		shortNames:       []string{"lm"},
+		pausedAnnotation: enterpriseApi.LicenseManagerPausedAnnotation,
+		instanceTypes:    []enterprise.InstanceType{enterprise.SplunkLicenseManager},
+		newObject:        func() client.Object { return &enterpriseApi.LicenseMaster{} },
+		newList:          func() client.ObjectList { return &enterpriseApi.LicenseMasterList{} },
+	},
+	{
+		kind:             "LicenseManager",
+		shortNames:       []string{"lmanager"},
+		pausedAnnotation: enterpriseApi.LicenseManagerPausedAnnotation,
+		instanceTypes:    []enterprise.InstanceType{enterprise.SplunkLicenseManager},
+		newObject:        func() client.Object { return &enterpriseApiV4.LicenseManager{} },
+		newList:          func() client.ObjectList { return &enterpriseApiV4.LicenseManagerList{} },
+	},
+	{
+		kind:             "ClusterMaster",
+		shortNames:       []string{"cm-idxc"},
+		pausedAnnotation: enterpriseApi.ClusterManagerPausedAnnotation,
+		instanceTypes:    []enterprise.InstanceType{enterprise.SplunkClusterManager},
+		newObject:        func() client.Object { return &enterpriseApi.ClusterMaster{} },
+		newList:          func() client.ObjectList { return &enterpriseApi.ClusterMasterList{} },
+	},
+	{
+		kind:             "ClusterManager",
+		shortNames:       []string{"cmanager-idxc"},
+		pausedAnnotation: enterpriseApi.ClusterManagerPausedAnnotation,
+		instanceTypes:    []enterprise.InstanceType{enterprise.SplunkClusterManager},
+		newObject:        func() client.Object { return &enterpriseApiV4.ClusterManager{} },
+		newList:          func() client.ObjectList { return &enterpriseApiV4.ClusterManagerList{} },
+	},
+	{
+		kind:             "IndexerCluster",
+		shortNames:       []string{"idc", "idxc"},
+		pausedAnnotation: enterpriseApi.IndexerClusterPausedAnnotation,
+		instanceTypes:    []enterprise.InstanceType{enterprise.SplunkIndexer},
+		newObject:        func() client.Object { return &enterpriseApi.IndexerCluster{} },
+		newList:          func() client.ObjectList { return &enterpriseApi.IndexerClusterList{} },
+	},
+	{
+		kind:             "SearchHeadCluster",
+		shortNames:       []string{"shc"},
+		pausedAnnotation: enterpriseApi.SearchHeadClusterPausedAnnotation,
+		instanceTypes:    []enterprise.InstanceType{enterprise.SplunkDeployer, enterprise.SplunkSearchHead},
+		newObject:        func() client.Object { return &enterpriseApi.SearchHeadCluster{} },
+		newList:          func() client.ObjectList { return &enterpriseApi.SearchHeadClusterList{} },
+	},
+	{
+		kind:             "MonitoringConsole",
+		shortNames:       []string{"mc"},
+		pausedAnnotation: enterpriseApi.MonitoringConsolePausedAnnotation,
+		instanceTypes:    []enterprise.InstanceType{enterprise.SplunkMonitoringConsole},
+		newObject:        func() client.Object { return &enterpriseApi.MonitoringConsole{} },
+		newList:          func() client.ObjectList { return &enterpriseApi.MonitoringConsoleList{} },
+	},
+	{
+		kind:             "Standalone",
+		shortNames:       []string{"stdaln"},
+		pausedAnnotation: enterpriseApi.StandalonePausedAnnotation,
+		instanceTypes:    []enterprise.InstanceType{enterprise.SplunkStandalone},
+		newObject:        func() client.Object { return &enterpriseApi.Standalone{} },
+		newList:          func() client.ObjectList { return &enterpriseApi.StandaloneList{} },
+	},
+}
+
+// getCRKind returns the kind of custom resource of name, which is a kind, its plural or one of its short names
+func getCRKind(name string) (*crKind, error) {
+	name = strings.ToLower(name)
+	for i := range crKinds {
+		kind := strings.ToLower(crKinds[i].kind)
+		if name == kind || name == kind+"s" {
+			return &crKinds[i], nil
+		}
+		for _, shortName := range crKinds[i].shortNames {
+			if name == shortName {
+				return &crKinds[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown kind %q", name)
+}
+
+// getCR returns the custom resource of kind with name, in the namespace of the plugin
+func (p *plugin) getCR(ctx context.Context, kindName string, name string) (*crKind, splcommon.MetaObject, error) {
+	kind, err := getCRKind(kindName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	obj := kind.newObject()
+	err = p.client.Get(ctx, types.NamespacedName{Namespace: p.namespace, Name: name}, obj)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kind, obj.(splcommon.MetaObject), nil
+}
+
+// listCRs returns the custom resources of kind in the namespace of the plugin
+func (p *plugin) listCRs(ctx context.Context, kind *crKind) ([]splcommon.MetaObject, error) {
+	list := kind.newList()
+	err := p.client.List(ctx, list, client.InNamespace(p.namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	crs := make([]splcommon.MetaObject, 0, len(items))
+	for _, item := range items {
+		crs = append(crs, item.(splcommon.MetaObject))
+	}
+	return crs, nil
+}
+
+// getAppContext returns the App Framework context of the status of cr, nil if the App Framework isn't applicable to cr
+func getAppContext(cr splcommon.MetaObject) *enterpriseApi.AppDeploymentContext {
+	switch cr := cr.(type) {
+	case *enterpriseApi.Standalone:
+		return &cr.Status.AppContext
+	case *enterpriseApi.ClusterMaster:
+		return &cr.Status.AppContext
+	case *enterpriseApiV4.ClusterManager:
+		return &cr.Status.AppContext
+	case *enterpriseApi.LicenseMaster:
+		return &cr.Status.AppContext
+	case *enterpriseApiV4.LicenseManager:
+		return &cr.Status.AppContext
+	case *enterpriseApi.SearchHeadCluster:
+		return &cr.Status.AppContext
+	case *enterpriseApi.MonitoringConsole:
+		return &cr.Status.AppContext
+	}
+	return nil
+}
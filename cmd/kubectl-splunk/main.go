@@ -0,0 +1,175 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// kubectl-splunk is a kubectl plugin for the day-2 operations of the custom resources of the Splunk operator
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+)
+
+const usage = `kubectl splunk runs day-2 operations on the custom resources of the Splunk operator.
+
+Usage:
+  kubectl splunk [flags] COMMAND [ARGS]
+
+Commands:
+  status [KIND [NAME]]     Show the phase of the custom resources, or the peers and members of one custom resource
+  apps KIND NAME           Show the App Framework deployment status of the apps of a custom resource
+  pause KIND NAME          Pause the reconciliation of a custom resource
+  resume KIND NAME         Resume the reconciliation of a custom resource
+  trigger-app-poll KIND    Check the app repositories of all the custom resources of a kind now
+  secrets KIND NAME        Show the versioned secrets of a custom resource, and the pods using them
+
+Kinds:
+  standalone (stdaln), clustermaster (cm-idxc), clustermanager (cmanager-idxc), licensemaster (lm),
+  licensemanager (lmanager), indexercluster (idc, idxc), searchheadcluster (shc), monitoringconsole (mc)
+
+Flags:
+`
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(enterpriseApi.AddToScheme(scheme))
+	utilruntime.Must(enterpriseApiV4.AddToScheme(scheme))
+}
+
+// plugin runs the commands of kubectl splunk
+type plugin struct {
+	// client of the Kubernetes cluster
+	client client.Client
+
+	// namespace of the custom resources
+	namespace string
+
+	// output format, table or json
+	output string
+
+	// out receives the output of the commands
+	out io.Writer
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the flags, connects to the cluster of the kubeconfig and runs the command of args
+func run(args []string, out io.Writer, errOut io.Writer) error {
+	flags := pflag.NewFlagSet("kubectl-splunk", pflag.ContinueOnError)
+	flags.SetOutput(errOut)
+	flags.Usage = func() {
+		fmt.Fprint(errOut, usage)
+		flags.PrintDefaults()
+	}
+
+	var kubeconfig, output string
+	flags.StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use")
+	flags.StringVarP(&output, "output", "o", outputTable, "Output format, table or json")
+	overrides := &clientcmd.ConfigOverrides{}
+	clientcmd.BindOverrideFlags(overrides, flags, clientcmd.RecommendedConfigOverrideFlags(""))
+	if err := flags.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("missing command")
+	}
+	if output != outputTable && output != outputJSON {
+		return fmt.Errorf("invalid output format %q, must be %s or %s", output, outputTable, outputJSON)
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	p := &plugin{
+		client:    c,
+		namespace: namespace,
+		output:    output,
+		out:       out,
+	}
+	return p.runCommand(context.Background(), flags.Args())
+}
+
+// runCommand runs the command of args, the first argument being the name of the command
+func (p *plugin) runCommand(ctx context.Context, args []string) error {
+	// the helpers of the operator log through the context, which is too verbose for a command line tool
+	ctx = log.IntoContext(ctx, logr.Discard())
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "status":
+		if len(args) > 2 {
+			return fmt.Errorf("usage: status [KIND [NAME]]")
+		}
+		return p.status(ctx, args)
+	case "apps":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: apps KIND NAME")
+		}
+		return p.apps(ctx, args[0], args[1])
+	case "pause", "resume":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s KIND NAME", command)
+		}
+		return p.setPaused(ctx, args[0], args[1], command == "pause")
+	case "trigger-app-poll":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: trigger-app-poll KIND")
+		}
+		return p.triggerAppPoll(ctx, args[0])
+	case "secrets":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: secrets KIND NAME")
+		}
+		return p.secrets(ctx, args[0], args[1])
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
+)
+
+func newTestPlugin(output string, objects ...client.Object) (*plugin, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return &plugin{
+		client:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		namespace: "test",
+		output:    output,
+		out:       out,
+	}, out
+}
+
+func TestGetCRKind(t *testing.T) {
+	for name, want := range map[string]string{
+		"standalone":        "Standalone",
+		"Standalones":       "Standalone",
+		"idxc":              "IndexerCluster",
+		"idc":               "IndexerCluster",
+		"shc":               "SearchHeadCluster",
+		"cm-idxc":           "ClusterMaster",
+		"cmanager-idxc":     "ClusterManager",
+		"LicenseManager":    "LicenseManager",
+		"lm":                "LicenseMaster",
+		"monitoringconsole": "MonitoringConsole",
+	} {
+		kind, err := getCRKind(name)
+		if err != nil || kind.kind != want {
+			t.Errorf("getCRKind(%q) = %v, %v; want %s", name, kind, err, want)
+		}
+	}
+	if _, err := getCRKind("deployment"); err == nil {
+		t.Errorf("getCRKind() didn't return an error for an unknown kind")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	ctx := context.TODO()
+	standalone := &enterpriseApi.Standalone{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "test", Annotations: map[string]string{enterpriseApi.StandalonePausedAnnotation: ""}},
+		Status:     enterpriseApi.StandaloneStatus{Phase: enterpriseApi.PhaseReady, Replicas: 2, ReadyReplicas: 1},
+	}
+	idxc := &enterpriseApi.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "idxc1", Namespace: "test"},
+		Status: enterpriseApi.IndexerClusterStatus{
+			Phase:        enterpriseApi.PhaseUpdating,
+			UpgradeStage: "IndexerCluster",
+			Replicas:     3,
+			Peers: []enterpriseApi.IndexerClusterMemberStatus{
+				{Name: "splunk-idxc1-indexer-0", Status: "Up", Searchable: true, BucketCount: 42, ActiveBundleID: "abc"},
+			},
+		},
+	}
+	cm := &enterpriseApiV4.ClusterManager{
+		ObjectMeta: metav1.ObjectMeta{Name: "cm1", Namespace: "test"},
+		Status: enterpriseApi.ClusterMasterStatus{
+			Phase:             enterpriseApi.PhaseReady,
+			BundlePushTracker: enterpriseApi.BundlePushInfo{NeedToPushMasterApps: true},
+		},
+	}
+	other := &enterpriseApi.Standalone{ObjectMeta: metav1.ObjectMeta{Name: "s2", Namespace: "other"}}
+
+	p, out := newTestPlugin(outputTable, standalone, idxc, cm, other)
+	if err := p.status(ctx, nil); err != nil {
+		t.Fatalf("status() returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("status() printed %d lines; want a header and 3 custom resources:\n%s", len(lines), out.String())
+	}
+	for i, want := range [][]string{
+		{"ClusterManager", "cm1", "Ready", "-", "-", "no"},
+		{"IndexerCluster", "idxc1", "Updating", "0/3", "IndexerCluster", "no"},
+		{"Standalone", "s1", "Ready", "1/2", "-", "yes"},
+	} {
+		if got := strings.Fields(lines[i+1]); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("status() row %d = %v; want %v", i, got, want)
+		}
+	}
+
+	// status of the peers of an indexer cluster
+	p, out = newTestPlugin(outputTable, idxc)
+	if err := p.status(ctx, []string{"idxc", "idxc1"}); err != nil {
+		t.Fatalf("status() returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Peers:") || !strings.Contains(out.String(), "splunk-idxc1-indexer-0") {
+		t.Errorf("status() didn't print the peers of the indexer cluster:\n%s", out.String())
+	}
+
+	// JSON status of a cluster manager
+	p, out = newTestPlugin(outputJSON, cm)
+	if err := p.status(ctx, []string{"ClusterManager", "cm1"}); err != nil {
+		t.Fatalf("status() returned error: %v", err)
+	}
+	var details crStatusDetails
+	if err := json.Unmarshal(out.Bytes(), &details); err != nil {
+		t.Fatalf("status() printed invalid JSON: %v", err)
+	}
+	if details.Kind != "ClusterManager" || details.Phase != enterpriseApi.PhaseReady || details.BundlePush == nil || !details.BundlePush.NeedToPushMasterApps {
+		t.Errorf("status() = %+v; want the bundle push state of the cluster manager", details)
+	}
+
+	if err := p.status(ctx, []string{"standalone", "missing"}); err == nil {
+		t.Errorf("status() didn't return an error for a missing custom resource")
+	}
+}
+
+func TestApps(t *testing.T) {
+	ctx := context.TODO()
+	standalone := &enterpriseApi.Standalone{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "test"},
+		Status: enterpriseApi.StandaloneStatus{
+			AppContext: enterpriseApi.AppDeploymentContext{
+				Summary: enterpriseApi.AppDeploymentSummary{TotalApps: 2, CompletedApps: 1, FailedApps: 1},
+				AppsSrcDeployStatus: map[string]enterpriseApi.AppSrcDeployInfo{
+					"appSrc1": {
+						AppDeploymentInfoList: []enterpriseApi.AppDeploymentInfo{
+							{
+								AppName:   "app2.tgz",
+								RepoState: enterpriseApi.RepoStateActive,
+								PhaseInfo: enterpriseApi.PhaseInfo{Phase: enterpriseApi.PhaseDownload, Status: enterpriseApi.AppPkgDownloadError, FailCount: 3, LastError: "access denied"},
+							},
+							{
+								AppName:   "app1.tgz",
+								RepoState: enterpriseApi.RepoStateActive,
+								PhaseInfo: enterpriseApi.PhaseInfo{Phase: enterpriseApi.PhaseInstall, Status: enterpriseApi.AppPkgInstallComplete},
+							},
+							{
+								AppName:   "app3.tgz",
+								RepoState: enterpriseApi.RepoStateDeleted,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	idxc := &enterpriseApi.IndexerCluster{ObjectMeta: metav1.ObjectMeta{Name: "idxc1", Namespace: "test"}}
+
+	p, out := newTestPlugin(outputJSON, standalone, idxc)
+	if err := p.apps(ctx, "stdaln", "s1"); err != nil {
+		t.Fatalf("apps() returned error: %v", err)
+	}
+	var status appsStatus
+	if err := json.Unmarshal(out.Bytes(), &status); err != nil {
+		t.Fatalf("apps() printed invalid JSON: %v", err)
+	}
+	if len(status.Apps) != 2 || status.Apps[0].AppName != "app1.tgz" || status.Apps[1].AppName != "app2.tgz" {
+		t.Fatalf("apps() = %+v; want the active apps sorted by name", status.Apps)
+	}
+	if status.Apps[1].Status != "Download Error" || status.Apps[1].FailCount != 3 || status.Apps[1].LastError != "access denied" {
+		t.Errorf("apps() = %+v; want the download error of app2.tgz", status.Apps[1])
+	}
+	if status.Summary.FailedApps != 1 {
+		t.Errorf("apps() summary = %+v; want 1 failed app", status.Summary)
+	}
+
+	if err := p.apps(ctx, "idxc", "idxc1"); err == nil {
+		t.Errorf("apps() didn't return an error for an indexer cluster")
+	}
+}
+
+func TestSetPaused(t *testing.T) {
+	ctx := context.TODO()
+	shc := &enterpriseApi.SearchHeadCluster{ObjectMeta: metav1.ObjectMeta{Name: "shc1", Namespace: "test"}}
+	p, _ := newTestPlugin(outputTable, shc)
+
+	isPaused := func() bool {
+		got := &enterpriseApi.SearchHeadCluster{}
+		if err := p.client.Get(ctx, types.NamespacedName{Namespace: "test", Name: "shc1"}, got); err != nil {
+			t.Fatalf("unable to get the search head cluster: %v", err)
+		}
+		_, ok := got.GetAnnotations()[enterpriseApi.SearchHeadClusterPausedAnnotation]
+		return ok
+	}
+
+	if err := p.setPaused(ctx, "shc", "shc1", true); err != nil || !isPaused() {
+		t.Errorf("setPaused() didn't pause the search head cluster, error: %v", err)
+	}
+	if err := p.setPaused(ctx, "shc", "shc1", true); err != nil || !isPaused() {
+		t.Errorf("setPaused() failed on a paused search head cluster, error: %v", err)
+	}
+	if err := p.setPaused(ctx, "shc", "shc1", false); err != nil || isPaused() {
+		t.Errorf("setPaused() didn't resume the search head cluster, error: %v", err)
+	}
+}
+
+func TestTriggerAppPoll(t *testing.T) {
+	ctx := context.TODO()
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-test-manual-app-update",
+			Namespace: "test",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "Standalone", Name: "s1"},
+				{Kind: "Standalone", Name: "s2"},
+				{Kind: "ClusterMaster", Name: "cm1"},
+			},
+		},
+		Data: map[string]string{
+			"Standalone":    "status: off\nrefCount: 1",
+			"ClusterMaster": "status: off\nrefCount: 1",
+		},
+	}
+
+	p, _ := newTestPlugin(outputTable)
+	if err := p.triggerAppPoll(ctx, "standalone"); err == nil {
+		t.Errorf("triggerAppPoll() didn't return an error without the manual app update configMap")
+	}
+
+	p, _ = newTestPlugin(outputTable, configMap)
+	if err := p.triggerAppPoll(ctx, "standalone"); err != nil {
+		t.Fatalf("triggerAppPoll() returned error: %v", err)
+	}
+	got := &corev1.ConfigMap{}
+	if err := p.client.Get(ctx, types.NamespacedName{Namespace: "test", Name: "splunk-test-manual-app-update"}, got); err != nil {
+		t.Fatalf("unable to get the manual app update configMap: %v", err)
+	}
+	if got.Data["Standalone"] != "status: on\nrefCount: 2" {
+		t.Errorf("triggerAppPoll() set %q; want the status on with the refCount of the Standalone owners", got.Data["Standalone"])
+	}
+	if got.Data["ClusterMaster"] != "status: off\nrefCount: 1" {
+		t.Errorf("triggerAppPoll() updated the ClusterMaster entry")
+	}
+
+	if err := p.triggerAppPoll(ctx, "shc"); err == nil {
+		t.Errorf("triggerAppPoll() didn't return an error for a kind without an entry")
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	ctx := context.TODO()
+	replicas := int32(2)
+	standalone := &enterpriseApi.Standalone{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "test"}}
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "splunk-s1-standalone", Namespace: "test"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	}
+	newSecret := func(name string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Labels: splutil.GetSecretLabels()}}
+	}
+	newPod := func(name string, secretName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{
+					{Name: "mnt-splunk-secrets", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secretName}}},
+				},
+			},
+		}
+	}
+
+	p, out := newTestPlugin(outputJSON, standalone, statefulSet,
+		newSecret("splunk-s1-standalone-secret-v1"), newSecret("splunk-s1-standalone-secret-v2"), newSecret("splunk-test-secret"),
+		newPod("splunk-s1-standalone-0", "splunk-s1-standalone-secret-v2"), newPod("splunk-s1-standalone-1", "splunk-s1-standalone-secret-v1"))
+	if err := p.secrets(ctx, "standalone", "s1"); err != nil {
+		t.Fatalf("secrets() returned error: %v", err)
+	}
+	var secrets []versionedSecret
+	if err := json.Unmarshal(out.Bytes(), &secrets); err != nil {
+		t.Fatalf("secrets() printed invalid JSON: %v", err)
+	}
+	if len(secrets) != 2 {
+		t.Fatalf("secrets() = %+v; want the 2 versioned secrets of the statefulset", secrets)
+	}
+	if secrets[0].Version != 1 || secrets[0].Latest || strings.Join(secrets[0].Pods, ",") != "splunk-s1-standalone-1" {
+		t.Errorf("secrets() version 1 = %+v; want it used by pod 1", secrets[0])
+	}
+	if secrets[1].Version != 2 || !secrets[1].Latest || strings.Join(secrets[1].Pods, ",") != "splunk-s1-standalone-0" {
+		t.Errorf("secrets() version 2 = %+v; want the latest version used by pod 0", secrets[1])
+	}
+}
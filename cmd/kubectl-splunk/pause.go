@@ -0,0 +1,63 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// setPaused pauses the reconciliation of a custom resource with the paused annotation of its kind, or resumes it by
+// removing the annotation
+func (p *plugin) setPaused(ctx context.Context, kindName string, name string, paused bool) error {
+	kind, cr, err := p.getCR(ctx, kindName, name)
+	if err != nil {
+		return err
+	}
+
+	annotations := cr.GetAnnotations()
+	if _, ok := annotations[kind.pausedAnnotation]; ok == paused {
+		fmt.Fprintf(p.out, "%s %s is already %s\n", kind.kind, name, pausedState(paused))
+		return nil
+	}
+
+	patch := client.MergeFrom(cr.DeepCopyObject().(client.Object))
+	if paused {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[kind.pausedAnnotation] = ""
+	} else {
+		delete(annotations, kind.pausedAnnotation)
+	}
+	cr.SetAnnotations(annotations)
+	err = p.client.Patch(ctx, cr, patch)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(p.out, "%s %s %s\n", kind.kind, name, pausedState(paused))
+	return nil
+}
+
+// pausedState describes the reconciliation state of a custom resource
+func pausedState(paused bool) string {
+	if paused {
+		return "paused"
+	}
+	return "resumed"
+}
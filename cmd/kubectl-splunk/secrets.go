@@ -0,0 +1,126 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
+)
+
+// versionedSecret is a versioned secret of a statefulset, as shown by the secrets command
+type versionedSecret struct {
+	StatefulSet string   `json:"statefulSet"`
+	Secret      string   `json:"secret"`
+	Version     int      `json:"version"`
+	Latest      bool     `json:"latest"`
+	Pods        []string `json:"pods"`
+}
+
+// secrets shows the versioned secrets of the statefulsets of a custom resource, and the pods which mount them
+func (p *plugin) secrets(ctx context.Context, kindName string, name string) error {
+	kind, _, err := p.getCR(ctx, kindName, name)
+	if err != nil {
+		return err
+	}
+
+	secrets := []versionedSecret{}
+	for _, instanceType := range kind.instanceTypes {
+		statefulSetName := enterprise.GetSplunkStatefulsetName(instanceType, name)
+		statefulSet := &appsv1.StatefulSet{}
+		err = p.client.Get(ctx, types.NamespacedName{Namespace: p.namespace, Name: statefulSetName}, statefulSet)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		// versioned secrets of the statefulset
+		statefulSetSecrets := map[string]*versionedSecret{}
+		_, latestVersion, versions := splutil.GetExistingLatestVersionedSecret(ctx, p.client, p.namespace, statefulSetName, true)
+		for version, secret := range versions {
+			statefulSetSecrets[secret.GetName()] = &versionedSecret{
+				StatefulSet: statefulSetName,
+				Secret:      secret.GetName(),
+				Version:     version,
+				Latest:      version == latestVersion,
+				Pods:        []string{},
+			}
+		}
+
+		// secrets mounted by the pods of the statefulset
+		var replicas int32
+		if statefulSet.Spec.Replicas != nil {
+			replicas = *statefulSet.Spec.Replicas
+		}
+		for i := int32(0); i < replicas; i++ {
+			podName := enterprise.GetSplunkStatefulsetPodName(instanceType, name, i)
+			secret, err := splutil.GetSecretFromPod(ctx, p.client, podName, p.namespace)
+			if err != nil {
+				continue
+			}
+			if _, ok := statefulSetSecrets[secret.GetName()]; !ok {
+				statefulSetSecrets[secret.GetName()] = &versionedSecret{
+					StatefulSet: statefulSetName,
+					Secret:      secret.GetName(),
+					Version:     -1,
+					Pods:        []string{},
+				}
+			}
+			statefulSetSecrets[secret.GetName()].Pods = append(statefulSetSecrets[secret.GetName()].Pods, podName)
+		}
+
+		start := len(secrets)
+		for _, secret := range statefulSetSecrets {
+			secrets = append(secrets, *secret)
+		}
+		sort.Slice(secrets[start:], func(i, j int) bool {
+			return secrets[start+i].Version < secrets[start+j].Version
+		})
+	}
+
+	if p.output == outputJSON {
+		return printJSON(p.out, secrets)
+	}
+	if len(secrets) == 0 {
+		fmt.Fprintf(p.out, "No versioned secrets found for %s %s\n", kind.kind, name)
+		return nil
+	}
+	rows := make([][]string, 0, len(secrets))
+	for _, secret := range secrets {
+		version := "-"
+		if secret.Version >= 0 {
+			version = strconv.Itoa(secret.Version)
+		}
+		rows = append(rows, []string{
+			secret.StatefulSet,
+			secret.Secret,
+			version,
+			formatBool(secret.Latest),
+			formatOptional(strings.Join(secret.Pods, ",")),
+		})
+	}
+	return printTable(p.out, []string{"STATEFULSET", "SECRET", "VERSION", "LATEST", "PODS"}, rows)
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+)
+
+// crStatus is the status of a custom resource, as shown by the status command
+type crStatus struct {
+	Kind          string              `json:"kind"`
+	Name          string              `json:"name"`
+	Phase         enterpriseApi.Phase `json:"phase"`
+	Replicas      *int32              `json:"replicas,omitempty"`
+	ReadyReplicas *int32              `json:"readyReplicas,omitempty"`
+	UpgradeStage  string              `json:"upgradeStage,omitempty"`
+	Paused        bool                `json:"paused"`
+}
+
+// crStatusDetails is the status of a custom resource with the state of its peers or members
+type crStatusDetails struct {
+	crStatus
+
+	// indexer cluster peers
+	MaintenanceMode *bool                                      `json:"maintenanceMode,omitempty"`
+	Peers           []enterpriseApi.IndexerClusterMemberStatus `json:"peers,omitempty"`
+
+	// search head cluster members
+	Captain      string                                        `json:"captain,omitempty"`
+	CaptainReady *bool                                         `json:"captainReady,omitempty"`
+	Members      []enterpriseApi.SearchHeadClusterMemberStatus `json:"members,omitempty"`
+
+	// cluster manager bundle push
+	BundlePush *enterpriseApi.BundlePushInfo `json:"bundlePush,omitempty"`
+
+	// App Framework deployment summary
+	Apps *enterpriseApi.AppDeploymentSummary `json:"apps,omitempty"`
+}
+
+// getCRStatus returns the status of cr of kind
+func getCRStatus(kind *crKind, cr splcommon.MetaObject) crStatus {
+	status := crStatus{
+		Kind: kind.kind,
+		Name: cr.GetName(),
+	}
+	_, status.Paused = cr.GetAnnotations()[kind.pausedAnnotation]
+
+	switch cr := cr.(type) {
+	case *enterpriseApi.Standalone:
+		status.Phase, status.UpgradeStage = cr.Status.Phase, cr.Status.UpgradeStage
+		status.Replicas, status.ReadyReplicas = &cr.Status.Replicas, &cr.Status.ReadyReplicas
+	case *enterpriseApi.IndexerCluster:
+		status.Phase, status.UpgradeStage = cr.Status.Phase, cr.Status.UpgradeStage
+		status.Replicas, status.ReadyReplicas = &cr.Status.Replicas, &cr.Status.ReadyReplicas
+	case *enterpriseApi.SearchHeadCluster:
+		status.Phase, status.UpgradeStage = cr.Status.Phase, cr.Status.UpgradeStage
+		status.Replicas, status.ReadyReplicas = &cr.Status.Replicas, &cr.Status.ReadyReplicas
+	case *enterpriseApi.ClusterMaster:
+		status.Phase, status.UpgradeStage = cr.Status.Phase, cr.Status.UpgradeStage
+	case *enterpriseApiV4.ClusterManager:
+		status.Phase, status.UpgradeStage = cr.Status.Phase, cr.Status.UpgradeStage
+	case *enterpriseApi.LicenseMaster:
+		status.Phase, status.UpgradeStage = cr.Status.Phase, cr.Status.UpgradeStage
+	case *enterpriseApiV4.LicenseManager:
+		status.Phase, status.UpgradeStage = cr.Status.Phase, cr.Status.UpgradeStage
+	case *enterpriseApi.MonitoringConsole:
+		status.Phase, status.UpgradeStage = cr.Status.Phase, cr.Status.UpgradeStage
+	}
+	return status
+}
+
+// getCRStatusDetails returns the status of cr of kind, with the state of its peers or members
+func getCRStatusDetails(kind *crKind, cr splcommon.MetaObject) crStatusDetails {
+	details := crStatusDetails{crStatus: getCRStatus(kind, cr)}
+
+	switch cr := cr.(type) {
+	case *enterpriseApi.IndexerCluster:
+		details.MaintenanceMode = &cr.Status.MaintenanceMode
+		details.Peers = cr.Status.Peers
+	case *enterpriseApi.SearchHeadCluster:
+		details.Captain = cr.Status.Captain
+		details.CaptainReady = &cr.Status.CaptainReady
+		details.Members = cr.Status.Members
+	case *enterpriseApi.ClusterMaster:
+		details.BundlePush = &cr.Status.BundlePushTracker
+	case *enterpriseApiV4.ClusterManager:
+		details.BundlePush = &cr.Status.BundlePushTracker
+	}
+	if appContext := getAppContext(cr); appContext != nil && appContext.Summary.TotalApps > 0 {
+		details.Apps = &appContext.Summary
+	}
+	return details
+}
+
+// formatReplicas prints the ready replicas of a custom resource, "-" for the kinds without replicas
+func (status *crStatus) formatReplicas() string {
+	if status.Replicas == nil || status.ReadyReplicas == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d", *status.ReadyReplicas, *status.Replicas)
+}
+
+// status shows the status of all the custom resources of the namespace, of the custom resources of a kind, or the
+// detailed status of a custom resource
+func (p *plugin) status(ctx context.Context, args []string) error {
+	if len(args) == 2 {
+		kind, cr, err := p.getCR(ctx, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		return p.printCRStatusDetails(getCRStatusDetails(kind, cr))
+	}
+
+	kinds := crKinds
+	if len(args) == 1 {
+		kind, err := getCRKind(args[0])
+		if err != nil {
+			return err
+		}
+		kinds = []crKind{*kind}
+	}
+
+	statuses := []crStatus{}
+	for i := range kinds {
+		crs, err := p.listCRs(ctx, &kinds[i])
+		if err != nil {
+			return err
+		}
+		for _, cr := range crs {
+			statuses = append(statuses, getCRStatus(&kinds[i], cr))
+		}
+	}
+
+	if p.output == outputJSON {
+		return printJSON(p.out, statuses)
+	}
+	if len(statuses) == 0 {
+		fmt.Fprintf(p.out, "No Splunk custom resources found in namespace %s\n", p.namespace)
+		return nil
+	}
+	rows := make([][]string, 0, len(statuses))
+	for i := range statuses {
+		rows = append(rows, []string{
+			statuses[i].Kind,
+			statuses[i].Name,
+			formatOptional(string(statuses[i].Phase)),
+			statuses[i].formatReplicas(),
+			formatOptional(statuses[i].UpgradeStage),
+			formatBool(statuses[i].Paused),
+		})
+	}
+	return printTable(p.out, []string{"KIND", "NAME", "PHASE", "READY", "UPGRADE STAGE", "PAUSED"}, rows)
+}
+
+// printCRStatusDetails prints the detailed status of a custom resource
+func (p *plugin) printCRStatusDetails(details crStatusDetails) error {
+	if p.output == outputJSON {
+		return printJSON(p.out, details)
+	}
+
+	fields := [][]string{
+		{"Kind:", details.Kind},
+		{"Name:", details.Name},
+		{"Phase:", formatOptional(string(details.Phase))},
+		{"Ready:", details.formatReplicas()},
+		{"Upgrade stage:", formatOptional(details.UpgradeStage)},
+		{"Paused:", formatBool(details.Paused)},
+	}
+	if details.MaintenanceMode != nil {
+		fields = append(fields, []string{"Maintenance mode:", formatBool(*details.MaintenanceMode)})
+	}
+	if details.CaptainReady != nil {
+		fields = append(fields, []string{"Captain:", fmt.Sprintf("%s (ready: %s)", formatOptional(details.Captain), formatBool(*details.CaptainReady))})
+	}
+	if details.BundlePush != nil {
+		fields = append(fields, []string{"Bundle push needed:", formatBool(details.BundlePush.NeedToPushMasterApps)})
+	}
+	if details.Apps != nil {
+		fields = append(fields, []string{"Apps:", fmt.Sprintf("%d total, %d completed, %d pending, %d failed",
+			details.Apps.TotalApps, details.Apps.CompletedApps, details.Apps.PendingApps, details.Apps.FailedApps)})
+	}
+	for _, field := range fields {
+		fmt.Fprintf(p.out, "%-20s%s\n", field[0], field[1])
+	}
+
+	if len(details.Peers) > 0 {
+		fmt.Fprintln(p.out, "\nPeers:")
+		rows := make([][]string, 0, len(details.Peers))
+		for _, peer := range details.Peers {
+			rows = append(rows, []string{
+				peer.Name,
+				peer.Status,
+				formatBool(peer.Searchable),
+				strconv.FormatInt(peer.BucketCount, 10),
+				formatOptional(peer.ActiveBundleID),
+			})
+		}
+		if err := printTable(p.out, []string{"NAME", "STATUS", "SEARCHABLE", "BUCKETS", "ACTIVE BUNDLE"}, rows); err != nil {
+			return err
+		}
+	}
+
+	if len(details.Members) > 0 {
+		fmt.Fprintln(p.out, "\nMembers:")
+		rows := make([][]string, 0, len(details.Members))
+		for _, member := range details.Members {
+			rows = append(rows, []string{
+				member.Name,
+				member.Status,
+				formatBool(member.Registered),
+				formatBool(member.Adhoc),
+				strconv.Itoa(member.ActiveHistoricalSearchCount),
+				strconv.Itoa(member.ActiveRealtimeSearchCount),
+			})
+		}
+		if err := printTable(p.out, []string{"NAME", "STATUS", "REGISTERED", "ADHOC", "HISTORICAL SEARCHES", "REALTIME SEARCHES"}, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
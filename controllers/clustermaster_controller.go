@@ -23,6 +23,7 @@ import (
 	"github.com/pkg/errors"
 	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
 	common "github.com/splunk/splunk-operator/controllers/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/concurrency"
 	enterprise "github.com/splunk/splunk-operator/pkg/splunk/enterprise"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -30,7 +31,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -59,6 +59,9 @@ type ClusterMasterReconciler struct {
 //+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses;networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=route.openshift.io,resources=routes;routes/custom-host,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes;tlsroutes,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -117,7 +120,7 @@ var ApplyClusterManager = func(ctx context.Context, client client.Client, instan
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterMasterReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&enterpriseApi.ClusterMaster{}).
 		WithEventFilter(predicate.Or(
 			predicate.GenerationChangedPredicate{},
@@ -149,10 +152,8 @@ func (r *ClusterMasterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				IsController: false,
 				OwnerType:    &enterpriseApi.ClusterMaster{},
 			}).
-		WithOptions(controller.Options{
-			MaxConcurrentReconciles: enterpriseApi.TotalWorker,
-		}).
-		Complete(r)
+		WithOptions(concurrency.ControllerOptions())
+	return common.CompleteWithNamespaceSelection(b, mgr.GetClient(), &enterpriseApi.ClusterMasterList{}, concurrency.Reconciler("ClusterMaster", r))
 }
 
 // recordInstrumentionData Record api profiling information to prometheus
@@ -162,4 +163,5 @@ func recordInstrumentionData(start time.Time, req ctrl.Request, module string, n
 	metricLabels[labelMethodName] = name
 	value := float64(time.Since(start) / time.Millisecond)
 	apiTotalTimeMetricEvents.With(metricLabels).Set(value)
+	reconcileDurationHistogram.WithLabelValues(name).Observe(time.Since(start).Seconds())
 }
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+)
+
+// LicenseSecretRequests maps a Secret to the requests of the license managers of list whose spec.licenseSecrets
+// refer to it. The license Secrets belong to the user, so they are watched rather than owned by the license managers
+func LicenseSecretRequests(c client.Reader, list client.ObjectList) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		ctx := context.TODO()
+		objects := list.DeepCopyObject().(client.ObjectList)
+		err := c.List(ctx, objects, client.InNamespace(obj.GetNamespace()))
+		if err != nil {
+			log.FromContext(ctx).Error(err, "unable to list the license managers of the namespace", "namespace", obj.GetNamespace())
+			return nil
+		}
+		items, err := meta.ExtractList(objects)
+		if err != nil {
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+		for _, item := range items {
+			var licenseSecrets []corev1.LocalObjectReference
+			switch cr := item.(type) {
+			case *enterpriseApi.LicenseMaster:
+				licenseSecrets = cr.Spec.LicenseSecrets
+			case *enterpriseApiV4.LicenseManager:
+				licenseSecrets = cr.Spec.LicenseSecrets
+			}
+			for _, licenseSecret := range licenseSecrets {
+				if licenseSecret.Name == obj.GetName() {
+					object := item.(client.Object)
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: object.GetNamespace(), Name: object.GetName()}})
+					break
+				}
+			}
+		}
+		return requests
+	}
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+)
+
+func TestLicenseSecretRequests(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = enterpriseApi.AddToScheme(scheme)
+	_ = enterpriseApiV4.AddToScheme(scheme)
+	licenseSecrets := []corev1.LocalObjectReference{{Name: "licenses"}}
+	lm := &enterpriseApi.LicenseMaster{ObjectMeta: metav1.ObjectMeta{Name: "lm1", Namespace: "tenant1"}}
+	lm.Spec.LicenseSecrets = licenseSecrets
+	other := &enterpriseApi.LicenseMaster{ObjectMeta: metav1.ObjectMeta{Name: "lm2", Namespace: "tenant1"}}
+	lmV4 := &enterpriseApiV4.LicenseManager{ObjectMeta: metav1.ObjectMeta{Name: "lm3", Namespace: "tenant1"}}
+	lmV4.Spec.LicenseSecrets = licenseSecrets
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(lm, other, lmV4).Build()
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "licenses", Namespace: "tenant1"}}
+	requests := LicenseSecretRequests(c, &enterpriseApi.LicenseMasterList{})(secret)
+	if len(requests) != 1 || requests[0].Name != "lm1" {
+		t.Errorf("LicenseSecretRequests() = %v; want lm1", requests)
+	}
+	requests = LicenseSecretRequests(c, &enterpriseApiV4.LicenseManagerList{})(secret)
+	if len(requests) != 1 || requests[0].Name != "lm3" {
+		t.Errorf("LicenseSecretRequests() = %v; want lm3", requests)
+	}
+
+	// the secrets of the other namespaces and the other secrets aren't mapped
+	secret.Namespace = "tenant2"
+	if requests = LicenseSecretRequests(c, &enterpriseApi.LicenseMasterList{})(secret); len(requests) != 0 {
+		t.Errorf("LicenseSecretRequests() = %v; want no request for another namespace", requests)
+	}
+	secret.Namespace, secret.Name = "tenant1", "other"
+	if requests = LicenseSecretRequests(c, &enterpriseApi.LicenseMasterList{})(secret); len(requests) != 0 {
+		t.Errorf("LicenseSecretRequests() = %v; want no request for another secret", requests)
+	}
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/splunk/splunk-operator/pkg/config"
+)
+
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// namespaceSelection is the Namespace selection of the controllers set up afterwards
+var namespaceSelection config.NamespaceSelection
+
+// SetNamespaceSelection sets the Namespace selection of the controllers set up afterwards
+func SetNamespaceSelection(selection config.NamespaceSelection) {
+	namespaceSelection = selection
+}
+
+// CompleteWithNamespaceSelection builds the controller of b, which reconciles with r the custom resources of the
+// selected Namespaces only. When the Namespaces are selected by label, the custom resources of list are reconciled
+// again as their Namespace is labelled, so that the controller follows the Namespaces entering the selection
+func CompleteWithNamespaceSelection(b *builder.Builder, c client.Client, list client.ObjectList, r reconcile.Reconciler) error {
+	if !namespaceSelection.IsSelective() {
+		return b.Complete(r)
+	}
+	if namespaceSelection.Selector != nil {
+		b = b.Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(namespaceRequests(c, list)))
+	}
+	return b.Complete(&namespaceSelectionReconciler{
+		client:     c,
+		selection:  namespaceSelection,
+		reconciler: r,
+	})
+}
+
+// namespaceRequests maps a Namespace to the requests of the custom resources of list in it
+func namespaceRequests(c client.Reader, list client.ObjectList) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		ctx := context.TODO()
+		objects := list.DeepCopyObject().(client.ObjectList)
+		err := c.List(ctx, objects, client.InNamespace(obj.GetName()))
+		if err != nil {
+			log.FromContext(ctx).Error(err, "unable to list the custom resources of the namespace", "namespace", obj.GetName())
+			return nil
+		}
+		items, err := meta.ExtractList(objects)
+		if err != nil {
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+		for _, item := range items {
+			if object, ok := item.(client.Object); ok {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: object.GetNamespace(), Name: object.GetName()}})
+			}
+		}
+		return requests
+	}
+}
+
+// namespaceSelectionReconciler skips the reconciles of the custom resources outside the Namespace selection
+type namespaceSelectionReconciler struct {
+	client     client.Reader
+	selection  config.NamespaceSelection
+	reconciler reconcile.Reconciler
+}
+
+// Reconcile reconciles the request when its Namespace is selected, and skips it otherwise
+func (r *namespaceSelectionReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	if !r.selection.OwnsShard(request.Namespace) {
+		return reconcile.Result{}, nil
+	}
+	if r.selection.Selector != nil {
+		namespace := corev1.Namespace{}
+		err := r.client.Get(ctx, types.NamespacedName{Name: request.Namespace}, &namespace)
+		if err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+		if !r.selection.Selects(namespace.GetName(), namespace.GetLabels()) {
+			return reconcile.Result{}, nil
+		}
+	}
+	return r.reconciler.Reconcile(ctx, request)
+}
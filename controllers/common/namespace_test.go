@@ -0,0 +1,79 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	"github.com/splunk/splunk-operator/pkg/config"
+)
+
+type countingReconciler struct {
+	count int
+}
+
+func (r *countingReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	r.count++
+	return reconcile.Result{}, nil
+}
+
+func TestNamespaceSelectionReconciler(t *testing.T) {
+	ctx := context.TODO()
+	selected := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "selected", Labels: map[string]string{"tenant": "splunk"}}}
+	other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+	c := fake.NewClientBuilder().WithObjects(selected, other).Build()
+
+	selector, _ := labels.Parse("tenant=splunk")
+	counter := &countingReconciler{}
+	r := &namespaceSelectionReconciler{client: c, selection: config.NamespaceSelection{Selector: selector}, reconciler: counter}
+
+	for namespace, want := range map[string]int{"selected": 1, "other": 0, "missing": 0} {
+		counter.count = 0
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "stack1"}})
+		if err != nil {
+			t.Errorf("Reconcile() returned error for %s: %v", namespace, err)
+		}
+		if counter.count != want {
+			t.Errorf("Reconcile() reconciled %d times in %s; want %d", counter.count, namespace, want)
+		}
+	}
+
+	// the shard is checked before the labels
+	r.selection.ShardCount = 2
+	reconciled := 0
+	for shard := 0; shard < 2; shard++ {
+		counter.count = 0
+		r.selection.ShardIndex = shard
+		_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "selected", Name: "stack1"}})
+		reconciled += counter.count
+	}
+	if reconciled != 1 {
+		t.Errorf("Reconcile() reconciled %d times across the shards; want 1", reconciled)
+	}
+}
+
+func TestNamespaceRequests(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = enterpriseApi.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&enterpriseApi.Standalone{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "tenant1"}},
+		&enterpriseApi.Standalone{ObjectMeta: metav1.ObjectMeta{Name: "s2", Namespace: "tenant1"}},
+		&enterpriseApi.Standalone{ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: "tenant2"}},
+	).Build()
+
+	mapFunc := namespaceRequests(c, &enterpriseApi.StandaloneList{})
+	requests := mapFunc(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant1"}})
+	if len(requests) != 2 || requests[0].Namespace != "tenant1" || requests[1].Namespace != "tenant1" {
+		t.Errorf("namespaceRequests() = %v; want the 2 standalones of tenant1", requests)
+	}
+}
@@ -0,0 +1,35 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+)
+
+// AppFrameworkPath is the path of the App Framework introspection endpoint
+const AppFrameworkPath = "/debug/appframework"
+
+// RegisterAppFrameworkEndpoint registers the read-only endpoint dumping the live App Framework state as JSON.
+// The pipelines can be filtered with the namespace query parameter
+func RegisterAppFrameworkEndpoint(register func(string, http.Handler) error) error {
+	return register(AppFrameworkPath, http.HandlerFunc(appFrameworkHandler))
+}
+
+// appFrameworkHandler serves the live App Framework state
+func appFrameworkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	debugInfo := enterprise.GetAppFrameworkDebugInfo(r.URL.Query().Get("namespace"))
+	data, err := json.MarshalIndent(debugInfo, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,41 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+)
+
+func TestRegisterAppFrameworkEndpoint(t *testing.T) {
+	mux := http.NewServeMux()
+	err := RegisterAppFrameworkEndpoint(func(path string, handler http.Handler) error {
+		mux.Handle(path, handler)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterAppFrameworkEndpoint() returned error: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, AppFrameworkPath+"?namespace=test", nil))
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("GET %s returned %d %s; want 200 application/json", AppFrameworkPath, recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	debugInfo := enterprise.AppFrameworkDebugInfo{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &debugInfo); err != nil {
+		t.Errorf("GET %s returned invalid JSON: %v", AppFrameworkPath, err)
+	}
+	if debugInfo.Pipelines == nil {
+		t.Errorf("GET %s didn't return the pipelines", AppFrameworkPath)
+	}
+
+	// the endpoint is read-only
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, AppFrameworkPath, nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST %s returned %d; want %d", AppFrameworkPath, recorder.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -36,6 +36,12 @@ var apiTotalTimeMetricEvents = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 	Help: "The time it takes to complete each call in standalone (in milliseconds)",
 }, []string{labelNamespace, labelName, labelKind, labelModuleName, labelMethodName})
 
+var reconcileDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "splunk_operator_reconcile_duration_seconds",
+	Help:    "The time it takes to reconcile a custom resource, by kind",
+	Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+}, []string{labelKind})
+
 func getPrometheusLabels(request reconcile.Request, kind string) prometheus.Labels {
 	return prometheus.Labels{
 		labelNamespace: request.Namespace,
@@ -50,5 +56,6 @@ func init() {
 		reconcileErrorCounter,
 		actionFailureCounters,
 		apiTotalTimeMetricEvents,
+		reconcileDurationHistogram,
 	)
 }
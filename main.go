@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 
@@ -36,11 +37,18 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
 	"github.com/splunk/splunk-operator/controllers"
+	common "github.com/splunk/splunk-operator/controllers/common"
 	debug "github.com/splunk/splunk-operator/controllers/debug"
 	"github.com/splunk/splunk-operator/pkg/config"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/concurrency"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	//+kubebuilder:scaffold:imports
 	//extapi "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 )
@@ -53,6 +61,7 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(enterpriseApi.AddToScheme(scheme))
+	utilruntime.Must(enterpriseApiV4.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 	//utilruntime.Must(extapi.AddToScheme(scheme))
 }
@@ -64,6 +73,10 @@ func main() {
 	var pprofActive bool
 	var logEncoder string
 	var logLevel int
+	var tracingEndpoint string
+	var tracingInsecure bool
+	var tracingSampleRatio float64
+	concurrencyConfig := concurrency.DefaultConfig()
 
 	flag.StringVar(&logEncoder, "logEncoder", "json", "log encoding ('json' or 'console')")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
@@ -73,6 +86,16 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&pprofActive, "pprof", true, "Enable pprof endpoint")
 	flag.IntVar(&logLevel, "loglevel", int(zapcore.InfoLevel), "set log level")
+	flag.StringVar(&tracingEndpoint, "tracing-endpoint", "", "The host:port of the OTLP HTTP collector the traces are exported to. Tracing is disabled when empty.")
+	flag.BoolVar(&tracingInsecure, "tracing-insecure", false, "Export the traces to the OTLP collector over HTTP instead of HTTPS")
+	flag.Float64Var(&tracingSampleRatio, "tracing-sample-ratio", 1, "The ratio of the traces which are exported, between 0 and 1")
+	flag.IntVar(&concurrencyConfig.MaxConcurrentReconciles, "max-concurrent-reconciles", concurrencyConfig.MaxConcurrentReconciles, "The number of workers of each controller")
+	flag.IntVar(&concurrencyConfig.FairQueueLimit, "fair-queue-limit", concurrencyConfig.FairQueueLimit, "The number of reconciles running at once across all the controllers, shared fairly between the namespaces. The fair queue is disabled when 0.")
+	flag.Float64Var(&concurrencyConfig.RateLimitQPS, "reconcile-rate-limit-qps", concurrencyConfig.RateLimitQPS, "The number of requeues per second of each controller")
+	flag.IntVar(&concurrencyConfig.RateLimitBurst, "reconcile-rate-limit-burst", concurrencyConfig.RateLimitBurst, "The burst of requeues of each controller")
+	flag.DurationVar(&concurrencyConfig.RetryBaseDelay, "reconcile-retry-base-delay", concurrencyConfig.RetryBaseDelay, "The delay of the first retry of a failing reconcile, doubled on each retry")
+	flag.DurationVar(&concurrencyConfig.RetryMaxDelay, "reconcile-retry-max-delay", concurrencyConfig.RetryMaxDelay, "The longest delay of the retries of a failing reconcile")
+	flag.IntVar(&concurrencyConfig.MaxHeavyOperations, "max-heavy-operations", concurrencyConfig.MaxHeavyOperations, "The number of pod execs, bundle pushes and app downloads running at once across all the controllers. They are not limited when 0.")
 
 	opts := zap.Options{
 		Development: true,
@@ -84,6 +107,19 @@ func main() {
 	// Logging setup
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	// Tracing setup
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingEndpoint, tracingInsecure, tracingSampleRatio)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
+	// Concurrency setup, before the controllers which use it
+	if err := concurrency.Setup(concurrencyConfig); err != nil {
+		setupLog.Error(err, "unable to set up the reconcile concurrency")
+		os.Exit(1)
+	}
+
 	options := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -93,7 +129,20 @@ func main() {
 		LeaderElectionID:       "270bec8c.splunk.com",
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), config.ManagerOptionsWithNamespaces(setupLog, options))
+	// the changes of the operator are recorded under its own field manager, which tells them apart from manual changes
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.UserAgent = splcommon.OperatorFieldManager
+
+	// the namespaces may also be selected by label and sharded across several operator instances
+	namespaceSelection, err := config.GetNamespaceSelection()
+	if err != nil {
+		setupLog.Error(err, "unable to get the namespace selection")
+		os.Exit(1)
+	}
+	common.SetNamespaceSelection(namespaceSelection)
+	options = config.ManagerOptionsWithNamespaceSelection(setupLog, options, namespaceSelection)
+
+	mgr, err := ctrl.NewManager(restConfig, config.ManagerOptionsWithNamespaces(setupLog, options))
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
@@ -141,8 +190,46 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Standalone")
 		os.Exit(1)
 	}
+	if err = (&controllers.ClusterManagerReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterManager")
+		os.Exit(1)
+	}
+	if err = (&controllers.LicenseManagerReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "LicenseManager")
+		os.Exit(1)
+	}
+	// The conversion webhook is disabled by the namespace scoped install that runs without cert-manager
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&enterpriseApiV4.IndexerCluster{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "IndexerCluster")
+			os.Exit(1)
+		}
+		if err = (&enterpriseApiV4.SearchHeadCluster{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SearchHeadCluster")
+			os.Exit(1)
+		}
+		if err = (&enterpriseApiV4.Standalone{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Standalone")
+			os.Exit(1)
+		}
+		if err = (&enterpriseApiV4.MonitoringConsole{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MonitoringConsole")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
+	if err := metrics.Registry.Register(enterprise.NewClusterHealthCollector(mgr.GetClient(), namespaceSelection)); err != nil {
+		setupLog.Error(err, "unable to register the cluster health metrics collector")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -158,7 +245,13 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	err = mgr.Start(ctrl.SetupSignalHandler())
+
+	// flush the pending spans before exiting
+	if err := shutdownTracing(context.Background()); err != nil {
+		setupLog.Error(err, "unable to export the pending traces")
+	}
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -171,6 +264,10 @@ func customSetupEndpoints(pprofActive bool, mgr manager.Manager) error {
 			setupLog.Error(err, "Unable to register pprof endpoint")
 			return err
 		}
+		if err := debug.RegisterAppFrameworkEndpoint(mgr.AddMetricsExtraHandler); err != nil {
+			setupLog.Error(err, "Unable to register App Framework introspection endpoint")
+			return err
+		}
 	}
 	return nil
 }
@@ -0,0 +1,132 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// WatchNamespaceSelectorEnvVar is the constant for env variable WATCH_NAMESPACE_SELECTOR
+	// which specifies the label selector of the Namespaces to watch.
+	// It can't be combined with WATCH_NAMESPACE.
+	WatchNamespaceSelectorEnvVar = "WATCH_NAMESPACE_SELECTOR"
+
+	// ShardCountEnvVar is the constant for env variable SHARD_COUNT
+	// which specifies the number of operator instances the Namespaces are sharded across.
+	ShardCountEnvVar = "SHARD_COUNT"
+
+	// ShardIndexEnvVar is the constant for env variable SHARD_INDEX
+	// which specifies the shard of this operator instance, from 0 to SHARD_COUNT-1.
+	ShardIndexEnvVar = "SHARD_INDEX"
+)
+
+// NamespaceSelection selects the Namespaces whose custom resources are reconciled by this operator instance
+type NamespaceSelection struct {
+	// Selector selects the Namespaces by label. The Namespaces are not selected by label when nil
+	Selector labels.Selector
+
+	// ShardIndex is the shard of this operator instance, which reconciles the Namespaces whose name hashes to it
+	ShardIndex int
+
+	// ShardCount is the number of shards. The Namespaces are not sharded when it is 0 or 1
+	ShardCount int
+}
+
+// GetNamespaceSelection returns the Namespace selection of the operator, from the WATCH_NAMESPACE_SELECTOR,
+// SHARD_COUNT and SHARD_INDEX env variables
+func GetNamespaceSelection() (NamespaceSelection, error) {
+	selection := NamespaceSelection{}
+
+	if value, found := os.LookupEnv(WatchNamespaceSelectorEnvVar); found && value != "" {
+		if len(GetWatchNamespaces()) > 0 {
+			return selection, fmt.Errorf("%s can't be combined with %s", WatchNamespaceSelectorEnvVar, WatchNamespaceEnvVar)
+		}
+		selector, err := labels.Parse(value)
+		if err != nil {
+			return selection, fmt.Errorf("invalid %s %q, error: %v", WatchNamespaceSelectorEnvVar, value, err)
+		}
+		selection.Selector = selector
+	}
+
+	if value, found := os.LookupEnv(ShardCountEnvVar); found && value != "" {
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 1 {
+			return selection, fmt.Errorf("invalid %s %q, it must be a positive number", ShardCountEnvVar, value)
+		}
+		selection.ShardCount = count
+	}
+	if value, found := os.LookupEnv(ShardIndexEnvVar); found && value != "" {
+		index, err := strconv.Atoi(value)
+		if err != nil || index < 0 || index >= selection.ShardCount {
+			return selection, fmt.Errorf("invalid %s %q, it must be between 0 and %s-1", ShardIndexEnvVar, value, ShardCountEnvVar)
+		}
+		selection.ShardIndex = index
+	} else if selection.ShardCount > 1 {
+		return selection, fmt.Errorf("%s must be set when %s is more than 1", ShardIndexEnvVar, ShardCountEnvVar)
+	}
+
+	return selection, nil
+}
+
+// IsSelective tells whether the selection leaves out some Namespaces
+func (s NamespaceSelection) IsSelective() bool {
+	return s.Selector != nil || s.IsSharded()
+}
+
+// IsSharded tells whether the Namespaces are sharded across several operator instances
+func (s NamespaceSelection) IsSharded() bool {
+	return s.ShardCount > 1
+}
+
+// OwnsShard tells whether the name of a Namespace hashes to the shard of this operator instance. All the custom
+// resources of a Namespace belong to the same shard, as they share the Namespace scoped objects
+func (s NamespaceSelection) OwnsShard(namespace string) bool {
+	if !s.IsSharded() {
+		return true
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(namespace))
+	return int(hash.Sum32()%uint32(s.ShardCount)) == s.ShardIndex
+}
+
+// Selects tells whether the custom resources of a Namespace, with its name and labels, are reconciled by this
+// operator instance
+func (s NamespaceSelection) Selects(namespace string, namespaceLabels map[string]string) bool {
+	if !s.OwnsShard(namespace) {
+		return false
+	}
+	return s.Selector == nil || s.Selector.Matches(labels.Set(namespaceLabels))
+}
+
+// ManagerOptionsWithNamespaceSelection returns an updated Options with the leader election ID of the shard, so that
+// the operator instances of each shard elect their own leader
+func ManagerOptionsWithNamespaceSelection(logger logr.Logger, opt ctrl.Options, selection NamespaceSelection) ctrl.Options {
+	if selection.Selector != nil {
+		logger.Info("Manager will reconcile the namespaces matching the selector", "selector", selection.Selector.String())
+	}
+	if selection.IsSharded() {
+		logger.Info("Manager will reconcile the namespaces of its shard", "shard", selection.ShardIndex, "shards", selection.ShardCount)
+		opt.LeaderElectionID = fmt.Sprintf("%s-shard-%d", opt.LeaderElectionID, selection.ShardIndex)
+	}
+	return opt
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestGetNamespaceSelection(t *testing.T) {
+	selection, err := GetNamespaceSelection()
+	if err != nil || selection.IsSelective() {
+		t.Errorf("GetNamespaceSelection() = %v, %v; want all the namespaces", selection, err)
+	}
+
+	t.Setenv(WatchNamespaceSelectorEnvVar, "tenant=splunk,tier!=dev")
+	t.Setenv(ShardCountEnvVar, "3")
+	t.Setenv(ShardIndexEnvVar, "2")
+	selection, err = GetNamespaceSelection()
+	if err != nil {
+		t.Fatalf("GetNamespaceSelection() returned error: %v", err)
+	}
+	if selection.Selector.String() != "tenant=splunk,tier!=dev" || selection.ShardCount != 3 || selection.ShardIndex != 2 {
+		t.Errorf("GetNamespaceSelection() = %v; want the selector and shard 2 of 3", selection)
+	}
+
+	invalid := map[string]map[string]string{
+		"invalid selector":       {WatchNamespaceSelectorEnvVar: "tenant in splunk"},
+		"selector and namespace": {WatchNamespaceEnvVar: "ns1"},
+		"invalid shard count":    {ShardCountEnvVar: "0"},
+		"shard index too large":  {ShardIndexEnvVar: "3"},
+		"missing shard index":    {ShardIndexEnvVar: ""},
+	}
+	for name, env := range invalid {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+			if _, err := GetNamespaceSelection(); err == nil {
+				t.Errorf("GetNamespaceSelection() didn't return an error")
+			}
+		})
+	}
+}
+
+func TestNamespaceSelectionSelects(t *testing.T) {
+	t.Setenv(WatchNamespaceSelectorEnvVar, "tenant=splunk")
+	selection, err := GetNamespaceSelection()
+	if err != nil {
+		t.Fatalf("GetNamespaceSelection() returned error: %v", err)
+	}
+	if !selection.Selects("ns1", map[string]string{"tenant": "splunk"}) {
+		t.Errorf("Selects() = false for a namespace matching the selector")
+	}
+	if selection.Selects("ns1", map[string]string{"tenant": "other"}) {
+		t.Errorf("Selects() = true for a namespace not matching the selector")
+	}
+
+	// each namespace is owned by exactly one shard
+	shards := []NamespaceSelection{}
+	for i := 0; i < 3; i++ {
+		shards = append(shards, NamespaceSelection{ShardIndex: i, ShardCount: 3})
+	}
+	owned := map[int]int{}
+	for i := 0; i < 30; i++ {
+		namespace := fmt.Sprintf("tenant-%d", i)
+		owners := 0
+		for _, shard := range shards {
+			if shard.Selects(namespace, nil) {
+				owners++
+				owned[shard.ShardIndex]++
+			}
+		}
+		if owners != 1 {
+			t.Errorf("%s is owned by %d shards; want 1", namespace, owners)
+		}
+	}
+	if len(owned) != 3 {
+		t.Errorf("the namespaces are owned by %d shards; want 3", len(owned))
+	}
+}
+
+func TestManagerOptionsWithNamespaceSelection(t *testing.T) {
+	options := ctrl.Options{LeaderElectionID: "270bec8c.splunk.com"}
+	got := ManagerOptionsWithNamespaceSelection(ctrl.Log, options, NamespaceSelection{})
+	if got.LeaderElectionID != "270bec8c.splunk.com" {
+		t.Errorf("ManagerOptionsWithNamespaceSelection() LeaderElectionID = %s; want it unchanged", got.LeaderElectionID)
+	}
+	got = ManagerOptionsWithNamespaceSelection(ctrl.Log, options, NamespaceSelection{ShardIndex: 1, ShardCount: 2})
+	if got.LeaderElectionID != "270bec8c.splunk.com-shard-1" {
+		t.Errorf("ManagerOptionsWithNamespaceSelection() LeaderElectionID = %s; want 270bec8c.splunk.com-shard-1", got.LeaderElectionID)
+	}
+}
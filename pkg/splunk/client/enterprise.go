@@ -16,17 +16,22 @@
 package client
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 )
 
 // SplunkHTTPClient defines the interface used by SplunkClient.
@@ -48,6 +53,9 @@ type SplunkClient struct {
 
 	// HTTP client used to process requests
 	Client SplunkHTTPClient
+
+	// context of the spans of the requests, if not the context of the requests
+	ctx context.Context
 }
 
 // NewSplunkClient returns a new SplunkClient object initialized with a username and password.
@@ -65,14 +73,35 @@ func NewSplunkClient(managementURI, username, password string) *SplunkClient {
 	}
 }
 
+// WithContext returns a shallow copy of the SplunkClient which traces its requests as part of ctx.
+func (c *SplunkClient) WithContext(ctx context.Context) *SplunkClient {
+	clientCopy := *c
+	clientCopy.ctx = ctx
+	return &clientCopy
+}
+
 // Do processes a Splunk REST API request and unmarshals response into obj, if not nil.
 func (c *SplunkClient) Do(request *http.Request, expectedStatus []int, obj interface{}) error {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = request.Context()
+	}
+	_, span := tracing.StartSpan(ctx, "SplunkClient.Do",
+		semconv.HTTPMethodKey.String(request.Method),
+		semconv.HTTPTargetKey.String(getEndpointLabel(request)),
+		semconv.NetPeerNameKey.String(request.URL.Hostname()))
+	defer span.End()
+
 	// send HTTP response and check status
 	request.SetBasicAuth(c.Username, c.Password)
+	start := time.Now()
 	response, err := c.Client.Do(request)
 	if err != nil {
+		recordRequestMetrics(request, start, 0, true)
+		tracing.RecordError(span, err)
 		return err
 	}
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(response.StatusCode))
 	//default set flag to false and the check response code
 	expectedStatusFlag := false
 	for i := 0; i < len(expectedStatus); i++ {
@@ -81,8 +110,11 @@ func (c *SplunkClient) Do(request *http.Request, expectedStatus []int, obj inter
 			break
 		}
 	}
+	recordRequestMetrics(request, start, response.StatusCode, !expectedStatusFlag)
 	if !expectedStatusFlag {
-		return fmt.Errorf("response code=%d from %s; want %d", response.StatusCode, request.URL, expectedStatus)
+		err = fmt.Errorf("response code=%d from %s; want %d", response.StatusCode, request.URL, expectedStatus)
+		tracing.RecordError(span, err)
+		return err
 	}
 	if obj == nil {
 		return nil
@@ -626,6 +658,108 @@ func (c *SplunkClient) DecommissionIndexerClusterPeer(enforceCounts bool) error
 	return c.Do(request, expectedStatus, nil)
 }
 
+// ClusterManagerRebalanceStatus represents the progress of a data rebalance of the indexer cluster
+type ClusterManagerRebalanceStatus struct {
+	// Indicates if a data rebalance is running
+	Running bool
+
+	// Completion of the running data rebalance, in percent
+	PercentComplete float64
+
+	// Message reported by the cluster manager
+	Message string
+}
+
+var (
+	// rebalanceNotRunningRegex matches the status of a data rebalance that is not started, completed or stopped
+	rebalanceNotRunningRegex = regexp.MustCompile(`(?i)\b(is not running|completed|stopped)\b`)
+
+	// rebalanceRunningRegex matches the status of a running data rebalance
+	rebalanceRunningRegex = regexp.MustCompile(`(?i)\b(is running|in progress|started)\b`)
+
+	// rebalancePercentRegex matches the completion of a running data rebalance
+	rebalancePercentRegex = regexp.MustCompile(`([0-9]+(\.[0-9]+)?)\s*%`)
+)
+
+// rebalanceMessage is a message of the response of a data rebalance action
+type rebalanceMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// rebalanceIndexerClusterBuckets sends a data rebalance action to the cluster manager, and returns the messages of the response
+func (c *SplunkClient) rebalanceIndexerClusterBuckets(params url.Values) ([]rebalanceMessage, error) {
+	endpoint := fmt.Sprintf("%s%s", c.ManagementURI, splcommon.URIClusterManagerRebalanceBuckets)
+	params.Set("output_mode", "json")
+	request, err := http.NewRequest("POST", endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	apiResponse := struct {
+		Messages []rebalanceMessage `json:"messages"`
+	}{}
+	expectedStatus := []int{200, 201}
+	err = c.Do(request, expectedStatus, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
+	return apiResponse.Messages, nil
+}
+
+// StartIndexerClusterRebalance starts a data rebalance of all the indexes of the indexer cluster, limited to maxRuntimeMinutes
+// when not 0. You can only use this on a cluster manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/Indexer/Rebalancethecluster
+func (c *SplunkClient) StartIndexerClusterRebalance(maxRuntimeMinutes int32) error {
+	params := url.Values{"action": {"start"}}
+	if maxRuntimeMinutes > 0 {
+		params.Set("max_runtime", strconv.Itoa(int(maxRuntimeMinutes)))
+	}
+	_, err := c.rebalanceIndexerClusterBuckets(params)
+	return err
+}
+
+// StopIndexerClusterRebalance stops the running data rebalance of the indexer cluster.
+// You can only use this on a cluster manager.
+func (c *SplunkClient) StopIndexerClusterRebalance() error {
+	_, err := c.rebalanceIndexerClusterBuckets(url.Values{"action": {"stop"}})
+	return err
+}
+
+// GetIndexerClusterRebalanceStatus queries the progress of the data rebalance of the indexer cluster, which is only reported
+// in the messages of the response. An error message, or a status that is neither running nor stopped, is returned as an
+// error, so that an unknown status is never taken for a completed rebalance. You can only use this on a cluster manager.
+func (c *SplunkClient) GetIndexerClusterRebalanceStatus() (*ClusterManagerRebalanceStatus, error) {
+	messages, err := c.rebalanceIndexerClusterBuckets(url.Values{"action": {"status"}})
+	if err != nil {
+		return nil, err
+	}
+
+	var texts []string
+	for _, message := range messages {
+		if strings.EqualFold(message.Type, "ERROR") {
+			return nil, fmt.Errorf("rebalance status error: %s", message.Text)
+		}
+		texts = append(texts, message.Text)
+	}
+
+	status := ClusterManagerRebalanceStatus{Message: strings.Join(texts, " ")}
+	switch {
+	case rebalanceNotRunningRegex.MatchString(status.Message):
+		status.Running = false
+	case rebalanceRunningRegex.MatchString(status.Message):
+		status.Running = true
+		percent := rebalancePercentRegex.FindStringSubmatch(status.Message)
+		if len(percent) > 1 {
+			status.PercentComplete, _ = strconv.ParseFloat(percent[1], 64)
+		}
+	default:
+		return nil, fmt.Errorf("unknown rebalance status: %q", status.Message)
+	}
+	return &status, nil
+}
+
 // BundlePush pushes the Cluster manager apps bundle to all the indexer peers
 func (c *SplunkClient) BundlePush(ignoreIdenticalBundle bool) error {
 	endpoint := fmt.Sprintf("%s%s", c.ManagementURI, splcommon.URIClusterManagerApplyBundle)
@@ -937,3 +1071,320 @@ func (c *SplunkClient) RestartSplunk() error {
 	expectedStatus := []int{200}
 	return c.Do(request, expectedStatus, nil)
 }
+
+// LicenseInfo represents a license installed on the license manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTlicense#licenser.2Flicenses
+type LicenseInfo struct {
+	// Name of the license, set from the entry name
+	Name string `json:"-"`
+
+	// Label of the license
+	Label string `json:"label"`
+
+	// License type, for example enterprise or forwarder
+	Type string `json:"type"`
+
+	// License status, VALID or EXPIRED
+	Status string `json:"status"`
+
+	// Stack the license belongs to
+	StackID string `json:"stack_id"`
+
+	// License expiration time, in epoch seconds
+	ExpirationTime int64 `json:"expiration_time"`
+
+	// Daily indexing quota of the license, in bytes
+	Quota float64 `json:"quota"`
+}
+
+// GetLicenses queries the license manager for the installed licenses.
+// You can only use this on a license manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTlicense#licenser.2Flicenses
+func (c *SplunkClient) GetLicenses() ([]LicenseInfo, error) {
+	apiResponse := struct {
+		Entry []struct {
+			Name    string      `json:"name"`
+			Content LicenseInfo `json:"content"`
+		} `json:"entry"`
+	}{}
+	path := splcommon.URILicenserGetLicenses
+	err := c.Get(path, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	var licenses []LicenseInfo
+	for _, e := range apiResponse.Entry {
+		e.Content.Name = e.Name
+		licenses = append(licenses, e.Content)
+	}
+	return licenses, nil
+}
+
+// LicensePoolInfo represents a license pool on the license manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTlicense#licenser.2Fpools
+type LicensePoolInfo struct {
+	// Name of the pool, set from the entry name
+	Name string `json:"-"`
+
+	// Stack the pool belongs to
+	StackID string `json:"stack_id"`
+
+	// Effective daily indexing quota of the pool, in bytes
+	EffectiveQuota float64 `json:"effective_quota"`
+
+	// Today's usage of the pool, in bytes
+	UsedBytes float64 `json:"used_bytes"`
+}
+
+// GetLicensePools queries the license manager for the license pools and today's usage per pool.
+// You can only use this on a license manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTlicense#licenser.2Fpools
+func (c *SplunkClient) GetLicensePools() ([]LicensePoolInfo, error) {
+	apiResponse := struct {
+		Entry []struct {
+			Name    string          `json:"name"`
+			Content LicensePoolInfo `json:"content"`
+		} `json:"entry"`
+	}{}
+	path := splcommon.URILicenserGetPools
+	err := c.Get(path, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	var pools []LicensePoolInfo
+	for _, e := range apiResponse.Entry {
+		e.Content.Name = e.Name
+		pools = append(pools, e.Content)
+	}
+	return pools, nil
+}
+
+// LicenseUsageInfo represents today's license usage of the license manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTlicense#licenser.2Fusage
+type LicenseUsageInfo struct {
+	// Daily indexing quota of all the licenses, in bytes
+	Quota float64 `json:"quota"`
+
+	// Today's usage of all the license peers, in bytes
+	PeersUsageBytes float64 `json:"slaves_usage_bytes"`
+}
+
+// GetLicenseUsage queries the license manager for today's license usage.
+// You can only use this on a license manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTlicense#licenser.2Fusage
+func (c *SplunkClient) GetLicenseUsage() (*LicenseUsageInfo, error) {
+	apiResponse := struct {
+		Entry []struct {
+			Content LicenseUsageInfo `json:"content"`
+		} `json:"entry"`
+	}{}
+	path := splcommon.URILicenserGetUsage
+	err := c.Get(path, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
+	if len(apiResponse.Entry) < 1 {
+		return nil, fmt.Errorf("invalid response from %s%s", c.ManagementURI, path)
+	}
+	return &apiResponse.Entry[0].Content, nil
+}
+
+// LicenseMessage represents a licenser message, such as a quota violation warning.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTlicense#licenser.2Fmessages
+type LicenseMessage struct {
+	// Message category, for example pool_over_quota or license_window
+	Category string `json:"category"`
+
+	// Message severity, for example WARN or ERROR
+	Severity string `json:"severity"`
+
+	// Message description
+	Description string `json:"description"`
+
+	// Pool the message belongs to
+	PoolID string `json:"pool_id"`
+
+	// Message creation time, in epoch seconds
+	CreateTime int64 `json:"create_time"`
+}
+
+// GetLicenseMessages queries the license manager for the licenser messages.
+// You can only use this on a license manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTlicense#licenser.2Fmessages
+func (c *SplunkClient) GetLicenseMessages() ([]LicenseMessage, error) {
+	apiResponse := struct {
+		Entry []struct {
+			Content LicenseMessage `json:"content"`
+		} `json:"entry"`
+	}{}
+	path := splcommon.URILicenserGetMessages
+	err := c.Get(path, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []LicenseMessage
+	for _, e := range apiResponse.Entry {
+		messages = append(messages, e.Content)
+	}
+	return messages, nil
+}
+
+// InstallLicense adds a license to the license manager, and returns the name of the installed license.
+// You can only use this on a license manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTlicense#licenser.2Flicenses
+func (c *SplunkClient) InstallLicense(payload string) (string, error) {
+	endpoint := fmt.Sprintf("%s%s?output_mode=json", c.ManagementURI, splcommon.URILicenserGetLicenses)
+	reqBody := url.Values{"payload": {payload}}.Encode()
+	request, err := http.NewRequest("POST", endpoint, strings.NewReader(reqBody))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	apiResponse := struct {
+		Entry []struct {
+			Name string `json:"name"`
+		} `json:"entry"`
+	}{}
+	expectedStatus := []int{200, 201}
+	err = c.Do(request, expectedStatus, &apiResponse)
+	if err != nil {
+		return "", err
+	}
+	if len(apiResponse.Entry) < 1 || apiResponse.Entry[0].Name == "" {
+		return "", fmt.Errorf("invalid response from %s", endpoint)
+	}
+	return apiResponse.Entry[0].Name, nil
+}
+
+// RemoveLicense removes a license from the license manager, where name is the name of the installed license.
+// You can only use this on a license manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTlicense#licenser.2Flicenses.2F.7Bname.7D
+func (c *SplunkClient) RemoveLicense(name string) error {
+	endpoint := fmt.Sprintf("%s%s/%s", c.ManagementURI, splcommon.URILicenserGetLicenses, url.PathEscape(name))
+	request, err := http.NewRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	// license already removed from the license manager
+	expectedStatus := []int{200, 404}
+	return c.Do(request, expectedStatus, nil)
+}
+
+// SearchOneshot runs a blocking search and decodes its results into obj, which must be a pointer to a slice of structs
+// or maps. Note that all the result fields are returned as strings.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTsearch#search.2Fjobs
+func (c *SplunkClient) SearchOneshot(search string, obj interface{}) error {
+	endpoint := fmt.Sprintf("%s%s", c.ManagementURI, splcommon.URISearchJobs)
+	reqBody := url.Values{
+		"search":      {search},
+		"exec_mode":   {"oneshot"},
+		"count":       {"0"},
+		"output_mode": {"json"},
+	}.Encode()
+	request, err := http.NewRequest("POST", endpoint, strings.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	apiResponse := struct {
+		Results interface{} `json:"results"`
+	}{Results: obj}
+	expectedStatus := []int{200}
+	return c.Do(request, expectedStatus, &apiResponse)
+}
+
+// parseSearchResultsByServer converts the numeric field of search results with a splunk_server field into a map of values by server
+func parseSearchResultsByServer(results []map[string]string, field string) (map[string]float64, error) {
+	values := make(map[string]float64)
+	for _, result := range results {
+		value, err := strconv.ParseFloat(result[field], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q for %s: %v", field, result[field], result["splunk_server"], err)
+		}
+		values[result["splunk_server"]] = value
+	}
+	return values, nil
+}
+
+// GetIndexerQueueFillRatios queries the fill ratio, between 0 and 1, of the fullest ingestion queue (parsing, aggregation,
+// typing and index queues) of each search peer, keyed by server name. You can use this on a cluster manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTintrospect#server.2Fintrospection.2Fqueues
+func (c *SplunkClient) GetIndexerQueueFillRatios() (map[string]float64, error) {
+	search := `| rest splunk_server=* /services/server/introspection/queues` +
+		` | where match(title, "(?i)^(parsing|agg|typing|index)queue$") AND max_size_bytes > 0` +
+		` | eval fill_ratio=current_size_bytes/max_size_bytes` +
+		` | stats max(fill_ratio) as fill_ratio by splunk_server`
+	var results []map[string]string
+	err := c.SearchOneshot(search, &results)
+	if err != nil {
+		return nil, err
+	}
+	return parseSearchResultsByServer(results, "fill_ratio")
+}
+
+// GetIndexerThroughput queries the average indexing throughput, in KB per second, of each search peer, keyed by server name.
+// You can use this on a cluster manager.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTintrospect#server.2Fintrospection.2Findexer
+func (c *SplunkClient) GetIndexerThroughput() (map[string]float64, error) {
+	search := `| rest splunk_server=* /services/server/introspection/indexer | fields splunk_server average_KBps`
+	var results []map[string]string
+	err := c.SearchOneshot(search, &results)
+	if err != nil {
+		return nil, err
+	}
+	return parseSearchResultsByServer(results, "average_KBps")
+}
+
+// SearchConcurrencyLimits represents the maximum numbers of concurrent searches of a search head.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTsystem#server.2Fstatus.2Flimits.2Fsearch-concurrency
+type SearchConcurrencyLimits struct {
+	// Maximum number of concurrent historical searches
+	MaxHistoricalSearches int `json:"max_hist_searches"`
+
+	// Maximum number of concurrent realtime searches
+	MaxRealtimeSearches int `json:"max_rt_searches"`
+}
+
+// GetSearchConcurrencyLimits queries the maximum numbers of concurrent searches of the search head
+func (c *SplunkClient) GetSearchConcurrencyLimits() (*SearchConcurrencyLimits, error) {
+	apiResponse := struct {
+		Entry []struct {
+			Content SearchConcurrencyLimits `json:"content"`
+		} `json:"entry"`
+	}{}
+	path := splcommon.URISearchConcurrencyLimits
+	err := c.Get(path, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
+	if len(apiResponse.Entry) < 1 {
+		return nil, fmt.Errorf("invalid response from %s%s", c.ManagementURI, path)
+	}
+	return &apiResponse.Entry[0].Content, nil
+}
+
+// GetSkippedSearchRatio queries the ratio, between 0 and 1, of the scheduled searches skipped in the last 15 minutes by
+// the search heads matching the host pattern. It returns 0 when no search was scheduled. You can use this on a search head
+func (c *SplunkClient) GetSkippedSearchRatio(hostPattern string) (float64, error) {
+	search := fmt.Sprintf(`search index=_internal sourcetype=scheduler host=%q earliest=-15m status=*`, hostPattern) +
+		` | stats count(eval(status=="skipped")) as skipped count as total` +
+		` | eval skipped_ratio=if(total > 0, skipped/total, 0)`
+	var results []map[string]string
+	err := c.SearchOneshot(search, &results)
+	if err != nil {
+		return 0, err
+	}
+	if len(results) < 1 {
+		return 0, nil
+	}
+	ratio, err := strconv.ParseFloat(results[0]["skipped_ratio"], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid skipped_ratio %q: %v", results[0]["skipped_ratio"], err)
+	}
+	return ratio, nil
+}
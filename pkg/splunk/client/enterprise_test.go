@@ -16,12 +16,22 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"reflect"
 	"strings"
 	"testing"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 
 	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
 )
@@ -559,3 +569,337 @@ func TestRestartSplunk(t *testing.T) {
 	}
 	splunkClientTester(t, "TestRestartSplunk", 200, "", wantRequest, test)
 }
+
+func TestGetLicenses(t *testing.T) {
+	wantRequest, _ := http.NewRequest("GET", splcommon.LocalURLLicenserGetLicenses, nil)
+	test := func(c SplunkClient) error {
+		licenses, err := c.GetLicenses()
+		if err != nil {
+			return err
+		}
+		if len(licenses) != 1 {
+			t.Fatalf("len(licenses)=%d; want 1", len(licenses))
+		}
+		if licenses[0].Name != "AAAA" || licenses[0].Status != "VALID" || licenses[0].ExpirationTime != 1700000000 || licenses[0].Quota != 1073741824 {
+			t.Errorf("unexpected license %+v", licenses[0])
+		}
+		return nil
+	}
+	body := `{"entry":[{"name":"AAAA","content":{"label":"Splunk Enterprise","type":"enterprise","status":"VALID","stack_id":"enterprise","expiration_time":1700000000,"quota":1073741824}}]}`
+	splunkClientTester(t, "TestGetLicenses", 200, body, wantRequest, test)
+
+	// test error code
+	test = func(c SplunkClient) error {
+		_, err := c.GetLicenses()
+		if err == nil {
+			t.Errorf("GetLicenses returned nil; want error")
+		}
+		return nil
+	}
+	splunkClientTester(t, "TestGetLicenses", 500, "", wantRequest, test)
+}
+
+func TestGetLicensePools(t *testing.T) {
+	wantRequest, _ := http.NewRequest("GET", splcommon.LocalURLLicenserGetPools, nil)
+	test := func(c SplunkClient) error {
+		pools, err := c.GetLicensePools()
+		if err != nil {
+			return err
+		}
+		if len(pools) != 1 {
+			t.Fatalf("len(pools)=%d; want 1", len(pools))
+		}
+		if pools[0].Name != "auto_generated_pool_enterprise" || pools[0].EffectiveQuota != 1073741824 || pools[0].UsedBytes != 536870912 {
+			t.Errorf("unexpected pool %+v", pools[0])
+		}
+		return nil
+	}
+	body := `{"entry":[{"name":"auto_generated_pool_enterprise","content":{"stack_id":"enterprise","quota":"MAX","effective_quota":1073741824,"used_bytes":536870912}}]}`
+	splunkClientTester(t, "TestGetLicensePools", 200, body, wantRequest, test)
+}
+
+func TestGetLicenseUsage(t *testing.T) {
+	wantRequest, _ := http.NewRequest("GET", splcommon.LocalURLLicenserGetUsage, nil)
+	test := func(c SplunkClient) error {
+		usage, err := c.GetLicenseUsage()
+		if err != nil {
+			return err
+		}
+		if usage.Quota != 1073741824 || usage.PeersUsageBytes != 536870912 {
+			t.Errorf("unexpected usage %+v", usage)
+		}
+		return nil
+	}
+	body := `{"entry":[{"name":"license_usage","content":{"quota":1073741824,"slaves_usage_bytes":536870912}}]}`
+	splunkClientTester(t, "TestGetLicenseUsage", 200, body, wantRequest, test)
+
+	// test body with no entries
+	test = func(c SplunkClient) error {
+		_, err := c.GetLicenseUsage()
+		if err == nil {
+			t.Errorf("GetLicenseUsage returned nil; want error")
+		}
+		return nil
+	}
+	splunkClientTester(t, "TestGetLicenseUsage", 200, `{"entry":[]}`, wantRequest, test)
+}
+
+func TestGetLicenseMessages(t *testing.T) {
+	wantRequest, _ := http.NewRequest("GET", splcommon.LocalURLLicenserGetMessages, nil)
+	test := func(c SplunkClient) error {
+		messages, err := c.GetLicenseMessages()
+		if err != nil {
+			return err
+		}
+		if len(messages) != 1 || messages[0].Category != "pool_over_quota" || messages[0].Severity != "WARN" {
+			t.Errorf("unexpected messages %+v", messages)
+		}
+		return nil
+	}
+	body := `{"entry":[{"name":"1","content":{"category":"pool_over_quota","severity":"WARN","description":"pool quota exceeded","pool_id":"auto_generated_pool_enterprise","create_time":1650000000}}]}`
+	splunkClientTester(t, "TestGetLicenseMessages", 200, body, wantRequest, test)
+}
+
+func TestInstallLicense(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/licenser/licenses?output_mode=json", nil)
+	test := func(c SplunkClient) error {
+		name, err := c.InstallLicense("<license></license>")
+		if err != nil {
+			return err
+		}
+		if name != "AAAA" {
+			t.Errorf("InstallLicense name=%s; want AAAA", name)
+		}
+		return nil
+	}
+	body := `{"entry":[{"name":"AAAA","content":{"status":"VALID"}}]}`
+	splunkClientTester(t, "TestInstallLicense", 201, body, wantRequest, test)
+
+	// test missing license name
+	test = func(c SplunkClient) error {
+		_, err := c.InstallLicense("<license></license>")
+		if err == nil {
+			t.Errorf("InstallLicense returned nil; want error")
+		}
+		return nil
+	}
+	splunkClientTester(t, "TestInstallLicense", 201, `{"entry":[]}`, wantRequest, test)
+
+	// test error code
+	splunkClientTester(t, "TestInstallLicense", 400, "", wantRequest, test)
+}
+
+func TestRemoveLicense(t *testing.T) {
+	wantRequest, _ := http.NewRequest("DELETE", "https://localhost:8089/services/licenser/licenses/AAAA", nil)
+	test := func(c SplunkClient) error {
+		return c.RemoveLicense("AAAA")
+	}
+	splunkClientTester(t, "TestRemoveLicense", 200, "", wantRequest, test)
+
+	// license already removed
+	splunkClientTester(t, "TestRemoveLicense", 404, "", wantRequest, test)
+
+	// test error code
+	test = func(c SplunkClient) error {
+		err := c.RemoveLicense("AAAA")
+		if err == nil {
+			t.Errorf("RemoveLicense returned nil; want error")
+		}
+		return nil
+	}
+	splunkClientTester(t, "TestRemoveLicense", 500, "", wantRequest, test)
+}
+
+func TestGetIndexerQueueFillRatios(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/search/jobs", nil)
+	test := func(c SplunkClient) error {
+		fillRatios, err := c.GetIndexerQueueFillRatios()
+		if err != nil {
+			return err
+		}
+		want := map[string]float64{"splunk-idxc-indexer-0": 0.25, "splunk-idxc-indexer-1": 0.9}
+		if !reflect.DeepEqual(fillRatios, want) {
+			t.Errorf("GetIndexerQueueFillRatios=%v; want %v", fillRatios, want)
+		}
+		return nil
+	}
+	body := `{"preview":false,"init_offset":0,"messages":[],"fields":[{"name":"splunk_server"},{"name":"fill_ratio"}],"results":[{"splunk_server":"splunk-idxc-indexer-0","fill_ratio":"0.25"},{"splunk_server":"splunk-idxc-indexer-1","fill_ratio":"0.9"}]}`
+	splunkClientTester(t, "TestGetIndexerQueueFillRatios", 200, body, wantRequest, test)
+
+	// test invalid value
+	test = func(c SplunkClient) error {
+		_, err := c.GetIndexerQueueFillRatios()
+		if err == nil {
+			t.Errorf("GetIndexerQueueFillRatios returned nil; want error")
+		}
+		return nil
+	}
+	splunkClientTester(t, "TestGetIndexerQueueFillRatios", 200, `{"results":[{"splunk_server":"splunk-idxc-indexer-0","fill_ratio":""}]}`, wantRequest, test)
+
+	// test error code
+	splunkClientTester(t, "TestGetIndexerQueueFillRatios", 500, "", wantRequest, test)
+}
+
+func TestGetIndexerThroughput(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/search/jobs", nil)
+	test := func(c SplunkClient) error {
+		throughput, err := c.GetIndexerThroughput()
+		if err != nil {
+			return err
+		}
+		want := map[string]float64{"splunk-idxc-indexer-0": 512.5}
+		if !reflect.DeepEqual(throughput, want) {
+			t.Errorf("GetIndexerThroughput=%v; want %v", throughput, want)
+		}
+		return nil
+	}
+	body := `{"results":[{"splunk_server":"splunk-idxc-indexer-0","average_KBps":"512.5"}]}`
+	splunkClientTester(t, "TestGetIndexerThroughput", 200, body, wantRequest, test)
+}
+
+func TestGetSearchConcurrencyLimits(t *testing.T) {
+	wantRequest, _ := http.NewRequest("GET", "https://localhost:8089/services/server/status/limits/search-concurrency?count=0&output_mode=json", nil)
+	test := func(c SplunkClient) error {
+		limits, err := c.GetSearchConcurrencyLimits()
+		if err != nil {
+			return err
+		}
+		if limits.MaxHistoricalSearches != 10 || limits.MaxRealtimeSearches != 8 {
+			t.Errorf("GetSearchConcurrencyLimits=%+v; want 10 historical and 8 realtime searches", limits)
+		}
+		return nil
+	}
+	body := `{"entry":[{"name":"search-concurrency","content":{"max_hist_searches":10,"max_rt_searches":8}}]}`
+	splunkClientTester(t, "TestGetSearchConcurrencyLimits", 200, body, wantRequest, test)
+
+	// test body with no entries
+	test = func(c SplunkClient) error {
+		_, err := c.GetSearchConcurrencyLimits()
+		if err == nil {
+			t.Errorf("GetSearchConcurrencyLimits returned nil; want error")
+		}
+		return nil
+	}
+	body = `{"entry":[]}`
+	splunkClientTester(t, "TestGetSearchConcurrencyLimits", 200, body, wantRequest, test)
+}
+
+func TestGetSkippedSearchRatio(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/search/jobs", nil)
+	test := func(c SplunkClient) error {
+		ratio, err := c.GetSkippedSearchRatio("splunk-shc-search-head-*")
+		if err != nil {
+			return err
+		}
+		if ratio != 0.25 {
+			t.Errorf("GetSkippedSearchRatio=%f; want 0.25", ratio)
+		}
+		return nil
+	}
+	body := `{"results":[{"skipped":"5","total":"20","skipped_ratio":"0.25"}]}`
+	splunkClientTester(t, "TestGetSkippedSearchRatio", 200, body, wantRequest, test)
+}
+
+func TestStartIndexerClusterRebalance(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/cluster/master/control/control/rebalance_buckets", nil)
+	test := func(c SplunkClient) error {
+		return c.StartIndexerClusterRebalance(60)
+	}
+	body := `{"messages":[{"type":"INFO","text":"Data rebalance started"}]}`
+	splunkClientTester(t, "TestStartIndexerClusterRebalance", 200, body, wantRequest, test)
+
+	// test error code
+	test = func(c SplunkClient) error {
+		err := c.StartIndexerClusterRebalance(0)
+		if err == nil {
+			t.Errorf("StartIndexerClusterRebalance returned nil; want error")
+		}
+		return nil
+	}
+	splunkClientTester(t, "TestStartIndexerClusterRebalance", 500, "", wantRequest, test)
+}
+
+func TestStopIndexerClusterRebalance(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/cluster/master/control/control/rebalance_buckets", nil)
+	test := func(c SplunkClient) error {
+		return c.StopIndexerClusterRebalance()
+	}
+	body := `{"messages":[{"type":"INFO","text":"Data rebalance stopped"}]}`
+	splunkClientTester(t, "TestStopIndexerClusterRebalance", 200, body, wantRequest, test)
+}
+
+func TestGetIndexerClusterRebalanceStatus(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/cluster/master/control/control/rebalance_buckets", nil)
+	wantStatus := ClusterManagerRebalanceStatus{Running: true, PercentComplete: 42.5, Message: "Data rebalance is running. 42.5% complete"}
+	test := func(c SplunkClient) error {
+		status, err := c.GetIndexerClusterRebalanceStatus()
+		if err != nil {
+			return err
+		}
+		if *status != wantStatus {
+			t.Errorf("GetIndexerClusterRebalanceStatus=%+v; want %+v", *status, wantStatus)
+		}
+		return nil
+	}
+	body := `{"messages":[{"type":"INFO","text":"Data rebalance is running. 42.5% complete"}]}`
+	splunkClientTester(t, "TestGetIndexerClusterRebalanceStatus", 200, body, wantRequest, test)
+
+	wantStatus = ClusterManagerRebalanceStatus{Message: "Data rebalance is not running"}
+	body = `{"messages":[{"type":"INFO","text":"Data rebalance is not running"}]}`
+	splunkClientTester(t, "TestGetIndexerClusterRebalanceStatus", 200, body, wantRequest, test)
+
+	// an empty, unknown or error status is never taken for a completed rebalance
+	test = func(c SplunkClient) error {
+		status, err := c.GetIndexerClusterRebalanceStatus()
+		if err == nil {
+			t.Errorf("GetIndexerClusterRebalanceStatus=%+v; want error", *status)
+		}
+		return nil
+	}
+	for _, body := range []string{
+		`{"messages":[]}`,
+		`{"messages":[{"type":"INFO","text":"Data rebalance status unavailable"}]}`,
+		`{"messages":[{"type":"ERROR","text":"Data rebalance completed with errors"}]}`,
+	} {
+		splunkClientTester(t, "TestGetIndexerClusterRebalanceStatus", 200, body, wantRequest, test)
+	}
+}
+
+func TestSplunkClientDoTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	wantRequest, _ := http.NewRequest("GET", "https://localhost:8089/services/cluster/master/info?count=0&output_mode=json", nil)
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	mockSplunkClient.AddHandler(wantRequest, 503, "", nil)
+	c := NewSplunkClient("https://localhost:8089", "admin", "p@ssw0rd")
+	c.Client = mockSplunkClient
+
+	// the request span is a child of the span of the context of the client
+	ctx, parentSpan := tracing.StartSpan(context.TODO(), "ApplyClusterManager")
+	err := c.WithContext(ctx).Get("/services/cluster/master/info", nil)
+	if err == nil {
+		t.Errorf("Get() returned nil; want error")
+	}
+	parentSpan.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans; want 2", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "SplunkClient.Do" || span.Parent().SpanID() != parentSpan.SpanContext().SpanID() {
+		t.Errorf("span %q isn't a child of the span of the client context", span.Name())
+	}
+	attrs := map[string]string{}
+	for _, attr := range span.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs[string(semconv.HTTPTargetKey)] != "/services/cluster/master/info" || attrs[string(semconv.HTTPStatusCodeKey)] != "503" {
+		t.Errorf("span attributes = %v; want the endpoint and status code of the request", attrs)
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("span status = %v; want %v", span.Status().Code, codes.Error)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// blank assignment to verify that HTTPClient implements S3Client
+var _ S3Client = &HTTPClient{}
+
+const (
+	// httpAppIndexFile is the JSON index listing the app packages under an app source location
+	httpAppIndexFile = "index.json"
+
+	// remoteClientTimeout is the timeout for the requests to the http and oci remote storage
+	remoteClientTimeout = 10 * time.Minute
+)
+
+// HTTPAppIndex represents the JSON index of the app packages published on a HTTP(S) server
+type HTTPAppIndex struct {
+	Apps []HTTPAppIndexEntry `json:"apps"`
+}
+
+// HTTPAppIndexEntry represents an app package in the JSON index
+type HTTPAppIndexEntry struct {
+	// App package name, relative to the app source location
+	Name string `json:"name"`
+
+	// Digest of the app package, in the form sha256:<hex>
+	Digest string `json:"digest"`
+
+	// App package size in bytes
+	Size int64 `json:"size"`
+
+	// Last modification time of the app package, in RFC3339 format
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// HTTPClient is a client to download the app packages from a HTTP(S) server
+type HTTPClient struct {
+	BucketName string
+	Username   string
+	Password   string
+	Prefix     string
+	Endpoint   string
+	Client     SplunkHTTPClient
+}
+
+// NewHTTPClient returns a HTTP(S) client
+func NewHTTPClient(ctx context.Context, bucketName string, username string, password string, prefix string, startAfter string, region string, endpoint string, fn GetInitFunc) (S3Client, error) {
+	cl := fn(ctx, endpoint, username, password)
+	if cl == nil {
+		return nil, fmt.Errorf("failed to create a HTTP client")
+	}
+
+	return &HTTPClient{
+		BucketName: bucketName,
+		Username:   username,
+		Password:   password,
+		Prefix:     prefix,
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		Client:     cl.(SplunkHTTPClient),
+	}, nil
+}
+
+// RegisterHTTPClient will add the corresponding function pointer to the map
+func RegisterHTTPClient() {
+	wrapperObject := GetS3ClientWrapper{GetS3Client: NewHTTPClient, GetInitFunc: InitHTTPClientWrapper}
+	S3Clients["http"] = wrapperObject
+}
+
+// InitHTTPClientWrapper is a wrapper around InitHTTPClientSession
+func InitHTTPClientWrapper(ctx context.Context, endpoint string, username string, password string) interface{} {
+	cl := InitHTTPClientSession(ctx, endpoint)
+	if cl == nil {
+		return nil
+	}
+	return cl
+}
+
+// InitHTTPClientSession initializes and returns a client session object for the http and oci remote storage
+func InitHTTPClientSession(ctx context.Context, endpoint string) SplunkHTTPClient {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("InitHTTPClientSession")
+
+	if !strings.HasPrefix(endpoint, "https://") && !strings.HasPrefix(endpoint, "http://") {
+		scopedLog.Info("Unsupported endpoint for the HTTP client", "endpoint", endpoint)
+		return nil
+	}
+
+	if strings.HasPrefix(endpoint, "http://") {
+		scopedLog.Info("Using insecure endpoint for the HTTP client", "endpoint", endpoint)
+	}
+
+	return &http.Client{Timeout: remoteClientTimeout}
+}
+
+// setRemoteAuth sets the authorization header from the volume credentials.
+// Username with password is used for the basic auth, password alone is used as the bearer token
+func setRemoteAuth(req *http.Request, username, password string) {
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	} else if password != "" {
+		req.Header.Set("Authorization", "Bearer "+password)
+	}
+}
+
+// getDigestHex returns the hex encoded sha256 digest, without the quotes and the algorithm prefix
+func getDigestHex(digest string) string {
+	return strings.TrimPrefix(strings.Trim(digest, "\""), "sha256:")
+}
+
+// downloadToFile writes the response body to the local file, and verifies the sha256 digest of the content
+func downloadToFile(resp *http.Response, localFile, digest string) error {
+	file, err := os.Create(localFile)
+	if err != nil {
+		return err
+	}
+
+	hasher := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hasher), resp.Body)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		actualDigest := hex.EncodeToString(hasher.Sum(nil))
+		if actualDigest != getDigestHex(digest) {
+			err = fmt.Errorf("digest mismatch for the downloaded file. expected: %s, actual: %s", getDigestHex(digest), actualDigest)
+		}
+	}
+
+	if err != nil {
+		os.Remove(localFile)
+	}
+	return err
+}
+
+// getURL returns the URL of a file relative to the bucket
+func (client *HTTPClient) getURL(remoteFile string) string {
+	return client.Endpoint + "/" + path.Join(client.BucketName, remoteFile)
+}
+
+// get sends a GET request for a file relative to the bucket
+func (client *HTTPClient) get(ctx context.Context, remoteFile string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", client.getURL(remoteFile), nil)
+	if err != nil {
+		return nil, err
+	}
+	setRemoteAuth(req, client.Username, client.Password)
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d for %s", resp.StatusCode, client.getURL(remoteFile))
+	}
+	return resp, nil
+}
+
+// GetAppsList get the list of apps from the JSON index under the app source location
+func (client *HTTPClient) GetAppsList(ctx context.Context) (S3Response, error) {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("GetAppsList")
+
+	scopedLog.Info("Getting Apps list", "Endpoint", client.Endpoint, "Bucket", client.BucketName, "Prefix", client.Prefix)
+	s3Resp := S3Response{}
+
+	resp, err := client.get(ctx, path.Join(client.Prefix, httpAppIndexFile))
+	if err != nil {
+		return s3Resp, err
+	}
+	defer resp.Body.Close()
+
+	var index HTTPAppIndex
+	err = json.NewDecoder(resp.Body).Decode(&index)
+	if err != nil {
+		return s3Resp, fmt.Errorf("unable to parse the app index: %v", err)
+	}
+
+	for _, app := range index.Apps {
+		if app.Name == "" || app.Digest == "" || app.Size <= 0 {
+			scopedLog.Error(nil, "Ignoring the app index entry missing the name, digest or size", "entry", app)
+			continue
+		}
+
+		newETag := getDigestHex(app.Digest)
+		newKey := path.Join(client.Prefix, app.Name)
+		newSize := app.Size
+		newRemoteObject := RemoteObject{Etag: &newETag, Key: &newKey, Size: &newSize}
+		if lastModified, err := time.Parse(time.RFC3339, app.LastModified); err == nil {
+			newRemoteObject.LastModified = &lastModified
+		}
+		s3Resp.Objects = append(s3Resp.Objects, &newRemoteObject)
+	}
+
+	return s3Resp, nil
+}
+
+// DownloadApp downloads an app package from the HTTP(S) server, and verifies its digest
+func (client *HTTPClient) DownloadApp(ctx context.Context, remoteFile string, localFile string, etag string) (bool, error) {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("DownloadApp").WithValues("remoteFile", remoteFile, "localFile", localFile)
+
+	resp, err := client.get(ctx, remoteFile)
+	if err != nil {
+		scopedLog.Error(err, "Unable to download remote file")
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	err = downloadToFile(resp, localFile, etag)
+	if err != nil {
+		scopedLog.Error(err, "Unable to download remote file")
+		return false, err
+	}
+
+	scopedLog.Info("File downloaded")
+	return true, nil
+}
+
+// GetInitContainerImage returns the initContainer image to be used with this client
+func (client *HTTPClient) GetInitContainerImage(ctx context.Context) string {
+	return ("curlimages/curl")
+}
+
+// GetInitContainerCmd returns the init container command on a per app source basis to be used by the initContainer
+func (client *HTTPClient) GetInitContainerCmd(ctx context.Context, endpoint string, bucket string, path string, appSrcName string, appMnt string) []string {
+	indexURL := strings.TrimSuffix(endpoint, "/") + "/" + strings.Trim(bucket+"/"+path, "/") + "/" + httpAppIndexFile
+	podSyncPath := filepath.Join(appMnt, appSrcName) + "/"
+
+	return ([]string{"--create-dirs", "-o", podSyncPath + httpAppIndexFile, indexURL})
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func getTestDigest(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	ctx := context.TODO()
+	fn := InitHTTPClientWrapper
+
+	httpClient, err := NewHTTPClient(ctx, "apps", "user", "pass", "admin/", "admin/", "", "https://apps.example.com/", fn)
+	if httpClient == nil || err != nil {
+		t.Errorf("NewHTTPClient should have returned a valid HTTP client.")
+	}
+
+	if httpClient.(*HTTPClient).Endpoint != "https://apps.example.com" {
+		t.Errorf("trailing slash should be removed from the endpoint")
+	}
+
+	httpClient, err = NewHTTPClient(ctx, "apps", "user", "pass", "admin/", "admin/", "", "apps.example.com", fn)
+	if httpClient != nil || err == nil {
+		t.Errorf("NewHTTPClient should have returned an error for the invalid endpoint.")
+	}
+}
+
+func TestHTTPClientGetAppsListAndDownload(t *testing.T) {
+	ctx := context.TODO()
+	appContent := "app1 package content"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/apps/admin/index.json":
+			fmt.Fprintf(w, `{"apps": [
+				{"name": "app1.tgz", "digest": "sha256:%s", "size": %d, "lastModified": "2022-05-01T10:00:00Z"},
+				{"name": "app2.tgz", "size": 10}
+			]}`, getTestDigest(appContent), len(appContent))
+		case "/apps/admin/app1.tgz":
+			fmt.Fprint(w, appContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s3Client, err := NewHTTPClient(ctx, "apps", "user", "pass", "admin/", "admin/", "", server.URL, InitHTTPClientWrapper)
+	if err != nil {
+		t.Fatalf("unable to create the HTTP client, error: %v", err)
+	}
+
+	resp, err := s3Client.GetAppsList(ctx)
+	if err != nil {
+		t.Fatalf("GetAppsList should not return an error, error: %v", err)
+	}
+
+	// entry missing the digest should be ignored
+	if len(resp.Objects) != 1 {
+		t.Fatalf("expected one app, got: %d", len(resp.Objects))
+	}
+
+	obj := resp.Objects[0]
+	if *obj.Key != "admin/app1.tgz" || *obj.Etag != getTestDigest(appContent) || *obj.Size != int64(len(appContent)) || obj.LastModified == nil {
+		t.Errorf("unexpected remote object, key: %s, etag: %s, size: %d", *obj.Key, *obj.Etag, *obj.Size)
+	}
+
+	dir, err := ioutil.TempDir("", "httpclient")
+	if err != nil {
+		t.Fatalf("unable to create temp dir, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	localFile := filepath.Join(dir, "app1.tgz")
+	ok, err := s3Client.DownloadApp(ctx, *obj.Key, localFile, *obj.Etag)
+	if !ok || err != nil {
+		t.Errorf("DownloadApp should not return an error, error: %v", err)
+	}
+
+	data, _ := ioutil.ReadFile(localFile)
+	if string(data) != appContent {
+		t.Errorf("unexpected downloaded content: %s", string(data))
+	}
+
+	// digest mismatch should return an error, and remove the local file
+	ok, err = s3Client.DownloadApp(ctx, *obj.Key, localFile, getTestDigest("other content"))
+	if ok || err == nil {
+		t.Errorf("DownloadApp should return an error for the digest mismatch")
+	}
+
+	if _, err = os.Stat(localFile); !os.IsNotExist(err) {
+		t.Errorf("local file should be removed after the digest mismatch")
+	}
+
+	// invalid credentials should return an error
+	s3Client, _ = NewHTTPClient(ctx, "apps", "user", "invalid", "admin/", "admin/", "", server.URL, InitHTTPClientWrapper)
+	_, err = s3Client.GetAppsList(ctx)
+	if err == nil {
+		t.Errorf("GetAppsList should return an error for the invalid credentials")
+	}
+}
+
+func TestHTTPClientTokenAuth(t *testing.T) {
+	ctx := context.TODO()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"apps": []}`)
+	}))
+	defer server.Close()
+
+	s3Client, _ := NewHTTPClient(ctx, "apps", "", "token1", "admin/", "admin/", "", server.URL, InitHTTPClientWrapper)
+	_, err := s3Client.GetAppsList(ctx)
+	if err != nil {
+		t.Errorf("GetAppsList should not return an error with the token, error: %v", err)
+	}
+}
+
+func TestHTTPGetInitContainerCmd(t *testing.T) {
+	ctx := context.TODO()
+	wantCmd := []string{"--create-dirs", "-o", "/mnt/apps-local/admin/index.json", "https://apps.example.com/sample_bucket/admin/index.json"}
+
+	httpClient := &HTTPClient{}
+	gotCmd := httpClient.GetInitContainerCmd(ctx, "https://apps.example.com", "sample_bucket", "admin", "admin", "/mnt/apps-local/")
+	if !reflect.DeepEqual(wantCmd, gotCmd) {
+		t.Errorf("Got incorrect Init container cmd %v", gotCmd)
+	}
+
+	if httpClient.GetInitContainerImage(ctx) != "curlimages/curl" {
+		t.Errorf("Got invalid init container image for HTTP client.")
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var splunkRequestDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "splunk_operator_splunk_request_duration_seconds",
+	Help:    "The latency of the Splunk REST API requests sent by the operator, by endpoint",
+	Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+}, []string{"method", "endpoint"})
+
+var splunkRequestErrorCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "splunk_operator_splunk_request_errors_total",
+	Help: "The number of failed Splunk REST API requests sent by the operator, by endpoint and status code",
+}, []string{"method", "endpoint", "code"})
+
+// endpointIDRegex matches the path segments holding identifiers, like peer GUIDs and license hashes
+var endpointIDRegex = regexp.MustCompile(`/[0-9A-Fa-f-]{32,}`)
+
+// getEndpointLabel returns the endpoint label of a request, its path without the identifiers
+func getEndpointLabel(request *http.Request) string {
+	return endpointIDRegex.ReplaceAllString(request.URL.Path, "/:id")
+}
+
+// recordRequestMetrics records the latency of a request, and counts it as failed when it didn't return an expected status.
+// code is 0 when no response was received
+func recordRequestMetrics(request *http.Request, start time.Time, code int, failed bool) {
+	endpoint := getEndpointLabel(request)
+	splunkRequestDurationHistogram.WithLabelValues(request.Method, endpoint).Observe(time.Since(start).Seconds())
+	if !failed {
+		return
+	}
+	codeLabel := "error"
+	if code != 0 {
+		codeLabel = strconv.Itoa(code)
+	}
+	splunkRequestErrorCounter.WithLabelValues(request.Method, endpoint, codeLabel).Inc()
+}
+
+func init() {
+	metrics.Registry.MustRegister(
+		splunkRequestDurationHistogram,
+		splunkRequestErrorCounter,
+	)
+}
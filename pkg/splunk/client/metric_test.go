@@ -0,0 +1,68 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+func TestGetEndpointLabel(t *testing.T) {
+	tests := map[string]string{
+		"https://localhost:8089/services/cluster/master/info?count=0&output_mode=json":                               "/services/cluster/master/info",
+		"https://localhost:8089/services/cluster/master/peers/D39B1729-E2C5-4273-B9B2-534DA7C2F866?count=0":          "/services/cluster/master/peers/:id",
+		"https://localhost:8089/services/licenser/licenses/f1d2d2f924e986ac86fdf7b36c94bcdf32beec15f1d2d2f924e986ac": "/services/licenser/licenses/:id",
+	}
+	for uri, want := range tests {
+		request, _ := http.NewRequest("GET", uri, nil)
+		if got := getEndpointLabel(request); got != want {
+			t.Errorf("getEndpointLabel(%s) = %s; want %s", uri, got, want)
+		}
+	}
+}
+
+func TestSplunkRequestMetrics(t *testing.T) {
+	endpoint := "/services/cluster/master/info"
+	wantRequest, _ := http.NewRequest("GET", "https://localhost:8089"+endpoint+"?count=0&output_mode=json", nil)
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	mockSplunkClient.AddHandler(wantRequest, 503, "", nil)
+	c := NewSplunkClient("https://localhost:8089", "admin", "p@ssw0rd")
+	c.Client = mockSplunkClient
+
+	before := testutil.ToFloat64(splunkRequestErrorCounter.WithLabelValues("GET", endpoint, "503"))
+	err := c.Get(endpoint, nil)
+	if err == nil {
+		t.Errorf("Get() returned nil; want error")
+	}
+	if got := testutil.ToFloat64(splunkRequestErrorCounter.WithLabelValues("GET", endpoint, "503")); got != before+1 {
+		t.Errorf("splunk_operator_splunk_request_errors_total = %v; want %v", got, before+1)
+	}
+	if count := testutil.CollectAndCount(splunkRequestDurationHistogram); count == 0 {
+		t.Errorf("splunk_operator_splunk_request_duration_seconds has no series")
+	}
+
+	// requests without a response are counted as errors
+	err = c.Get("/services/unknown", nil)
+	if err == nil {
+		t.Errorf("Get() returned nil; want error")
+	}
+	if got := testutil.ToFloat64(splunkRequestErrorCounter.WithLabelValues("GET", "/services/unknown", "error")); got != 1 {
+		t.Errorf("splunk_operator_splunk_request_errors_total = %v; want 1", got)
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// blank assignment to verify that OCIClient implements S3Client
+var _ S3Client = &OCIClient{}
+
+const (
+	// ociManifestMediaTypes are the manifest media types accepted from the registry
+	ociManifestMediaTypes = "application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json"
+
+	// ociTitleAnnotation holds the file name of an artifact layer
+	ociTitleAnnotation = "org.opencontainers.image.title"
+
+	// ociCreatedAnnotation holds the creation time of an artifact
+	ociCreatedAnnotation = "org.opencontainers.image.created"
+)
+
+var (
+	ociAuthParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	ociNextLinkRegex  = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+// OCITagList represents the tag listing of a repository
+type OCITagList struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// OCIDescriptor represents an OCI content descriptor
+type OCIDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// OCIManifest represents an OCI image manifest
+type OCIManifest struct {
+	Layers      []OCIDescriptor   `json:"layers"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// OCIClient is a client to download the app packages published as OCI artifacts to a registry.
+// Each tag of the repository is an app package, stored as the first layer of the artifact
+type OCIClient struct {
+	Repository string
+	Username   string
+	Password   string
+	Prefix     string
+	Endpoint   string
+	Client     SplunkHTTPClient
+
+	// bearer token issued by the registry token service
+	token string
+}
+
+// NewOCIClient returns an OCI registry client
+func NewOCIClient(ctx context.Context, bucketName string, username string, password string, prefix string, startAfter string, region string, endpoint string, fn GetInitFunc) (S3Client, error) {
+	cl := fn(ctx, endpoint, username, password)
+	if cl == nil {
+		return nil, fmt.Errorf("failed to create an OCI registry client")
+	}
+
+	// repository is the volume path joined with the app source location
+	repository := strings.Trim(path.Join(bucketName, prefix), "/")
+
+	return &OCIClient{
+		Repository: repository,
+		Username:   username,
+		Password:   password,
+		Prefix:     prefix,
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		Client:     cl.(SplunkHTTPClient),
+	}, nil
+}
+
+// RegisterOCIClient will add the corresponding function pointer to the map
+func RegisterOCIClient() {
+	wrapperObject := GetS3ClientWrapper{GetS3Client: NewOCIClient, GetInitFunc: InitHTTPClientWrapper}
+	S3Clients["oci"] = wrapperObject
+}
+
+// getAuthToken gets a bearer token from the registry token service for the given auth challenge
+func (client *OCIClient) getAuthToken(ctx context.Context, challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported auth challenge from the registry: %s", challenge)
+	}
+
+	params := make(map[string]string)
+	for _, match := range ociAuthParamRegex.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+
+	if params["realm"] == "" {
+		return "", fmt.Errorf("auth challenge from the registry is missing the realm: %s", challenge)
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", err
+	}
+
+	query := tokenURL.Query()
+	for _, param := range []string{"service", "scope"} {
+		if params[param] != "" {
+			query.Set(param, params[param])
+		}
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	setRemoteAuth(req, client.Username, client.Password)
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d from the registry token service", resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&tokenResp)
+	if err != nil {
+		return "", err
+	}
+
+	if tokenResp.Token != "" {
+		return tokenResp.Token, nil
+	}
+	return tokenResp.AccessToken, nil
+}
+
+// get sends a GET request to the registry. When the registry asks for a token, the token is
+// fetched from the registry token service and the request is sent again
+func (client *OCIClient) get(ctx context.Context, requestURL string, accept string) (*http.Response, error) {
+	var resp *http.Response
+	for attempt := 0; attempt < 2; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if client.token != "" {
+			req.Header.Set("Authorization", "Bearer "+client.token)
+		} else {
+			setRemoteAuth(req, client.Username, client.Password)
+		}
+
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		resp, err = client.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			break
+		}
+
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		client.token, err = client.getAuthToken(ctx, challenge)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d for %s", resp.StatusCode, requestURL)
+	}
+	return resp, nil
+}
+
+// getTags returns all the tags of the repository, following the pagination links
+func (client *OCIClient) getTags(ctx context.Context) ([]string, error) {
+	var tags []string
+	requestURL := fmt.Sprintf("%s/v2/%s/tags/list", client.Endpoint, client.Repository)
+	for requestURL != "" {
+		resp, err := client.get(ctx, requestURL, "")
+		if err != nil {
+			return nil, err
+		}
+
+		var tagList OCITagList
+		err = json.NewDecoder(resp.Body).Decode(&tagList)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the tag list: %v", err)
+		}
+		tags = append(tags, tagList.Tags...)
+
+		requestURL = ""
+		if match := ociNextLinkRegex.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+			requestURL = match[1]
+			if strings.HasPrefix(requestURL, "/") {
+				requestURL = client.Endpoint + requestURL
+			}
+		}
+	}
+	return tags, nil
+}
+
+// getManifest returns the manifest for the tag
+func (client *OCIClient) getManifest(ctx context.Context, tag string) (*OCIManifest, error) {
+	resp, err := client.get(ctx, fmt.Sprintf("%s/v2/%s/manifests/%s", client.Endpoint, client.Repository, tag), ociManifestMediaTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var manifest OCIManifest
+	err = json.NewDecoder(resp.Body).Decode(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the manifest for tag %s: %v", tag, err)
+	}
+	return &manifest, nil
+}
+
+// GetAppsList get the list of apps from the repository tags
+func (client *OCIClient) GetAppsList(ctx context.Context) (S3Response, error) {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("GetAppsList")
+
+	scopedLog.Info("Getting Apps list", "Endpoint", client.Endpoint, "Repository", client.Repository)
+	s3Resp := S3Response{}
+
+	tags, err := client.getTags(ctx)
+	if err != nil {
+		return s3Resp, err
+	}
+
+	for _, tag := range tags {
+		manifest, err := client.getManifest(ctx, tag)
+		if err != nil {
+			return s3Resp, err
+		}
+
+		if len(manifest.Layers) == 0 {
+			scopedLog.Error(nil, "Ignoring the artifact without any layers", "tag", tag)
+			continue
+		}
+
+		// app package name is the title of the layer, and defaults to the tag
+		layer := manifest.Layers[0]
+		appName := layer.Annotations[ociTitleAnnotation]
+		if appName == "" {
+			appName = tag + ".tgz"
+		}
+
+		newETag := getDigestHex(layer.Digest)
+		newKey := path.Join(client.Prefix, appName)
+		newSize := layer.Size
+		newRemoteObject := RemoteObject{Etag: &newETag, Key: &newKey, Size: &newSize}
+		if created, err := time.Parse(time.RFC3339, manifest.Annotations[ociCreatedAnnotation]); err == nil {
+			newRemoteObject.LastModified = &created
+		}
+		s3Resp.Objects = append(s3Resp.Objects, &newRemoteObject)
+	}
+
+	return s3Resp, nil
+}
+
+// DownloadApp pulls the app package blob from the registry, and verifies its digest
+func (client *OCIClient) DownloadApp(ctx context.Context, remoteFile string, localFile string, etag string) (bool, error) {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("DownloadApp").WithValues("remoteFile", remoteFile, "localFile", localFile)
+
+	resp, err := client.get(ctx, fmt.Sprintf("%s/v2/%s/blobs/sha256:%s", client.Endpoint, client.Repository, getDigestHex(etag)), "")
+	if err != nil {
+		scopedLog.Error(err, "Unable to download remote file")
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	err = downloadToFile(resp, localFile, etag)
+	if err != nil {
+		scopedLog.Error(err, "Unable to download remote file")
+		return false, err
+	}
+
+	scopedLog.Info("File downloaded")
+	return true, nil
+}
+
+// GetInitContainerImage returns the initContainer image to be used with this client
+func (client *OCIClient) GetInitContainerImage(ctx context.Context) string {
+	return ("ghcr.io/oras-project/oras")
+}
+
+// GetInitContainerCmd returns the init container command on a per app source basis to be used by the initContainer
+func (client *OCIClient) GetInitContainerCmd(ctx context.Context, endpoint string, bucket string, path string, appSrcName string, appMnt string) []string {
+	registry := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSuffix(endpoint, "/"), "https://"), "http://")
+	repository := strings.Trim(bucket+"/"+path, "/")
+	podSyncPath := filepath.Join(appMnt, appSrcName) + "/"
+
+	return ([]string{"pull", registry + "/" + repository, "-o", podSyncPath})
+}
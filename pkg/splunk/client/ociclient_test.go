@@ -0,0 +1,151 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewOCIClient(t *testing.T) {
+	ctx := context.TODO()
+
+	ociClient, err := NewOCIClient(ctx, "splunk", "", "", "apps/security/", "apps/security/", "", "https://registry.example.com", InitHTTPClientWrapper)
+	if ociClient == nil || err != nil {
+		t.Fatalf("NewOCIClient should have returned a valid OCI client.")
+	}
+
+	if ociClient.(*OCIClient).Repository != "splunk/apps/security" {
+		t.Errorf("unexpected repository %s", ociClient.(*OCIClient).Repository)
+	}
+
+	ociClient, err = NewOCIClient(ctx, "splunk", "", "", "/", "/", "", "https://registry.example.com", InitHTTPClientWrapper)
+	if err != nil || ociClient.(*OCIClient).Repository != "splunk" {
+		t.Errorf("repository should be the volume path when the location is empty")
+	}
+
+	ociClient, err = NewOCIClient(ctx, "splunk", "", "", "apps/", "apps/", "", "registry.example.com", InitHTTPClientWrapper)
+	if ociClient != nil || err == nil {
+		t.Errorf("NewOCIClient should have returned an error for the invalid endpoint.")
+	}
+}
+
+func TestOCIClientGetAppsListAndDownload(t *testing.T) {
+	ctx := context.TODO()
+	app1Content := "app1 package content"
+	app2Content := "app2 package content"
+	app1Digest := getTestDigest(app1Content)
+	app2Digest := getTestDigest(app2Content)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// token service
+		if r.URL.Path == "/token" {
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "user" || password != "pass" || r.URL.Query().Get("scope") != "repository:splunk/apps:pull" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token": "token1"}`)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer token1" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:splunk/apps:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/splunk/apps/tags/list":
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/splunk/apps/tags/list?last=app1>; rel="next"`)
+				fmt.Fprint(w, `{"name": "splunk/apps", "tags": ["app1"]}`)
+			} else {
+				fmt.Fprint(w, `{"name": "splunk/apps", "tags": ["app2"]}`)
+			}
+		case "/v2/splunk/apps/manifests/app1":
+			fmt.Fprintf(w, `{"layers": [{"digest": "sha256:%s", "size": %d, "annotations": {"org.opencontainers.image.title": "security_app.tgz"}}],
+				"annotations": {"org.opencontainers.image.created": "2022-05-01T10:00:00Z"}}`, app1Digest, len(app1Content))
+		case "/v2/splunk/apps/manifests/app2":
+			fmt.Fprintf(w, `{"layers": [{"digest": "sha256:%s", "size": %d}]}`, app2Digest, len(app2Content))
+		case "/v2/splunk/apps/blobs/sha256:" + app1Digest:
+			fmt.Fprint(w, app1Content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s3Client, err := NewOCIClient(ctx, "splunk", "user", "pass", "apps/", "apps/", "", server.URL, InitHTTPClientWrapper)
+	if err != nil {
+		t.Fatalf("unable to create the OCI client, error: %v", err)
+	}
+
+	resp, err := s3Client.GetAppsList(ctx)
+	if err != nil {
+		t.Fatalf("GetAppsList should not return an error, error: %v", err)
+	}
+
+	if len(resp.Objects) != 2 {
+		t.Fatalf("expected two apps, got: %d", len(resp.Objects))
+	}
+
+	if *resp.Objects[0].Key != "apps/security_app.tgz" || *resp.Objects[0].Etag != app1Digest || resp.Objects[0].LastModified == nil {
+		t.Errorf("unexpected remote object, key: %s, etag: %s", *resp.Objects[0].Key, *resp.Objects[0].Etag)
+	}
+
+	// app name defaults to the tag
+	if *resp.Objects[1].Key != "apps/app2.tgz" || *resp.Objects[1].Size != int64(len(app2Content)) {
+		t.Errorf("unexpected remote object, key: %s, size: %d", *resp.Objects[1].Key, *resp.Objects[1].Size)
+	}
+
+	dir, err := ioutil.TempDir("", "ociclient")
+	if err != nil {
+		t.Fatalf("unable to create temp dir, error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	localFile := filepath.Join(dir, "security_app.tgz")
+	ok, err := s3Client.DownloadApp(ctx, *resp.Objects[0].Key, localFile, *resp.Objects[0].Etag)
+	if !ok || err != nil {
+		t.Errorf("DownloadApp should not return an error, error: %v", err)
+	}
+
+	data, _ := ioutil.ReadFile(localFile)
+	if string(data) != app1Content {
+		t.Errorf("unexpected downloaded content: %s", string(data))
+	}
+
+	// missing blob should return an error
+	ok, err = s3Client.DownloadApp(ctx, *resp.Objects[1].Key, localFile, *resp.Objects[1].Etag)
+	if ok || err == nil {
+		t.Errorf("DownloadApp should return an error for the missing blob")
+	}
+
+	// invalid credentials should fail to get the token
+	s3Client, _ = NewOCIClient(ctx, "splunk", "user", "invalid", "apps/", "apps/", "", server.URL, InitHTTPClientWrapper)
+	_, err = s3Client.GetAppsList(ctx)
+	if err == nil {
+		t.Errorf("GetAppsList should return an error for the invalid credentials")
+	}
+}
@@ -96,6 +96,10 @@ func RegisterS3Client(ctx context.Context, provider string) {
 		RegisterAWSS3Client()
 	case "minio":
 		RegisterMinioClient()
+	case "http":
+		RegisterHTTPClient()
+	case "oci":
+		RegisterOCIClient()
 	default:
 		scopedLog.Error(nil, "invalid provider specified", "provider", provider)
 	}
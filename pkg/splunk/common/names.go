@@ -60,6 +60,9 @@ const (
 	// DefaultVarVolumeStorageCapacity represents default storage capacity for var volume
 	DefaultVarVolumeStorageCapacity = "100Gi"
 
+	// OperatorFieldManager is the field manager recorded in the managedFields of the objects changed by the operator
+	OperatorFieldManager = "splunk-operator"
+
 	// SortFieldContainerPort represents field name ContainerPort for sorting
 	SortFieldContainerPort = "ContainerPort"
 
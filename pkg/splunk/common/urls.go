@@ -36,6 +36,9 @@ const (
 
 	//URIClusterManagerGetSearchHeads = "/services/cluster/master/searchheads"
 	URIClusterManagerGetSearchHeads = URICLusterManagerServices + "/searchheads"
+
+	//URIClusterManagerRebalanceBuckets = "/services/cluster/master/control/control/rebalance_buckets"
+	URIClusterManagerRebalanceBuckets = URICLusterManagerServices + "/control/control/rebalance_buckets"
 )
 
 // List of URLs - Cluster Manager
@@ -102,9 +105,50 @@ const (
 	LicenseManagerDMCGroup = "dmc_group_license_master"
 )
 
+// List of URIs - Licenser
+const (
+
+	//URILicenserServices = "/services/licenser"
+	URILicenserServices = "/services/licenser"
+
+	//URILicenserGetLicenses = "/services/licenser/licenses"
+	URILicenserGetLicenses = URILicenserServices + "/licenses"
+
+	//URILicenserGetPools = "/services/licenser/pools"
+	URILicenserGetPools = URILicenserServices + "/pools"
+
+	//URILicenserGetUsage = "/services/licenser/usage"
+	URILicenserGetUsage = URILicenserServices + "/usage"
+
+	//URILicenserGetMessages = "/services/licenser/messages"
+	URILicenserGetMessages = URILicenserServices + "/messages"
+)
+
+// List of URIs - Search
+const (
+
+	//URISearchJobs = "/services/search/jobs"
+	URISearchJobs = "/services/search/jobs"
+
+	//URISearchConcurrencyLimits = "/services/server/status/limits/search-concurrency"
+	URISearchConcurrencyLimits = "/services/server/status/limits/search-concurrency"
+)
+
 // List of URLs - License Manager/Peer
 const (
 
+	//LocalURLLicenserGetLicenses = "https://localhost:8089/services/licenser/licenses?count=0&output_mode=json"
+	LocalURLLicenserGetLicenses = "https://localhost:8089" + URILicenserGetLicenses + "?count=0&output_mode=json"
+
+	//LocalURLLicenserGetPools = "https://localhost:8089/services/licenser/pools?count=0&output_mode=json"
+	LocalURLLicenserGetPools = "https://localhost:8089" + URILicenserGetPools + "?count=0&output_mode=json"
+
+	//LocalURLLicenserGetUsage = "https://localhost:8089/services/licenser/usage?count=0&output_mode=json"
+	LocalURLLicenserGetUsage = "https://localhost:8089" + URILicenserGetUsage + "?count=0&output_mode=json"
+
+	//LocalURLLicenserGetMessages = "https://localhost:8089/services/licenser/messages?count=0&output_mode=json"
+	LocalURLLicenserGetMessages = "https://localhost:8089" + URILicenserGetMessages + "?count=0&output_mode=json"
+
 	//LocalURLLicensePeerJSONOutput = "https://localhost:8089/services/licenser/localslave?output_mode=json"
 	LocalURLLicensePeerJSONOutput = "https://localhost:8089/services/licenser/localslave?output_mode=json"
 
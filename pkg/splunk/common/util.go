@@ -17,7 +17,6 @@ package common
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
@@ -165,26 +164,6 @@ func CompareVolumeMounts(a []corev1.VolumeMount, b []corev1.VolumeMount) bool {
 	return sortAndCompareSlices(a, b, SortFieldName)
 }
 
-// CompareByMarshall compares two Kubernetes objects by marshalling them to JSON.
-// It returns true if there are differences between the two marshalled values, or false otherwise.
-func CompareByMarshall(a interface{}, b interface{}) bool {
-	aBytes, err := json.Marshal(a)
-	if err != nil {
-		return true
-	}
-
-	bBytes, err := json.Marshal(b)
-	if err != nil {
-		return true
-	}
-
-	if bytes.Compare(aBytes, bBytes) != 0 {
-		return true
-	}
-
-	return false
-}
-
 // CompareSortedStrings returns true if there are differences between the two sorted lists of strings, or false otherwise.
 func CompareSortedStrings(a []string, b []string) bool {
 	if len(a) != len(b) {
@@ -222,6 +201,10 @@ func GetIstioAnnotations(ports []corev1.ContainerPort) map[string]string {
 	sortedPorts := SortContainerPorts(ports)
 	for idx := range sortedPorts {
 		_, skip := excludeOutboundPortsLookup[sortedPorts[idx].ContainerPort]
+		// istio only intercepts TCP traffic
+		if sortedPorts[idx].Protocol != "" && sortedPorts[idx].Protocol != corev1.ProtocolTCP {
+			skip = true
+		}
 		if !skip {
 			if includeInboundPortsBuf.Len() > 0 {
 				fmt.Fprint(includeInboundPortsBuf, ",")
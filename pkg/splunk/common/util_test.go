@@ -25,7 +25,6 @@ import (
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -465,41 +464,6 @@ func TestCompareVolumeMounts(t *testing.T) {
 	test(true)
 }
 
-func TestCompareByMarshall(t *testing.T) {
-	var a corev1.ResourceRequirements
-	var b corev1.ResourceRequirements
-
-	test := func(want bool) {
-		f := func() bool {
-			return CompareByMarshall(a, b)
-		}
-		compareTester(t, "CompareByMarshall", f, a, b, want)
-	}
-
-	test(false)
-
-	low := corev1.ResourceList{
-		corev1.ResourceCPU:    resource.MustParse("0.1"),
-		corev1.ResourceMemory: resource.MustParse("512Mi"),
-	}
-	medium := corev1.ResourceList{
-		corev1.ResourceCPU:    resource.MustParse("8"),
-		corev1.ResourceMemory: resource.MustParse("4Gi"),
-	}
-	high := corev1.ResourceList{
-		corev1.ResourceCPU:    resource.MustParse("32"),
-		corev1.ResourceMemory: resource.MustParse("32Gi"),
-	}
-
-	a = corev1.ResourceRequirements{Requests: low, Limits: high}
-	b = corev1.ResourceRequirements{Requests: low, Limits: high}
-	test(false)
-
-	a = corev1.ResourceRequirements{Requests: medium, Limits: high}
-	b = corev1.ResourceRequirements{Requests: low, Limits: high}
-	test(true)
-}
-
 func TestCompareSortedStrings(t *testing.T) {
 	var a []string
 	var b []string
@@ -562,6 +526,16 @@ func TestGetIstioAnnotations(t *testing.T) {
 		"traffic.sidecar.istio.io/includeInboundPorts":  "",
 	}
 	test()
+
+	// UDP ports are not intercepted
+	ports = []corev1.ContainerPort{
+		{ContainerPort: 8000, Protocol: corev1.ProtocolTCP}, {ContainerPort: 514, Protocol: corev1.ProtocolUDP},
+	}
+	want = map[string]string{
+		"traffic.sidecar.istio.io/excludeOutboundPorts": "8089,8191,9997",
+		"traffic.sidecar.istio.io/includeInboundPorts":  "8000",
+	}
+	test()
 }
 
 func TestGetLabels(t *testing.T) {
@@ -637,7 +611,7 @@ func TestAppendPodAffinity(t *testing.T) {
 	test := func(want corev1.Affinity) {
 		got := AppendPodAntiAffinity(&affinity, identifier, typeLabel)
 		f := func() bool {
-			return CompareByMarshall(got, want)
+			return !reflect.DeepEqual(*got, want)
 		}
 		compareTester(t, "AppendPodAntiAffinity()", f, got, want, false)
 	}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+)
+
+// Config configures the concurrency of the reconciles of all the controllers, and of the heavy operations they run
+type Config struct {
+	// MaxConcurrentReconciles is the number of workers of each controller
+	MaxConcurrentReconciles int
+
+	// FairQueueLimit is the number of reconciles running at once across all the controllers, shared fairly between
+	// the namespaces. The fair queue is disabled when 0
+	FairQueueLimit int
+
+	// RateLimitQPS and RateLimitBurst limit the rate of the requeues of each controller
+	RateLimitQPS   float64
+	RateLimitBurst int
+
+	// RetryBaseDelay and RetryMaxDelay bound the exponential delay of the retries of a failing reconcile
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+
+	// MaxHeavyOperations is the number of pod execs, bundle pushes and app downloads running at once across all the
+	// controllers. They are not limited when 0
+	MaxHeavyOperations int
+}
+
+// DefaultConfig returns the configuration of the controller-runtime defaults, with neither fair queue nor heavy
+// operations limit
+func DefaultConfig() Config {
+	return Config{
+		MaxConcurrentReconciles: enterpriseApi.TotalWorker,
+		RateLimitQPS:            10,
+		RateLimitBurst:          100,
+		RetryBaseDelay:          5 * time.Millisecond,
+		RetryMaxDelay:           1000 * time.Second,
+	}
+}
+
+// config is the configuration set up for the controllers
+var config = DefaultConfig()
+
+// fairQueue is the fair queue shared by all the controllers. It is nil when disabled
+var fairQueue *FairQueue
+
+// Setup validates and sets up config for the controllers set up afterwards
+func Setup(c Config) error {
+	switch {
+	case c.MaxConcurrentReconciles < 1:
+		return fmt.Errorf("invalid max concurrent reconciles %d, it must be at least 1", c.MaxConcurrentReconciles)
+	case c.FairQueueLimit < 0:
+		return fmt.Errorf("invalid fair queue limit %d, it must be positive or 0", c.FairQueueLimit)
+	case c.RateLimitQPS <= 0 || c.RateLimitBurst < 1:
+		return fmt.Errorf("invalid reconcile rate limit of %v requeues per second with a burst of %d, both must be positive", c.RateLimitQPS, c.RateLimitBurst)
+	case c.RetryBaseDelay <= 0 || c.RetryMaxDelay < c.RetryBaseDelay:
+		return fmt.Errorf("invalid retry delays %v and %v, the base delay must be positive and less than the max delay", c.RetryBaseDelay, c.RetryMaxDelay)
+	case c.MaxHeavyOperations < 0:
+		return fmt.Errorf("invalid max heavy operations %d, it must be positive or 0", c.MaxHeavyOperations)
+	}
+
+	config = c
+	fairQueue = nil
+	if c.FairQueueLimit > 0 {
+		fairQueue = NewFairQueue(c.FairQueueLimit)
+	}
+	setHeavyOperationLimit(c.MaxHeavyOperations)
+	return nil
+}
+
+// ControllerOptions returns the options of a controller, with its own rate limiter: the slower of the exponential
+// retry delay of each request and the overall rate limit of the controller
+func ControllerOptions() controller.Options {
+	return controller.Options{
+		MaxConcurrentReconciles: config.MaxConcurrentReconciles,
+		RateLimiter: workqueue.NewMaxOfRateLimiter(
+			workqueue.NewItemExponentialFailureRateLimiter(config.RetryBaseDelay, config.RetryMaxDelay),
+			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(config.RateLimitQPS), config.RateLimitBurst)},
+		),
+	}
+}
+
+// Reconciler returns r running through the fair queue shared by all the controllers, or r when it is disabled. kind
+// is the kind of the custom resources reconciled by r
+func Reconciler(kind string, r reconcile.Reconciler) reconcile.Reconciler {
+	if fairQueue == nil {
+		return r
+	}
+	return &fairReconciler{queue: fairQueue, kind: kind, reconciler: r}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetup(t *testing.T) {
+	defer func() {
+		if err := Setup(DefaultConfig()); err != nil {
+			t.Errorf("Setup() returned error for the default config: %v", err)
+		}
+	}()
+
+	invalid := map[string]func(*Config){
+		"no workers":          func(c *Config) { c.MaxConcurrentReconciles = 0 },
+		"negative fair queue": func(c *Config) { c.FairQueueLimit = -1 },
+		"no qps":              func(c *Config) { c.RateLimitQPS = 0 },
+		"no burst":            func(c *Config) { c.RateLimitBurst = 0 },
+		"inverted delays":     func(c *Config) { c.RetryMaxDelay = time.Millisecond },
+		"negative heavy ops":  func(c *Config) { c.MaxHeavyOperations = -1 },
+	}
+	for name, update := range invalid {
+		c := DefaultConfig()
+		update(&c)
+		if err := Setup(c); err == nil {
+			t.Errorf("Setup() didn't return an error for %s", name)
+		}
+	}
+
+	c := DefaultConfig()
+	c.MaxConcurrentReconciles = 3
+	c.FairQueueLimit = 5
+	c.MaxHeavyOperations = 2
+	if err := Setup(c); err != nil {
+		t.Fatalf("Setup() returned error: %v", err)
+	}
+	options := ControllerOptions()
+	if options.MaxConcurrentReconciles != 3 || options.RateLimiter == nil {
+		t.Errorf("ControllerOptions() = %v; want 3 workers and a rate limiter", options)
+	}
+	if _, ok := Reconciler("Standalone", &testReconciler{}).(*fairReconciler); !ok {
+		t.Errorf("Reconciler() didn't run the reconciler through the fair queue")
+	}
+	if cap(heavyOperationSlots) != 2 {
+		t.Errorf("Setup() set %d heavy operation slots; want 2", cap(heavyOperationSlots))
+	}
+
+	if err := Setup(DefaultConfig()); err != nil {
+		t.Fatalf("Setup() returned error for the default config: %v", err)
+	}
+	if _, ok := Reconciler("Standalone", &testReconciler{}).(*testReconciler); !ok {
+		t.Errorf("Reconciler() wrapped the reconciler while the fair queue is disabled")
+	}
+	if heavyOperationSlots != nil {
+		t.Errorf("Setup() limited the heavy operations by default")
+	}
+}
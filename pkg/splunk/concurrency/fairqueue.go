@@ -0,0 +1,206 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// fairQueueRetryDelay is the delay after which a reconcile deferred by the fair queue is retried
+	fairQueueRetryDelay = 2 * time.Second
+)
+
+// FairQueue limits the number of reconciles running at once across all the controllers, and shares them fairly
+// between the namespaces: the waiting reconciles are started in turn, one namespace after the other, and a namespace
+// can't hold more than its share of the limit while the queue is full
+type FairQueue struct {
+	mu sync.Mutex
+
+	// limit is the number of reconciles running at once
+	limit int
+
+	// running is the number of reconciles running
+	running int
+
+	// runningByNamespace is the number of reconciles running in each namespace
+	runningByNamespace map[string]int
+
+	// waiting is the FIFO of the waiting reconciles of each namespace
+	waiting map[string][]chan struct{}
+
+	// turns is the round-robin order of the namespaces with waiting reconciles
+	turns []string
+}
+
+// NewFairQueue returns a FairQueue running limit reconciles at once
+func NewFairQueue(limit int) *FairQueue {
+	return &FairQueue{
+		limit:              limit,
+		runningByNamespace: make(map[string]int),
+		waiting:            make(map[string][]chan struct{}),
+	}
+}
+
+// Acquire waits for the turn of a reconcile of namespace, and returns the function releasing it once done. ok is
+// false when the namespace already holds its share of the queue, in which case the reconcile should be retried later
+func (q *FairQueue) Acquire(ctx context.Context, namespace string) (release func(), ok bool, err error) {
+	release = func() { q.release(namespace) }
+
+	q.mu.Lock()
+	if q.running < q.limit {
+		q.running++
+		q.runningByNamespace[namespace]++
+		q.mu.Unlock()
+		return release, true, nil
+	}
+	if q.runningByNamespace[namespace]+len(q.waiting[namespace]) >= q.share(namespace) {
+		q.mu.Unlock()
+		reconcileDeferredCounter.WithLabelValues(namespace).Inc()
+		return nil, false, nil
+	}
+
+	turn := make(chan struct{})
+	if len(q.waiting[namespace]) == 0 {
+		q.turns = append(q.turns, namespace)
+	}
+	q.waiting[namespace] = append(q.waiting[namespace], turn)
+	reconcileQueueDepthGauge.WithLabelValues(namespace).Inc()
+	q.mu.Unlock()
+
+	select {
+	case <-turn:
+		return release, true, nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case <-turn:
+		// the turn came while the context was done, it is handed over to the next reconcile
+		q.releaseLocked(namespace)
+	default:
+		q.removeWaiting(namespace, turn)
+	}
+	return nil, false, ctx.Err()
+}
+
+// share returns the number of reconciles a namespace can hold while the queue is full: the limit divided by the
+// number of namespaces running or waiting for reconciles, and at least one
+func (q *FairQueue) share(namespace string) int {
+	namespaces := map[string]bool{namespace: true}
+	for ns, running := range q.runningByNamespace {
+		if running > 0 {
+			namespaces[ns] = true
+		}
+	}
+	for _, ns := range q.turns {
+		namespaces[ns] = true
+	}
+	share := (q.limit + len(namespaces) - 1) / len(namespaces)
+	if share < 1 {
+		return 1
+	}
+	return share
+}
+
+// release ends a reconcile of namespace, and starts the next waiting reconcile
+func (q *FairQueue) release(namespace string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.releaseLocked(namespace)
+}
+
+// releaseLocked ends a reconcile of namespace with the lock of the queue held
+func (q *FairQueue) releaseLocked(namespace string) {
+	q.running--
+	q.runningByNamespace[namespace]--
+	if q.runningByNamespace[namespace] == 0 {
+		delete(q.runningByNamespace, namespace)
+	}
+	q.dispatch()
+}
+
+// dispatch starts the waiting reconciles while the limit isn't reached, taking the namespaces in turn
+func (q *FairQueue) dispatch() {
+	for q.running < q.limit && len(q.turns) > 0 {
+		namespace := q.turns[0]
+		q.turns = q.turns[1:]
+		turn := q.waiting[namespace][0]
+		q.waiting[namespace] = q.waiting[namespace][1:]
+		if len(q.waiting[namespace]) > 0 {
+			q.turns = append(q.turns, namespace)
+		} else {
+			delete(q.waiting, namespace)
+		}
+		reconcileQueueDepthGauge.WithLabelValues(namespace).Dec()
+
+		q.running++
+		q.runningByNamespace[namespace]++
+		close(turn)
+	}
+}
+
+// removeWaiting removes a waiting reconcile of namespace whose context is done
+func (q *FairQueue) removeWaiting(namespace string, turn chan struct{}) {
+	waiting := q.waiting[namespace]
+	for i := range waiting {
+		if waiting[i] == turn {
+			waiting = append(waiting[:i], waiting[i+1:]...)
+			break
+		}
+	}
+	reconcileQueueDepthGauge.WithLabelValues(namespace).Dec()
+	if len(waiting) > 0 {
+		q.waiting[namespace] = waiting
+		return
+	}
+	delete(q.waiting, namespace)
+	for i := range q.turns {
+		if q.turns[i] == namespace {
+			q.turns = append(q.turns[:i], q.turns[i+1:]...)
+			break
+		}
+	}
+}
+
+// fairReconciler runs the reconciles of a controller through a fair queue
+type fairReconciler struct {
+	queue      *FairQueue
+	kind       string
+	reconciler reconcile.Reconciler
+}
+
+// Reconcile waits for the turn of the request in the fair queue before reconciling it. The request is requeued when
+// its namespace already holds its share of the queue, which frees the worker for the other namespaces
+func (r *fairReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	start := time.Now()
+	release, ok, err := r.queue.Acquire(ctx, request.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !ok {
+		return reconcile.Result{RequeueAfter: fairQueueRetryDelay}, nil
+	}
+	defer release()
+	reconcileQueueWaitHistogram.WithLabelValues(r.kind).Observe(time.Since(start).Seconds())
+
+	return r.reconciler.Reconcile(ctx, request)
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// waitForWaiting waits until namespace has count reconciles waiting in q
+func waitForWaiting(t *testing.T, q *FairQueue, namespace string, count int) {
+	for i := 0; i < 100; i++ {
+		q.mu.Lock()
+		waiting := len(q.waiting[namespace])
+		q.mu.Unlock()
+		if waiting == count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%s doesn't have %d reconciles waiting", namespace, count)
+}
+
+func TestFairQueueAcquire(t *testing.T) {
+	ctx := context.TODO()
+	q := NewFairQueue(2)
+
+	// the limit isn't reached
+	release1, ok, err := q.Acquire(ctx, "ns1")
+	if err != nil || !ok {
+		t.Errorf("Acquire() returned ok=%t, err=%v; want the reconcile to run", ok, err)
+	}
+	_, ok, err = q.Acquire(ctx, "ns1")
+	if err != nil || !ok {
+		t.Errorf("Acquire() returned ok=%t, err=%v; want the reconcile to run", ok, err)
+	}
+
+	// ns1 holds the whole queue, while its share is 1 of 2 with ns2 waiting
+	started := make(chan string, 3)
+	acquire := func(namespace string) {
+		release, ok, _ := q.Acquire(ctx, namespace)
+		if ok {
+			started <- namespace
+			release()
+		}
+	}
+	go acquire("ns2")
+	waitForWaiting(t, q, "ns2", 1)
+	if _, ok, _ := q.Acquire(ctx, "ns1"); ok {
+		t.Errorf("Acquire() didn't defer the reconcile of ns1, which holds more than its share")
+	}
+
+	// ns2 runs when ns1 releases a reconcile
+	release1()
+	select {
+	case namespace := <-started:
+		if namespace != "ns2" {
+			t.Errorf("Acquire() started %s; want ns2", namespace)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Acquire() didn't start the reconcile of ns2")
+	}
+}
+
+func TestFairQueueTurns(t *testing.T) {
+	ctx := context.TODO()
+	q := NewFairQueue(4)
+
+	releases := []func(){}
+	for i := 0; i < 4; i++ {
+		release, _, _ := q.Acquire(ctx, "ns1")
+		releases = append(releases, release)
+	}
+
+	// the namespaces are started in turn, whatever the order they queued in
+	started := make(chan string, 4)
+	acquire := func(namespace string) {
+		release, ok, _ := q.Acquire(ctx, namespace)
+		if ok {
+			started <- namespace
+			defer release()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	go acquire("ns2")
+	waitForWaiting(t, q, "ns2", 1)
+	go acquire("ns2")
+	waitForWaiting(t, q, "ns2", 2)
+	go acquire("ns3")
+	waitForWaiting(t, q, "ns3", 1)
+
+	releases[0]()
+	releases[1]()
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case namespace := <-started:
+			got[namespace] = true
+		case <-time.After(time.Second):
+			t.Errorf("Acquire() didn't start the reconciles of ns2 and ns3")
+		}
+	}
+	if !got["ns2"] || !got["ns3"] {
+		t.Errorf("Acquire() started %v; want ns2 and ns3", got)
+	}
+	releases[2]()
+	releases[3]()
+	select {
+	case namespace := <-started:
+		if namespace != "ns2" {
+			t.Errorf("Acquire() started %s; want ns2", namespace)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Acquire() didn't start the second reconcile of ns2")
+	}
+}
+
+func TestFairQueueContextDone(t *testing.T) {
+	q := NewFairQueue(1)
+	release, _, _ := q.Acquire(context.TODO(), "ns1")
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	_, ok, err := q.Acquire(ctx, "ns2")
+	if ok || err == nil {
+		t.Errorf("Acquire() returned ok=%t, err=%v; want the error of the context", ok, err)
+	}
+	if len(q.waiting) != 0 || len(q.turns) != 0 {
+		t.Errorf("Acquire() left the reconcile waiting: %v", q.waiting)
+	}
+
+	release()
+	if q.running != 0 || len(q.runningByNamespace) != 0 {
+		t.Errorf("release() left %d reconciles running", q.running)
+	}
+}
+
+type testReconciler struct {
+	requests []reconcile.Request
+}
+
+func (r *testReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	r.requests = append(r.requests, request)
+	return reconcile.Result{}, nil
+}
+
+func TestFairReconciler(t *testing.T) {
+	ctx := context.TODO()
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns1", Name: "stack1"}}
+	q := NewFairQueue(1)
+	r := &testReconciler{}
+	fair := &fairReconciler{queue: q, kind: "Standalone", reconciler: r}
+
+	result, err := fair.Reconcile(ctx, request)
+	if err != nil || result.RequeueAfter != 0 || len(r.requests) != 1 {
+		t.Errorf("Reconcile() returned %v, %v after %d reconciles; want the request to be reconciled", result, err, len(r.requests))
+	}
+
+	// the namespace already holds the whole queue
+	release, _, _ := q.Acquire(ctx, "ns1")
+	defer release()
+	result, err = fair.Reconcile(ctx, request)
+	if err != nil || result.RequeueAfter != fairQueueRetryDelay || len(r.requests) != 1 {
+		t.Errorf("Reconcile() returned %v, %v after %d reconciles; want the request to be requeued", result, err, len(r.requests))
+	}
+}
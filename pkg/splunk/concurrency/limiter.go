@@ -0,0 +1,75 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"time"
+)
+
+// Heavy operations, whose number running at once is capped across all the controllers
+const (
+	// OperationPodExec is a command run on a Splunk pod
+	OperationPodExec = "pod_exec"
+
+	// OperationBundlePush is a bundle push of a cluster manager or deployer
+	OperationBundlePush = "bundle_push"
+
+	// OperationAppDownload is a download of an app package from the remote storage
+	OperationAppDownload = "app_download"
+)
+
+// heavyOperationKey is the context key marking the contexts which already hold a heavy operation slot
+type heavyOperationKey struct{}
+
+// heavyOperationSlots holds a token for each heavy operation running. It is nil when they are not limited
+var heavyOperationSlots chan struct{}
+
+// setHeavyOperationLimit caps the number of heavy operations running at once, 0 removing the cap
+func setHeavyOperationLimit(limit int) {
+	if limit <= 0 {
+		heavyOperationSlots = nil
+		return
+	}
+	heavyOperationSlots = make(chan struct{}, limit)
+}
+
+// AcquireHeavyOperation waits for a heavy operation slot, and returns the context to run the operation with and the
+// function releasing the slot. The operations run with a context which already holds a slot, like the pod exec of a
+// bundle push, don't take another slot
+func AcquireHeavyOperation(ctx context.Context, operation string) (context.Context, func(), error) {
+	slots := heavyOperationSlots
+	if slots == nil || ctx.Value(heavyOperationKey{}) != nil {
+		return ctx, func() {}, nil
+	}
+
+	start := time.Now()
+	heavyOperationWaitingGauge.WithLabelValues(operation).Inc()
+	select {
+	case slots <- struct{}{}:
+		heavyOperationWaitingGauge.WithLabelValues(operation).Dec()
+	case <-ctx.Done():
+		heavyOperationWaitingGauge.WithLabelValues(operation).Dec()
+		return ctx, nil, ctx.Err()
+	}
+	heavyOperationWaitHistogram.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	heavyOperationRunningGauge.WithLabelValues(operation).Inc()
+
+	release := func() {
+		heavyOperationRunningGauge.WithLabelValues(operation).Dec()
+		<-slots
+	}
+	return context.WithValue(ctx, heavyOperationKey{}, operation), release, nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAcquireHeavyOperation(t *testing.T) {
+	setHeavyOperationLimit(1)
+	defer setHeavyOperationLimit(0)
+
+	ctx, release, err := AcquireHeavyOperation(context.TODO(), OperationBundlePush)
+	if err != nil {
+		t.Fatalf("AcquireHeavyOperation() returned error: %v", err)
+	}
+
+	// the pod exec of the bundle push doesn't take another slot
+	_, releaseNested, err := AcquireHeavyOperation(ctx, OperationPodExec)
+	if err != nil {
+		t.Errorf("AcquireHeavyOperation() returned error for a nested operation: %v", err)
+	}
+	releaseNested()
+
+	// the other operations wait for the slot
+	timeoutCtx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	_, _, err = AcquireHeavyOperation(timeoutCtx, OperationAppDownload)
+	if err == nil {
+		t.Errorf("AcquireHeavyOperation() didn't wait for the slot held by the bundle push")
+	}
+
+	release()
+	_, release, err = AcquireHeavyOperation(context.TODO(), OperationAppDownload)
+	if err != nil {
+		t.Errorf("AcquireHeavyOperation() returned error after the slot was released: %v", err)
+	}
+	release()
+}
+
+func TestAcquireHeavyOperationUnlimited(t *testing.T) {
+	setHeavyOperationLimit(0)
+	for i := 0; i < 3; i++ {
+		ctx, _, err := AcquireHeavyOperation(context.TODO(), OperationPodExec)
+		if err != nil {
+			t.Errorf("AcquireHeavyOperation() returned error: %v", err)
+		}
+		if ctx.Value(heavyOperationKey{}) != nil {
+			t.Errorf("AcquireHeavyOperation() marked the context while the operations are not limited")
+		}
+	}
+}
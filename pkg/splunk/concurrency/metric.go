@@ -0,0 +1,66 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// waitBuckets are the buckets of the wait time histograms, in seconds
+var waitBuckets = []float64{0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+
+var reconcileQueueDepthGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "splunk_operator_reconcile_queue_depth",
+	Help: "The number of reconciles waiting in the fair queue, by namespace",
+}, []string{"namespace"})
+
+var reconcileQueueWaitHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "splunk_operator_reconcile_queue_wait_seconds",
+	Help:    "The time reconciles wait in the fair queue before running, by kind",
+	Buckets: waitBuckets,
+}, []string{"kind"})
+
+var reconcileDeferredCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "splunk_operator_reconcile_deferred_total",
+	Help: "The number of reconciles requeued because their namespace held its share of the fair queue, by namespace",
+}, []string{"namespace"})
+
+var heavyOperationWaitingGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "splunk_operator_heavy_operations_waiting",
+	Help: "The number of heavy operations waiting for a slot, by operation",
+}, []string{"operation"})
+
+var heavyOperationRunningGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "splunk_operator_heavy_operations_running",
+	Help: "The number of heavy operations holding a slot, by operation",
+}, []string{"operation"})
+
+var heavyOperationWaitHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "splunk_operator_heavy_operation_wait_seconds",
+	Help:    "The time heavy operations wait for a slot, by operation",
+	Buckets: waitBuckets,
+}, []string{"operation"})
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileQueueDepthGauge,
+		reconcileQueueWaitHistogram,
+		reconcileDeferredCounter,
+		heavyOperationWaitingGauge,
+		heavyOperationRunningGauge,
+		heavyOperationWaitHistogram,
+	)
+}
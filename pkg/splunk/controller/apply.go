@@ -0,0 +1,237 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// conflictManagerRegexp extracts the field manager from the causes of an apply conflict, such as
+// conflict with "kubectl-edit" using apps/v1
+var conflictManagerRegexp = regexp.MustCompile(`conflict with "([^"]*)"`)
+
+// applyCheck lists the fields compared to tell whether an object must be applied again
+type applyCheck struct {
+	gvk schema.GroupVersionKind
+
+	// paths are the fields of the objects compared with the applied configuration
+	paths []fieldPath
+}
+
+var (
+	metadataApplyPaths = []fieldPath{{"metadata", "labels"}, {"metadata", "annotations"}, {"metadata", "ownerReferences"}}
+
+	statefulSetApplyCheck = applyCheck{
+		gvk:   schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+		paths: append([]fieldPath{{"spec"}}, metadataApplyPaths...),
+	}
+	deploymentApplyCheck = applyCheck{
+		gvk:   schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		paths: append([]fieldPath{{"spec"}}, metadataApplyPaths...),
+	}
+	serviceApplyCheck = applyCheck{
+		gvk:   schema.GroupVersionKind{Version: "v1", Kind: "Service"},
+		paths: append([]fieldPath{{"spec"}}, metadataApplyPaths...),
+	}
+	configMapApplyCheck = applyCheck{
+		gvk:   schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		paths: append([]fieldPath{{"data"}}, metadataApplyPaths...),
+	}
+	serviceAccountApplyCheck = applyCheck{
+		gvk:   schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"},
+		paths: append([]fieldPath{{"imagePullSecrets"}}, metadataApplyPaths...),
+	}
+	ingressApplyCheck = applyCheck{
+		gvk:   schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+		paths: append([]fieldPath{{"spec"}}, metadataApplyPaths...),
+	}
+	networkPolicyApplyCheck = applyCheck{
+		gvk:   schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"},
+		paths: append([]fieldPath{{"spec"}}, metadataApplyPaths...),
+	}
+	secretApplyCheck = applyCheck{
+		gvk:   schema.GroupVersionKind{Version: "v1", Kind: "Secret"},
+		paths: append([]fieldPath{{"data"}, {"type"}}, metadataApplyPaths...),
+	}
+)
+
+// needsApply tells whether desired must be applied to current: some of its fields differ in current, or some fields
+// applied before by the operator were removed from desired
+func needsApply(ctx context.Context, check applyCheck, current client.Object, desired client.Object) bool {
+	scopedLog := log.FromContext(ctx).WithName("needsApply").WithValues("kind", check.gvk.Kind, "name", current.GetName())
+
+	currentContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		scopedLog.Error(err, "Unable to convert the current object")
+		return true
+	}
+	desiredContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		scopedLog.Error(err, "Unable to convert the desired object")
+		return true
+	}
+	appliedFields, err := operatorAppliedFields(current.GetManagedFields())
+	if err != nil {
+		scopedLog.Error(err, "Unable to decode the managed fields")
+		return true
+	}
+
+	var paths []fieldPath
+	for _, path := range check.paths {
+		desiredValue, _ := getField(desiredContent, path)
+		currentValue, _ := getField(currentContent, path)
+		diffFields(desiredValue, currentValue, path, nil, &paths)
+		if fields := getAppliedFields(appliedFields, path); fields != nil && hasRemovedFields(fields, desiredValue) {
+			scopedLog.Info("Fields applied before were removed", "path", path.String())
+			return true
+		}
+	}
+	return len(paths) > 0
+}
+
+// applyObject creates or updates obj with server-side apply, under the field manager of the operator, and updates obj
+// with the applied object. Only the fields set in obj are owned by the operator, so the fields set by other controllers,
+// like the sidecars of service mesh injectors, are kept. The fields which conflict with other field managers are taken
+// over, unless the drift policy of the custom resource is ReportOnly
+func applyObject(ctx context.Context, c splcommon.ControllerClient, check applyCheck, obj client.Object) error {
+	scopedLog := log.FromContext(ctx).WithName("applyObject").WithValues(
+		"kind", check.gvk.Kind,
+		"name", obj.GetName(),
+		"namespace", obj.GetNamespace())
+
+	// the applied configuration only has the kind and the fields of the operator. The kind of typed objects is left
+	// empty after the apply, like the other calls of the client
+	defer obj.GetObjectKind().SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	obj.GetObjectKind().SetGroupVersionKind(check.gvk)
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+
+	err := splutil.ApplyResource(ctx, c, obj, false)
+	if k8serrors.IsConflict(err) {
+		managers := conflictManagers(err)
+		recorder := getDriftRecorder(ctx)
+		if recorder != nil && !recorder.Enforce() && hasOtherFieldManager(managers) {
+			return err
+		}
+		scopedLog.Info("Taking over the conflicting fields", "managers", managers)
+		err = splutil.ApplyResource(ctx, c, obj, true)
+	}
+	return err
+}
+
+// operatorAppliedFields returns the field set applied by the operator, or nil when the operator didn't apply the object
+func operatorAppliedFields(managedFields []metav1.ManagedFieldsEntry) (map[string]interface{}, error) {
+	for _, entry := range managedFields {
+		if entry.Manager != splcommon.OperatorFieldManager || entry.Operation != metav1.ManagedFieldsOperationApply ||
+			entry.Subresource != "" || entry.FieldsV1 == nil {
+			continue
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return nil, err
+		}
+		return fields, nil
+	}
+	return nil, nil
+}
+
+// getAppliedFields returns the applied fields at path, or nil when none was applied
+func getAppliedFields(fields map[string]interface{}, path fieldPath) map[string]interface{} {
+	for _, segment := range path {
+		name, ok := segment.(string)
+		if !ok || fields == nil {
+			return nil
+		}
+		fields, _ = fields["f:"+name].(map[string]interface{})
+	}
+	return fields
+}
+
+// hasRemovedFields tells whether some of the applied fields are missing from desired. Applying desired removes them
+// from the object, which the comparison of the fields of desired with the object doesn't detect
+func hasRemovedFields(fields map[string]interface{}, desired interface{}) bool {
+	for key, value := range fields {
+		childFields, _ := value.(map[string]interface{})
+		switch {
+		case strings.HasPrefix(key, "f:"):
+			desiredMap, _ := desired.(map[string]interface{})
+			desiredValue, found := desiredMap[key[2:]]
+			if !found || hasRemovedFields(childFields, desiredValue) {
+				return true
+			}
+		case strings.HasPrefix(key, "k:") || strings.HasPrefix(key, "v:"):
+			desiredList, _ := desired.([]interface{})
+			element := findAppliedListElement(key, desiredList)
+			if element == nil || hasRemovedFields(childFields, element) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findAppliedListElement returns the element of list identified by the key of a field set, or nil when none matches
+func findAppliedListElement(key string, list []interface{}) interface{} {
+	for _, element := range list {
+		if findListElementFields(map[string]interface{}{key: map[string]interface{}{}}, element) != nil {
+			return element
+		}
+	}
+	return nil
+}
+
+// conflictManagers returns the field managers of the fields which conflicted with an apply
+func conflictManagers(err error) []string {
+	managers := []string{}
+	var status k8serrors.APIStatus
+	if !errors.As(err, &status) || status.Status().Details == nil {
+		return managers
+	}
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		if match := conflictManagerRegexp.FindStringSubmatch(cause.Message); match != nil {
+			managers = append(managers, match[1])
+		}
+	}
+	return managers
+}
+
+// hasOtherFieldManager tells whether managers has a field manager which isn't the operator
+func hasOtherFieldManager(managers []string) bool {
+	for _, manager := range managers {
+		if !isOperatorFieldManager(manager) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// startTestAPIServer starts an API server for the tests which need a real server-side apply, and skips the test when
+// the envtest binaries aren't available (see the test target of the Makefile)
+func startTestAPIServer(t *testing.T) client.Client {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS isn't set, skipping the test requiring an API server")
+	}
+
+	testEnv := &envtest.Environment{}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("failed to start the API server: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := testEnv.Stop(); err != nil {
+			t.Errorf("failed to stop the API server: %v", err)
+		}
+	})
+
+	c, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+	return c
+}
+
+// newEnvtestStatefulSet returns the StatefulSet applied by the operator, with one splunk container
+func newEnvtestStatefulSet(env ...corev1.EnvVar) *appsv1.StatefulSet {
+	labels := map[string]string{"app.kubernetes.io/name": "indexer"}
+	return &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "StatefulSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-indexer",
+			Namespace: "default",
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    func(n int32) *int32 { return &n }(1),
+			ServiceName: "splunk-stack1-indexer-headless",
+			Selector:    &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "splunk", Image: "splunk/splunk", Env: env},
+					},
+				},
+			},
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType},
+		},
+	}
+}
+
+// applyAs applies the fields of a StatefulSet as fieldManager, with force taking over the fields of the operator
+func applyAs(ctx context.Context, t *testing.T, c client.Client, fieldManager string, spec map[string]interface{}) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "StatefulSet",
+		"metadata": map[string]interface{}{
+			"name":      "splunk-stack1-indexer",
+			"namespace": "default",
+		},
+		"spec": spec,
+	}}
+	err := c.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
+	if err != nil {
+		t.Fatalf("failed to apply the StatefulSet as %s: %v", fieldManager, err)
+	}
+}
+
+func TestApplyStatefulSetKeepsFieldsOfOtherManagers(t *testing.T) {
+	ctx := context.TODO()
+	c := startTestAPIServer(t)
+
+	if _, err := ApplyStatefulSet(ctx, c, newEnvtestStatefulSet()); err != nil {
+		t.Fatalf("ApplyStatefulSet() failed to create the StatefulSet: %v", err)
+	}
+
+	// a sidecar injector adds its container, and an autoscaler scales the StatefulSet
+	applyAs(ctx, t, c, "sidecar-injector", map[string]interface{}{
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "istio-proxy", "image": "istio/proxyv2"},
+				},
+			},
+		},
+	})
+	applyAs(ctx, t, c, "horizontal-pod-autoscaler", map[string]interface{}{"replicas": int64(3)})
+
+	// the operator changes its own container
+	revised := newEnvtestStatefulSet(corev1.EnvVar{Name: "SPLUNK_ROLE", Value: "splunk_indexer"})
+	if _, err := ApplyStatefulSet(ctx, c, revised); err != nil {
+		t.Fatalf("ApplyStatefulSet() failed to update the StatefulSet: %v", err)
+	}
+
+	var current appsv1.StatefulSet
+	err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "splunk-stack1-indexer"}, &current)
+	if err != nil {
+		t.Fatalf("failed to get the StatefulSet: %v", err)
+	}
+	if current.Spec.Replicas == nil || *current.Spec.Replicas != 3 {
+		t.Errorf("the replicas of the autoscaler weren't kept: got %v, want 3", current.Spec.Replicas)
+	}
+	containers := map[string]corev1.Container{}
+	for _, container := range current.Spec.Template.Spec.Containers {
+		containers[container.Name] = container
+	}
+	if _, ok := containers["istio-proxy"]; !ok {
+		t.Errorf("the sidecar container wasn't kept: got %v", current.Spec.Template.Spec.Containers)
+	}
+	splunk, ok := containers["splunk"]
+	if !ok || len(splunk.Env) != 1 || splunk.Env[0].Name != "SPLUNK_ROLE" {
+		t.Errorf("the splunk container wasn't updated: got %v", current.Spec.Template.Spec.Containers)
+	}
+}
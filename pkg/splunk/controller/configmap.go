@@ -28,12 +28,16 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 // ApplyConfigMap creates or updates a Kubernetes ConfigMap
 func ApplyConfigMap(ctx context.Context, client splcommon.ControllerClient, configMap *corev1.ConfigMap) (bool, error) {
+	ctx, span := tracing.StartSpan(ctx, "ApplyConfigMap", tracing.AttributeObjectName.String(configMap.GetName()))
+	defer span.End()
+
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("ApplyConfigMap").WithValues(
 		"name", configMap.GetObjectMeta().GetName(),
@@ -47,42 +51,30 @@ func ApplyConfigMap(ctx context.Context, client splcommon.ControllerClient, conf
 	// dataUpdated flag returns if the data on the configMap has been succesfully updated
 	dataUpdated := false
 	if err == nil {
-		// updateNeeded flag indicates if an update to the configMap is necessary
-		updateNeeded := false
-
-		// dataDifferent flag indicates the data on the configmap read from etcd is different
-		// from the data on the configMap passed as an argument to this function
-		dataDifferent := false
-		if !reflect.DeepEqual(configMap.Data, current.Data) {
-			scopedLog.Info("Updating existing ConfigMap", "ResourceVerison", current.GetResourceVersion())
-			current.Data = configMap.Data
-			updateNeeded = true
-			dataDifferent = true
-			configMap = &current
-		}
-		if !reflect.DeepEqual(configMap.GetOwnerReferences(), current.GetOwnerReferences()) {
-			scopedLog.Info("Updating existing ConfigMap", "ResourceVerison", current.GetResourceVersion())
-			current.OwnerReferences = configMap.OwnerReferences
-			updateNeeded = true
-			configMap = &current
+		// the data changed by someone else than the operator is kept or reverted depending on the drift policy
+		drift := detectDrift(ctx, configMapDriftCheck, &current, configMap)
+		if drift != nil && !drift.Reverted {
+			if err = drift.keep(&current, configMap); err != nil {
+				return false, err
+			}
 		}
 
-		if updateNeeded {
-			err = splutil.UpdateResource(ctx, client, &current)
-			if err == nil {
-				configMap = &current
-				// Update the dataUpdated flag only when there is a data change
-				// and configMap is successfully updated by client
-				if dataDifferent {
-					dataUpdated = true
-				}
+		// only the data and the owner references of the operator are applied, the ones of other custom resources are kept
+		if needsApply(ctx, configMapApplyCheck, &current, configMap) {
+			scopedLog.Info("Updating existing ConfigMap", "ResourceVerison", current.GetResourceVersion())
+			// the configMap passed is left unchanged, as callers apply it again with new data
+			err = applyObject(ctx, client, configMapApplyCheck, configMap.DeepCopy())
+			// Update the dataUpdated flag only when there is a data change
+			// and configMap is successfully updated by client
+			if err == nil && !reflect.DeepEqual(configMap.Data, current.Data) {
+				dataUpdated = true
 			}
 		} else {
 			scopedLog.Info("No changes for ConfigMap")
 		}
 
 	} else if errors.IsNotFound(err) {
-		err = splutil.CreateResource(ctx, client, configMap)
+		err = applyObject(ctx, client, configMapApplyCheck, configMap)
 		if err == nil {
 			dataUpdated = true
 			gerr := client.Get(ctx, namespacedName, &current)
@@ -90,7 +82,6 @@ func ApplyConfigMap(ctx context.Context, client splcommon.ControllerClient, conf
 				scopedLog.Error(gerr, "Newly created resource still not in cache sleeping for 10 micro second", "configmap", configMap.Name, "error", gerr.Error())
 				time.Sleep(10 * time.Microsecond)
 			}
-			configMap = &current
 		}
 	}
 
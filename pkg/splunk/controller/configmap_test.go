@@ -37,8 +37,8 @@ func TestApplyConfigMap(t *testing.T) {
 		{MetaName: "*v1.ConfigMap-test-defaults"},
 		{MetaName: "*v1.ConfigMap-test-defaults"},
 	}
-	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": {funcCalls[0]}}
-	updateCalls := map[string][]spltest.MockFuncCall{"Get": {funcCalls[0]}, "Update": {funcCalls[0]}}
+	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Patch": {funcCalls[0]}}
+	updateCalls := map[string][]spltest.MockFuncCall{"Get": {funcCalls[0]}, "Patch": {funcCalls[0]}}
 
 	current := corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
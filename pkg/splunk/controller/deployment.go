@@ -20,8 +20,9 @@ import (
 	"fmt"
 
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
-	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -31,6 +32,9 @@ import (
 
 // ApplyDeployment creates or updates a Kubernetes Deployment
 func ApplyDeployment(ctx context.Context, c splcommon.ControllerClient, revised *appsv1.Deployment) (enterpriseApi.Phase, error) {
+	ctx, span := tracing.StartSpan(ctx, "ApplyDeployment", tracing.AttributeObjectName.String(revised.GetName()))
+	defer span.End()
+
 	log := log.FromContext(ctx)
 	scopedLog := log.WithName("ApplyDeployment").WithValues(
 		"name", revised.GetObjectMeta().GetName(),
@@ -41,34 +45,34 @@ func ApplyDeployment(ctx context.Context, c splcommon.ControllerClient, revised
 
 	err := c.Get(ctx, namespacedName, &current)
 	if err != nil && k8serrors.IsNotFound(err) {
-		return enterpriseApi.PhasePending, splutil.CreateResource(ctx, c, revised)
+		return enterpriseApi.PhasePending, applyObject(ctx, c, deploymentApplyCheck, revised)
 	} else if err != nil {
 		return enterpriseApi.PhasePending, err
 	}
 
 	// found an existing Deployment
-
-	// check for changes in Pod template
-	hasUpdates := MergePodUpdates(ctx, &current.Spec.Template, &revised.Spec.Template, current.GetObjectMeta().GetName())
 	desiredReplicas := *revised.Spec.Replicas
-	*revised = current // caller expects that object passed represents latest state
 
-	// check for scaling
-	if revised.Spec.Replicas != nil {
-		if *revised.Spec.Replicas < desiredReplicas {
+	// only apply if there are material differences
+	if needsApply(ctx, deploymentApplyCheck, &current, revised) {
+		err = applyObject(ctx, c, deploymentApplyCheck, revised)
+		if err != nil {
+			return enterpriseApi.PhaseUpdating, err
+		}
+
+		// check for scaling, and for changes in Pod template
+		if current.Spec.Replicas != nil && *current.Spec.Replicas < desiredReplicas {
 			scopedLog.Info(fmt.Sprintf("Scaling replicas up to %d", desiredReplicas))
-			*revised.Spec.Replicas = desiredReplicas
-			return enterpriseApi.PhaseScalingUp, splutil.UpdateResource(ctx, c, revised)
-		} else if *revised.Spec.Replicas > desiredReplicas {
+			return enterpriseApi.PhaseScalingUp, nil
+		} else if current.Spec.Replicas != nil && *current.Spec.Replicas > desiredReplicas {
 			scopedLog.Info(fmt.Sprintf("Scaling replicas down to %d", desiredReplicas))
-			*revised.Spec.Replicas = desiredReplicas
-			return enterpriseApi.PhaseScalingDown, splutil.UpdateResource(ctx, c, revised)
+			return enterpriseApi.PhaseScalingDown, nil
 		}
-	}
-
-	// only update if there are material differences, as determined by comparison function
-	if hasUpdates {
-		return enterpriseApi.PhaseUpdating, splutil.UpdateResource(ctx, c, revised)
+		if !equality.Semantic.DeepEqual(current.Spec.Template, revised.Spec.Template) {
+			return enterpriseApi.PhaseUpdating, nil
+		}
+	} else {
+		*revised = current // caller expects that object passed represents latest state
 	}
 
 	// check if updates are in progress
@@ -28,8 +28,8 @@ import (
 
 func TestApplyDeployment(t *testing.T) {
 	funcCalls := []spltest.MockFuncCall{{MetaName: "*v1.Deployment-test-splunk-stack1-worker"}}
-	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": funcCalls}
-	updateCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Update": funcCalls}
+	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Patch": funcCalls}
+	updateCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Patch": funcCalls}
 	var replicas int32 = 1
 	current := appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,493 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// FieldDrift is a field of a Kubernetes object which was modified by someone else than the operator
+type FieldDrift struct {
+	// Path of the field, such as spec.template.spec.containers[0].image
+	Path string `json:"path"`
+
+	// Manager is the field manager which last modified the field, from the managedFields of the object
+	Manager string `json:"manager"`
+}
+
+// ObjectDrift is the drift of a Kubernetes object from the desired object of the operator
+type ObjectDrift struct {
+	Kind     string       `json:"kind"`
+	Name     string       `json:"name"`
+	Fields   []FieldDrift `json:"fields"`
+	Reverted bool         `json:"reverted"`
+}
+
+// String describes the drifted fields of the object
+func (d ObjectDrift) String() string {
+	fields := make([]string, 0, len(d.Fields))
+	for _, field := range d.Fields {
+		fields = append(fields, fmt.Sprintf("%s (by %s)", field.Path, field.Manager))
+	}
+	action := "kept"
+	if d.Reverted {
+		action = "reverted"
+	}
+	return fmt.Sprintf("%s %s: %s %s", d.Kind, d.Name, strings.Join(fields, ", "), action)
+}
+
+// DriftRecorder records the drift of the Kubernetes objects applied during a reconcile, and applies the drift policy of
+// the custom resource
+type DriftRecorder struct {
+	policy  enterpriseApi.DriftPolicy
+	mutex   sync.Mutex
+	drifts  map[string]ObjectDrift
+	checked int
+}
+
+// NewDriftRecorder returns a DriftRecorder applying policy to the drifted objects
+func NewDriftRecorder(policy enterpriseApi.DriftPolicy) *DriftRecorder {
+	return &DriftRecorder{
+		policy: policy,
+		drifts: map[string]ObjectDrift{},
+	}
+}
+
+// driftRecorderKey is the key of the DriftRecorder of a context
+type driftRecorderKey struct{}
+
+// WithDriftRecorder returns a context with recorder, which the apply functions use to detect and record drift
+func WithDriftRecorder(ctx context.Context, recorder *DriftRecorder) context.Context {
+	return context.WithValue(ctx, driftRecorderKey{}, recorder)
+}
+
+// getDriftRecorder returns the DriftRecorder of ctx, or nil when the drift of the objects isn't detected
+func getDriftRecorder(ctx context.Context) *DriftRecorder {
+	recorder, _ := ctx.Value(driftRecorderKey{}).(*DriftRecorder)
+	return recorder
+}
+
+// Enforce tells whether the drifted fields are reverted to the desired object
+func (r *DriftRecorder) Enforce() bool {
+	return r.policy != enterpriseApi.DriftPolicyReportOnly
+}
+
+// Drifts returns the recorded drifts, sorted by kind and name
+func (r *DriftRecorder) Drifts() []ObjectDrift {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	drifts := make([]ObjectDrift, 0, len(r.drifts))
+	for _, drift := range r.drifts {
+		drifts = append(drifts, drift)
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Kind != drifts[j].Kind {
+			return drifts[i].Kind < drifts[j].Kind
+		}
+		return drifts[i].Name < drifts[j].Name
+	})
+	return drifts
+}
+
+// Checked returns the number of objects checked for drift
+func (r *DriftRecorder) Checked() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.checked
+}
+
+// check counts an object checked for drift
+func (r *DriftRecorder) check() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.checked++
+}
+
+// record records the drift of an object
+func (r *DriftRecorder) record(drift ObjectDrift) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.drifts[drift.Kind+"/"+drift.Name] = drift
+}
+
+// fieldPath is the path of a field in the unstructured content of an object, made of map keys and list indexes
+type fieldPath []interface{}
+
+// String formats the path, such as spec.template.spec.containers[0].image
+func (p fieldPath) String() string {
+	var sb strings.Builder
+	for _, segment := range p {
+		switch s := segment.(type) {
+		case string:
+			if sb.Len() > 0 {
+				sb.WriteString(".")
+			}
+			sb.WriteString(s)
+		case int:
+			sb.WriteString("[" + strconv.Itoa(s) + "]")
+		}
+	}
+	return sb.String()
+}
+
+// child returns the path of a child field, without sharing the segments of p
+func (p fieldPath) child(segment interface{}) fieldPath {
+	path := make(fieldPath, len(p), len(p)+1)
+	copy(path, p)
+	return append(path, segment)
+}
+
+// driftCheck detects the drift of a kind of objects
+type driftCheck struct {
+	kind string
+
+	// roots are the fields compared with the desired object
+	roots []string
+
+	// ignored are the fields not compared, such as the fields owned by other reconcile steps
+	ignored []string
+}
+
+var (
+	statefulSetDriftCheck = driftCheck{kind: "StatefulSet", roots: []string{"spec"}, ignored: []string{"spec.replicas", "spec.volumeClaimTemplates"}}
+	serviceDriftCheck     = driftCheck{kind: "Service", roots: []string{"spec"}}
+	configMapDriftCheck   = driftCheck{kind: "ConfigMap", roots: []string{"data"}}
+	secretDriftCheck      = driftCheck{kind: "Secret", roots: []string{"data"}}
+)
+
+// objectDrift is the drift of an object detected by an apply function
+type objectDrift struct {
+	ObjectDrift
+	paths []fieldPath
+}
+
+// detectDrift returns the drift of current from desired, i.e. the fields which differ and were last modified by
+// someone else than the operator, or nil when there is no drift or no DriftRecorder in ctx. Fields only modified by the
+// operator differ because the custom resource changed, and aren't drift. The drift is recorded in the DriftRecorder
+func detectDrift(ctx context.Context, check driftCheck, current client.Object, desired client.Object) *objectDrift {
+	recorder := getDriftRecorder(ctx)
+	if recorder == nil {
+		return nil
+	}
+	recorder.check()
+	scopedLog := log.FromContext(ctx).WithName("detectDrift").WithValues("kind", check.kind, "name", current.GetName())
+
+	currentContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		scopedLog.Error(err, "Unable to convert the current object")
+		return nil
+	}
+	desiredContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		scopedLog.Error(err, "Unable to convert the desired object")
+		return nil
+	}
+
+	var paths []fieldPath
+	for _, root := range check.roots {
+		diffFields(desiredContent[root], currentContent[root], fieldPath{root}, check.ignored, &paths)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	// the fields of the maps are compared in random order
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i].String() < paths[j].String()
+	})
+
+	managedFields, err := decodeManagedFields(current.GetManagedFields())
+	if err != nil {
+		scopedLog.Error(err, "Unable to decode the managedFields")
+		return nil
+	}
+
+	drift := &objectDrift{ObjectDrift: ObjectDrift{Kind: check.kind, Name: current.GetName(), Reverted: recorder.Enforce()}}
+	for _, path := range paths {
+		manager := lastFieldManager(managedFields, currentContent, path)
+		if manager == "" {
+			continue
+		}
+		drift.paths = append(drift.paths, path)
+		drift.Fields = append(drift.Fields, FieldDrift{Path: path.String(), Manager: manager})
+	}
+	if len(drift.paths) == 0 {
+		return nil
+	}
+
+	scopedLog.Info("Detected drift", "drift", drift.String())
+	recorder.record(drift.ObjectDrift)
+	return drift
+}
+
+// keep copies the drifted fields of current into desired, so that they aren't reverted
+func (d *objectDrift) keep(current client.Object, desired client.Object) error {
+	return copyFields(desired, current, d.paths)
+}
+
+// diffFields appends to paths the fields of desired which differ in current. Zero values of desired are defaulted or
+// set by Kubernetes, and lists of different lengths differ as a whole
+func diffFields(desired interface{}, current interface{}, path fieldPath, ignored []string, paths *[]fieldPath) {
+	for _, ignoredPath := range ignored {
+		if path.String() == ignoredPath {
+			return
+		}
+	}
+
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		currentValue, _ := current.(map[string]interface{})
+		for key, value := range desiredValue {
+			diffFields(value, currentValue[key], path.child(key), ignored, paths)
+		}
+	case []interface{}:
+		if len(desiredValue) == 0 {
+			return
+		}
+		currentValue, ok := current.([]interface{})
+		if !ok || len(currentValue) != len(desiredValue) {
+			*paths = append(*paths, path)
+			return
+		}
+		for i := range desiredValue {
+			diffFields(desiredValue[i], currentValue[i], path.child(i), ignored, paths)
+		}
+	default:
+		if desired == nil || reflect.ValueOf(desired).IsZero() {
+			return
+		}
+		if !reflect.DeepEqual(desired, current) {
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// managedFieldSet is the set of fields owned by a field manager
+type managedFieldSet struct {
+	manager string
+	time    *metav1.Time
+	fields  map[string]interface{}
+}
+
+// decodeManagedFields decodes the field sets of managedFields, except for the status subresource
+func decodeManagedFields(managedFields []metav1.ManagedFieldsEntry) ([]managedFieldSet, error) {
+	sets := []managedFieldSet{}
+	for _, entry := range managedFields {
+		if entry.Subresource != "" || entry.FieldsV1 == nil {
+			continue
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return nil, err
+		}
+		sets = append(sets, managedFieldSet{manager: entry.Manager, time: entry.Time, fields: fields})
+	}
+	return sets, nil
+}
+
+// lastFieldManager returns the field manager which last modified the field at path of content, or an empty string
+// when the field is only owned by the operator, or by nobody
+func lastFieldManager(managedFields []managedFieldSet, content map[string]interface{}, path fieldPath) string {
+	var last *managedFieldSet
+	for i := range managedFields {
+		set := &managedFields[i]
+		if isOperatorFieldManager(set.manager) || !ownsField(set.fields, content, path) {
+			continue
+		}
+		if last == nil || (set.time != nil && (last.time == nil || last.time.Before(set.time))) {
+			last = set
+		}
+	}
+	if last == nil {
+		return ""
+	}
+	return last.manager
+}
+
+// isOperatorFieldManager tells whether manager is the field manager of the operator
+func isOperatorFieldManager(manager string) bool {
+	return manager == splcommon.OperatorFieldManager
+}
+
+// ownsField tells whether a field set owns the field at path of content. The list elements are identified by their
+// keys or values, and an empty set owns all the fields under it
+func ownsField(fields map[string]interface{}, content interface{}, path fieldPath) bool {
+	for _, segment := range path {
+		if len(fields) == 0 {
+			return true
+		}
+		switch s := segment.(type) {
+		case string:
+			child, ok := fields["f:"+s].(map[string]interface{})
+			if !ok {
+				return false
+			}
+			fields = child
+			contentMap, _ := content.(map[string]interface{})
+			content = contentMap[s]
+		case int:
+			list, _ := content.([]interface{})
+			if s >= len(list) {
+				return false
+			}
+			content = list[s]
+			fields = findListElementFields(fields, content)
+			if fields == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// findListElementFields returns the fields of a list element, identified by its keys ("k:" sets) or its value ("v:" sets)
+func findListElementFields(fields map[string]interface{}, element interface{}) map[string]interface{} {
+	for key, value := range fields {
+		elementFields, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(key, "k:"):
+			keys := map[string]interface{}{}
+			if json.Unmarshal([]byte(key[2:]), &keys) != nil {
+				continue
+			}
+			elementMap, _ := element.(map[string]interface{})
+			matches := true
+			for k, v := range keys {
+				if !jsonEqual(v, elementMap[k]) {
+					matches = false
+					break
+				}
+			}
+			if matches {
+				return elementFields
+			}
+		case strings.HasPrefix(key, "v:"):
+			var v interface{}
+			if json.Unmarshal([]byte(key[2:]), &v) == nil && jsonEqual(v, element) {
+				return elementFields
+			}
+		}
+	}
+	return nil
+}
+
+// jsonEqual tells whether a and b have the same JSON encoding, which ignores the differences of number types
+func jsonEqual(a interface{}, b interface{}) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(aJSON) == string(bJSON)
+}
+
+// copyFields copies the fields at paths of src into dst, or removes them from dst when src doesn't have them
+func copyFields(dst client.Object, src client.Object, paths []fieldPath) error {
+	dstContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(dst)
+	if err != nil {
+		return err
+	}
+	srcContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(src)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		value, found := getField(srcContent, path)
+		setField(dstContent, path, value, found)
+	}
+
+	// decode into a zero object, so that the removed fields don't keep their previous values
+	dstValue := reflect.ValueOf(dst).Elem()
+	dstValue.Set(reflect.Zero(dstValue.Type()))
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(dstContent, dst)
+}
+
+// getField returns the field at path of content, and whether it was found
+func getField(content interface{}, path fieldPath) (interface{}, bool) {
+	for _, segment := range path {
+		switch s := segment.(type) {
+		case string:
+			contentMap, ok := content.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if content, ok = contentMap[s]; !ok {
+				return nil, false
+			}
+		case int:
+			list, ok := content.([]interface{})
+			if !ok || s >= len(list) {
+				return nil, false
+			}
+			content = list[s]
+		}
+	}
+	return content, true
+}
+
+// setField sets the field at path of content to value, or removes it when found is false. The maps of the path are
+// created when missing, the lists must have the element of the path
+func setField(content map[string]interface{}, path fieldPath, value interface{}, found bool) {
+	var parent interface{} = content
+	for i, segment := range path {
+		last := i == len(path)-1
+		switch s := segment.(type) {
+		case string:
+			parentMap, ok := parent.(map[string]interface{})
+			if !ok {
+				return
+			}
+			if last {
+				if found {
+					parentMap[s] = runtime.DeepCopyJSONValue(value)
+				} else {
+					delete(parentMap, s)
+				}
+				return
+			}
+			if _, ok := parentMap[s]; !ok {
+				parentMap[s] = map[string]interface{}{}
+			}
+			parent = parentMap[s]
+		case int:
+			list, ok := parent.([]interface{})
+			if !ok || s >= len(list) {
+				return
+			}
+			if last {
+				if found {
+					list[s] = runtime.DeepCopyJSONValue(value)
+				}
+				return
+			}
+			parent = list[s]
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+// driftTestStatefulSets returns the current and desired statefulsets of the drift tests. The image and the update
+// strategy of the current statefulset were edited with kubectl, and its service account is from a previous spec
+func driftTestStatefulSets() (*appsv1.StatefulSet, *appsv1.StatefulSet) {
+	replicas := int32(1)
+	desired := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "splunk-s1-standalone", Namespace: "test"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:       &replicas,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					ServiceAccountName: "splunk",
+					Containers:         []corev1.Container{{Name: "splunk", Image: "splunk/splunk:9.0.0"}},
+				},
+			},
+		},
+	}
+
+	current := desired.DeepCopy()
+	current.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+	current.Spec.Template.Spec.ServiceAccountName = "default"
+	current.Spec.Template.Spec.Containers[0].Image = "splunk/splunk:debug"
+	current.ManagedFields = []metav1.ManagedFieldsEntry{
+		{
+			Manager:   splcommon.OperatorFieldManager,
+			Operation: metav1.ManagedFieldsOperationUpdate,
+			Time:      &metav1.Time{Time: time.Unix(1000, 0)},
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{},"f:template":{"f:spec":{"f:serviceAccountName":{},` +
+				`"f:containers":{"k:{\"name\":\"splunk\"}":{".":{},"f:name":{}}}}}}}`)},
+		},
+		{
+			Manager:   "kubectl-edit",
+			Operation: metav1.ManagedFieldsOperationUpdate,
+			Time:      &metav1.Time{Time: time.Unix(2000, 0)},
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:updateStrategy":{"f:type":{}},"f:template":{"f:spec":{` +
+				`"f:containers":{"k:{\"name\":\"splunk\"}":{"f:image":{}}}}}}}`)},
+		},
+		{
+			Manager:     "kube-controller-manager",
+			Operation:   metav1.ManagedFieldsOperationUpdate,
+			Subresource: "status",
+			FieldsV1:    &metav1.FieldsV1{Raw: []byte(`{"f:status":{"f:replicas":{}}}`)},
+		},
+	}
+	return current, desired
+}
+
+func TestDetectDrift(t *testing.T) {
+	current, desired := driftTestStatefulSets()
+
+	// no drift detection without a recorder
+	if drift := detectDrift(context.TODO(), statefulSetDriftCheck, current, desired); drift != nil {
+		t.Errorf("detectDrift() returned %v without a DriftRecorder; want nil", drift)
+	}
+
+	recorder := NewDriftRecorder(enterpriseApi.DriftPolicyReportOnly)
+	ctx := WithDriftRecorder(context.TODO(), recorder)
+	drift := detectDrift(ctx, statefulSetDriftCheck, current, desired)
+	if drift == nil {
+		t.Fatalf("detectDrift() returned nil; want the drift of the kubectl changes")
+	}
+	want := ObjectDrift{
+		Kind: "StatefulSet",
+		Name: "splunk-s1-standalone",
+		Fields: []FieldDrift{
+			{Path: "spec.template.spec.containers[0].image", Manager: "kubectl-edit"},
+			{Path: "spec.updateStrategy.type", Manager: "kubectl-edit"},
+		},
+	}
+	if !reflect.DeepEqual(drift.ObjectDrift, want) {
+		t.Errorf("detectDrift() = %v; want %v", drift.ObjectDrift, want)
+	}
+	if drifts := recorder.Drifts(); len(drifts) != 1 || drifts[0].Name != want.Name {
+		t.Errorf("Drifts() = %v; want the drift of %s", drifts, want.Name)
+	}
+
+	// changes of the operator only aren't drift
+	current.ManagedFields = current.ManagedFields[:1]
+	if drift := detectDrift(ctx, statefulSetDriftCheck, current, desired); drift != nil {
+		t.Errorf("detectDrift() returned %v for the changes of the operator; want nil", drift)
+	}
+}
+
+func TestLastFieldManager(t *testing.T) {
+	content := map[string]interface{}{"data": map[string]interface{}{"key": "value"}}
+	path := fieldPath{"data", "key"}
+	fields := map[string]interface{}{"f:data": map[string]interface{}{"f:key": map[string]interface{}{}}}
+	managedFields := []managedFieldSet{
+		{manager: "kubectl-edit", time: &metav1.Time{Time: time.Unix(1000, 0)}, fields: fields},
+		{manager: "helm", time: &metav1.Time{Time: time.Unix(3000, 0)}, fields: fields},
+		{manager: splcommon.OperatorFieldManager, time: &metav1.Time{Time: time.Unix(4000, 0)}, fields: fields},
+		{manager: "kubectl-patch", time: &metav1.Time{Time: time.Unix(2000, 0)}, fields: fields},
+	}
+	if manager := lastFieldManager(managedFields, content, path); manager != "helm" {
+		t.Errorf("lastFieldManager() = %s; want helm", manager)
+	}
+
+	// an atomic parent owns the fields under it
+	managedFields = []managedFieldSet{{manager: "kubectl-edit", fields: map[string]interface{}{"f:data": map[string]interface{}{}}}}
+	if manager := lastFieldManager(managedFields, content, path); manager != "kubectl-edit" {
+		t.Errorf("lastFieldManager() = %s; want kubectl-edit", manager)
+	}
+	if manager := lastFieldManager(managedFields, content, fieldPath{"spec"}); manager != "" {
+		t.Errorf("lastFieldManager() = %s for a field not owned; want an empty manager", manager)
+	}
+}
+
+func TestApplyStatefulSetDrift(t *testing.T) {
+	for _, tc := range []struct {
+		policy         enterpriseApi.DriftPolicy
+		wantImage      string
+		wantUpdateType appsv1.StatefulSetUpdateStrategyType
+	}{
+		{policy: enterpriseApi.DriftPolicyEnforce, wantImage: "splunk/splunk:9.0.0", wantUpdateType: appsv1.OnDeleteStatefulSetStrategyType},
+		{policy: enterpriseApi.DriftPolicyReportOnly, wantImage: "splunk/splunk:debug", wantUpdateType: appsv1.RollingUpdateStatefulSetStrategyType},
+	} {
+		current, desired := driftTestStatefulSets()
+		c := spltest.NewFakeApplyClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(current).Build())
+		recorder := NewDriftRecorder(tc.policy)
+		ctx := WithDriftRecorder(context.TODO(), recorder)
+
+		_, err := ApplyStatefulSet(ctx, c, desired)
+		if err != nil {
+			t.Fatalf("ApplyStatefulSet() with policy %s returned error: %v", tc.policy, err)
+		}
+
+		got := &appsv1.StatefulSet{}
+		err = c.Get(ctx, types.NamespacedName{Namespace: "test", Name: "splunk-s1-standalone"}, got)
+		if err != nil {
+			t.Fatalf("Get() returned error: %v", err)
+		}
+		if got.Spec.Template.Spec.Containers[0].Image != tc.wantImage {
+			t.Errorf("policy %s: image = %s; want %s", tc.policy, got.Spec.Template.Spec.Containers[0].Image, tc.wantImage)
+		}
+		if got.Spec.UpdateStrategy.Type != tc.wantUpdateType {
+			t.Errorf("policy %s: update strategy = %s; want %s", tc.policy, got.Spec.UpdateStrategy.Type, tc.wantUpdateType)
+		}
+		// the changes of the spec are applied with both policies
+		if got.Spec.Template.Spec.ServiceAccountName != "splunk" {
+			t.Errorf("policy %s: service account = %s; want splunk", tc.policy, got.Spec.Template.Spec.ServiceAccountName)
+		}
+
+		drifts := recorder.Drifts()
+		if len(drifts) != 1 || len(drifts[0].Fields) != 2 || drifts[0].Reverted != (tc.policy == enterpriseApi.DriftPolicyEnforce) {
+			t.Errorf("policy %s: Drifts() = %v; want the image and update strategy drift", tc.policy, drifts)
+		}
+	}
+}
+
+func TestApplyConfigMapDrift(t *testing.T) {
+	current := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-s1-defaults",
+			Namespace: "test",
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kubectl-edit", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:default.yml":{}}}`)}},
+			},
+		},
+		Data: map[string]string{"default.yml": "edited"},
+	}
+	c := spltest.NewFakeApplyClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(current).Build())
+	ctx := WithDriftRecorder(context.TODO(), NewDriftRecorder(enterpriseApi.DriftPolicyReportOnly))
+
+	desired := PrepareConfigMap("splunk-s1-defaults", "test", map[string]string{"default.yml": "desired"})
+	dataUpdated, err := ApplyConfigMap(ctx, c, desired)
+	if err != nil {
+		t.Fatalf("ApplyConfigMap() returned error: %v", err)
+	}
+	if dataUpdated {
+		t.Errorf("ApplyConfigMap() updated the data of the configMap with the report-only policy")
+	}
+
+	got := &corev1.ConfigMap{}
+	_ = c.Get(ctx, types.NamespacedName{Namespace: "test", Name: "splunk-s1-defaults"}, got)
+	if got.Data["default.yml"] != "edited" {
+		t.Errorf("default.yml = %s; want the edited data kept", got.Data["default.yml"])
+	}
+}
+
+func TestCopyFields(t *testing.T) {
+	dst := &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort, ExternalName: "removed"}}
+	src := &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, Ports: []corev1.ServicePort{{Name: "http", Port: 80}}}}
+	err := copyFields(dst, src, []fieldPath{{"spec", "type"}, {"spec", "ports"}, {"spec", "externalName"}})
+	if err != nil {
+		t.Fatalf("copyFields() returned error: %v", err)
+	}
+	want := corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, Ports: []corev1.ServicePort{{Name: "http", Port: 80}}}
+	if !reflect.DeepEqual(dst.Spec, want) {
+		t.Errorf("copyFields() = %v; want %v", dst.Spec, want)
+	}
+}
+
+func TestObjectDriftString(t *testing.T) {
+	drift := ObjectDrift{
+		Kind:     "Service",
+		Name:     "splunk-s1-standalone-service",
+		Fields:   []FieldDrift{{Path: "spec.type", Manager: "kubectl-edit"}},
+		Reverted: true,
+	}
+	want := "Service splunk-s1-standalone-service: spec.type (by kubectl-edit) reverted"
+	if drift.String() != want {
+		t.Errorf("String() = %s; want %s", drift.String(), want)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
+)
+
+// ApplyIngress creates or updates a Kubernetes Ingress
+func ApplyIngress(ctx context.Context, client splcommon.ControllerClient, revised *networkingv1.Ingress) error {
+	ctx, span := tracing.StartSpan(ctx, "ApplyIngress", tracing.AttributeObjectName.String(revised.GetName()))
+	defer span.End()
+
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("ApplyIngress").WithValues(
+		"name", revised.GetObjectMeta().GetName(),
+		"namespace", revised.GetObjectMeta().GetNamespace())
+
+	namespacedName := types.NamespacedName{Namespace: revised.GetNamespace(), Name: revised.GetName()}
+	var current networkingv1.Ingress
+
+	err := client.Get(ctx, namespacedName, &current)
+	if err != nil && k8serrors.IsNotFound(err) {
+		return applyObject(ctx, client, ingressApplyCheck, revised)
+	} else if err != nil {
+		return err
+	}
+
+	// ingress class is set by the admission controller, when the default class is used
+	if revised.Spec.IngressClassName == nil {
+		revised.Spec.IngressClassName = current.Spec.IngressClassName
+	}
+
+	// the labels and annotations added by other controllers are kept
+	if needsApply(ctx, ingressApplyCheck, &current, revised) {
+		scopedLog.Info("Updating existing Ingress")
+		return applyObject(ctx, client, ingressApplyCheck, revised)
+	}
+	*revised = current // caller expects that object passed represents latest state
+
+	scopedLog.Info("No update to existing Ingress")
+	return nil
+}
+
+// ApplyNetworkPolicy creates or updates a Kubernetes NetworkPolicy
+func ApplyNetworkPolicy(ctx context.Context, client splcommon.ControllerClient, revised *networkingv1.NetworkPolicy) error {
+	ctx, span := tracing.StartSpan(ctx, "ApplyNetworkPolicy", tracing.AttributeObjectName.String(revised.GetName()))
+	defer span.End()
+
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("ApplyNetworkPolicy").WithValues(
+		"name", revised.GetObjectMeta().GetName(),
+		"namespace", revised.GetObjectMeta().GetNamespace())
+
+	namespacedName := types.NamespacedName{Namespace: revised.GetNamespace(), Name: revised.GetName()}
+	var current networkingv1.NetworkPolicy
+
+	err := client.Get(ctx, namespacedName, &current)
+	if err != nil && k8serrors.IsNotFound(err) {
+		return applyObject(ctx, client, networkPolicyApplyCheck, revised)
+	} else if err != nil {
+		return err
+	}
+
+	if needsApply(ctx, networkPolicyApplyCheck, &current, revised) {
+		scopedLog.Info("Updating existing NetworkPolicy")
+		return applyObject(ctx, client, networkPolicyApplyCheck, revised)
+	}
+	*revised = current // caller expects that object passed represents latest state
+
+	scopedLog.Info("No update to existing NetworkPolicy")
+	return nil
+}
+
+// ApplyUnstructuredObject creates or updates a Kubernetes object of a kind without a Go type, like the OpenShift Route
+func ApplyUnstructuredObject(ctx context.Context, client splcommon.ControllerClient, revised *unstructured.Unstructured) error {
+	ctx, span := tracing.StartSpan(ctx, "ApplyUnstructuredObject", tracing.AttributeObjectName.String(revised.GetName()))
+	defer span.End()
+
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("ApplyUnstructuredObject").WithValues(
+		"kind", revised.GetKind(),
+		"name", revised.GetName(),
+		"namespace", revised.GetNamespace())
+
+	namespacedName := types.NamespacedName{Namespace: revised.GetNamespace(), Name: revised.GetName()}
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(revised.GroupVersionKind())
+	check := applyCheck{gvk: revised.GroupVersionKind(), paths: append([]fieldPath{{"spec"}}, metadataApplyPaths...)}
+
+	err := client.Get(ctx, namespacedName, current)
+	if err != nil && k8serrors.IsNotFound(err) {
+		scopedLog.Info("Creating object")
+		return applyObject(ctx, client, check, revised)
+	} else if err != nil {
+		return err
+	}
+
+	if needsApply(ctx, check, current, revised) {
+		scopedLog.Info("Updating existing object")
+		return applyObject(ctx, client, check, revised)
+	}
+
+	*revised = *current // caller expects that object passed represents latest state
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+func TestApplyIngress(t *testing.T) {
+	funcCalls := []spltest.MockFuncCall{{MetaName: "*v1.Ingress-test-ingress"}}
+	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Patch": funcCalls}
+	updateCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Patch": funcCalls}
+	current := networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "test",
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{Host: "splunk.example.com"}},
+		},
+	}
+	revised := current.DeepCopy()
+	revised.Spec.Rules[0].Host = "splunk2.example.com"
+	reconcile := func(c *spltest.MockClient, cr interface{}) error {
+		return ApplyIngress(context.TODO(), c, cr.(*networkingv1.Ingress))
+	}
+	spltest.ReconcileTester(t, "TestApplyIngress", &current, revised, createCalls, updateCalls, reconcile, false)
+}
+
+func TestApplyNetworkPolicy(t *testing.T) {
+	funcCalls := []spltest.MockFuncCall{{MetaName: "*v1.NetworkPolicy-test-network-policy"}}
+	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Patch": funcCalls}
+	updateCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Patch": funcCalls}
+	current := networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "network-policy",
+			Namespace: "test",
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": "indexer"}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+	revised := current.DeepCopy()
+	revised.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{}}
+	reconcile := func(c *spltest.MockClient, cr interface{}) error {
+		return ApplyNetworkPolicy(context.TODO(), c, cr.(*networkingv1.NetworkPolicy))
+	}
+	spltest.ReconcileTester(t, "TestApplyNetworkPolicy", &current, revised, createCalls, updateCalls, reconcile, false)
+}
+
+func TestApplyUnstructuredObject(t *testing.T) {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+
+	revised := &unstructured.Unstructured{}
+	revised.SetAPIVersion("route.openshift.io/v1")
+	revised.SetKind("Route")
+	revised.SetName("route")
+	revised.SetNamespace("test")
+	revised.SetAnnotations(map[string]string{"haproxy.router.openshift.io/balance": "roundrobin"})
+	revised.Object["spec"] = map[string]interface{}{
+		"host": "splunk.example.com",
+		"port": map[string]interface{}{"targetPort": int64(8000)},
+	}
+
+	err := ApplyUnstructuredObject(ctx, c, revised.DeepCopy())
+	if err != nil || len(c.Calls["Patch"]) != 1 {
+		t.Fatalf("ApplyUnstructuredObject should create the object, error: %v", err)
+	}
+
+	// fields defaulted by the API server don't cause an update
+	current := revised.DeepCopy()
+	current.Object["spec"].(map[string]interface{})["wildcardPolicy"] = "None"
+	current.SetAnnotations(map[string]string{"haproxy.router.openshift.io/balance": "roundrobin", "openshift.io/host.generated": "false"})
+	err = c.Update(ctx, current)
+	if err != nil {
+		t.Fatalf("unable to update the object, error: %v", err)
+	}
+
+	c.ResetCalls()
+	err = ApplyUnstructuredObject(ctx, c, revised.DeepCopy())
+	if err != nil || len(c.Calls["Patch"]) != 0 {
+		t.Errorf("ApplyUnstructuredObject should not update the object, error: %v", err)
+	}
+
+	// changed fields cause an apply, which keeps the annotations added by other controllers
+	changed := revised.DeepCopy()
+	changed.Object["spec"].(map[string]interface{})["port"] = map[string]interface{}{"targetPort": int64(8001)}
+	c.ResetCalls()
+	err = ApplyUnstructuredObject(ctx, c, changed)
+	if err != nil || len(c.Calls["Patch"]) != 1 {
+		t.Errorf("ApplyUnstructuredObject should update the object, error: %v", err)
+	}
+
+	port, _, _ := unstructured.NestedInt64(changed.Object, "spec", "port", "targetPort")
+	if port != 8001 {
+		t.Errorf("spec should be updated, got: %v", changed.Object["spec"])
+	}
+}
@@ -21,12 +21,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
-	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"time"
 
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
-	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 )
 
 // ApplySecret creates or updates a Kubernetes Secret, and returns active secrets if successful
@@ -36,6 +35,9 @@ func ApplySecret(ctx context.Context, client splcommon.ControllerClient, secret
 		return nil, errors.New(splcommon.InvalidSecretObjectError)
 	}
 
+	ctx, span := tracing.StartSpan(ctx, "ApplySecret", tracing.AttributeObjectName.String(secret.GetName()))
+	defer span.End()
+
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("ApplySecret").WithValues(
 		"name", secret.GetObjectMeta().GetName(),
@@ -47,17 +49,23 @@ func ApplySecret(ctx context.Context, client splcommon.ControllerClient, secret
 	err := client.Get(ctx, namespacedName, &result)
 	if err == nil {
 		scopedLog.Info("Found existing Secret, update if needed")
-		if !reflect.DeepEqual(&result, secret) {
-			result = *secret
-			err = splutil.UpdateResource(ctx, client, &result)
+		// the data changed by someone else than the operator is kept or reverted depending on the drift policy
+		drift := detectDrift(ctx, secretDriftCheck, &result, secret)
+		if drift != nil && !drift.Reverted {
+			if err = drift.keep(&result, secret); err != nil {
+				return nil, err
+			}
+		}
+		if needsApply(ctx, secretApplyCheck, &result, secret) {
+			result = *secret.DeepCopy()
+			err = applyObject(ctx, client, secretApplyCheck, &result)
 			if err != nil {
 				return nil, err
 			}
-			secret = &result
 		}
 	} else if k8serrors.IsNotFound(err) {
 		scopedLog.Info("Didn't find secret, creating one")
-		err = splutil.CreateResource(ctx, client, secret)
+		err = applyObject(ctx, client, secretApplyCheck, secret)
 		if err != nil {
 			return nil, err
 		}
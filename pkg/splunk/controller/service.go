@@ -19,7 +19,7 @@ import (
 	"context"
 
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
-	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -28,6 +28,9 @@ import (
 
 // ApplyService creates or updates a Kubernetes Service
 func ApplyService(ctx context.Context, client splcommon.ControllerClient, revised *corev1.Service) error {
+	ctx, span := tracing.StartSpan(ctx, "ApplyService", tracing.AttributeObjectName.String(revised.GetName()))
+	defer span.End()
+
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("ApplyService").WithValues(
 		"name", revised.GetObjectMeta().GetName(),
@@ -38,28 +41,26 @@ func ApplyService(ctx context.Context, client splcommon.ControllerClient, revise
 
 	err := client.Get(ctx, namespacedName, &current)
 	if err != nil && k8serrors.IsNotFound(err) {
-		return splutil.CreateResource(ctx, client, revised)
+		return applyObject(ctx, client, serviceApplyCheck, revised)
 	} else if err != nil {
 		return err
 	}
 
-	// check for changes in service template
-	hasUpdates := MergeServiceSpecUpdates(ctx, &current.Spec, &revised.Spec, current.GetObjectMeta().GetName())
-	*revised = current // caller expects that object passed represents latest state
-
-	// only update if there are material differences, as determined by comparison function
-	if hasUpdates {
-		scopedLog.Info("Updating existing Service")
-		err = splutil.UpdateResource(ctx, client, revised)
-		if err != nil {
-			return err
-		}
-		err = client.Get(ctx, namespacedName, revised)
-		if err != nil {
+	// check for changes made by someone else than the operator, which are kept or reverted depending on the drift policy
+	drift := detectDrift(ctx, serviceDriftCheck, &current, revised)
+	if drift != nil && !drift.Reverted {
+		if err = drift.keep(&current, revised); err != nil {
 			return err
 		}
 	}
 
+	// only apply if there are material differences
+	if needsApply(ctx, serviceApplyCheck, &current, revised) {
+		scopedLog.Info("Updating existing Service")
+		return applyObject(ctx, client, serviceApplyCheck, revised)
+	}
+	*revised = current // caller expects that object passed represents latest state
+
 	// all is good!
 	scopedLog.Info("No update to existing Service")
 	return nil
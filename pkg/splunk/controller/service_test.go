@@ -27,8 +27,8 @@ import (
 
 func TestApplyService(t *testing.T) {
 	funcCalls := []spltest.MockFuncCall{{MetaName: "*v1.Service-test-svc"}}
-	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": funcCalls}
-	updateCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls}
+	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Patch": funcCalls}
+	updateCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Patch": funcCalls}
 	current := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "svc",
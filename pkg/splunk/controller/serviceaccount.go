@@ -16,10 +16,9 @@ package controller
 
 import (
 	"context"
-	"reflect"
 
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
-	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -28,6 +27,9 @@ import (
 
 // ApplyServiceAccount creates or updates a Kubernetes serviceAccount
 func ApplyServiceAccount(ctx context.Context, client splcommon.ControllerClient, serviceAccount *corev1.ServiceAccount) error {
+	ctx, span := tracing.StartSpan(ctx, "ApplyServiceAccount", tracing.AttributeObjectName.String(serviceAccount.GetName()))
+	defer span.End()
+
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("ApplyServiceAccount").WithValues("serviceAccount", serviceAccount.GetName(),
 		"namespace", serviceAccount.GetNamespace())
@@ -37,18 +39,12 @@ func ApplyServiceAccount(ctx context.Context, client splcommon.ControllerClient,
 
 	err := client.Get(ctx, namespacedName, &current)
 	if err == nil {
-		if !reflect.DeepEqual(serviceAccount, &current) {
+		if needsApply(ctx, serviceAccountApplyCheck, &current, serviceAccount) {
 			scopedLog.Info("Updating service account")
-			current = *serviceAccount
-			err = splutil.UpdateResource(ctx, client, &current)
-			if err != nil {
-				return err
-			}
-			// after update get the latest resource
-			err = client.Get(ctx, namespacedName, &current)
+			return applyObject(ctx, client, serviceAccountApplyCheck, serviceAccount)
 		}
 	} else if k8serrors.IsNotFound(err) {
-		err = splutil.CreateResource(ctx, client, serviceAccount)
+		err = applyObject(ctx, client, serviceAccountApplyCheck, serviceAccount)
 	} else if err != nil {
 		return err
 	}
@@ -26,11 +26,8 @@ import (
 
 func TestApplyServiceAccount(t *testing.T) {
 	funcCalls := []spltest.MockFuncCall{{MetaName: "*v1.ServiceAccount-test-defaults"}}
-	updateFunCalls := []spltest.MockFuncCall{
-		{MetaName: "*v1.ServiceAccount-test-defaults"},
-		{MetaName: "*v1.ServiceAccount-test-defaults"}}
-	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": funcCalls}
-	updateCalls := map[string][]spltest.MockFuncCall{"Get": updateFunCalls, "Update": funcCalls}
+	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Patch": funcCalls}
+	updateCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Patch": funcCalls}
 	current := corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "defaults",
@@ -38,7 +35,7 @@ func TestApplyServiceAccount(t *testing.T) {
 		},
 	}
 	revised := current.DeepCopy()
-	revised.ResourceVersion = "dummy"
+	revised.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "registry"}}
 	reconcile := func(c *spltest.MockClient, cr interface{}) error {
 		err := ApplyServiceAccount(context.TODO(), c, cr.(*corev1.ServiceAccount))
 		return err
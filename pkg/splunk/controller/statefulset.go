@@ -22,9 +22,11 @@ import (
 
 	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -61,47 +63,60 @@ func (mgr *DefaultStatefulSetPodManager) FinishRecycle(ctx context.Context, n in
 
 // ApplyStatefulSet creates or updates a Kubernetes StatefulSet
 func ApplyStatefulSet(ctx context.Context, c splcommon.ControllerClient, revised *appsv1.StatefulSet) (enterpriseApi.Phase, error) {
+	ctx, span := tracing.StartSpan(ctx, "ApplyStatefulSet", tracing.AttributeObjectName.String(revised.GetName()))
+	defer span.End()
+
 	namespacedName := types.NamespacedName{Namespace: revised.GetNamespace(), Name: revised.GetName()}
 	var current appsv1.StatefulSet
 
+	// In every reconcile, the statefulSet spec created by the operator is compared
+	// against the one stored in etcd. While comparing the two specs, for the fields
+	// represented by slices(ports, volume mounts etc..) the order of the elements is
+	// important i.e any change in order followed by an update of statefulSet will cause
+	// a change in the UpdatedRevision field in the StatefulSpec. This inturn triggers
+	// a pod recycle unnecessarily. To avoid the same, sort the slices before applying them.
+	SortStatefulSetSlices(ctx, &revised.Spec.Template.Spec, revised.GetObjectMeta().GetName())
+
 	err := c.Get(ctx, namespacedName, &current)
 	if err != nil {
-		// In every reconcile, the statefulSet spec created by the operator is compared
-		// against the one stored in etcd. While comparing the two specs, for the fields
-		// represented by slices(ports, volume mounts etc..) the order of the elements is
-		// important i.e any change in order followed by an update of statefulSet will cause
-		// a change in the UpdatedRevision field in the StatefulSpec. This inturn triggers
-		// a pod recycle unnecessarily. To avoid the same, sort the slices during the
-		// statefulSet creation.
-		// Note: During the update scenario below, MergePodUpdates takes care of sorting.
-		SortStatefulSetSlices(ctx, &revised.Spec.Template.Spec, revised.GetObjectMeta().GetName())
-
 		// no StatefulSet exists -> just create a new one
-		err = splutil.CreateResource(ctx, c, revised)
+		err = applyObject(ctx, c, statefulSetApplyCheck, revised)
 		return enterpriseApi.PhasePending, err
 	}
 
-	// found an existing StatefulSet
-
-	// check for changes in Pod template
-	hasUpdates := MergePodUpdates(ctx, &current.Spec.Template, &revised.Spec.Template, current.GetObjectMeta().GetName())
-	*revised = current // caller expects that object passed represents latest state
+	// found an existing StatefulSet, which is compared with the same order of the slices
+	SortStatefulSetSlices(ctx, &current.Spec.Template.Spec, current.GetObjectMeta().GetName())
 
-	// only update if there are material differences, as determined by comparison function
-	if hasUpdates {
-		// this updates the desired state template, but doesn't actually modify any pods
-		// because we use an "OnUpdate" strategy https://kubernetes.io/docs/concepts/workloads/controllers/statefulset/#update-strategies
-		// note also that this ignores Replicas, which is handled below by UpdateStatefulSetPods
+	// the replicas are scaled by UpdateStatefulSetPods, and the volume claim templates can't be changed
+	revised.Spec.Replicas = current.Spec.Replicas
+	revised.Spec.VolumeClaimTemplates = current.Spec.VolumeClaimTemplates
 
-		err = splutil.UpdateResource(ctx, c, revised)
-		if err != nil {
-			return enterpriseApi.PhaseUpdating, err
+	// check for changes made by someone else than the operator, which are kept or reverted depending on the drift policy
+	drift := detectDrift(ctx, statefulSetDriftCheck, &current, revised)
+	if drift != nil && !drift.Reverted {
+		if err = drift.keep(&current, revised); err != nil {
+			return enterpriseApi.PhaseError, err
 		}
-		// always pass the latest resource back to caller
-		err = c.Get(ctx, namespacedName, revised)
+	}
+
+	// only apply if there are material differences
+	if !needsApply(ctx, statefulSetApplyCheck, &current, revised) {
+		*revised = current // caller expects that object passed represents latest state
+		return enterpriseApi.PhaseReady, nil
+	}
+
+	// this updates the desired state template, but doesn't actually modify any pods
+	// because we use an "OnUpdate" strategy https://kubernetes.io/docs/concepts/workloads/controllers/statefulset/#update-strategies
+	err = applyObject(ctx, c, statefulSetApplyCheck, revised)
+	if err != nil {
 		return enterpriseApi.PhaseUpdating, err
 	}
 
+	// the pods are recycled by UpdateStatefulSetPods when the applied pod template changed
+	if !equality.Semantic.DeepEqual(current.Spec.Template, revised.Spec.Template) {
+		return enterpriseApi.PhaseUpdating, nil
+	}
+
 	// scaling and pod updates are handled by UpdateStatefulSetPods
 	return enterpriseApi.PhaseReady, nil
 }
@@ -0,0 +1,300 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// newAppPkgCache returns an empty app package cache
+func newAppPkgCache() *appPkgCache {
+	return &appPkgCache{
+		entries: make(map[appPkgCacheKey]*appPkgCacheEntry),
+		lru:     list.New(),
+	}
+}
+
+// getAppPkgCache returns the operator wide app package cache, nil before the global resource tracker is initialized
+func getAppPkgCache() *appPkgCache {
+	if operatorResourceTracker == nil {
+		return nil
+	}
+	return operatorResourceTracker.appPkgCache
+}
+
+// getAppPkgCacheDir returns the Operator volume directory for the shared app packages
+func getAppPkgCacheDir() string {
+	return filepath.Join(splcommon.AppDownloadVolume, "appCache") + "/"
+}
+
+// getAppPkgCacheKey returns the cache key for the app package handled by the worker
+func getAppPkgCacheKey(ctx context.Context, worker *PipelineWorker) (appPkgCacheKey, error) {
+	var cacheKey appPkgCacheKey
+
+	appSrc, err := getAppSrcSpec(worker.afwConfig.AppSources, worker.appSrcName)
+	if err != nil {
+		return cacheKey, err
+	}
+
+	vol, err := splclient.GetAppSrcVolume(ctx, *appSrc, worker.afwConfig)
+	if err != nil {
+		return cacheKey, err
+	}
+
+	remoteObjectKey, err := getRemoteObjectKey(ctx, worker.cr, worker.afwConfig, worker.appSrcName, worker.appDeployInfo.AppName)
+	if err != nil {
+		return cacheKey, err
+	}
+
+	cacheKey.endpoint = vol.Endpoint
+	cacheKey.bucket = strings.Split(vol.Path, "/")[0]
+	cacheKey.key = remoteObjectKey
+	cacheKey.etag = strings.Trim(worker.appDeployInfo.ObjectHash, "\"")
+
+	return cacheKey, nil
+}
+
+// getAppPkgCacheHolder returns the reference name used by the worker's CR app source
+func getAppPkgCacheHolder(worker *PipelineWorker) string {
+	cr := worker.cr
+	return filepath.Join(cr.GetNamespace(), cr.GetObjectKind().GroupVersionKind().Kind, cr.GetName(), worker.appSrcName)
+}
+
+// getCachedAppPkgPath returns the local path of the app package, if the package is available in the cache
+func getCachedAppPkgPath(ctx context.Context, worker *PipelineWorker) (string, bool) {
+	appPkgCache := getAppPkgCache()
+	if appPkgCache == nil {
+		return "", false
+	}
+
+	cacheKey, err := getAppPkgCacheKey(ctx, worker)
+	if err != nil {
+		return "", false
+	}
+
+	return appPkgCache.lookup(cacheKey)
+}
+
+// getFilePath returns the content addressed local path for an app package
+// For e.g., app package sample_app.tgz is stored as <cache dir>/<sha256 of the cache key>_sample_app.tgz
+func (cacheKey appPkgCacheKey) getFilePath() string {
+	digest := sha256.Sum256([]byte(strings.Join([]string{cacheKey.endpoint, cacheKey.bucket, cacheKey.key, cacheKey.etag}, "|")))
+	return getAppPkgCacheDir() + hex.EncodeToString(digest[:]) + "_" + filepath.Base(cacheKey.key)
+}
+
+// purgeStaleAppPkgs removes the app packages left behind by the previous Operator run.
+// The cache index lives in memory, so these packages can not be tracked anymore.
+func (appPkgCache *appPkgCache) purgeStaleAppPkgs(ctx context.Context) {
+	appPkgCache.cleanupOnce.Do(func() {
+		reqLogger := log.FromContext(ctx)
+		scopedLog := reqLogger.WithName("purgeStaleAppPkgs")
+
+		err := os.RemoveAll(getAppPkgCacheDir())
+		if err != nil {
+			scopedLog.Error(err, "unable to clean up the app package cache directory", "path", getAppPkgCacheDir())
+		}
+	})
+}
+
+// getOrReserve returns the local path for the app package.
+// If the package is already in the cache, a reference is added for the holder and true is returned.
+// Otherwise, the storage is reserved for the package and the caller is expected to download it, then
+// call either markReady or abort.
+func (appPkgCache *appPkgCache) getOrReserve(ctx context.Context, cacheKey appPkgCacheKey, holder string, size uint64) (string, bool, error) {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("appPkgCache.getOrReserve").WithValues("key", cacheKey.key, "etag", cacheKey.etag, "holder", holder)
+
+	appPkgCache.purgeStaleAppPkgs(ctx)
+
+	appPkgCache.mutex.Lock()
+	defer appPkgCache.mutex.Unlock()
+
+	localPath := cacheKey.getFilePath()
+	if entry, ok := appPkgCache.entries[cacheKey]; ok {
+		if entry.state == appPkgCacheEntryDownloading {
+			return "", false, fmt.Errorf("app package download is already in progress")
+		}
+
+		entry.holders[holder] = struct{}{}
+		appPkgCache.lru.MoveToFront(entry.lruElem)
+		appPkgCacheHitCounter.Inc()
+		scopedLog.Info("app package found in the cache")
+		return localPath, true, nil
+	}
+
+	err := appPkgCache.reserveStorage(ctx, size)
+	if err != nil {
+		return "", false, err
+	}
+
+	err = createAppDownloadDir(ctx, getAppPkgCacheDir())
+	if err != nil {
+		releaseStorage(size)
+		return "", false, err
+	}
+
+	entry := &appPkgCacheEntry{
+		key:     cacheKey,
+		state:   appPkgCacheEntryDownloading,
+		size:    size,
+		holders: map[string]struct{}{holder: {}},
+	}
+	entry.lruElem = appPkgCache.lru.PushFront(entry)
+	appPkgCache.entries[cacheKey] = entry
+	appPkgCacheMissCounter.Inc()
+
+	return localPath, false, nil
+}
+
+// reserveStorage reserves the storage for a new app package, evicting the least recently used
+// packages without any references, when there is not enough space available.
+// Caller must hold the cache mutex
+func (appPkgCache *appPkgCache) reserveStorage(ctx context.Context, size uint64) error {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("appPkgCache.reserveStorage")
+
+	for {
+		err := reserveStorage(size)
+		if err == nil {
+			return nil
+		}
+
+		victim := appPkgCache.getEvictionCandidate()
+		if victim == nil {
+			return err
+		}
+
+		scopedLog.Info("evicting app package from the cache", "key", victim.key.key, "etag", victim.key.etag, "size", victim.size)
+		appPkgCache.removeEntry(ctx, victim)
+		appPkgCacheEvictionCounter.Inc()
+	}
+}
+
+// getEvictionCandidate returns the least recently used app package that is not referred by any CR.
+// Caller must hold the cache mutex
+func (appPkgCache *appPkgCache) getEvictionCandidate() *appPkgCacheEntry {
+	for elem := appPkgCache.lru.Back(); elem != nil; elem = elem.Prev() {
+		entry := elem.Value.(*appPkgCacheEntry)
+		if entry.state == appPkgCacheEntryReady && len(entry.holders) == 0 {
+			return entry
+		}
+	}
+	return nil
+}
+
+// removeEntry deletes the app package from the Operator pod and releases its storage.
+// Caller must hold the cache mutex
+func (appPkgCache *appPkgCache) removeEntry(ctx context.Context, entry *appPkgCacheEntry) {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("appPkgCache.removeEntry")
+
+	localPath := entry.key.getFilePath()
+	err := os.Remove(localPath)
+	if err != nil && !os.IsNotExist(err) {
+		scopedLog.Error(err, "unable to delete the app package from the cache", "path", localPath)
+	}
+
+	appPkgCache.lru.Remove(entry.lruElem)
+	delete(appPkgCache.entries, entry.key)
+	releaseStorage(entry.size)
+}
+
+// markReady marks the app package as downloaded, so that it can be shared with the other CRs
+func (appPkgCache *appPkgCache) markReady(ctx context.Context, cacheKey appPkgCacheKey) {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("appPkgCache.markReady").WithValues("key", cacheKey.key, "etag", cacheKey.etag)
+
+	appPkgCache.mutex.Lock()
+	defer appPkgCache.mutex.Unlock()
+
+	entry, ok := appPkgCache.entries[cacheKey]
+	if !ok {
+		return
+	}
+
+	// size on the CR status is only an estimate, so adjust the reservation to the actual package size
+	fileInfo, err := os.Stat(cacheKey.getFilePath())
+	if err != nil {
+		scopedLog.Error(err, "unable to get the app package size")
+	} else if actualSize := uint64(fileInfo.Size()); actualSize > entry.size {
+		if reserveStorage(actualSize-entry.size) == nil {
+			entry.size = actualSize
+		}
+	} else if actualSize < entry.size {
+		releaseStorage(entry.size - actualSize)
+		entry.size = actualSize
+	}
+
+	entry.state = appPkgCacheEntryReady
+	appPkgCache.lru.MoveToFront(entry.lruElem)
+}
+
+// abort removes the app package reserved through getOrReserve, when the download fails
+func (appPkgCache *appPkgCache) abort(ctx context.Context, cacheKey appPkgCacheKey) {
+	appPkgCache.mutex.Lock()
+	defer appPkgCache.mutex.Unlock()
+
+	entry, ok := appPkgCache.entries[cacheKey]
+	if !ok || entry.state != appPkgCacheEntryDownloading {
+		return
+	}
+
+	appPkgCache.removeEntry(ctx, entry)
+}
+
+// release drops the holder's reference to the app package. The package stays in the cache
+// until its storage is needed for some other app package.
+// Returns false if the holder was not referring to the package
+func (appPkgCache *appPkgCache) release(ctx context.Context, cacheKey appPkgCacheKey, holder string) bool {
+	appPkgCache.mutex.Lock()
+	defer appPkgCache.mutex.Unlock()
+
+	entry, ok := appPkgCache.entries[cacheKey]
+	if !ok {
+		return false
+	}
+
+	if _, ok := entry.holders[holder]; !ok {
+		return false
+	}
+
+	delete(entry.holders, holder)
+	return true
+}
+
+// lookup returns the local path of the app package, if it is available in the cache
+func (appPkgCache *appPkgCache) lookup(cacheKey appPkgCacheKey) (string, bool) {
+	appPkgCache.mutex.Lock()
+	defer appPkgCache.mutex.Unlock()
+
+	entry, ok := appPkgCache.entries[cacheKey]
+	if !ok || entry.state != appPkgCacheEntryReady {
+		return "", false
+	}
+
+	appPkgCache.lru.MoveToFront(entry.lruElem)
+	return cacheKey.getFilePath(), true
+}
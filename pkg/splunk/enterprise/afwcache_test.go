@@ -0,0 +1,249 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func setupAppPkgCacheTest(t *testing.T, availableDiskSpace uint64) (*appPkgCache, func()) {
+	defaultVol := splcommon.AppDownloadVolume
+	splcommon.AppDownloadVolume = t.TempDir() + "/"
+
+	defaultTracker := operatorResourceTracker
+	operatorResourceTracker = &globalResourceTracker{
+		storage: &storageTracker{
+			availableDiskSpace: availableDiskSpace,
+		},
+		appPkgCache: newAppPkgCache(),
+	}
+
+	return operatorResourceTracker.appPkgCache, func() {
+		splcommon.AppDownloadVolume = defaultVol
+		operatorResourceTracker = defaultTracker
+	}
+}
+
+func TestGetAppPkgCacheKey(t *testing.T) {
+	ctx := context.TODO()
+	cr := enterpriseApi.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Spec: enterpriseApi.StandaloneSpec{
+			AppFrameworkConfig: enterpriseApi.AppFrameworkSpec{
+				VolList: []enterpriseApi.VolumeSpec{
+					{Name: "msos_s2s3_vol", Endpoint: "https://s3-eu-west-2.amazonaws.com", Path: "testbucket-rs-london/apps", SecretRef: "s3-secret", Type: "s3", Provider: "aws"},
+				},
+				AppSources: []enterpriseApi.AppSourceSpec{
+					{Name: "adminApps",
+						Location: "adminAppsRepo",
+						AppSourceDefaultSpec: enterpriseApi.AppSourceDefaultSpec{
+							VolName: "msos_s2s3_vol",
+							Scope:   enterpriseApi.ScopeLocal},
+					},
+				},
+			},
+		},
+	}
+
+	worker := &PipelineWorker{
+		cr:         &cr,
+		appSrcName: "adminApps",
+		afwConfig:  &cr.Spec.AppFrameworkConfig,
+		appDeployInfo: &enterpriseApi.AppDeploymentInfo{
+			AppName:    "app1.tgz",
+			ObjectHash: "\"abcd1234\"",
+		},
+	}
+
+	cacheKey, err := getAppPkgCacheKey(ctx, worker)
+	if err != nil {
+		t.Errorf("unable to get the cache key, error: %v", err)
+	}
+
+	expectedKey := appPkgCacheKey{
+		endpoint: "https://s3-eu-west-2.amazonaws.com",
+		bucket:   "testbucket-rs-london",
+		key:      "apps/adminAppsRepo/app1.tgz",
+		etag:     "abcd1234",
+	}
+	if cacheKey != expectedKey {
+		t.Errorf("got cache key %v, expected %v", cacheKey, expectedKey)
+	}
+
+	if holder := getAppPkgCacheHolder(worker); holder != "test/Standalone/stack1/adminApps" {
+		t.Errorf("incorrect cache holder %s", holder)
+	}
+
+	// same package from a different CR should map to the same cache key
+	cr2 := cr
+	cr2.Name = "stack2"
+	worker.cr = &cr2
+	cacheKey2, _ := getAppPkgCacheKey(ctx, worker)
+	if cacheKey2 != cacheKey {
+		t.Errorf("same app package should share the cache key across the CRs")
+	}
+
+	// unknown app source should return an error
+	worker.appSrcName = "invalidAppSrc"
+	_, err = getAppPkgCacheKey(ctx, worker)
+	if err == nil {
+		t.Errorf("should return an error for an invalid app source")
+	}
+}
+
+func TestAppPkgCacheGetOrReserve(t *testing.T) {
+	ctx := context.TODO()
+	appPkgCache, cleanup := setupAppPkgCacheTest(t, 1000)
+	defer cleanup()
+
+	cacheKey := appPkgCacheKey{bucket: "bucket", key: "apps/app1.tgz", etag: "abcd"}
+
+	// first request should be a miss, with the storage reserved
+	localPath, found, err := appPkgCache.getOrReserve(ctx, cacheKey, "test/Standalone/s1/admin", 100)
+	if err != nil || found {
+		t.Errorf("first request should be a cache miss, found: %t, err: %v", found, err)
+	}
+	if operatorResourceTracker.storage.availableDiskSpace != 900 {
+		t.Errorf("storage should be reserved for the app package")
+	}
+
+	// while the download is in progress, other CRs should wait
+	_, _, err = appPkgCache.getOrReserve(ctx, cacheKey, "test/Standalone/s2/admin", 100)
+	if err == nil {
+		t.Errorf("should return an error while the download is in progress")
+	}
+
+	// pod copy should not find the package until it is ready
+	if _, ok := appPkgCache.lookup(cacheKey); ok {
+		t.Errorf("app package should not be available before the download is complete")
+	}
+
+	err = os.WriteFile(localPath, make([]byte, 60), 0644)
+	if err != nil {
+		t.Errorf("unable to create the app package, error: %v", err)
+	}
+	appPkgCache.markReady(ctx, cacheKey)
+
+	// reservation should be adjusted to the actual size
+	if operatorResourceTracker.storage.availableDiskSpace != 940 {
+		t.Errorf("reservation should match the app package size, available: %d", operatorResourceTracker.storage.availableDiskSpace)
+	}
+
+	// second CR should reuse the package without any new reservation
+	localPath2, found, err := appPkgCache.getOrReserve(ctx, cacheKey, "test/Standalone/s2/admin", 100)
+	if err != nil || !found || localPath2 != localPath {
+		t.Errorf("second request should be a cache hit, found: %t, err: %v", found, err)
+	}
+	if operatorResourceTracker.storage.availableDiskSpace != 940 {
+		t.Errorf("cache hit should not reserve any storage")
+	}
+
+	if path, ok := appPkgCache.lookup(cacheKey); !ok || path != localPath {
+		t.Errorf("pod copy should find the cached app package")
+	}
+
+	if len(appPkgCache.entries[cacheKey].holders) != 2 {
+		t.Errorf("both the CRs should be referring to the app package")
+	}
+}
+
+func TestAppPkgCacheAbort(t *testing.T) {
+	ctx := context.TODO()
+	appPkgCache, cleanup := setupAppPkgCacheTest(t, 1000)
+	defer cleanup()
+
+	cacheKey := appPkgCacheKey{bucket: "bucket", key: "apps/app1.tgz", etag: "abcd"}
+
+	_, _, err := appPkgCache.getOrReserve(ctx, cacheKey, "test/Standalone/s1/admin", 100)
+	if err != nil {
+		t.Errorf("unable to reserve the app package, error: %v", err)
+	}
+
+	appPkgCache.abort(ctx, cacheKey)
+	if _, ok := appPkgCache.entries[cacheKey]; ok {
+		t.Errorf("failed download should be removed from the cache")
+	}
+	if operatorResourceTracker.storage.availableDiskSpace != 1000 {
+		t.Errorf("failed download should release the storage")
+	}
+}
+
+func TestAppPkgCacheEviction(t *testing.T) {
+	ctx := context.TODO()
+	appPkgCache, cleanup := setupAppPkgCacheTest(t, 250)
+	defer cleanup()
+
+	addAppPkg := func(cacheKey appPkgCacheKey, holder string) {
+		localPath, _, err := appPkgCache.getOrReserve(ctx, cacheKey, holder, 100)
+		if err != nil {
+			t.Errorf("unable to reserve the app package, error: %v", err)
+			return
+		}
+		err = os.WriteFile(localPath, make([]byte, 100), 0644)
+		if err != nil {
+			t.Errorf("unable to create the app package, error: %v", err)
+		}
+		appPkgCache.markReady(ctx, cacheKey)
+	}
+
+	key1 := appPkgCacheKey{bucket: "bucket", key: "apps/app1.tgz", etag: "1"}
+	key2 := appPkgCacheKey{bucket: "bucket", key: "apps/app2.tgz", etag: "2"}
+	key3 := appPkgCacheKey{bucket: "bucket", key: "apps/app3.tgz", etag: "3"}
+
+	addAppPkg(key1, "test/Standalone/s1/admin")
+	addAppPkg(key2, "test/Standalone/s1/admin")
+
+	// packages still in use should not be evicted
+	_, _, err := appPkgCache.getOrReserve(ctx, key3, "test/Standalone/s1/admin", 100)
+	if err == nil {
+		t.Errorf("should not evict the app packages in use")
+	}
+
+	// once released, the least recently used package should be evicted
+	appPkgCache.release(ctx, key1, "test/Standalone/s1/admin")
+	appPkgCache.release(ctx, key2, "test/Standalone/s1/admin")
+	appPkgCache.lookup(key1)
+
+	addAppPkg(key3, "test/Standalone/s1/admin")
+	if _, ok := appPkgCache.entries[key2]; ok {
+		t.Errorf("least recently used app package should be evicted")
+	}
+	if _, err := os.Stat(key2.getFilePath()); !os.IsNotExist(err) {
+		t.Errorf("evicted app package should be removed from the disk")
+	}
+	if _, ok := appPkgCache.entries[key1]; !ok {
+		t.Errorf("recently used app package should not be evicted")
+	}
+	if operatorResourceTracker.storage.availableDiskSpace != 50 {
+		t.Errorf("incorrect available storage after eviction: %d", operatorResourceTracker.storage.availableDiskSpace)
+	}
+
+	// release by an unknown holder should be a no-op
+	if appPkgCache.release(ctx, key1, "test/Standalone/s2/admin") {
+		t.Errorf("release should fail for a holder not referring to the app package")
+	}
+}
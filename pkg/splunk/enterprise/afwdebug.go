@@ -0,0 +1,269 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+)
+
+// AppFrameworkDebugInfo is the live state of the App Framework of the operator, as returned by the introspection endpoint
+type AppFrameworkDebugInfo struct {
+	// Storage of the operator pod used for the app packages
+	Storage StorageDebugInfo `json:"storage"`
+
+	// App packages shared across the CRs
+	AppPkgCache []AppPkgCacheDebugInfo `json:"appPkgCache"`
+
+	// Resource mutexes currently held
+	ResourceMutexHolders []ResourceMutexDebugInfo `json:"resourceMutexHolders"`
+
+	// Pipelines of the App Framework scheduler currently running
+	Pipelines []AppInstallPipelineDebugInfo `json:"pipelines"`
+}
+
+// StorageDebugInfo is the state of the storage tracker of the operator pod
+type StorageDebugInfo struct {
+	// Set when a persistent volume is configured for the operator pod
+	Configured bool `json:"configured"`
+
+	// Disk space available for new app packages, in bytes
+	AvailableDiskSpace uint64 `json:"availableDiskSpace"`
+
+	// Disk space reserved by the app packages, in bytes
+	ReservedDiskSpace uint64 `json:"reservedDiskSpace"`
+}
+
+// AppPkgCacheDebugInfo is an app package of the shared app package cache
+type AppPkgCacheDebugInfo struct {
+	Bucket  string   `json:"bucket"`
+	Key     string   `json:"key"`
+	Etag    string   `json:"etag"`
+	State   string   `json:"state"`
+	Size    uint64   `json:"size"`
+	Holders []string `json:"holders"`
+}
+
+// ResourceMutexDebugInfo identifies the holder of a resource mutex
+type ResourceMutexDebugInfo struct {
+	Resource string    `json:"resource"`
+	CR       string    `json:"cr"`
+	Function string    `json:"function"`
+	Since    time.Time `json:"since"`
+}
+
+// AppInstallPipelineDebugInfo is the state of the App Framework pipeline of a CR
+type AppInstallPipelineDebugInfo struct {
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	EntryTime time.Time `json:"entryTime"`
+
+	// Workers queued in each phase of the pipeline
+	Phases map[enterpriseApi.AppPhaseType][]PipelineWorkerDebugInfo `json:"phases"`
+
+	// Install slots of each pod, which allow one install at a time
+	InstallSlots []PodInstallSlotDebugInfo `json:"installSlots,omitempty"`
+}
+
+// PipelineWorkerDebugInfo is a worker of a pipeline phase
+type PipelineWorkerDebugInfo struct {
+	AppSource string                           `json:"appSource"`
+	AppName   string                           `json:"appName"`
+	Pod       string                           `json:"pod,omitempty"`
+	Active    bool                             `json:"active"`
+	FanOut    bool                             `json:"fanOut,omitempty"`
+	Status    enterpriseApi.AppPhaseStatusType `json:"status,omitempty"`
+	FailCount uint32                           `json:"failCount,omitempty"`
+	LastError string                           `json:"lastError,omitempty"`
+}
+
+// PodInstallSlotDebugInfo is the number of installs running on a pod
+type PodInstallSlotDebugInfo struct {
+	Pod      string `json:"pod"`
+	InUse    int    `json:"inUse"`
+	Capacity int    `json:"capacity"`
+}
+
+// activeAppInstallPipelines tracks the App Framework pipelines currently running
+var activeAppInstallPipelines = struct {
+	mutex     sync.Mutex
+	pipelines map[*AppInstallPipeline]struct{}
+}{
+	pipelines: make(map[*AppInstallPipeline]struct{}),
+}
+
+// registerAppInstallPipeline exposes a pipeline on the introspection endpoint
+func registerAppInstallPipeline(ppln *AppInstallPipeline) {
+	activeAppInstallPipelines.mutex.Lock()
+	defer activeAppInstallPipelines.mutex.Unlock()
+	activeAppInstallPipelines.pipelines[ppln] = struct{}{}
+}
+
+// unregisterAppInstallPipeline removes a pipeline from the introspection endpoint, once the scheduler yields
+func unregisterAppInstallPipeline(ppln *AppInstallPipeline) {
+	activeAppInstallPipelines.mutex.Lock()
+	defer activeAppInstallPipelines.mutex.Unlock()
+	delete(activeAppInstallPipelines.pipelines, ppln)
+}
+
+// GetAppFrameworkDebugInfo returns the live state of the App Framework. Only the pipelines of the CRs of namespace are
+// returned, unless namespace is empty
+func GetAppFrameworkDebugInfo(namespace string) AppFrameworkDebugInfo {
+	debugInfo := AppFrameworkDebugInfo{
+		AppPkgCache:          []AppPkgCacheDebugInfo{},
+		ResourceMutexHolders: []ResourceMutexDebugInfo{},
+		Pipelines:            []AppInstallPipelineDebugInfo{},
+	}
+	if operatorResourceTracker == nil {
+		return debugInfo
+	}
+
+	// storage tracker
+	if isPersistantVolConfigured() {
+		sTracker := operatorResourceTracker.storage
+		sTracker.mutex.Lock()
+		debugInfo.Storage = StorageDebugInfo{
+			Configured:         true,
+			AvailableDiskSpace: sTracker.availableDiskSpace,
+			ReservedDiskSpace:  sTracker.reservedDiskSpace,
+		}
+		sTracker.mutex.Unlock()
+	}
+
+	// app package cache
+	if appPkgCache := getAppPkgCache(); appPkgCache != nil {
+		appPkgCache.mutex.Lock()
+		for _, entry := range appPkgCache.entries {
+			entryInfo := AppPkgCacheDebugInfo{
+				Bucket:  entry.key.bucket,
+				Key:     entry.key.key,
+				Etag:    entry.key.etag,
+				State:   "downloading",
+				Size:    entry.size,
+				Holders: []string{},
+			}
+			if entry.state == appPkgCacheEntryReady {
+				entryInfo.State = "ready"
+			}
+			for holder := range entry.holders {
+				entryInfo.Holders = append(entryInfo.Holders, holder)
+			}
+			sort.Strings(entryInfo.Holders)
+			debugInfo.AppPkgCache = append(debugInfo.AppPkgCache, entryInfo)
+		}
+		appPkgCache.mutex.Unlock()
+		sort.Slice(debugInfo.AppPkgCache, func(i, j int) bool {
+			return debugInfo.AppPkgCache[i].Key < debugInfo.AppPkgCache[j].Key
+		})
+	}
+
+	// resource mutex holders
+	if commonResourceTracker := operatorResourceTracker.commonResourceTracker; commonResourceTracker != nil {
+		commonResourceTracker.mutex.Lock()
+		for resourceName, holder := range commonResourceTracker.mutexHolders {
+			debugInfo.ResourceMutexHolders = append(debugInfo.ResourceMutexHolders, ResourceMutexDebugInfo{
+				Resource: resourceName,
+				CR:       holder.cr,
+				Function: holder.function,
+				Since:    holder.since,
+			})
+		}
+		commonResourceTracker.mutex.Unlock()
+		sort.Slice(debugInfo.ResourceMutexHolders, func(i, j int) bool {
+			return debugInfo.ResourceMutexHolders[i].Resource < debugInfo.ResourceMutexHolders[j].Resource
+		})
+	}
+
+	// pipelines
+	activeAppInstallPipelines.mutex.Lock()
+	for ppln := range activeAppInstallPipelines.pipelines {
+		if namespace == "" || ppln.cr.GetNamespace() == namespace {
+			debugInfo.Pipelines = append(debugInfo.Pipelines, getAppInstallPipelineDebugInfo(ppln))
+		}
+	}
+	activeAppInstallPipelines.mutex.Unlock()
+	sort.Slice(debugInfo.Pipelines, func(i, j int) bool {
+		a, b := debugInfo.Pipelines[i], debugInfo.Pipelines[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Kind < b.Kind
+	})
+
+	return debugInfo
+}
+
+// publishDebugInfo publishes a snapshot of the workers of the phase for the debug endpoint. It's called by the phase
+// manager, which owns the workers of the phase, so that the debug endpoint never reads the workers while the phase
+// manager or its worker goroutines update them
+func (pplnPhase *PipelinePhase) publishDebugInfo() {
+	pplnPhase.mutex.Lock()
+	defer pplnPhase.mutex.Unlock()
+
+	workers := make([]PipelineWorkerDebugInfo, 0, len(pplnPhase.q))
+	for _, worker := range pplnPhase.q {
+		workerInfo := PipelineWorkerDebugInfo{
+			AppSource: worker.appSrcName,
+			Pod:       worker.targetPodName,
+			Active:    worker.isActive,
+			FanOut:    worker.fanOut,
+		}
+		if worker.appDeployInfo != nil {
+			workerInfo.AppName = worker.appDeployInfo.AppName
+			workerInfo.Status = worker.appDeployInfo.PhaseInfo.Status
+			workerInfo.FailCount = worker.appDeployInfo.PhaseInfo.FailCount
+			workerInfo.LastError = worker.appDeployInfo.PhaseInfo.LastError
+		}
+		workers = append(workers, workerInfo)
+	}
+	pplnPhase.debugWorkers = workers
+}
+
+// getAppInstallPipelineDebugInfo returns the last published state of the phases, and the install slots of a pipeline
+func getAppInstallPipelineDebugInfo(ppln *AppInstallPipeline) AppInstallPipelineDebugInfo {
+	pplnInfo := AppInstallPipelineDebugInfo{
+		Kind:      ppln.cr.GetObjectKind().GroupVersionKind().Kind,
+		Namespace: ppln.cr.GetNamespace(),
+		Name:      ppln.cr.GetName(),
+		EntryTime: time.Unix(ppln.afwEntryTime, 0).UTC(),
+		Phases:    make(map[enterpriseApi.AppPhaseType][]PipelineWorkerDebugInfo, len(ppln.pplnPhases)),
+	}
+
+	for phaseType, pplnPhase := range ppln.pplnPhases {
+		pplnPhase.mutex.Lock()
+		workers := pplnPhase.debugWorkers
+		if workers == nil {
+			workers = []PipelineWorkerDebugInfo{}
+		}
+		for i, installSlot := range pplnPhase.installTracker {
+			pplnInfo.InstallSlots = append(pplnInfo.InstallSlots, PodInstallSlotDebugInfo{
+				Pod:      getApplicablePodNameForAppFramework(ppln.cr, i),
+				InUse:    len(installSlot),
+				Capacity: cap(installSlot),
+			})
+		}
+		pplnPhase.mutex.Unlock()
+		pplnInfo.Phases[phaseType] = workers
+	}
+
+	return pplnInfo
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+)
+
+func TestGetAppFrameworkDebugInfo(t *testing.T) {
+	defaultTracker := operatorResourceTracker
+	operatorResourceTracker = &globalResourceTracker{
+		storage: &storageTracker{
+			availableDiskSpace: 1000,
+		},
+		commonResourceTracker: &commonResourceTracker{
+			mutexMap: make(map[string]*sync.Mutex),
+		},
+		appPkgCache: newAppPkgCache(),
+	}
+	defer func() {
+		operatorResourceTracker = defaultTracker
+	}()
+
+	cr := &enterpriseApi.Standalone{
+		TypeMeta:   metav1.TypeMeta{Kind: "Standalone"},
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+	}
+
+	// storage reservations
+	if err := reserveStorage(300); err != nil {
+		t.Fatalf("reserveStorage() returned error: %v", err)
+	}
+	debugInfo := GetAppFrameworkDebugInfo("")
+	if !debugInfo.Storage.Configured || debugInfo.Storage.AvailableDiskSpace != 700 || debugInfo.Storage.ReservedDiskSpace != 300 {
+		t.Errorf("GetAppFrameworkDebugInfo() storage = %+v; want 700 bytes available and 300 bytes reserved", debugInfo.Storage)
+	}
+	releaseStorage(300)
+
+	// resource mutex holders
+	unlock := lockResourceMutex("splunk-test-manual-app-update", cr, "ApplyManualAppUpdateConfigMap")
+	debugInfo = GetAppFrameworkDebugInfo("")
+	if len(debugInfo.ResourceMutexHolders) != 1 {
+		t.Fatalf("GetAppFrameworkDebugInfo() returned %d mutex holders; want 1", len(debugInfo.ResourceMutexHolders))
+	}
+	holder := debugInfo.ResourceMutexHolders[0]
+	if holder.Resource != "splunk-test-manual-app-update" || holder.CR != "test/stack1" || holder.Function != "ApplyManualAppUpdateConfigMap" {
+		t.Errorf("GetAppFrameworkDebugInfo() mutex holder = %+v", holder)
+	}
+	unlock()
+	if debugInfo = GetAppFrameworkDebugInfo(""); len(debugInfo.ResourceMutexHolders) != 0 {
+		t.Errorf("GetAppFrameworkDebugInfo() returned a mutex holder after the mutex was unlocked")
+	}
+
+	// live pipeline
+	ppln := &AppInstallPipeline{
+		pplnPhases:   make(map[enterpriseApi.AppPhaseType]*PipelinePhase, 3),
+		afwEntryTime: time.Now().Unix(),
+		cr:           cr,
+	}
+	initPipelinePhase(ppln, enterpriseApi.PhaseDownload)
+	initPipelinePhase(ppln, enterpriseApi.PhasePodCopy)
+	initPipelinePhase(ppln, enterpriseApi.PhaseInstall)
+	ppln.pplnPhases[enterpriseApi.PhaseDownload].q = append(ppln.pplnPhases[enterpriseApi.PhaseDownload].q, &PipelineWorker{
+		appSrcName: "appSrc1",
+		appDeployInfo: &enterpriseApi.AppDeploymentInfo{
+			AppName:   "app1.tgz",
+			PhaseInfo: enterpriseApi.PhaseInfo{Phase: enterpriseApi.PhaseDownload, Status: enterpriseApi.AppPkgDownloadInProgress, FailCount: 1},
+		},
+		targetPodName: "splunk-stack1-standalone-0",
+		isActive:      true,
+		cr:            cr,
+	})
+	installSlot := make(chan struct{}, maxParallelInstallsPerPod)
+	installSlot <- struct{}{}
+	ppln.pplnPhases[enterpriseApi.PhaseInstall].installTracker = []chan struct{}{installSlot}
+
+	// the workers are reported once published by the phase manager
+	registerAppInstallPipeline(ppln)
+	debugInfo = GetAppFrameworkDebugInfo("test")
+	if len(debugInfo.Pipelines) != 1 || len(debugInfo.Pipelines[0].Phases[enterpriseApi.PhaseDownload]) != 0 {
+		t.Errorf("GetAppFrameworkDebugInfo() returned workers before they were published")
+	}
+	ppln.pplnPhases[enterpriseApi.PhaseDownload].publishDebugInfo()
+	if debugInfo = GetAppFrameworkDebugInfo("other"); len(debugInfo.Pipelines) != 0 {
+		t.Errorf("GetAppFrameworkDebugInfo() returned %d pipelines for another namespace; want 0", len(debugInfo.Pipelines))
+	}
+	debugInfo = GetAppFrameworkDebugInfo("test")
+	unregisterAppInstallPipeline(ppln)
+	if len(debugInfo.Pipelines) != 1 {
+		t.Fatalf("GetAppFrameworkDebugInfo() returned %d pipelines; want 1", len(debugInfo.Pipelines))
+	}
+	pplnInfo := debugInfo.Pipelines[0]
+	if pplnInfo.Kind != "Standalone" || pplnInfo.Namespace != "test" || pplnInfo.Name != "stack1" {
+		t.Errorf("GetAppFrameworkDebugInfo() pipeline = %s %s/%s; want Standalone test/stack1", pplnInfo.Kind, pplnInfo.Namespace, pplnInfo.Name)
+	}
+	workers := pplnInfo.Phases[enterpriseApi.PhaseDownload]
+	if len(workers) != 1 || workers[0].AppName != "app1.tgz" || !workers[0].Active || workers[0].Status != enterpriseApi.AppPkgDownloadInProgress || workers[0].FailCount != 1 {
+		t.Errorf("GetAppFrameworkDebugInfo() download workers = %+v", workers)
+	}
+	if len(pplnInfo.Phases[enterpriseApi.PhaseInstall]) != 0 {
+		t.Errorf("GetAppFrameworkDebugInfo() returned install workers; want none")
+	}
+	if len(pplnInfo.InstallSlots) != 1 || pplnInfo.InstallSlots[0].Pod != "splunk-stack1-standalone-0" || pplnInfo.InstallSlots[0].InUse != 1 {
+		t.Errorf("GetAppFrameworkDebugInfo() install slots = %+v", pplnInfo.InstallSlots)
+	}
+
+	if debugInfo = GetAppFrameworkDebugInfo(""); len(debugInfo.Pipelines) != 0 {
+		t.Errorf("GetAppFrameworkDebugInfo() returned an unregistered pipeline")
+	}
+}
+
+func TestAppFrameworkDebugInfoConcurrentPhases(t *testing.T) {
+	// the debug endpoint reads the pipeline while the phase managers update it
+	ppln := newConcurrentPhasesTestPipeline(t)
+	registerAppInstallPipeline(ppln)
+	defer unregisterAppInstallPipeline(ppln)
+
+	runConcurrentPhases(ppln, func() {
+		GetAppFrameworkDebugInfo("test")
+	})
+
+	debugInfo := GetAppFrameworkDebugInfo("test")
+	if len(debugInfo.Pipelines) != 1 || len(debugInfo.Pipelines[0].InstallSlots) != 1 {
+		t.Errorf("GetAppFrameworkDebugInfo() returned %+v; want 1 pipeline with 1 install slot", debugInfo.Pipelines)
+	}
+}
@@ -0,0 +1,427 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// app.conf stanza and key used to declare the app dependencies
+	appConfDependencyStanza = "install"
+	appConfDependencyKey    = "dependencies"
+)
+
+// appManifest represents the fields of app.manifest used by the App Framework
+type appManifest struct {
+	Info struct {
+		ID struct {
+			Name string `json:"name"`
+		} `json:"id"`
+	} `json:"info"`
+	Dependencies map[string]json.RawMessage `json:"dependencies"`
+}
+
+// getAppIDFromPkgName derives the app ID from the app package name
+// For e.g., app ID for the app package sample_app.tgz is sample_app
+func getAppIDFromPkgName(appName string) string {
+	for _, ext := range []string{".tar.gz", ".tgz", ".spl", ".tar"} {
+		if strings.HasSuffix(appName, ext) {
+			return strings.TrimSuffix(appName, ext)
+		}
+	}
+	return appName
+}
+
+// parseAppManifestDependencies returns the app ID and the dependencies declared in app.manifest
+func parseAppManifestDependencies(data []byte) (string, []string, error) {
+	var manifest appManifest
+	err := json.Unmarshal(data, &manifest)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var dependencies []string
+	for appID := range manifest.Dependencies {
+		dependencies = append(dependencies, appID)
+	}
+	sort.Strings(dependencies)
+
+	return manifest.Info.ID.Name, dependencies, nil
+}
+
+// parseAppConfDependencies returns the comma separated list of dependencies from the app.conf
+// For e.g.,
+// [install]
+// dependencies = Splunk_TA_nix, Splunk_SA_CIM
+func parseAppConfDependencies(r io.Reader) []string {
+	var dependencies []string
+	var stanza string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			stanza = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		if stanza != appConfDependencyStanza {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) != appConfDependencyKey {
+			continue
+		}
+
+		for _, appID := range strings.Split(kv[1], ",") {
+			appID = strings.TrimSpace(appID)
+			if appID != "" {
+				dependencies = append(dependencies, appID)
+			}
+		}
+	}
+
+	return dependencies
+}
+
+// readAppPkgDependencies reads the app ID and the dependencies from the app package.
+// Dependencies from app.manifest take precedence over the ones from app.conf
+func readAppPkgDependencies(ctx context.Context, appPkgPath string) (string, []string, error) {
+	file, err := os.Open(appPkgPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return "", nil, err
+	}
+	defer gzipReader.Close()
+
+	var appID, manifestAppID string
+	var confDependencies, manifestDependencies []string
+	var foundManifest bool
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		// app package has a single top level directory, named after the app ID
+		name := strings.TrimPrefix(path.Clean(header.Name), "./")
+		parts := strings.SplitN(name, "/", 2)
+		if appID == "" && parts[0] != "." {
+			appID = parts[0]
+		}
+		if len(parts) != 2 || header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		switch parts[1] {
+		case "app.manifest":
+			data, err := ioutil.ReadAll(tarReader)
+			if err != nil {
+				return "", nil, err
+			}
+			manifestAppID, manifestDependencies, err = parseAppManifestDependencies(data)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid app.manifest, error: %v", err)
+			}
+			foundManifest = true
+		case "default/app.conf":
+			confDependencies = parseAppConfDependencies(tarReader)
+		}
+	}
+
+	if manifestAppID != "" {
+		appID = manifestAppID
+	}
+
+	if foundManifest && len(manifestDependencies) > 0 {
+		return appID, manifestDependencies, nil
+	}
+	return appID, confDependencies, nil
+}
+
+// updateAppDependencyInfo updates the app ID and the dependencies, once the app package is downloaded
+func (ppln *AppInstallPipeline) updateAppDependencyInfo(ctx context.Context, worker *PipelineWorker) {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("updateAppDependencyInfo").WithValues("name", worker.cr.GetName(), "namespace", worker.cr.GetNamespace(), "app name", worker.appDeployInfo.AppName)
+
+	appDeployInfo := worker.appDeployInfo
+	appID := getAppIDFromPkgName(appDeployInfo.AppName)
+
+	appPkgLocalPath, ok := getCachedAppPkgPath(ctx, worker)
+	if !ok {
+		appPkgLocalPath = getAppPackageLocalPath(ctx, worker)
+	}
+
+	pkgAppID, dependencies, err := readAppPkgDependencies(ctx, appPkgLocalPath)
+	if err != nil {
+		scopedLog.Info("unable to read the app dependencies, assuming no dependencies", "reason", err.Error())
+	} else if pkgAppID != "" {
+		appID = pkgAppID
+	}
+
+	ppln.dependencyMutex.Lock()
+	defer ppln.dependencyMutex.Unlock()
+
+	appDeployInfo.AppID = appID
+	appDeployInfo.Dependencies = dependencies
+
+	if len(dependencies) > 0 {
+		scopedLog.Info("app dependencies", "app ID", appID, "dependencies", dependencies)
+	}
+}
+
+// validateAppDependencies updates the dependency errors of the apps of the pipeline, while the download phase may
+// update their app IDs and dependencies
+func (ppln *AppInstallPipeline) validateAppDependencies(ctx context.Context) map[string]*enterpriseApi.AppDeploymentInfo {
+	ppln.dependencyMutex.Lock()
+	defer ppln.dependencyMutex.Unlock()
+
+	return validateAppDependencies(ctx, ppln.appDeployContext)
+}
+
+// getLocalScopedAppsByID returns the map of app ID:app deploy info for all the active local scoped apps.
+// Apps not yet downloaded do not have an app ID, and are returned in a separate list
+func getLocalScopedAppsByID(ctx context.Context, appDeployContext *enterpriseApi.AppDeploymentContext) (map[string]*enterpriseApi.AppDeploymentInfo, []*enterpriseApi.AppDeploymentInfo) {
+	appsByID := make(map[string]*enterpriseApi.AppDeploymentInfo)
+	var unknownApps []*enterpriseApi.AppDeploymentInfo
+
+	// iterate over the app sources in a fixed order, so that the duplicate app IDs are resolved consistently
+	var appSrcNames []string
+	for appSrcName := range appDeployContext.AppsSrcDeployStatus {
+		appSrcNames = append(appSrcNames, appSrcName)
+	}
+	sort.Strings(appSrcNames)
+
+	for _, appSrcName := range appSrcNames {
+		if getAppSrcScope(ctx, &appDeployContext.AppFrameworkConfig, appSrcName) != enterpriseApi.ScopeLocal {
+			continue
+		}
+
+		deployInfoList := appDeployContext.AppsSrcDeployStatus[appSrcName].AppDeploymentInfoList
+		for i := range deployInfoList {
+			appDeployInfo := &deployInfoList[i]
+			if appDeployInfo.RepoState != enterpriseApi.RepoStateActive {
+				continue
+			}
+
+			appID := appDeployInfo.AppID
+			// apps installed before the dependency support do not have an app ID
+			if appID == "" && appDeployInfo.DeployStatus == enterpriseApi.DeployStatusComplete {
+				appID = getAppIDFromPkgName(appDeployInfo.AppName)
+			}
+
+			if appID == "" {
+				unknownApps = append(unknownApps, appDeployInfo)
+			} else if _, ok := appsByID[appID]; !ok {
+				appsByID[appID] = appDeployInfo
+			}
+		}
+	}
+
+	return appsByID, unknownApps
+}
+
+// isAppDeploymentFailed checks if the app reached the max. retries in any of the phases, or can not be installed due to its dependencies
+func isAppDeploymentFailed(ctx context.Context, appDeployInfo *enterpriseApi.AppDeploymentInfo, afwConfig *enterpriseApi.AppFrameworkSpec) bool {
+	if appDeployInfo.DependencyError != "" || isPhaseMaxRetriesReached(ctx, &appDeployInfo.PhaseInfo, afwConfig) {
+		return true
+	}
+
+	for i := range appDeployInfo.AuxPhaseInfo {
+		if isPhaseMaxRetriesReached(ctx, &appDeployInfo.AuxPhaseInfo[i], afwConfig) {
+			return true
+		}
+	}
+	return false
+}
+
+// findAppDependencyCycles returns the apps that are part of a dependency cycle, mapped to the cycle path
+func findAppDependencyCycles(appsByID map[string]*enterpriseApi.AppDeploymentInfo) map[string]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	cycles := make(map[string]string)
+	state := make(map[string]int, len(appsByID))
+	var stack []string
+
+	var visit func(appID string)
+	visit = func(appID string) {
+		state[appID] = visiting
+		stack = append(stack, appID)
+
+		for _, dependency := range appsByID[appID].Dependencies {
+			if _, ok := appsByID[dependency]; !ok {
+				continue
+			}
+
+			switch state[dependency] {
+			case unvisited:
+				visit(dependency)
+			case visiting:
+				// found a back edge, every app from the dependency till the top of the stack is in the cycle
+				var start int
+				for start = len(stack) - 1; stack[start] != dependency; start-- {
+				}
+				cyclePath := strings.Join(append(append([]string{}, stack[start:]...), dependency), " -> ")
+				for _, member := range stack[start:] {
+					if _, ok := cycles[member]; !ok {
+						cycles[member] = cyclePath
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[appID] = visited
+	}
+
+	appIDs := make([]string, 0, len(appsByID))
+	for appID := range appsByID {
+		appIDs = append(appIDs, appID)
+	}
+	sort.Strings(appIDs)
+
+	for _, appID := range appIDs {
+		if state[appID] == unvisited {
+			visit(appID)
+		}
+	}
+
+	return cycles
+}
+
+// validateAppDependencies updates the dependency errors for all the local scoped apps.
+// An app can not be installed if it is part of a dependency cycle, or if any of its dependencies is either
+// missing from the app sources or failed to install.
+func validateAppDependencies(ctx context.Context, appDeployContext *enterpriseApi.AppDeploymentContext) map[string]*enterpriseApi.AppDeploymentInfo {
+	if appDeployContext == nil {
+		return nil
+	}
+
+	appsByID, unknownApps := getLocalScopedAppsByID(ctx, appDeployContext)
+	afwConfig := &appDeployContext.AppFrameworkConfig
+
+	// apps failed to download will never get an app ID, so they can not be pending dependencies
+	var pendingApps int
+	for _, appDeployInfo := range unknownApps {
+		if !isAppDeploymentFailed(ctx, appDeployInfo, afwConfig) {
+			pendingApps++
+		}
+	}
+
+	cycles := findAppDependencyCycles(appsByID)
+
+	// resolve the apps in the topological order, so that the failures are propagated to the dependent apps
+	resolved := make(map[string]bool, len(appsByID))
+	var resolve func(appID string) string
+	resolve = func(appID string) string {
+		appDeployInfo := appsByID[appID]
+		if resolved[appID] {
+			return appDeployInfo.DependencyError
+		}
+		resolved[appID] = true
+
+		var dependencyError string
+		if cyclePath, ok := cycles[appID]; ok {
+			dependencyError = fmt.Sprintf("dependency cycle detected: %s", cyclePath)
+		} else {
+			for _, dependency := range appDeployInfo.Dependencies {
+				dependencyInfo, ok := appsByID[dependency]
+				if !ok {
+					// dependency may still be in the download phase
+					if pendingApps == 0 {
+						dependencyError = fmt.Sprintf("missing dependency: %s", dependency)
+						break
+					}
+					continue
+				}
+
+				if resolve(dependency) != "" || isAppDeploymentFailed(ctx, dependencyInfo, afwConfig) {
+					dependencyError = fmt.Sprintf("dependency failed to install: %s", dependency)
+					break
+				}
+			}
+		}
+
+		appDeployInfo.DependencyError = dependencyError
+		return dependencyError
+	}
+
+	for appID := range appsByID {
+		resolve(appID)
+	}
+
+	return appsByID
+}
+
+// isAppInstalledOnPod checks if the app installation is complete on the given pod
+func isAppInstalledOnPod(appDeployInfo *enterpriseApi.AppDeploymentInfo, podID int) bool {
+	if appDeployInfo.DeployStatus == enterpriseApi.DeployStatusComplete {
+		return true
+	}
+
+	phaseInfo := &appDeployInfo.PhaseInfo
+	if len(appDeployInfo.AuxPhaseInfo) > podID {
+		phaseInfo = &appDeployInfo.AuxPhaseInfo[podID]
+	}
+
+	return phaseInfo.Phase == enterpriseApi.PhaseInstall && phaseInfo.Status == enterpriseApi.AppPkgInstallComplete
+}
+
+// areAppDependenciesInstalled checks if all the dependencies of the worker's app are installed on the target pod
+func areAppDependenciesInstalled(worker *PipelineWorker, appsByID map[string]*enterpriseApi.AppDeploymentInfo) bool {
+	podID, _ := getOrdinalValFromPodName(worker.targetPodName)
+
+	for _, dependency := range worker.appDeployInfo.Dependencies {
+		dependencyInfo, ok := appsByID[dependency]
+		if !ok || !isAppInstalledOnPod(dependencyInfo, podID) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,370 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+// createTestAppPkg creates an app package with the given files
+func createTestAppPkg(t *testing.T, appPkgPath string, files map[string]string) {
+	file, err := os.Create(appPkgPath)
+	if err != nil {
+		t.Fatalf("unable to create the app package, error: %v", err)
+	}
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	defer gzipWriter.Close()
+	tarWriter := tar.NewWriter(gzipWriter)
+	defer tarWriter.Close()
+
+	for name, content := range files {
+		err = tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatalf("unable to write the tar header, error: %v", err)
+		}
+		_, err = tarWriter.Write([]byte(content))
+		if err != nil {
+			t.Fatalf("unable to write the tar content, error: %v", err)
+		}
+	}
+}
+
+func TestGetAppIDFromPkgName(t *testing.T) {
+	tests := map[string]string{
+		"app1.tgz":    "app1",
+		"app1.tar.gz": "app1",
+		"app1.spl":    "app1",
+		"app1":        "app1",
+	}
+
+	for appName, expected := range tests {
+		if appID := getAppIDFromPkgName(appName); appID != expected {
+			t.Errorf("got app ID %s for %s, expected %s", appID, appName, expected)
+		}
+	}
+}
+
+func TestParseAppConfDependencies(t *testing.T) {
+	appConf := `
+[launcher]
+version = 1.0.0
+
+# dependencies = commented
+[install]
+is_configured = false
+dependencies = Splunk_TA_nix, Splunk_SA_CIM ,
+
+[package]
+dependencies = ignored
+`
+	dependencies := parseAppConfDependencies(strings.NewReader(appConf))
+	if !reflect.DeepEqual(dependencies, []string{"Splunk_TA_nix", "Splunk_SA_CIM"}) {
+		t.Errorf("incorrect dependencies from app.conf: %v", dependencies)
+	}
+
+	if dependencies = parseAppConfDependencies(strings.NewReader("[install]\nis_configured = 1\n")); len(dependencies) != 0 {
+		t.Errorf("app.conf without dependencies should not return any dependency")
+	}
+}
+
+func TestReadAppPkgDependencies(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+
+	// dependencies from app.conf
+	appPkgPath := filepath.Join(dir, "app1.tgz")
+	createTestAppPkg(t, appPkgPath, map[string]string{
+		"dashboards_app/default/app.conf": "[install]\ndependencies = search_commands\n",
+		"dashboards_app/bin/run.py":       "",
+	})
+	appID, dependencies, err := readAppPkgDependencies(ctx, appPkgPath)
+	if err != nil || appID != "dashboards_app" || !reflect.DeepEqual(dependencies, []string{"search_commands"}) {
+		t.Errorf("incorrect app dependencies, app ID: %s, dependencies: %v, err: %v", appID, dependencies, err)
+	}
+
+	// app.manifest should take precedence
+	appPkgPath = filepath.Join(dir, "app2.tgz")
+	createTestAppPkg(t, appPkgPath, map[string]string{
+		"./app2_dir/default/app.conf": "[install]\ndependencies = ignored\n",
+		"./app2_dir/app.manifest":     `{"info": {"id": {"name": "app2"}}, "dependencies": {"Splunk_TA_nix": {"version": "*"}, "Splunk_SA_CIM": {"version": "*"}}}`,
+	})
+	appID, dependencies, err = readAppPkgDependencies(ctx, appPkgPath)
+	if err != nil || appID != "app2" || !reflect.DeepEqual(dependencies, []string{"Splunk_SA_CIM", "Splunk_TA_nix"}) {
+		t.Errorf("incorrect app dependencies, app ID: %s, dependencies: %v, err: %v", appID, dependencies, err)
+	}
+
+	// invalid app.manifest
+	appPkgPath = filepath.Join(dir, "app3.tgz")
+	createTestAppPkg(t, appPkgPath, map[string]string{
+		"app3/app.manifest": "{",
+	})
+	_, _, err = readAppPkgDependencies(ctx, appPkgPath)
+	if err == nil {
+		t.Errorf("invalid app.manifest should return an error")
+	}
+
+	// not an app package
+	appPkgPath = filepath.Join(dir, "app4.tgz")
+	err = os.WriteFile(appPkgPath, []byte("not a package"), 0644)
+	if err != nil {
+		t.Errorf("unable to create the file, error: %v", err)
+	}
+	_, _, err = readAppPkgDependencies(ctx, appPkgPath)
+	if err == nil {
+		t.Errorf("invalid app package should return an error")
+	}
+}
+
+func getAppDependencyTestContext(apps ...enterpriseApi.AppDeploymentInfo) *enterpriseApi.AppDeploymentContext {
+	for i := range apps {
+		apps[i].RepoState = enterpriseApi.RepoStateActive
+	}
+
+	return &enterpriseApi.AppDeploymentContext{
+		AppFrameworkConfig: enterpriseApi.AppFrameworkSpec{
+			PhaseMaxRetries: 3,
+			AppSources: []enterpriseApi.AppSourceSpec{
+				{Name: "localApps",
+					AppSourceDefaultSpec: enterpriseApi.AppSourceDefaultSpec{
+						Scope: enterpriseApi.ScopeLocal},
+				},
+			},
+		},
+		AppsSrcDeployStatus: map[string]enterpriseApi.AppSrcDeployInfo{
+			"localApps": {AppDeploymentInfoList: apps},
+		},
+	}
+}
+
+func getAppDependencyErrors(appDeployContext *enterpriseApi.AppDeploymentContext) map[string]string {
+	dependencyErrors := make(map[string]string)
+	for _, appDeployInfo := range appDeployContext.AppsSrcDeployStatus["localApps"].AppDeploymentInfoList {
+		dependencyErrors[appDeployInfo.AppName] = appDeployInfo.DependencyError
+	}
+	return dependencyErrors
+}
+
+func TestValidateAppDependencies(t *testing.T) {
+	ctx := context.TODO()
+
+	// valid dependency chain
+	appDeployContext := getAppDependencyTestContext(
+		enterpriseApi.AppDeploymentInfo{AppName: "a.tgz", AppID: "a", Dependencies: []string{"b"}},
+		enterpriseApi.AppDeploymentInfo{AppName: "b.tgz", AppID: "b", Dependencies: []string{"c"}},
+		enterpriseApi.AppDeploymentInfo{AppName: "c.tgz", AppID: "c"},
+	)
+	appsByID := validateAppDependencies(ctx, appDeployContext)
+	if len(appsByID) != 3 {
+		t.Errorf("all the apps should be indexed by app ID")
+	}
+	for appName, dependencyError := range getAppDependencyErrors(appDeployContext) {
+		if dependencyError != "" {
+			t.Errorf("app %s should not have any dependency error, got: %s", appName, dependencyError)
+		}
+	}
+
+	// dependency cycle
+	appDeployContext = getAppDependencyTestContext(
+		enterpriseApi.AppDeploymentInfo{AppName: "a.tgz", AppID: "a", Dependencies: []string{"b"}},
+		enterpriseApi.AppDeploymentInfo{AppName: "b.tgz", AppID: "b", Dependencies: []string{"a"}},
+		enterpriseApi.AppDeploymentInfo{AppName: "c.tgz", AppID: "c", Dependencies: []string{"a"}},
+		enterpriseApi.AppDeploymentInfo{AppName: "d.tgz", AppID: "d"},
+	)
+	validateAppDependencies(ctx, appDeployContext)
+	dependencyErrors := getAppDependencyErrors(appDeployContext)
+	if !strings.HasPrefix(dependencyErrors["a.tgz"], "dependency cycle detected") || !strings.HasPrefix(dependencyErrors["b.tgz"], "dependency cycle detected") {
+		t.Errorf("apps in the cycle should report the cycle, got: %v", dependencyErrors)
+	}
+	if dependencyErrors["c.tgz"] != "dependency failed to install: a" {
+		t.Errorf("app depending on a cycle should fail, got: %s", dependencyErrors["c.tgz"])
+	}
+	if dependencyErrors["d.tgz"] != "" {
+		t.Errorf("independent app should not fail, got: %s", dependencyErrors["d.tgz"])
+	}
+
+	// missing dependency is reported only when all the apps are downloaded
+	appDeployContext = getAppDependencyTestContext(
+		enterpriseApi.AppDeploymentInfo{AppName: "a.tgz", AppID: "a", Dependencies: []string{"x"}},
+		enterpriseApi.AppDeploymentInfo{AppName: "b.tgz"},
+	)
+	validateAppDependencies(ctx, appDeployContext)
+	if dependencyError := getAppDependencyErrors(appDeployContext)["a.tgz"]; dependencyError != "" {
+		t.Errorf("dependency should be pending while some apps are not downloaded, got: %s", dependencyError)
+	}
+
+	appDeployContext.AppsSrcDeployStatus["localApps"].AppDeploymentInfoList[1].AppID = "b"
+	validateAppDependencies(ctx, appDeployContext)
+	if dependencyError := getAppDependencyErrors(appDeployContext)["a.tgz"]; dependencyError != "missing dependency: x" {
+		t.Errorf("missing dependency should be reported, got: %s", dependencyError)
+	}
+
+	// failed dependency
+	appDeployContext = getAppDependencyTestContext(
+		enterpriseApi.AppDeploymentInfo{AppName: "a.tgz", AppID: "a", Dependencies: []string{"b"}},
+		enterpriseApi.AppDeploymentInfo{AppName: "b.tgz", AppID: "b", PhaseInfo: enterpriseApi.PhaseInfo{Phase: enterpriseApi.PhaseInstall, Status: enterpriseApi.AppPkgInstallError, FailCount: 4}},
+	)
+	validateAppDependencies(ctx, appDeployContext)
+	if dependencyError := getAppDependencyErrors(appDeployContext)["a.tgz"]; dependencyError != "dependency failed to install: b" {
+		t.Errorf("failed dependency should be reported, got: %s", dependencyError)
+	}
+
+	// once fixed, the error should be cleared
+	appDeployContext.AppsSrcDeployStatus["localApps"].AppDeploymentInfoList[1].PhaseInfo.FailCount = 0
+	validateAppDependencies(ctx, appDeployContext)
+	if dependencyError := getAppDependencyErrors(appDeployContext)["a.tgz"]; dependencyError != "" {
+		t.Errorf("dependency error should be cleared, got: %s", dependencyError)
+	}
+}
+
+func TestAreAppDependenciesInstalled(t *testing.T) {
+	ctx := context.TODO()
+	appDeployContext := getAppDependencyTestContext(
+		enterpriseApi.AppDeploymentInfo{AppName: "a.tgz", AppID: "a", Dependencies: []string{"b"}},
+		enterpriseApi.AppDeploymentInfo{AppName: "b.tgz", AppID: "b", PhaseInfo: enterpriseApi.PhaseInfo{Phase: enterpriseApi.PhaseInstall, Status: enterpriseApi.AppPkgInstallPending},
+			AuxPhaseInfo: []enterpriseApi.PhaseInfo{
+				{Phase: enterpriseApi.PhaseInstall, Status: enterpriseApi.AppPkgInstallComplete},
+				{Phase: enterpriseApi.PhaseInstall, Status: enterpriseApi.AppPkgInstallPending},
+			}},
+	)
+	appsByID := validateAppDependencies(ctx, appDeployContext)
+
+	worker := &PipelineWorker{
+		appDeployInfo: &appDeployContext.AppsSrcDeployStatus["localApps"].AppDeploymentInfoList[0],
+		targetPodName: "splunk-stand1-standalone-0",
+	}
+	if !areAppDependenciesInstalled(worker, appsByID) {
+		t.Errorf("dependency is installed on pod 0")
+	}
+
+	worker.targetPodName = "splunk-stand1-standalone-1"
+	if areAppDependenciesInstalled(worker, appsByID) {
+		t.Errorf("dependency is not yet installed on pod 1")
+	}
+
+	// apps without dependencies can be installed anytime
+	worker.appDeployInfo = &appDeployContext.AppsSrcDeployStatus["localApps"].AppDeploymentInfoList[1]
+	if !areAppDependenciesInstalled(worker, appsByID) {
+		t.Errorf("app without dependencies should be eligible for install")
+	}
+}
+
+// newConcurrentPhasesTestPipeline returns a pipeline with downloaded apps, that are moved to the pod copy phase by the
+// download phase manager
+func newConcurrentPhasesTestPipeline(t *testing.T) *AppInstallPipeline {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+	cr := &enterpriseApi.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stand1",
+			Namespace: "test",
+		},
+		Spec: enterpriseApi.StandaloneSpec{
+			CommonSplunkSpec: enterpriseApi.CommonSplunkSpec{
+				Mock: true,
+			},
+		},
+	}
+
+	var replicas int32 = 1
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stand1-standalone",
+			Namespace: "test",
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+		},
+	}
+	_, err := splctrl.ApplyStatefulSet(ctx, c, sts)
+	if err != nil {
+		t.Fatalf("unable to apply statefulset")
+	}
+
+	var apps []enterpriseApi.AppDeploymentInfo
+	for _, appName := range []string{"a.tgz", "b.tgz", "c.tgz", "d.tgz"} {
+		apps = append(apps, enterpriseApi.AppDeploymentInfo{
+			AppName: appName,
+			PhaseInfo: enterpriseApi.PhaseInfo{
+				Phase:  enterpriseApi.PhaseDownload,
+				Status: enterpriseApi.AppPkgDownloadComplete,
+			},
+		})
+	}
+	appDeployContext := getAppDependencyTestContext(apps...)
+	appDeployContext.AppsStatusMaxConcurrentAppDownloads = 1
+
+	ppln := initAppInstallPipeline(ctx, appDeployContext, c, cr)
+	deployInfoList := appDeployContext.AppsSrcDeployStatus["localApps"].AppDeploymentInfoList
+	for i := range deployInfoList {
+		ppln.createAndAddPipelineWorker(ctx, enterpriseApi.PhaseDownload, &deployInfoList[i], "localApps", "", &appDeployContext.AppFrameworkConfig, c, cr, sts)
+	}
+	return ppln
+}
+
+// runConcurrentPhases runs the download and install phase managers till the downloaded apps leave the download phase,
+// and calls poll meanwhile
+func runConcurrentPhases(ppln *AppInstallPipeline, poll func()) {
+	ctx := context.TODO()
+	ppln.phaseWaiter.Add(1)
+	go ppln.installPhaseManager(ctx)
+	ppln.phaseWaiter.Add(1)
+	go ppln.downloadPhaseManager(ctx)
+
+	pendingDownloads := func() int {
+		downloadPhase := ppln.pplnPhases[enterpriseApi.PhaseDownload]
+		downloadPhase.mutex.Lock()
+		defer downloadPhase.mutex.Unlock()
+		return len(downloadPhase.q)
+	}
+	for i := 0; i < 100 && pendingDownloads() > 0; i++ {
+		poll()
+		time.Sleep(50 * time.Millisecond)
+	}
+	close(ppln.sigTerm)
+	ppln.phaseWaiter.Wait()
+}
+
+func TestAppDependencyInfoConcurrentPhases(t *testing.T) {
+	// the downloaded apps get their app ID and dependencies, while the install phase validates the dependencies
+	ppln := newConcurrentPhasesTestPipeline(t)
+	runConcurrentPhases(ppln, func() {})
+
+	// the app packages are missing, so the apps have no dependencies
+	for _, appDeployInfo := range ppln.appDeployContext.AppsSrcDeployStatus["localApps"].AppDeploymentInfoList {
+		if appDeployInfo.AppID != strings.TrimSuffix(appDeployInfo.AppName, ".tgz") || len(appDeployInfo.Dependencies) != 0 {
+			t.Errorf("app %s got app ID %q and dependencies %v; want app ID %q and no dependencies", appDeployInfo.AppName, appDeployInfo.AppID, appDeployInfo.Dependencies, strings.TrimSuffix(appDeployInfo.AppName, ".tgz"))
+		}
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// configMap key for the App Framework dry-run plan
+	appFrameworkPlanKey = "plan"
+)
+
+// isAppFrameworkDryRunEnabled checks if the App Framework dry-run mode is enabled through the spec or the annotation
+func isAppFrameworkDryRunEnabled(cr splcommon.MetaObject, appFrameworkConf *enterpriseApi.AppFrameworkSpec) bool {
+	if appFrameworkConf.DryRun {
+		return true
+	}
+
+	_, ok := cr.GetAnnotations()[enterpriseApi.AppFrameworkDryRunAnnotation]
+	return ok
+}
+
+// getAppFrameworkPlanTrigger returns the trigger for the dry-run plan. Any change to the CR spec
+// or to the dry-run annotation value recomputes the plan
+func getAppFrameworkPlanTrigger(cr splcommon.MetaObject) string {
+	return fmt.Sprintf("%d/%s", cr.GetGeneration(), cr.GetAnnotations()[enterpriseApi.AppFrameworkDryRunAnnotation])
+}
+
+// isAppFrameworkPlanStale checks if the dry-run plan needs to be recomputed
+func isAppFrameworkPlanStale(ctx context.Context, cr splcommon.MetaObject, appStatusContext *enterpriseApi.AppDeploymentContext) bool {
+	plan := appStatusContext.DryRunPlan
+	if plan == nil || plan.Trigger != getAppFrameworkPlanTrigger(cr) {
+		return true
+	}
+
+	return isAppRepoPollingEnabled(appStatusContext) && plan.PlanTime+appStatusContext.AppsRepoStatusPollInterval <= time.Now().Unix()
+}
+
+// checkAndUpdateAppFrameworkPlan computes the App changes from the remote storage listing, without changing the App deployment status.
+// The plan is updated in the CR status and in the plan configMap
+func checkAndUpdateAppFrameworkPlan(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject,
+	appFrameworkConf *enterpriseApi.AppFrameworkSpec, appStatusContext *enterpriseApi.AppDeploymentContext) error {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("checkAndUpdateAppFrameworkPlan").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
+
+	if !isAppFrameworkPlanStale(ctx, cr, appStatusContext) {
+		return nil
+	}
+
+	scopedLog.Info("Computing the App Framework dry-run plan")
+	plan := &enterpriseApi.AppFrameworkPlan{
+		PlanTime:      time.Now().Unix(),
+		Trigger:       getAppFrameworkPlanTrigger(cr),
+		ConfigMapName: GetSplunkAppFrameworkPlanConfigMapName(cr.GetName(), cr.GetObjectKind().GroupVersionKind().Kind),
+	}
+
+	err := computeAppFrameworkPlan(ctx, client, cr, appFrameworkConf, appStatusContext, plan)
+	if err != nil {
+		scopedLog.Error(err, "Unable to compute the App Framework dry-run plan")
+		plan.Error = err.Error()
+	}
+
+	appStatusContext.DryRunPlan = plan
+
+	return applyAppFrameworkPlanConfigMap(ctx, client, cr, plan)
+}
+
+// computeAppFrameworkPlan runs the remote storage listing through the App repo change handling on a copy of the App deployment status,
+// and updates the plan with the differences
+func computeAppFrameworkPlan(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject,
+	appFrameworkConf *enterpriseApi.AppFrameworkSpec, appStatusContext *enterpriseApi.AppDeploymentContext, plan *enterpriseApi.AppFrameworkPlan) error {
+
+	sourceToAppsList, err := GetAppListFromS3Bucket(ctx, client, cr, appFrameworkConf)
+	if len(sourceToAppsList) != len(appFrameworkConf.AppSources) {
+		return fmt.Errorf("unable to get apps list from remote storage for all the app sources, error: %v", err)
+	}
+
+	err = cleanAppListObjectDigests(ctx, sourceToAppsList, appFrameworkConf)
+	if err != nil {
+		return err
+	}
+
+	plannedContext := appStatusContext.DeepCopy()
+	plannedContext.DryRunPlan = nil
+	if plannedContext.AppsSrcDeployStatus == nil {
+		plannedContext.AppsSrcDeployStatus = make(map[string]enterpriseApi.AppSrcDeployInfo)
+	}
+
+	_, err = handleAppRepoChanges(ctx, client, cr, plannedContext, sourceToAppsList, appFrameworkConf)
+	if err != nil {
+		return err
+	}
+
+	updateAppFrameworkPlan(ctx, cr, appFrameworkConf, appStatusContext, plannedContext, plan)
+	return nil
+}
+
+// getAppSourcePlan returns the App changes for an App source, by comparing the current and the planned App deployment info
+func getAppSourcePlan(appSrcName, scope string, current, planned []enterpriseApi.AppDeploymentInfo) enterpriseApi.AppSourcePlan {
+	srcPlan := enterpriseApi.AppSourcePlan{
+		Name:  appSrcName,
+		Scope: scope,
+	}
+
+	currentApps := make(map[string]*enterpriseApi.AppDeploymentInfo, len(current))
+	for i := range current {
+		currentApps[current[i].AppName] = &current[i]
+	}
+
+	for i := range planned {
+		plannedApp := &planned[i]
+		currentApp, ok := currentApps[plannedApp.AppName]
+		switch {
+		case !ok || currentApp.RepoState == enterpriseApi.RepoStateDeleted:
+			if plannedApp.RepoState == enterpriseApi.RepoStateActive {
+				srcPlan.AppsToAdd = append(srcPlan.AppsToAdd, plannedApp.AppName)
+			}
+		case plannedApp.RepoState == enterpriseApi.RepoStateDeleted:
+			srcPlan.AppsToDelete = append(srcPlan.AppsToDelete, plannedApp.AppName)
+		case currentApp.ObjectHash != plannedApp.ObjectHash:
+			srcPlan.AppsToUpdate = append(srcPlan.AppsToUpdate, plannedApp.AppName)
+		}
+	}
+
+	sort.Strings(srcPlan.AppsToAdd)
+	sort.Strings(srcPlan.AppsToUpdate)
+	sort.Strings(srcPlan.AppsToDelete)
+
+	return srcPlan
+}
+
+// getAppFrameworkPlanPods returns the pods where the App packages are copied for the CR
+func getAppFrameworkPlanPods(cr splcommon.MetaObject) []string {
+	replicas := 1
+	if isFanOutApplicableToCR(cr) {
+		if standalone, ok := cr.(*enterpriseApi.Standalone); ok {
+			replicas = int(standalone.Spec.Replicas)
+		}
+	}
+
+	var pods []string
+	for i := 0; i < replicas; i++ {
+		if podName := getApplicablePodNameForAppFramework(cr, i); podName != "" {
+			pods = append(pods, podName)
+		}
+	}
+	return pods
+}
+
+// updateAppFrameworkPlan updates the plan with the App changes for all the App sources
+func updateAppFrameworkPlan(ctx context.Context, cr splcommon.MetaObject, appFrameworkConf *enterpriseApi.AppFrameworkSpec,
+	currentContext, plannedContext *enterpriseApi.AppDeploymentContext, plan *enterpriseApi.AppFrameworkPlan) {
+
+	var appSrcNames []string
+	for appSrcName := range plannedContext.AppsSrcDeployStatus {
+		appSrcNames = append(appSrcNames, appSrcName)
+	}
+	sort.Strings(appSrcNames)
+
+	var podsAffected bool
+	for _, appSrcName := range appSrcNames {
+		// App sources removed from the spec are only known to the current status
+		scopeConf := appFrameworkConf
+		if !CheckIfAppSrcExistsInConfig(appFrameworkConf, appSrcName) {
+			scopeConf = &currentContext.AppFrameworkConfig
+		}
+		scope := getAppSrcScope(ctx, scopeConf, appSrcName)
+
+		srcPlan := getAppSourcePlan(appSrcName, scope, currentContext.AppsSrcDeployStatus[appSrcName].AppDeploymentInfoList,
+			plannedContext.AppsSrcDeployStatus[appSrcName].AppDeploymentInfoList)
+		if len(srcPlan.AppsToAdd) == 0 && len(srcPlan.AppsToUpdate) == 0 && len(srcPlan.AppsToDelete) == 0 {
+			continue
+		}
+		plan.AppSources = append(plan.AppSources, srcPlan)
+
+		if len(srcPlan.AppsToAdd) == 0 && len(srcPlan.AppsToUpdate) == 0 {
+			continue
+		}
+		podsAffected = true
+
+		if scope == enterpriseApi.ScopeLocal {
+			// updating an installed app may need a restart to reflect the changes
+			if len(srcPlan.AppsToUpdate) > 0 {
+				plan.RestartRequired = true
+			}
+		} else {
+			// bundle push may trigger a rolling restart of the cluster members
+			plan.BundlePushRequired = true
+			plan.RestartRequired = true
+		}
+	}
+
+	if podsAffected {
+		plan.AffectedPods = getAppFrameworkPlanPods(cr)
+	}
+}
+
+// applyAppFrameworkPlanConfigMap creates or updates the configMap with the App Framework dry-run plan
+func applyAppFrameworkPlanConfigMap(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, plan *enterpriseApi.AppFrameworkPlan) error {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("applyAppFrameworkPlanConfigMap").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
+
+	planData, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	configMap := splctrl.PrepareConfigMap(plan.ConfigMapName, cr.GetNamespace(), map[string]string{appFrameworkPlanKey: string(planData)})
+	configMap.SetOwnerReferences(append(configMap.GetOwnerReferences(), splcommon.AsOwner(cr, true)))
+
+	_, err = splctrl.ApplyConfigMap(ctx, client, configMap)
+	if err != nil {
+		scopedLog.Error(err, "Unable to apply the App Framework plan configMap", "configMap", plan.ConfigMapName)
+	}
+	return err
+}
+
+// removeAppFrameworkPlan removes the dry-run plan, once the dry-run mode is disabled
+func removeAppFrameworkPlan(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, appStatusContext *enterpriseApi.AppDeploymentContext) error {
+	if appStatusContext.DryRunPlan == nil {
+		return nil
+	}
+
+	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: appStatusContext.DryRunPlan.ConfigMapName}
+	configMap, err := splctrl.GetConfigMap(ctx, client, namespacedName)
+	if err == nil {
+		err = splutil.DeleteResource(ctx, client, configMap)
+	}
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	appStatusContext.DryRunPlan = nil
+	return nil
+}
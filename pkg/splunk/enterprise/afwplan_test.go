@@ -0,0 +1,236 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestIsAppFrameworkDryRunEnabled(t *testing.T) {
+	cr := enterpriseApi.Standalone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+
+	if isAppFrameworkDryRunEnabled(&cr, &cr.Spec.AppFrameworkConfig) {
+		t.Errorf("dry-run should be disabled by default")
+	}
+
+	cr.Spec.AppFrameworkConfig.DryRun = true
+	if !isAppFrameworkDryRunEnabled(&cr, &cr.Spec.AppFrameworkConfig) {
+		t.Errorf("dry-run should be enabled through the spec")
+	}
+
+	cr.Spec.AppFrameworkConfig.DryRun = false
+	cr.Annotations = map[string]string{enterpriseApi.AppFrameworkDryRunAnnotation: ""}
+	if !isAppFrameworkDryRunEnabled(&cr, &cr.Spec.AppFrameworkConfig) {
+		t.Errorf("dry-run should be enabled through the annotation")
+	}
+}
+
+func TestIsAppFrameworkPlanStale(t *testing.T) {
+	ctx := context.TODO()
+	cr := enterpriseApi.Standalone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "stack1",
+			Namespace:  "test",
+			Generation: 2,
+		},
+	}
+
+	var appDeployContext enterpriseApi.AppDeploymentContext
+	if !isAppFrameworkPlanStale(ctx, &cr, &appDeployContext) {
+		t.Errorf("missing plan should be stale")
+	}
+
+	appDeployContext.DryRunPlan = &enterpriseApi.AppFrameworkPlan{
+		PlanTime: time.Now().Unix(),
+		Trigger:  getAppFrameworkPlanTrigger(&cr),
+	}
+	if isAppFrameworkPlanStale(ctx, &cr, &appDeployContext) {
+		t.Errorf("plan with the same trigger should not be stale")
+	}
+
+	// annotation value change should recompute the plan
+	cr.Annotations = map[string]string{enterpriseApi.AppFrameworkDryRunAnnotation: "1"}
+	if !isAppFrameworkPlanStale(ctx, &cr, &appDeployContext) {
+		t.Errorf("plan should be stale when the annotation value changes")
+	}
+
+	// poll interval elapsed should recompute the plan
+	appDeployContext.DryRunPlan.Trigger = getAppFrameworkPlanTrigger(&cr)
+	appDeployContext.AppsRepoStatusPollInterval = 60
+	appDeployContext.DryRunPlan.PlanTime = time.Now().Unix() - 61
+	if !isAppFrameworkPlanStale(ctx, &cr, &appDeployContext) {
+		t.Errorf("plan should be stale when the poll interval elapsed")
+	}
+}
+
+func TestUpdateAppFrameworkPlan(t *testing.T) {
+	ctx := context.TODO()
+	cr := enterpriseApi.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Spec: enterpriseApi.StandaloneSpec{
+			Replicas: 2,
+			AppFrameworkConfig: enterpriseApi.AppFrameworkSpec{
+				AppSources: []enterpriseApi.AppSourceSpec{
+					{Name: "appSrc1", AppSourceDefaultSpec: enterpriseApi.AppSourceDefaultSpec{Scope: enterpriseApi.ScopeLocal}},
+					{Name: "appSrc2", AppSourceDefaultSpec: enterpriseApi.AppSourceDefaultSpec{Scope: enterpriseApi.ScopeLocal}},
+				},
+			},
+		},
+	}
+
+	currentContext := enterpriseApi.AppDeploymentContext{
+		AppsSrcDeployStatus: map[string]enterpriseApi.AppSrcDeployInfo{
+			"appSrc1": {
+				AppDeploymentInfoList: []enterpriseApi.AppDeploymentInfo{
+					{AppName: "app1.tgz", ObjectHash: "hash1", RepoState: enterpriseApi.RepoStateActive},
+					{AppName: "app2.tgz", ObjectHash: "hash2", RepoState: enterpriseApi.RepoStateActive},
+					{AppName: "app3.tgz", ObjectHash: "hash3", RepoState: enterpriseApi.RepoStateActive},
+				},
+			},
+			"appSrc2": {
+				AppDeploymentInfoList: []enterpriseApi.AppDeploymentInfo{
+					{AppName: "app4.tgz", ObjectHash: "hash4", RepoState: enterpriseApi.RepoStateActive},
+				},
+			},
+		},
+	}
+
+	plannedContext := currentContext.DeepCopy()
+	plannedContext.AppsSrcDeployStatus["appSrc1"] = enterpriseApi.AppSrcDeployInfo{
+		AppDeploymentInfoList: []enterpriseApi.AppDeploymentInfo{
+			{AppName: "app1.tgz", ObjectHash: "hash1", RepoState: enterpriseApi.RepoStateActive},
+			{AppName: "app2.tgz", ObjectHash: "hash2-new", RepoState: enterpriseApi.RepoStateActive},
+			{AppName: "app3.tgz", ObjectHash: "hash3", RepoState: enterpriseApi.RepoStateDeleted},
+			{AppName: "app5.tgz", ObjectHash: "hash5", RepoState: enterpriseApi.RepoStateActive},
+		},
+	}
+
+	plan := &enterpriseApi.AppFrameworkPlan{}
+	updateAppFrameworkPlan(ctx, &cr, &cr.Spec.AppFrameworkConfig, &currentContext, plannedContext, plan)
+
+	expectedSources := []enterpriseApi.AppSourcePlan{
+		{
+			Name:         "appSrc1",
+			Scope:        enterpriseApi.ScopeLocal,
+			AppsToAdd:    []string{"app5.tgz"},
+			AppsToUpdate: []string{"app2.tgz"},
+			AppsToDelete: []string{"app3.tgz"},
+		},
+	}
+	if !reflect.DeepEqual(plan.AppSources, expectedSources) {
+		t.Errorf("unexpected app source plan, got: %+v, expected: %+v", plan.AppSources, expectedSources)
+	}
+
+	if plan.BundlePushRequired || !plan.RestartRequired {
+		t.Errorf("local app update should require a restart, but not a bundle push")
+	}
+
+	expectedPods := []string{"splunk-stack1-standalone-0", "splunk-stack1-standalone-1"}
+	if !reflect.DeepEqual(plan.AffectedPods, expectedPods) {
+		t.Errorf("unexpected affected pods, got: %v, expected: %v", plan.AffectedPods, expectedPods)
+	}
+
+	// cluster scoped changes require a bundle push
+	cr.Spec.AppFrameworkConfig.AppSources[0].Scope = enterpriseApi.ScopeCluster
+	plan = &enterpriseApi.AppFrameworkPlan{}
+	updateAppFrameworkPlan(ctx, &cr, &cr.Spec.AppFrameworkConfig, &currentContext, plannedContext, plan)
+	if !plan.BundlePushRequired || !plan.RestartRequired {
+		t.Errorf("cluster scoped app changes should require a bundle push")
+	}
+
+	// no changes should result in an empty plan
+	plan = &enterpriseApi.AppFrameworkPlan{}
+	updateAppFrameworkPlan(ctx, &cr, &cr.Spec.AppFrameworkConfig, &currentContext, &currentContext, plan)
+	if len(plan.AppSources) != 0 || len(plan.AffectedPods) != 0 || plan.RestartRequired {
+		t.Errorf("unchanged apps should result in an empty plan, got: %+v", plan)
+	}
+}
+
+func TestApplyAndRemoveAppFrameworkPlan(t *testing.T) {
+	ctx := context.TODO()
+	cr := enterpriseApi.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+
+	client := spltest.NewMockClient()
+	plan := &enterpriseApi.AppFrameworkPlan{
+		PlanTime:      time.Now().Unix(),
+		ConfigMapName: GetSplunkAppFrameworkPlanConfigMapName(cr.GetName(), cr.Kind),
+		AppSources:    []enterpriseApi.AppSourcePlan{{Name: "appSrc1", AppsToAdd: []string{"app1.tgz"}}},
+	}
+
+	if plan.ConfigMapName != "splunk-stack1-standalone-app-plan" {
+		t.Errorf("unexpected plan configMap name %s", plan.ConfigMapName)
+	}
+
+	err := applyAppFrameworkPlanConfigMap(ctx, client, &cr, plan)
+	if err != nil {
+		t.Errorf("unable to apply the plan configMap, error: %v", err)
+	}
+
+	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: plan.ConfigMapName}
+	configMap, err := splctrl.GetConfigMap(ctx, client, namespacedName)
+	if err != nil {
+		t.Fatalf("plan configMap should exist, error: %v", err)
+	}
+
+	var savedPlan enterpriseApi.AppFrameworkPlan
+	err = json.Unmarshal([]byte(configMap.Data[appFrameworkPlanKey]), &savedPlan)
+	if err != nil || !reflect.DeepEqual(&savedPlan, plan) {
+		t.Errorf("unexpected plan in the configMap, got: %+v, expected: %+v", savedPlan, plan)
+	}
+
+	appDeployContext := enterpriseApi.AppDeploymentContext{DryRunPlan: plan}
+	err = removeAppFrameworkPlan(ctx, client, &cr, &appDeployContext)
+	if err != nil {
+		t.Errorf("unable to remove the plan, error: %v", err)
+	}
+
+	if appDeployContext.DryRunPlan != nil {
+		t.Errorf("plan should be removed from the status")
+	}
+
+	_, err = splctrl.GetConfigMap(ctx, client, namespacedName)
+	if err == nil {
+		t.Errorf("plan configMap should be deleted")
+	}
+}
@@ -27,7 +27,9 @@ import (
 	"github.com/pkg/errors"
 	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/concurrency"
 	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -74,13 +76,13 @@ func getApplicablePodNameForAppFramework(cr splcommon.MetaObject, ordinalIdx int
 	switch cr.GetObjectKind().GroupVersionKind().Kind {
 	case "Standalone":
 		podType = "standalone"
-	case "LicenseMaster":
+	case "LicenseMaster", "LicenseManager":
 		podType = "license-master"
 	case "SearchHeadCluster":
 		podType = "deployer"
 	case "IndexerCluster":
 		return ""
-	case "ClusterMaster":
+	case "ClusterMaster", "ClusterManager":
 		podType = "cluster-master"
 	case "MonitoringConsole":
 		podType = "monitoring-console"
@@ -140,6 +142,7 @@ func (ppln *AppInstallPipeline) deleteWorkerFromPipelinePhase(ctx context.Contex
 func setContextForNewPhase(phaseInfo *enterpriseApi.PhaseInfo, newPhase enterpriseApi.AppPhaseType) {
 	phaseInfo.Phase = newPhase
 	phaseInfo.FailCount = 0
+	phaseInfo.LastError = ""
 	setPhaseStatusToPending(phaseInfo)
 }
 
@@ -306,34 +309,15 @@ func updatePplnWorkerPhaseInfo(ctx context.Context, appDeployInfo *enterpriseApi
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("updatePplnWorkerPhaseInfo").WithValues("appName", appDeployInfo.AppName)
 
-	scopedLog.Info("changing the status", "old status", appPhaseStatusAsStr(appDeployInfo.PhaseInfo.Status), "new status", appPhaseStatusAsStr(statusType))
+	scopedLog.Info("changing the status", "old status", AppPhaseStatusAsStr(appDeployInfo.PhaseInfo.Status), "new status", AppPhaseStatusAsStr(statusType))
 	appDeployInfo.PhaseInfo.FailCount = failCount
 	appDeployInfo.PhaseInfo.Status = statusType
 }
 
-func (downloadWorker *PipelineWorker) createDownloadDirOnOperator(ctx context.Context) (string, error) {
-	reqLogger := log.FromContext(ctx)
-	scopedLog := reqLogger.WithName("createDownloadDirOnOperator").WithValues("appSrcName", downloadWorker.appSrcName, "appName", downloadWorker.appDeployInfo.AppName)
-	scope := getAppSrcScope(ctx, downloadWorker.afwConfig, downloadWorker.appSrcName)
-
-	kind := downloadWorker.cr.GetObjectKind().GroupVersionKind().Kind
-
-	// This is how the path to download apps looks like -
-	// /opt/splunk/appframework/downloadedApps/<namespace>/<CR_Kind>/<CR_Name>/<scope>/<appSrc_Name>/
-	// For e.g., if the we are trying to download app app1.tgz under "admin" app source name, for a Standalone CR with name "stand1"
-	// in default namespace, then it will be downloaded at the path -
-	// /opt/splunk/appframework/downloadedApps/default/Standalone/stand1/local/admin/app1.tgz_<hash>
-	localPath := filepath.Join(splcommon.AppDownloadVolume, "downloadedApps", downloadWorker.cr.GetNamespace(), kind, downloadWorker.cr.GetName(), scope, downloadWorker.appSrcName) + "/"
-	// create the sub-directories on the volume for downloading scoped apps
-	err := createAppDownloadDir(ctx, localPath)
-	if err != nil {
-		scopedLog.Error(err, "unable to create app download directory on operator")
-	}
-	return localPath, err
-}
-
-// download API will do the actual work of downloading apps from remote storage
-func (downloadWorker *PipelineWorker) download(ctx context.Context, pplnPhase *PipelinePhase, s3ClientMgr S3ClientManager, localPath string, downloadWorkersRunPool chan struct{}) {
+// downloadToCache downloads the app package into the shared app package cache
+func (downloadWorker *PipelineWorker) downloadToCache(ctx context.Context, pplnPhase *PipelinePhase, s3ClientMgr S3ClientManager, appPkgCache *appPkgCache, cacheKey appPkgCacheKey, localFile string, downloadWorkersRunPool chan struct{}) {
+	ctx, span := tracing.StartCRSpan(ctx, "PipelineWorker.downloadToCache", downloadWorker.cr, tracing.AttributeAppName.String(downloadWorker.appDeployInfo.AppName))
+	defer span.End()
 
 	defer func() {
 		downloadWorker.isActive = false
@@ -343,6 +327,17 @@ func (downloadWorker *PipelineWorker) download(ctx context.Context, pplnPhase *P
 		downloadWorker.waiter.Done()
 	}()
 
+	err := downloadWorker.downloadAppPkg(ctx, s3ClientMgr, localFile)
+	if err != nil {
+		appPkgCache.abort(ctx, cacheKey)
+		return
+	}
+
+	appPkgCache.markReady(ctx, cacheKey)
+}
+
+// downloadAppPkg downloads the app package to the given local file, and updates the download state of the app
+func (downloadWorker *PipelineWorker) downloadAppPkg(ctx context.Context, s3ClientMgr S3ClientManager, localFile string) error {
 	splunkCR := downloadWorker.cr
 	appSrcName := downloadWorker.appSrcName
 	reqLogger := log.FromContext(ctx)
@@ -351,14 +346,14 @@ func (downloadWorker *PipelineWorker) download(ctx context.Context, pplnPhase *P
 	appDeployInfo := downloadWorker.appDeployInfo
 	appName := appDeployInfo.AppName
 
-	localFile := getLocalAppFileName(ctx, localPath, appName, appDeployInfo.ObjectHash)
 	remoteFile, err := getRemoteObjectKey(ctx, splunkCR, downloadWorker.afwConfig, appSrcName, appName)
 	if err != nil {
 		scopedLog.Error(err, "unable to get remote object key", "appName", appName)
 		// increment the retry count and mark this app as download pending
 		updatePplnWorkerPhaseInfo(ctx, appDeployInfo, appDeployInfo.PhaseInfo.FailCount+1, enterpriseApi.AppPkgDownloadPending)
+		appDeployInfo.PhaseInfo.LastError = err.Error()
 
-		return
+		return err
 	}
 
 	// download the app from remote storage
@@ -367,20 +362,68 @@ func (downloadWorker *PipelineWorker) download(ctx context.Context, pplnPhase *P
 		scopedLog.Error(err, "unable to download app", "appName", appName)
 
 		// remove the local file
-		err = os.RemoveAll(localFile)
-		if err != nil {
-			scopedLog.Error(err, "unable to remove local file from operator")
+		rmErr := os.RemoveAll(localFile)
+		if rmErr != nil {
+			scopedLog.Error(rmErr, "unable to remove local file from operator")
 		}
 
 		// increment the retry count and mark this app as download pending
 		updatePplnWorkerPhaseInfo(ctx, appDeployInfo, appDeployInfo.PhaseInfo.FailCount+1, enterpriseApi.AppPkgDownloadPending)
-		return
+		appDeployInfo.PhaseInfo.LastError = err.Error()
+		return err
 	}
 
 	// download is successfull, update the state and reset the retry count
 	updatePplnWorkerPhaseInfo(ctx, appDeployInfo, 0, enterpriseApi.AppPkgDownloadComplete)
 
 	scopedLog.Info("Finished downloading app")
+	return nil
+}
+
+// scheduleCachedDownload uses the app package from the shared cache when some other CR already downloaded it,
+// otherwise starts the download into the cache.
+// Returns false if the download can not be scheduled for now
+func (downloadWorker *PipelineWorker) scheduleCachedDownload(ctx context.Context, pplnPhase *PipelinePhase, appPkgCache *appPkgCache, downloadWorkersRunPool chan struct{}) bool {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("scheduleCachedDownload").WithValues("appSrcName", downloadWorker.appSrcName, "appName", downloadWorker.appDeployInfo.AppName)
+	appDeployInfo := downloadWorker.appDeployInfo
+
+	cacheKey, err := getAppPkgCacheKey(ctx, downloadWorker)
+	if err != nil {
+		scopedLog.Error(err, "unable to get the app package cache key")
+		// increment the retry count and mark this app as download pending
+		updatePplnWorkerPhaseInfo(ctx, appDeployInfo, appDeployInfo.PhaseInfo.FailCount+1, enterpriseApi.AppPkgDownloadPending)
+		appDeployInfo.PhaseInfo.LastError = err.Error()
+		return false
+	}
+
+	localFile, found, err := appPkgCache.getOrReserve(ctx, cacheKey, getAppPkgCacheHolder(downloadWorker), appDeployInfo.Size)
+	if err != nil {
+		scopedLog.Info("unable to schedule the app package download for now", "reason", err.Error())
+		// setting isActive to false here so that downloadPhaseManager can take care of it.
+		downloadWorker.isActive = false
+		return false
+	}
+
+	if found {
+		scopedLog.Info("app is already downloaded on operator pod, hence skipping it.")
+		// update the state to be download complete
+		updatePplnWorkerPhaseInfo(ctx, appDeployInfo, 0, enterpriseApi.AppPkgDownloadComplete)
+		return false
+	}
+
+	// increment the count in worker waitgroup
+	downloadWorker.waiter.Add(1)
+
+	// update the download state of app to be DownloadInProgress
+	updatePplnWorkerPhaseInfo(ctx, appDeployInfo, appDeployInfo.PhaseInfo.FailCount, enterpriseApi.AppPkgDownloadInProgress)
+
+	// get the S3ClientMgr instance
+	s3ClientMgr, _ := getS3ClientMgr(ctx, downloadWorker.client, downloadWorker.cr, downloadWorker.afwConfig, downloadWorker.appSrcName)
+
+	// start the actual download
+	go downloadWorker.downloadToCache(ctx, pplnPhase, *s3ClientMgr, appPkgCache, cacheKey, localFile, downloadWorkersRunPool)
+	return true
 }
 
 // downloadWorkerHandler schedules the download workers to download app/s
@@ -405,51 +448,11 @@ downloadWork:
 					break downloadWork
 				}
 
-				// do not redownload the app if it is already downloaded
-				if isAppAlreadyDownloaded(ctx, downloadWorker) {
-					scopedLog.Info("app is already downloaded on operator pod, hence skipping it.", "appSrcName", downloadWorker.appSrcName, "appName", downloadWorker.appDeployInfo.AppName)
-					// update the state to be download complete
-					updatePplnWorkerPhaseInfo(ctx, downloadWorker.appDeployInfo, 0, enterpriseApi.AppPkgDownloadComplete)
+				// the shared app package cache downloads the same app package only once for all the CRs
+				if !downloadWorker.scheduleCachedDownload(ctx, pplnPhase, getAppPkgCache(), downloadWorkersRunPool) {
 					<-downloadWorkersRunPool
-					continue
-				}
-
-				// do not proceed if we dont have enough disk space to download this app
-
-				err := reserveStorage(downloadWorker.appDeployInfo.Size)
-				if err != nil {
-					scopedLog.Error(err, "insufficient storage for the app pkg download. appSrcName: %s, app name: %s, app size: %d Bytes", downloadWorker.appSrcName, downloadWorker.appDeployInfo.AppName, downloadWorker.appDeployInfo.Size)
-					// setting isActive to false here so that downloadPhaseManager can take care of it.
-					downloadWorker.isActive = false
-					<-downloadWorkersRunPool
-					continue
-				}
-
-				// increment the count in worker waitgroup
-				downloadWorker.waiter.Add(1)
-
-				// update the download state of app to be DownloadInProgress
-				updatePplnWorkerPhaseInfo(ctx, downloadWorker.appDeployInfo, downloadWorker.appDeployInfo.PhaseInfo.FailCount, enterpriseApi.AppPkgDownloadInProgress)
-
-				appDeployInfo := downloadWorker.appDeployInfo
-
-				// create the sub-directories on the volume for downloading scoped apps
-				localPath, err := downloadWorker.createDownloadDirOnOperator(ctx)
-				if err != nil {
-
-					// increment the retry count and mark this app as download pending
-					updatePplnWorkerPhaseInfo(ctx, appDeployInfo, appDeployInfo.PhaseInfo.FailCount+1, enterpriseApi.AppPkgDownloadPending)
-
-					<-downloadWorkersRunPool
-					continue
 				}
 
-				// get the S3ClientMgr instance
-				s3ClientMgr, _ := getS3ClientMgr(ctx, downloadWorker.client, downloadWorker.cr, downloadWorker.afwConfig, downloadWorker.appSrcName)
-
-				// start the actual download
-				go downloadWorker.download(ctx, pplnPhase, *s3ClientMgr, localPath, downloadWorkersRunPool)
-
 			default:
 				<-downloadWorkersRunPool
 			}
@@ -525,6 +528,7 @@ downloadPhase:
 					downloadWorker.appDeployInfo.PhaseInfo.Status = enterpriseApi.AppPkgDownloadError
 					ppln.deleteWorkerFromPipelinePhase(ctx, phaseInfo.Phase, downloadWorker)
 				} else if isPhaseStatusComplete(phaseInfo) {
+					ppln.updateAppDependencyInfo(ctx, downloadWorker)
 					ppln.transitionWorkerPhase(ctx, downloadWorker, enterpriseApi.PhaseDownload, enterpriseApi.PhasePodCopy)
 				} else if checkIfWorkerIsEligibleForRun(ctx, downloadWorker, phaseInfo, enterpriseApi.AppPkgDownloadComplete) {
 					downloadWorker.waiter = &pplnPhase.workerWaiter
@@ -539,6 +543,7 @@ downloadPhase:
 			}
 		}
 
+		pplnPhase.publishDebugInfo()
 		time.Sleep(200 * time.Millisecond)
 	}
 }
@@ -547,6 +552,9 @@ downloadPhase:
 func (ctx *localScopePlaybookContext) runPlaybook(rctx context.Context) error {
 	worker := ctx.worker
 	cr := worker.cr
+	rctx, span := tracing.StartCRSpan(rctx, "localScopePlaybookContext.runPlaybook", cr, tracing.AttributeAppName.String(worker.appDeployInfo.AppName), tracing.AttributePodName.String(worker.targetPodName))
+	defer span.End()
+
 	reqLogger := log.FromContext(rctx)
 	scopedLog := reqLogger.WithName("localScopeInstallContext.runPlaybook").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace(), "pod", worker.targetPodName, "app name", worker.appDeployInfo.AppName)
 
@@ -567,6 +575,7 @@ func (ctx *localScopePlaybookContext) runPlaybook(rctx context.Context) error {
 	if !checkIfFileExistsOnPod(rctx, cr, appPkgPathOnPod, ctx.podExecClient) {
 		scopedLog.Error(nil, "app pkg missing on Pod", "app pkg path", appPkgPathOnPod)
 		phaseInfo.Status = enterpriseApi.AppPkgMissingOnPodError
+		phaseInfo.LastError = fmt.Sprintf("app pkg missing on Pod. app pkg path: %s", appPkgPathOnPod)
 
 		return fmt.Errorf("app pkg missing on Pod. app pkg path: %s", appPkgPathOnPod)
 	}
@@ -585,6 +594,7 @@ func (ctx *localScopePlaybookContext) runPlaybook(rctx context.Context) error {
 	// if the app was already installed previously, then just mark it for install complete
 	if stdErr != "" || err != nil {
 		phaseInfo.FailCount++
+		phaseInfo.LastError = fmt.Sprintf("local scoped app package install failed. stdErr: %s, error: %v", stdErr, err)
 		scopedLog.Error(err, "local scoped app package install failed", "stdout", stdOut, "stderr", stdErr, "app pkg path", appPkgPathOnPod, "failCount", phaseInfo.FailCount)
 		return fmt.Errorf("local scoped app package install failed. stdOut: %s, stdErr: %s, app pkg path: %s, failCount: %d", stdOut, stdErr, appPkgPathOnPod, phaseInfo.FailCount)
 	}
@@ -648,6 +658,9 @@ func extractClusterScopedAppOnPod(ctx context.Context, worker *PipelineWorker, a
 // runPodCopyWorker runs one pod copy worker
 func runPodCopyWorker(ctx context.Context, worker *PipelineWorker, ch chan struct{}) {
 	cr := worker.cr
+	ctx, span := tracing.StartCRSpan(ctx, "runPodCopyWorker", cr, tracing.AttributeAppName.String(worker.appDeployInfo.AppName), tracing.AttributePodName.String(worker.targetPodName))
+	defer span.End()
+
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("runPodCopyWorker").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace(), "app name", worker.appDeployInfo.AppName, "pod", worker.targetPodName)
 	defer func() {
@@ -662,6 +675,11 @@ func runPodCopyWorker(ctx context.Context, worker *PipelineWorker, ch chan struc
 	appPkgLocalDir := getAppPackageLocalDir(cr, appSrcScope, worker.appSrcName)
 	appPkgLocalPath := appPkgLocalDir + appPkgFileName
 
+	// reuse the app package from the shared cache, if available
+	if cachedAppPkgPath, ok := getCachedAppPkgPath(ctx, worker); ok {
+		appPkgLocalPath = cachedAppPkgPath
+	}
+
 	appPkgPathOnPod := filepath.Join(appBktMnt, worker.appSrcName, appPkgFileName)
 
 	phaseInfo := getPhaseInfoByPhaseType(ctx, worker, enterpriseApi.PhasePodCopy)
@@ -678,6 +696,7 @@ func runPodCopyWorker(ctx context.Context, worker *PipelineWorker, ch chan struc
 	stdOut, stdErr, err := CopyFileToPod(ctx, worker.client, cr.GetNamespace(), appPkgLocalPath, appPkgPathOnPod, podExecClient)
 	if err != nil {
 		phaseInfo.FailCount++
+		phaseInfo.LastError = fmt.Sprintf("app package pod copy failed. stdErr: %s, error: %v", stdErr, err)
 		scopedLog.Error(err, "app package pod copy failed", "stdout", stdOut, "stderr", stdErr, "failCount", phaseInfo.FailCount)
 		return
 	}
@@ -686,6 +705,7 @@ func runPodCopyWorker(ctx context.Context, worker *PipelineWorker, ch chan struc
 		err = extractClusterScopedAppOnPod(ctx, worker, appSrcScope, appPkgPathOnPod, appPkgLocalPath, podExecClient)
 		if err != nil {
 			phaseInfo.FailCount++
+			phaseInfo.LastError = fmt.Sprintf("extracting the app package on pod failed. error: %v", err)
 			scopedLog.Error(err, "extracting the app package on pod failed", "failCount", phaseInfo.FailCount)
 			return
 		}
@@ -803,6 +823,7 @@ podCopyPhase:
 			}
 		}
 
+		pplnPhase.publishDebugInfo()
 		time.Sleep(200 * time.Millisecond)
 	}
 }
@@ -978,6 +999,9 @@ func (ppln *AppInstallPipeline) installPhaseManager(ctx context.Context) {
 	for i := range podInstallTracker {
 		podInstallTracker[i] = make(chan struct{}, maxParallelInstallsPerPod)
 	}
+	pplnPhase.mutex.Lock()
+	pplnPhase.installTracker = podInstallTracker
+	pplnPhase.mutex.Unlock()
 
 	// Set the msgChannel that matches the installWorkerPool size.
 	pplnPhase.msgChannel = make(chan *PipelineWorker, replicas)
@@ -1000,6 +1024,9 @@ installPhase:
 			}
 
 		default:
+			// apps are installed only after all their dependencies are installed
+			appsByID := ppln.validateAppDependencies(ctx)
+
 			for _, installWorker := range pplnPhase.q {
 				appScope := getAppSrcScope(ctx, installWorker.afwConfig, installWorker.appSrcName)
 				if enterpriseApi.ScopeLocal != appScope {
@@ -1016,7 +1043,13 @@ installPhase:
 					ppln.deleteWorkerFromPipelinePhase(ctx, phaseInfo.Phase, installWorker)
 				} else if phaseInfo.Status == enterpriseApi.AppPkgMissingOnPodError {
 					ppln.transitionWorkerPhase(ctx, installWorker, enterpriseApi.PhaseInstall, enterpriseApi.PhasePodCopy)
+				} else if !installWorker.isActive && installWorker.appDeployInfo.DependencyError != "" {
+					scopedLog.Error(nil, "app can not be installed", "name", installWorker.cr.GetName(), "namespace", installWorker.cr.GetNamespace(), "App name", installWorker.appDeployInfo.AppName, "reason", installWorker.appDeployInfo.DependencyError)
+					phaseInfo.Status = enterpriseApi.AppPkgDependencyError
+					phaseInfo.LastError = installWorker.appDeployInfo.DependencyError
+					ppln.deleteWorkerFromPipelinePhase(ctx, phaseInfo.Phase, installWorker)
 				} else if checkIfWorkerIsEligibleForRun(ctx, installWorker, phaseInfo, enterpriseApi.AppPkgInstallComplete) &&
+					areAppDependenciesInstalled(installWorker, appsByID) &&
 					getInstallSlotForPod(ctx, podInstallTracker, installWorker.targetPodName) {
 					installWorker.waiter = &pplnPhase.workerWaiter
 					select {
@@ -1035,6 +1068,7 @@ installPhase:
 			}
 		}
 
+		pplnPhase.publishDebugInfo()
 		time.Sleep(200 * time.Millisecond)
 	}
 }
@@ -1097,7 +1131,7 @@ func isAppInstallationCompleteOnAllReplicas(auxPhaseInfo []enterpriseApi.PhaseIn
 
 // isClusterScoped checks whether current cr is a SHC or a CM
 func isClusterScoped(kind string) bool {
-	return kind == "ClusterMaster" || kind == "SearchHeadCluster"
+	return kind == "ClusterMaster" || kind == "ClusterManager" || kind == "SearchHeadCluster"
 }
 
 // checkIfBundlePushIsDone checks if the bundle push is done, if there are cluster scoped apps
@@ -1145,6 +1179,14 @@ func deleteAppPkgFromOperator(ctx context.Context, worker *PipelineWorker) {
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("deleteAppPkgFromOperator").WithValues("name", worker.cr.GetName(), "namespace", worker.cr.GetNamespace(), "app pkg", worker.appDeployInfo.AppName)
 
+	// app packages from the shared cache are only dereferenced, so that the other CRs can still use them
+	if appPkgCache := getAppPkgCache(); appPkgCache != nil {
+		cacheKey, err := getAppPkgCacheKey(ctx, worker)
+		if err == nil && appPkgCache.release(ctx, cacheKey, getAppPkgCacheHolder(worker)) {
+			return
+		}
+	}
+
 	appPkgLocalPath := getAppPackageLocalPath(ctx, worker)
 	err := os.Remove(appPkgLocalPath)
 	if err != nil {
@@ -1165,11 +1207,11 @@ func afwGetReleventStatefulsetByKind(ctx context.Context, cr splcommon.MetaObjec
 	switch cr.GetObjectKind().GroupVersionKind().Kind {
 	case "Standalone":
 		instanceID = SplunkStandalone
-	case "LicenseMaster":
+	case "LicenseMaster", "LicenseManager":
 		instanceID = SplunkLicenseManager
 	case "SearchHeadCluster":
 		instanceID = SplunkDeployer
-	case "ClusterMaster":
+	case "ClusterMaster", "ClusterManager":
 		instanceID = SplunkClusterManager
 	case "MonitoringConsole":
 		instanceID = SplunkMonitoringConsole
@@ -1223,7 +1265,7 @@ func getLocalScopePlaybookContext(ctx context.Context, installWorker *PipelineWo
 func getClusterScopePlaybookContext(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, afwPipeline *AppInstallPipeline, podName string, kind string, podExecClient splutil.PodExecClientImpl) PlaybookImpl {
 
 	switch kind {
-	case "ClusterMaster":
+	case "ClusterMaster", "ClusterManager":
 		return getIdxcPlaybookContext(ctx, client, cr, afwPipeline, podName, podExecClient)
 	case "SearchHeadCluster":
 		return getSHCPlaybookContext(ctx, client, cr, afwPipeline, podName, podExecClient)
@@ -1308,6 +1350,12 @@ func (shcPlaybookContext *SHCPlaybookContext) isBundlePushComplete(ctx context.C
 
 // triggerBundlePush triggers the bundle push operation for SHC
 func (shcPlaybookContext *SHCPlaybookContext) triggerBundlePush(ctx context.Context) error {
+	ctx, release, err := concurrency.AcquireHeavyOperation(ctx, concurrency.OperationBundlePush)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	cmd := fmt.Sprintf(applySHCBundleCmdStr, shcPlaybookContext.searchHeadCaptainURL, shcBundlePushStatusCheckFile)
 	streamOptions := splutil.NewStreamOptionsObject(cmd)
 	stdOut, stdErr, err := shcPlaybookContext.podExecClient.RunPodExecCommand(ctx, streamOptions, []string{"/bin/sh"})
@@ -1321,7 +1369,7 @@ func (shcPlaybookContext *SHCPlaybookContext) triggerBundlePush(ctx context.Cont
 // getClusterScopedAppsLocOnPod returns the cluster apps directory
 func getClusterScopedAppsLocOnPod(cr splcommon.MetaObject) string {
 	switch cr.GetObjectKind().GroupVersionKind().Kind {
-	case "ClusterMaster":
+	case "ClusterMaster", "ClusterManager":
 		return idxcAppsLocationOnClusterManager
 	case "SearchHeadCluster":
 		return shcAppsLocationOnDeployer
@@ -1349,6 +1397,9 @@ func adjustClusterAppsFilePermissions(ctx context.Context, podExecClient splutil
 
 // runPlaybook will implement the bundle push logic for SHC
 func (shcPlaybookContext *SHCPlaybookContext) runPlaybook(ctx context.Context) error {
+	ctx, span := tracing.StartCRSpan(ctx, "SHCPlaybookContext.runPlaybook", shcPlaybookContext.cr, tracing.AttributePodName.String(shcPlaybookContext.targetPodName))
+	defer span.End()
+
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("runPlaybook").WithValues("crName", shcPlaybookContext.cr.GetName(), "namespace", shcPlaybookContext.cr.GetNamespace())
 
@@ -1435,6 +1486,12 @@ func (idxcPlaybookContext *IdxcPlaybookContext) isBundlePushComplete(ctx context
 func (idxcPlaybookContext *IdxcPlaybookContext) triggerBundlePush(ctx context.Context) error {
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("idxcPlaybookContext.triggerBundlePush()")
+	ctx, release, err := concurrency.AcquireHeavyOperation(ctx, concurrency.OperationBundlePush)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	streamOptions := splutil.NewStreamOptionsObject(applyIdxcBundleCmdStr)
 	stdOut, stdErr, err := idxcPlaybookContext.podExecClient.RunPodExecCommand(ctx, streamOptions, []string{"/bin/sh"})
 
@@ -1454,6 +1511,8 @@ func (idxcPlaybookContext *IdxcPlaybookContext) triggerBundlePush(ctx context.Co
 // 1. If the bundle push is not in progress, run the logic to push the bundle from CM to indexer peers
 // 2. OR else, if the bundle push is already in progress, check the status of bundle push
 func (idxcPlaybookContext *IdxcPlaybookContext) runPlaybook(ctx context.Context) error {
+	ctx, span := tracing.StartCRSpan(ctx, "IdxcPlaybookContext.runPlaybook", idxcPlaybookContext.cr, tracing.AttributePodName.String(idxcPlaybookContext.targetPodName))
+	defer span.End()
 
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("RunPlaybook").WithValues("crName", idxcPlaybookContext.cr.GetName(), "namespace", idxcPlaybookContext.cr.GetNamespace())
@@ -1539,6 +1598,9 @@ func isPhaseInfoEligibleForSchedulerEntry(ctx context.Context, appSrcName string
 
 // afwSchedulerEntry Starts the scheduler Pipeline with the required phases
 func afwSchedulerEntry(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, appDeployContext *enterpriseApi.AppDeploymentContext, appFrameworkConfig *enterpriseApi.AppFrameworkSpec) (bool, error) {
+	ctx, span := tracing.StartCRSpan(ctx, "afwSchedulerEntry", cr)
+	defer span.End()
+
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("afwSchedulerEntry").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
 
@@ -1556,6 +1618,10 @@ func afwSchedulerEntry(ctx context.Context, client splcommon.ControllerClient, c
 
 	afwPipeline := initAppInstallPipeline(ctx, appDeployContext, client, cr)
 
+	// expose the pipeline on the introspection endpoint while it runs
+	registerAppInstallPipeline(afwPipeline)
+	defer unregisterAppInstallPipeline(afwPipeline)
+
 	// Start the download phase manager
 	afwPipeline.phaseWaiter.Add(1)
 	go afwPipeline.downloadPhaseManager(ctx)
@@ -1617,6 +1683,9 @@ func afwSchedulerEntry(ctx context.Context, client splcommon.ControllerClient, c
 
 // afwYieldWatcher issues termination request to the scheduler when the yield time expires or the pipelines become empty.
 func (ppln *AppInstallPipeline) afwYieldWatcher(ctx context.Context) {
+	ctx, span := tracing.StartCRSpan(ctx, "afwYieldWatcher", ppln.cr)
+	defer span.End()
+
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("afwYieldWatcher").WithValues("name", ppln.cr.GetName(), "namespace", ppln.cr.GetNamespace())
 	yieldTrigger := time.After(time.Duration(ppln.appDeployContext.AppFrameworkConfig.SchedulerYieldInterval) * time.Second)
@@ -1383,20 +1383,18 @@ func TestPipelineWorkerDownloadShouldPass(t *testing.T) {
 
 		s3ClientMgr.initFn = initFunc
 
-		pplnPhase := &PipelinePhase{}
 		worker := &PipelineWorker{
 			appSrcName:    appSrc.Name,
 			cr:            &cr,
 			sts:           sts,
 			afwConfig:     &cr.Spec.AppFrameworkConfig,
 			appDeployInfo: appDeployInfoList[index],
-			waiter:        new(sync.WaitGroup),
 		}
-		var downloadWorkersRunPool = make(chan struct{}, 1)
-		downloadWorkersRunPool <- struct{}{}
-		worker.waiter.Add(1)
-		go worker.download(ctx, pplnPhase, *s3ClientMgr, localPath, downloadWorkersRunPool)
-		worker.waiter.Wait()
+		localFile := getLocalAppFileName(ctx, localPath, worker.appDeployInfo.AppName, worker.appDeployInfo.ObjectHash)
+		err = worker.downloadAppPkg(ctx, *s3ClientMgr, localFile)
+		if err != nil {
+			t.Errorf("downloadAppPkg should not return an error, error: %v", err)
+		}
 	}
 
 	// verify if all the apps are in DownloadComplete state
@@ -1482,15 +1480,10 @@ func TestPipelineWorkerDownloadShouldFail(t *testing.T) {
 		waiter:        new(sync.WaitGroup),
 	}
 
-	pplnPhase := &PipelinePhase{}
-	var downloadWorkersRunPool = make(chan struct{}, 1)
-	downloadWorkersRunPool <- struct{}{}
-	worker.waiter.Add(1)
-	go worker.download(ctx, pplnPhase, *s3ClientMgr, "", downloadWorkersRunPool)
-	worker.waiter.Wait()
+	err := worker.downloadAppPkg(ctx, *s3ClientMgr, "")
 
 	// we should return error here
-	if ok, _ := areAppsDownloadedSuccessfully(appDeployInfoList); ok {
+	if ok, _ := areAppsDownloadedSuccessfully(appDeployInfoList); ok || err == nil {
 		t.Errorf("We should have returned error here since appSrcName is invalid in the worker")
 	}
 
@@ -1507,7 +1500,7 @@ func TestPipelineWorkerDownloadShouldFail(t *testing.T) {
 	client.AddObject(&s3Secret)
 
 	// Create namespace scoped secret
-	_, err := splutil.ApplyNamespaceScopedSecretObject(ctx, client, "test")
+	_, err = splutil.ApplyNamespaceScopedSecretObject(ctx, client, "test")
 	if err != nil {
 		t.Errorf(err.Error())
 	}
@@ -1526,12 +1519,9 @@ func TestPipelineWorkerDownloadShouldFail(t *testing.T) {
 
 	s3ClientMgr.initFn = initFunc
 
-	worker.waiter.Add(1)
-	downloadWorkersRunPool <- struct{}{}
-	go worker.download(ctx, pplnPhase, *s3ClientMgr, "", downloadWorkersRunPool)
-	worker.waiter.Wait()
+	err = worker.downloadAppPkg(ctx, *s3ClientMgr, "")
 	// we should return error here
-	if ok, _ := areAppsDownloadedSuccessfully(appDeployInfoList); ok {
+	if ok, _ := areAppsDownloadedSuccessfully(appDeployInfoList); ok || err == nil {
 		t.Errorf("We should have returned error here since objectHash is empty in the worker")
 	}
 
@@ -1672,61 +1662,6 @@ func TestScheduleDownloads(t *testing.T) {
 	downloadPhaseWaiter.Wait()
 }
 
-func TestCreateDownloadDirOnOperator(t *testing.T) {
-	ctx := context.TODO()
-	standalone := enterpriseApi.Standalone{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "s1",
-			Namespace: "test",
-		},
-		TypeMeta: metav1.TypeMeta{
-			Kind: "Standalone",
-		},
-		Spec: enterpriseApi.StandaloneSpec{
-			Replicas: 1,
-			AppFrameworkConfig: enterpriseApi.AppFrameworkSpec{
-				PhaseMaxRetries:           3,
-				AppsRepoPollInterval:      60,
-				MaxConcurrentAppDownloads: 5,
-				VolList: []enterpriseApi.VolumeSpec{
-					{
-						Name:      "test_volume",
-						Endpoint:  "https://s3-eu-west-2.amazonaws.com",
-						Path:      "testbucket-rs-london",
-						SecretRef: "s3-secret",
-						Provider:  "aws",
-					},
-				},
-				AppSources: []enterpriseApi.AppSourceSpec{
-					{
-						Name:     "appSrc1",
-						Location: "adminAppsRepo",
-						AppSourceDefaultSpec: enterpriseApi.AppSourceDefaultSpec{
-							VolName: "test_volume",
-							Scope:   "local",
-						},
-					},
-				},
-			},
-		},
-	}
-
-	worker := &PipelineWorker{
-		appSrcName: "appSrc1",
-		cr:         &standalone,
-		afwConfig:  &standalone.Spec.AppFrameworkConfig,
-		appDeployInfo: &enterpriseApi.AppDeploymentInfo{
-			AppName: "testApp1",
-		},
-	}
-
-	localPath, err := worker.createDownloadDirOnOperator(ctx)
-	defer os.Remove(localPath)
-	if err != nil {
-		t.Errorf("we should have created the download directory=%s, err=%v", localPath, err)
-	}
-}
-
 func getConvertedClient(client splcommon.ControllerClient) splcommon.ControllerClient {
 	return client
 }
@@ -0,0 +1,260 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// configMap key for the App deployment status
+	appDeployStatusKey = "appSrcDeployStatus"
+)
+
+// getAppDeploymentContextFromCR returns the App deployment context of the CR, nil if the App framework is not applicable to the CR
+func getAppDeploymentContextFromCR(cr splcommon.MetaObject) *enterpriseApi.AppDeploymentContext {
+	switch cr := cr.(type) {
+	case *enterpriseApi.Standalone:
+		return &cr.Status.AppContext
+	case *enterpriseApi.ClusterMaster:
+		return &cr.Status.AppContext
+	case *enterpriseApi.SearchHeadCluster:
+		return &cr.Status.AppContext
+	case *enterpriseApi.LicenseMaster:
+		return &cr.Status.AppContext
+	case *enterpriseApi.MonitoringConsole:
+		return &cr.Status.AppContext
+	}
+	return nil
+}
+
+// encodeAppDeployStatus compacts the App deployment status to be saved in the configMap
+func encodeAppDeployStatus(appsSrcDeployStatus map[string]enterpriseApi.AppSrcDeployInfo) (string, error) {
+	data, err := json.Marshal(appsSrcDeployStatus)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err = zw.Write(data)
+	if err != nil {
+		return "", err
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeAppDeployStatus reads the App deployment status saved in the configMap
+func decodeAppDeployStatus(encoded string) (map[string]enterpriseApi.AppSrcDeployInfo, error) {
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	appsSrcDeployStatus := make(map[string]enterpriseApi.AppSrcDeployInfo)
+	err = json.Unmarshal(data, &appsSrcDeployStatus)
+	return appsSrcDeployStatus, err
+}
+
+// loadAppDeployStatus reads the App deployment status from the configMap into the App deployment context
+func loadAppDeployStatus(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, afwStatusContext *enterpriseApi.AppDeploymentContext) error {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("loadAppDeployStatus").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
+
+	// App deployment status from the CR status(i.e, saved by an older operator version) takes the precedence
+	if afwStatusContext == nil || afwStatusContext.AppDeployStatusConfigMap == "" || len(afwStatusContext.AppsSrcDeployStatus) != 0 {
+		return nil
+	}
+
+	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: afwStatusContext.AppDeployStatusConfigMap}
+	configMap, err := splctrl.GetConfigMap(ctx, client, namespacedName)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			scopedLog.Info("App deployment status configMap is missing, apps will be deployed again", "configMap", namespacedName.Name)
+			return nil
+		}
+		return err
+	}
+
+	appsSrcDeployStatus, err := decodeAppDeployStatus(configMap.Data[appDeployStatusKey])
+	if err != nil {
+		scopedLog.Error(err, "Unable to read the App deployment status", "configMap", namespacedName.Name)
+		return err
+	}
+
+	afwStatusContext.AppsSrcDeployStatus = appsSrcDeployStatus
+	return nil
+}
+
+// GetAppDeployStatus returns the App deployment status of a CR from its status or from the App deployment status configMap,
+// without changing afwStatusContext
+func GetAppDeployStatus(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, afwStatusContext *enterpriseApi.AppDeploymentContext) (map[string]enterpriseApi.AppSrcDeployInfo, error) {
+	if afwStatusContext == nil {
+		return nil, nil
+	}
+
+	statusContext := *afwStatusContext
+	err := loadAppDeployStatus(ctx, client, cr, &statusContext)
+	if err != nil {
+		return nil, err
+	}
+	return statusContext.AppsSrcDeployStatus, nil
+}
+
+// saveAppDeployStatus saves the App deployment status in the configMap, and updates the App deployment summary
+func saveAppDeployStatus(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, afwStatusContext *enterpriseApi.AppDeploymentContext) error {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("saveAppDeployStatus").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
+
+	encoded, err := encodeAppDeployStatus(afwStatusContext.AppsSrcDeployStatus)
+	if err != nil {
+		return err
+	}
+
+	configMapName := GetSplunkAppDeployStatusConfigMapName(cr.GetName(), cr.GetObjectKind().GroupVersionKind().Kind)
+	configMap := splctrl.PrepareConfigMap(configMapName, cr.GetNamespace(), map[string]string{appDeployStatusKey: encoded})
+	configMap.SetOwnerReferences(append(configMap.GetOwnerReferences(), splcommon.AsOwner(cr, true)))
+
+	_, err = splctrl.ApplyConfigMap(ctx, client, configMap)
+	if err != nil {
+		scopedLog.Error(err, "Unable to apply the App deployment status configMap", "configMap", configMapName)
+		return err
+	}
+
+	afwStatusContext.AppDeployStatusConfigMap = configMapName
+	afwStatusContext.Summary = getAppDeploymentSummary(ctx, cr, afwStatusContext)
+	return nil
+}
+
+// offloadAppDeployStatus saves the App deployment status of the CR in the configMap.
+// Returns true if the App deployment status can be left out of the CR status
+func offloadAppDeployStatus(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject) bool {
+	// Nothing to save, when the App deployment status is not yet loaded from the configMap
+	afwStatusContext := getAppDeploymentContextFromCR(cr)
+	if afwStatusContext == nil || len(afwStatusContext.AppsSrcDeployStatus) == 0 {
+		return false
+	}
+
+	// Keep the App deployment status in the CR status, rather than losing it
+	return saveAppDeployStatus(ctx, client, cr, afwStatusContext) == nil
+}
+
+// isAppPhaseFailed checks if the phase ended up with an error
+func isAppPhaseFailed(ctx context.Context, phaseInfo *enterpriseApi.PhaseInfo, afwConfig *enterpriseApi.AppFrameworkSpec) bool {
+	switch phaseInfo.Status {
+	case enterpriseApi.AppPkgDownloadError, enterpriseApi.AppPkgPodCopyError, enterpriseApi.AppPkgDependencyError,
+		enterpriseApi.AppPkgMissingOnPodError, enterpriseApi.AppPkgInstallError:
+		return true
+	}
+
+	return isPhaseMaxRetriesReached(ctx, phaseInfo, afwConfig)
+}
+
+// getAppDeploymentFailure returns the failure details for the phase
+func getAppDeploymentFailure(appSrcName string, appDeployInfo *enterpriseApi.AppDeploymentInfo, phaseInfo *enterpriseApi.PhaseInfo, podName string) enterpriseApi.AppDeploymentFailure {
+	failure := enterpriseApi.AppDeploymentFailure{
+		AppSource: appSrcName,
+		AppName:   appDeployInfo.AppName,
+		Phase:     phaseInfo.Phase,
+		Status:    AppPhaseStatusAsStr(phaseInfo.Status),
+		FailCount: phaseInfo.FailCount,
+		Message:   phaseInfo.LastError,
+	}
+
+	if phaseInfo.Phase != enterpriseApi.PhaseDownload {
+		failure.Pod = podName
+	}
+
+	if failure.Message == "" {
+		failure.Message = appDeployInfo.DependencyError
+	}
+	return failure
+}
+
+// getAppDeploymentSummary returns the summary counters and the failure details of the App deployment status
+func getAppDeploymentSummary(ctx context.Context, cr splcommon.MetaObject, afwStatusContext *enterpriseApi.AppDeploymentContext) enterpriseApi.AppDeploymentSummary {
+	var summary enterpriseApi.AppDeploymentSummary
+	afwConfig := &afwStatusContext.AppFrameworkConfig
+
+	var appSrcNames []string
+	for appSrcName := range afwStatusContext.AppsSrcDeployStatus {
+		appSrcNames = append(appSrcNames, appSrcName)
+	}
+	sort.Strings(appSrcNames)
+
+	for _, appSrcName := range appSrcNames {
+		appDeployList := afwStatusContext.AppsSrcDeployStatus[appSrcName].AppDeploymentInfoList
+		for i := range appDeployList {
+			appDeployInfo := &appDeployList[i]
+			if appDeployInfo.RepoState != enterpriseApi.RepoStateActive {
+				continue
+			}
+			summary.TotalApps++
+
+			var failures []enterpriseApi.AppDeploymentFailure
+			if isAppPhaseFailed(ctx, &appDeployInfo.PhaseInfo, afwConfig) {
+				failures = append(failures, getAppDeploymentFailure(appSrcName, appDeployInfo, &appDeployInfo.PhaseInfo, getApplicablePodNameForAppFramework(cr, 0)))
+			}
+
+			for podID := range appDeployInfo.AuxPhaseInfo {
+				if isAppPhaseFailed(ctx, &appDeployInfo.AuxPhaseInfo[podID], afwConfig) {
+					failures = append(failures, getAppDeploymentFailure(appSrcName, appDeployInfo, &appDeployInfo.AuxPhaseInfo[podID], getApplicablePodNameForAppFramework(cr, podID)))
+				}
+			}
+
+			switch {
+			case len(failures) > 0:
+				summary.FailedApps++
+				summary.Failures = append(summary.Failures, failures...)
+			case appDeployInfo.DeployStatus == enterpriseApi.DeployStatusComplete:
+				summary.CompletedApps++
+			default:
+				summary.PendingApps++
+			}
+		}
+	}
+
+	return summary
+}
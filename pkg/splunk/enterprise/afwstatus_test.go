@@ -0,0 +1,204 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getAppDeployStatusTestCR() *enterpriseApi.Standalone {
+	return &enterpriseApi.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Spec: enterpriseApi.StandaloneSpec{
+			Replicas: 2,
+		},
+		Status: enterpriseApi.StandaloneStatus{
+			AppContext: enterpriseApi.AppDeploymentContext{
+				AppFrameworkConfig: enterpriseApi.AppFrameworkSpec{
+					PhaseMaxRetries: 3,
+				},
+				AppsSrcDeployStatus: map[string]enterpriseApi.AppSrcDeployInfo{
+					"appSrc1": {
+						AppDeploymentInfoList: []enterpriseApi.AppDeploymentInfo{
+							{
+								AppName:      "app1.tgz",
+								RepoState:    enterpriseApi.RepoStateActive,
+								DeployStatus: enterpriseApi.DeployStatusComplete,
+								PhaseInfo:    enterpriseApi.PhaseInfo{Phase: enterpriseApi.PhaseInstall, Status: enterpriseApi.AppPkgInstallComplete},
+							},
+							{
+								AppName:      "app2.tgz",
+								RepoState:    enterpriseApi.RepoStateActive,
+								DeployStatus: enterpriseApi.DeployStatusPending,
+								PhaseInfo:    enterpriseApi.PhaseInfo{Phase: enterpriseApi.PhaseDownload, Status: enterpriseApi.AppPkgDownloadError, FailCount: 4, LastError: "access denied"},
+							},
+							{
+								AppName:      "app3.tgz",
+								RepoState:    enterpriseApi.RepoStateActive,
+								DeployStatus: enterpriseApi.DeployStatusInProgress,
+								PhaseInfo:    enterpriseApi.PhaseInfo{Phase: enterpriseApi.PhasePodCopy, Status: enterpriseApi.AppPkgPodCopyComplete},
+								AuxPhaseInfo: []enterpriseApi.PhaseInfo{
+									{Phase: enterpriseApi.PhaseInstall, Status: enterpriseApi.AppPkgInstallComplete},
+									{Phase: enterpriseApi.PhaseInstall, Status: enterpriseApi.AppPkgInstallError, FailCount: 4, LastError: "install failed"},
+								},
+							},
+							{
+								AppName:   "app4.tgz",
+								RepoState: enterpriseApi.RepoStateDeleted,
+							},
+						},
+					},
+					"appSrc2": {
+						AppDeploymentInfoList: []enterpriseApi.AppDeploymentInfo{
+							{
+								AppName:      "app5.tgz",
+								RepoState:    enterpriseApi.RepoStateActive,
+								DeployStatus: enterpriseApi.DeployStatusPending,
+								PhaseInfo:    enterpriseApi.PhaseInfo{Phase: enterpriseApi.PhaseDownload, Status: enterpriseApi.AppPkgDownloadPending, FailCount: 1},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestEncodeDecodeAppDeployStatus(t *testing.T) {
+	cr := getAppDeployStatusTestCR()
+
+	encoded, err := encodeAppDeployStatus(cr.Status.AppContext.AppsSrcDeployStatus)
+	if err != nil {
+		t.Errorf("unable to encode the app deploy status, error: %v", err)
+	}
+
+	decoded, err := decodeAppDeployStatus(encoded)
+	if err != nil {
+		t.Errorf("unable to decode the app deploy status, error: %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded, cr.Status.AppContext.AppsSrcDeployStatus) {
+		t.Errorf("decoded app deploy status doesn't match, got: %+v", decoded)
+	}
+
+	_, err = decodeAppDeployStatus("invalid")
+	if err == nil {
+		t.Errorf("decoding an invalid app deploy status should return an error")
+	}
+}
+
+func TestGetAppDeploymentSummary(t *testing.T) {
+	ctx := context.TODO()
+	cr := getAppDeployStatusTestCR()
+
+	summary := getAppDeploymentSummary(ctx, cr, &cr.Status.AppContext)
+	if summary.TotalApps != 4 || summary.CompletedApps != 1 || summary.PendingApps != 1 || summary.FailedApps != 2 {
+		t.Errorf("unexpected summary counters, got: %+v", summary)
+	}
+
+	expectedFailures := []enterpriseApi.AppDeploymentFailure{
+		{
+			AppSource: "appSrc1",
+			AppName:   "app2.tgz",
+			Phase:     enterpriseApi.PhaseDownload,
+			Status:    "Download Error",
+			FailCount: 4,
+			Message:   "access denied",
+		},
+		{
+			AppSource: "appSrc1",
+			AppName:   "app3.tgz",
+			Phase:     enterpriseApi.PhaseInstall,
+			Pod:       "splunk-stack1-standalone-1",
+			Status:    "Install Error",
+			FailCount: 4,
+			Message:   "install failed",
+		},
+	}
+	if !reflect.DeepEqual(summary.Failures, expectedFailures) {
+		t.Errorf("unexpected failures, got: %+v, expected: %+v", summary.Failures, expectedFailures)
+	}
+}
+
+func TestOffloadAndLoadAppDeployStatus(t *testing.T) {
+	ctx := context.TODO()
+	client := spltest.NewMockClient()
+	cr := getAppDeployStatusTestCR()
+	appsSrcDeployStatus := cr.Status.AppContext.AppsSrcDeployStatus
+
+	if !offloadAppDeployStatus(ctx, client, cr) {
+		t.Errorf("app deploy status should be saved in the configMap")
+	}
+
+	if cr.Status.AppContext.AppDeployStatusConfigMap != "splunk-stack1-standalone-app-deploy-status" {
+		t.Errorf("unexpected app deploy status configMap name %s", cr.Status.AppContext.AppDeployStatusConfigMap)
+	}
+
+	if cr.Status.AppContext.Summary.TotalApps != 4 {
+		t.Errorf("summary should be updated, got: %+v", cr.Status.AppContext.Summary)
+	}
+
+	// app deploy status is loaded from the configMap, once it is left out of the CR status
+	appDeployContext := cr.Status.AppContext.DeepCopy()
+	appDeployContext.AppsSrcDeployStatus = nil
+	err := loadAppDeployStatus(ctx, client, cr, appDeployContext)
+	if err != nil {
+		t.Errorf("unable to load the app deploy status, error: %v", err)
+	}
+
+	if !reflect.DeepEqual(appDeployContext.AppsSrcDeployStatus, appsSrcDeployStatus) {
+		t.Errorf("loaded app deploy status doesn't match, got: %+v", appDeployContext.AppsSrcDeployStatus)
+	}
+
+	// app deploy status is read without updating the App deployment context
+	appDeployContext.AppsSrcDeployStatus = nil
+	gotStatus, err := GetAppDeployStatus(ctx, client, cr, appDeployContext)
+	if err != nil || !reflect.DeepEqual(gotStatus, appsSrcDeployStatus) {
+		t.Errorf("GetAppDeployStatus() = %+v, %v; want the saved app deploy status", gotStatus, err)
+	}
+	if appDeployContext.AppsSrcDeployStatus != nil {
+		t.Errorf("GetAppDeployStatus() updated the App deployment context")
+	}
+
+	// missing configMap should not return an error
+	appDeployContext.AppDeployStatusConfigMap = "missing"
+	err = loadAppDeployStatus(ctx, client, cr, appDeployContext)
+	if err != nil || appDeployContext.AppsSrcDeployStatus != nil {
+		t.Errorf("missing configMap should not return an error, error: %v", err)
+	}
+
+	// nothing to save when the app deploy status is empty
+	var mc enterpriseApi.MonitoringConsole
+	if offloadAppDeployStatus(ctx, client, &mc) {
+		t.Errorf("empty app deploy status should not be saved")
+	}
+
+	var idxc enterpriseApi.IndexerCluster
+	if offloadAppDeployStatus(ctx, client, &idxc) {
+		t.Errorf("app deploy status is not applicable to the indexer cluster")
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+)
+
+const (
+	// defaultScaleUpCooldownSeconds is the default minimum time between a scaling event and a scale up
+	defaultScaleUpCooldownSeconds = 300
+
+	// defaultScaleDownCooldownSeconds is the default minimum time between a scaling event and a scale down
+	defaultScaleDownCooldownSeconds = 1800
+
+	// defaultScaleUpQueueFillPercent is the default fill ratio of the ingestion queues above which the indexers are scaled up
+	defaultScaleUpQueueFillPercent = 70
+
+	// defaultScaleDownQueueFillPercent is the default fill ratio of the ingestion queues below which the indexers are scaled down
+	defaultScaleDownQueueFillPercent = 20
+
+	// defaultScaleUpConcurrencyPercent is the default search concurrency above which the search head cluster members are scaled up
+	defaultScaleUpConcurrencyPercent = 80
+
+	// defaultScaleDownConcurrencyPercent is the default search concurrency below which the search head cluster members are scaled down
+	defaultScaleDownConcurrencyPercent = 20
+
+	// defaultScaleUpSkippedSearchPercent is the default ratio of skipped scheduled searches above which the search head cluster
+	// members are scaled up
+	defaultScaleUpSkippedSearchPercent = 5
+
+	// minSearchHeadClusterReplicas is the minimum number of search head cluster members
+	minSearchHeadClusterReplicas = 3
+
+	// autoscalingPollInterval is the interval between two evaluations of the autoscaling metrics
+	autoscalingPollInterval = time.Minute
+)
+
+// validateAutoscalingSpec checks the bounds of an AutoscalingSpec, and sets the default cooldown periods
+func validateAutoscalingSpec(spec *enterpriseApi.AutoscalingSpec) error {
+	if !spec.Enabled {
+		return nil
+	}
+
+	if spec.MinReplicas == 0 {
+		spec.MinReplicas = 1
+	}
+	if spec.MaxReplicas < spec.MinReplicas {
+		return fmt.Errorf("autoscaling maxReplicas (%d) should be greater than or equal to minReplicas (%d)", spec.MaxReplicas, spec.MinReplicas)
+	}
+
+	if spec.ScaleUpCooldownSeconds == 0 {
+		spec.ScaleUpCooldownSeconds = defaultScaleUpCooldownSeconds
+	}
+	if spec.ScaleDownCooldownSeconds == 0 {
+		spec.ScaleDownCooldownSeconds = defaultScaleDownCooldownSeconds
+	}
+	return nil
+}
+
+// getAutoscaledReplicas returns the number of replicas decided by the autoscaling, within the bounds of the spec.
+// The autoscaling starts from the number of replicas of the spec
+func getAutoscaledReplicas(spec *enterpriseApi.AutoscalingSpec, status *enterpriseApi.AutoscalingStatus, replicas int32) int32 {
+	if status.DesiredReplicas == 0 {
+		status.DesiredReplicas = replicas
+	}
+	if status.DesiredReplicas < spec.MinReplicas {
+		status.DesiredReplicas = spec.MinReplicas
+	}
+	if status.DesiredReplicas > spec.MaxReplicas {
+		status.DesiredReplicas = spec.MaxReplicas
+	}
+	return status.DesiredReplicas
+}
+
+// scaleAutoscaledReplicas adds delta to the number of replicas decided by the autoscaling, when the cooldown period is over
+// and the result is within the bounds of the spec, and not below floor. It returns true when the number of replicas changed
+func scaleAutoscaledReplicas(spec *enterpriseApi.AutoscalingSpec, status *enterpriseApi.AutoscalingStatus, delta int32, floor int32, reason string, now time.Time) bool {
+	cooldown := int64(spec.ScaleUpCooldownSeconds)
+	if delta < 0 {
+		cooldown = int64(spec.ScaleDownCooldownSeconds)
+	}
+	if delta == 0 || now.Unix()-status.LastScaleTime < cooldown {
+		return false
+	}
+
+	if floor < spec.MinReplicas {
+		floor = spec.MinReplicas
+	}
+	replicas := status.DesiredReplicas + delta
+	if replicas > spec.MaxReplicas || (delta < 0 && replicas < floor) {
+		return false
+	}
+
+	status.DesiredReplicas = replicas
+	status.LastScaleTime = now.Unix()
+	status.LastScaleReason = reason
+	return true
+}
+
+// publishAutoscalingDecision exports a scaling decision of the autoscaling as an event and as metrics
+func publishAutoscalingDecision(ctx context.Context, eventPublisher *K8EventPublisher, cr splcommon.MetaObject, instanceType InstanceType, replicas int32, desiredReplicas int32, reason string) {
+	direction := "up"
+	if desiredReplicas < replicas {
+		direction = "down"
+	}
+	autoscalingDecisionCounter.WithLabelValues(cr.GetNamespace(), cr.GetName(), instanceType.ToString(), direction).Inc()
+	autoscalingDesiredReplicasGauge.WithLabelValues(cr.GetNamespace(), cr.GetName(), instanceType.ToString()).Set(float64(desiredReplicas))
+	if eventPublisher != nil {
+		eventPublisher.Normal(ctx, "Autoscaling", fmt.Sprintf("scaling %s from %d to %d replicas: %s", instanceType.ToString(), replicas, desiredReplicas, reason))
+	}
+}
+
+// getAutoscalingResult returns the reconcile result polling the autoscaling metrics, without delaying an earlier requeue
+func getAutoscalingResult(result reconcile.Result, scaled bool) reconcile.Result {
+	requeueAfter := autoscalingPollInterval
+	if scaled {
+		requeueAfter = time.Second * 5
+	}
+	return requeueNoLaterThan(result, requeueAfter)
+}
+
+// getIndexerAutoscalingDelta returns the scaling of the indexers decided from the fill ratio of the fullest ingestion queue
+// and the average indexing throughput per indexer, with the reason of the decision
+func getIndexerAutoscalingDelta(spec *enterpriseApi.IndexerClusterAutoscalingSpec, replicas int32, queueFillPercent int32, throughputKBps int32) (int32, string) {
+	if queueFillPercent >= spec.ScaleUpQueueFillPercent {
+		return 1, fmt.Sprintf("ingestion queue fill ratio %d%% is above %d%%", queueFillPercent, spec.ScaleUpQueueFillPercent)
+	}
+	if spec.ScaleUpThroughputKBps > 0 && throughputKBps >= spec.ScaleUpThroughputKBps {
+		return 1, fmt.Sprintf("indexing throughput %dKBps per indexer is above %dKBps", throughputKBps, spec.ScaleUpThroughputKBps)
+	}
+
+	// the throughput of the remaining indexers must stay below the threshold after the scale down
+	if queueFillPercent <= spec.ScaleDownQueueFillPercent && replicas > 1 &&
+		(spec.ScaleUpThroughputKBps == 0 || int64(throughputKBps)*int64(replicas)/int64(replicas-1) < int64(spec.ScaleUpThroughputKBps)) {
+		return -1, fmt.Sprintf("ingestion queue fill ratio %d%% is below %d%%", queueFillPercent, spec.ScaleDownQueueFillPercent)
+	}
+	return 0, ""
+}
+
+// validateIndexerClusterAutoscalingSpec checks an IndexerClusterAutoscalingSpec, and sets its default values
+func validateIndexerClusterAutoscalingSpec(spec *enterpriseApi.IndexerClusterAutoscalingSpec) error {
+	if !spec.Enabled {
+		return nil
+	}
+
+	if spec.ScaleUpQueueFillPercent == 0 {
+		spec.ScaleUpQueueFillPercent = defaultScaleUpQueueFillPercent
+	}
+	if spec.ScaleDownQueueFillPercent == 0 {
+		spec.ScaleDownQueueFillPercent = defaultScaleDownQueueFillPercent
+	}
+	if spec.ScaleDownQueueFillPercent >= spec.ScaleUpQueueFillPercent {
+		return fmt.Errorf("autoscaling scaleDownQueueFillPercent (%d) should be lower than scaleUpQueueFillPercent (%d)", spec.ScaleDownQueueFillPercent, spec.ScaleUpQueueFillPercent)
+	}
+	return validateAutoscalingSpec(&spec.AutoscalingSpec)
+}
+
+// applyAutoscaling for indexerClusterPodManager polls the cluster manager for the ingestion metrics of the indexers, and
+// updates the number of replicas decided by the autoscaling. It returns true when the number of replicas changed.
+// Scale downs go through the decommission of the indexers, and the replicas are never lower than the replication factor
+func (mgr *indexerClusterPodManager) applyAutoscaling(ctx context.Context, eventPublisher *K8EventPublisher) (bool, error) {
+	spec := &mgr.cr.Spec.Autoscaling
+	status := &mgr.cr.Status.Autoscaling
+
+	cm := mgr.getClusterManagerClient(ctx)
+	fillRatios, err := cm.GetIndexerQueueFillRatios()
+	if err != nil {
+		return false, fmt.Errorf("could not get the ingestion queues from cluster manager: %v", err)
+	}
+	throughput, err := cm.GetIndexerThroughput()
+	if err != nil {
+		return false, fmt.Errorf("could not get the indexing throughput from cluster manager: %v", err)
+	}
+
+	// only the metrics of the peers of this indexer cluster are used
+	var maxFillRatio, totalThroughput float64
+	var count int
+	for _, peer := range mgr.cr.Status.Peers {
+		fillRatio, ok := fillRatios[peer.Name]
+		if !ok {
+			continue
+		}
+		maxFillRatio = math.Max(maxFillRatio, fillRatio)
+		totalThroughput += throughput[peer.Name]
+		count++
+	}
+	if count == 0 {
+		mgr.log.Info("No autoscaling metrics reported for the indexers")
+		return false, nil
+	}
+	status.QueueFillPercent = int32(math.Round(maxFillRatio * 100))
+	status.ThroughputKBps = int32(math.Round(totalThroughput / float64(count)))
+
+	replicationFactor, err := mgr.getReplicationFactor(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current := status.DesiredReplicas
+	delta, reason := getIndexerAutoscalingDelta(spec, current, status.QueueFillPercent, status.ThroughputKBps)
+	if !scaleAutoscaledReplicas(&spec.AutoscalingSpec, &status.AutoscalingStatus, delta, replicationFactor, reason, time.Now()) {
+		return false, nil
+	}
+
+	mgr.log.Info("Autoscaling indexers", "replicas", current, "desiredReplicas", status.DesiredReplicas, "reason", reason)
+	publishAutoscalingDecision(ctx, eventPublisher, mgr.cr, SplunkIndexer, current, status.DesiredReplicas, reason)
+	return true, nil
+}
+
+// getSearchHeadAutoscalingDelta returns the scaling of the search head cluster members decided from the search concurrency,
+// the ratio of skipped scheduled searches and the time since the search concurrency was last above the scale down threshold,
+// with the reason of the decision
+func getSearchHeadAutoscalingDelta(spec *enterpriseApi.SearchHeadClusterAutoscalingSpec, replicas int32, concurrencyPercent int32, skippedSearchPercent int32, quietSeconds int64) (int32, string) {
+	if concurrencyPercent >= spec.ScaleUpConcurrencyPercent {
+		return 1, fmt.Sprintf("search concurrency %d%% is above %d%%", concurrencyPercent, spec.ScaleUpConcurrencyPercent)
+	}
+	if skippedSearchPercent >= spec.ScaleUpSkippedSearchPercent && skippedSearchPercent > 0 {
+		return 1, fmt.Sprintf("skipped scheduled searches %d%% is above %d%%", skippedSearchPercent, spec.ScaleUpSkippedSearchPercent)
+	}
+
+	// the search concurrency of the remaining members must stay below the threshold after the scale down
+	if concurrencyPercent <= spec.ScaleDownConcurrencyPercent && quietSeconds >= int64(spec.ScaleDownCooldownSeconds) && replicas > 1 &&
+		int64(concurrencyPercent)*int64(replicas)/int64(replicas-1) < int64(spec.ScaleUpConcurrencyPercent) {
+		return -1, fmt.Sprintf("search concurrency has been below %d%% for %d seconds", spec.ScaleDownConcurrencyPercent, quietSeconds)
+	}
+	return 0, ""
+}
+
+// validateSearchHeadClusterAutoscalingSpec checks a SearchHeadClusterAutoscalingSpec, and sets its default values
+func validateSearchHeadClusterAutoscalingSpec(spec *enterpriseApi.SearchHeadClusterAutoscalingSpec) error {
+	if !spec.Enabled {
+		return nil
+	}
+
+	if spec.MinReplicas == 0 {
+		spec.MinReplicas = minSearchHeadClusterReplicas
+	}
+	if spec.MinReplicas < minSearchHeadClusterReplicas {
+		return fmt.Errorf("autoscaling minReplicas (%d) should be at least %d for a search head cluster", spec.MinReplicas, minSearchHeadClusterReplicas)
+	}
+	if spec.ScaleUpConcurrencyPercent == 0 {
+		spec.ScaleUpConcurrencyPercent = defaultScaleUpConcurrencyPercent
+	}
+	if spec.ScaleDownConcurrencyPercent == 0 {
+		spec.ScaleDownConcurrencyPercent = defaultScaleDownConcurrencyPercent
+	}
+	if spec.ScaleUpSkippedSearchPercent == 0 {
+		spec.ScaleUpSkippedSearchPercent = defaultScaleUpSkippedSearchPercent
+	}
+	if spec.ScaleDownConcurrencyPercent >= spec.ScaleUpConcurrencyPercent {
+		return fmt.Errorf("autoscaling scaleDownConcurrencyPercent (%d) should be lower than scaleUpConcurrencyPercent (%d)", spec.ScaleDownConcurrencyPercent, spec.ScaleUpConcurrencyPercent)
+	}
+	return validateAutoscalingSpec(&spec.AutoscalingSpec)
+}
+
+// getSearchConcurrencyPercent returns the active searches of the members, in percent of their maximum numbers of
+// concurrent historical and realtime searches
+func getSearchConcurrencyPercent(members []enterpriseApi.SearchHeadClusterMemberStatus, maxHistoricalSearches int, maxRealtimeSearches int) int32 {
+	var historical, realtime int
+	for _, member := range members {
+		historical += member.ActiveHistoricalSearchCount
+		realtime += member.ActiveRealtimeSearchCount
+	}
+
+	var ratio float64
+	if maxHistoricalSearches > 0 {
+		ratio = float64(historical) / float64(maxHistoricalSearches*len(members))
+	}
+	if maxRealtimeSearches > 0 {
+		ratio = math.Max(ratio, float64(realtime)/float64(maxRealtimeSearches*len(members)))
+	}
+	return int32(math.Round(ratio * 100))
+}
+
+// applyAutoscaling for searchHeadClusterPodManager uses the search activity reported by the members and the skipped scheduled
+// searches to update the number of members decided by the autoscaling. It returns true when the number of members changed.
+// Scale downs go through the detention and the removal of the members
+func (mgr *searchHeadClusterPodManager) applyAutoscaling(ctx context.Context, eventPublisher *K8EventPublisher) (bool, error) {
+	spec := &mgr.cr.Spec.Autoscaling
+	status := &mgr.cr.Status.Autoscaling
+
+	if len(mgr.cr.Status.Members) == 0 {
+		mgr.log.Info("No autoscaling metrics reported for the search head cluster members")
+		return false, nil
+	}
+
+	c := mgr.getClient(ctx, 0)
+	maxHistoricalSearches := int(spec.MaxSearchesPerMember)
+	maxRealtimeSearches := int(spec.MaxSearchesPerMember)
+	if spec.MaxSearchesPerMember == 0 {
+		limits, err := c.GetSearchConcurrencyLimits()
+		if err != nil {
+			return false, fmt.Errorf("could not get the search concurrency limits: %v", err)
+		}
+		maxHistoricalSearches = limits.MaxHistoricalSearches
+		maxRealtimeSearches = limits.MaxRealtimeSearches
+	}
+	skippedRatio, err := c.GetSkippedSearchRatio(fmt.Sprintf("%s-*", GetSplunkStatefulsetName(SplunkSearchHead, mgr.cr.GetName())))
+	if err != nil {
+		return false, fmt.Errorf("could not get the skipped scheduled searches: %v", err)
+	}
+	status.ConcurrencyPercent = getSearchConcurrencyPercent(mgr.cr.Status.Members, maxHistoricalSearches, maxRealtimeSearches)
+	status.SkippedSearchPercent = int32(math.Round(skippedRatio * 100))
+
+	// the quiet period starts when the search concurrency drops below the scale down threshold
+	now := time.Now()
+	if status.LastBusyTime == 0 || status.ConcurrencyPercent > spec.ScaleDownConcurrencyPercent {
+		status.LastBusyTime = now.Unix()
+	}
+
+	current := status.DesiredReplicas
+	delta, reason := getSearchHeadAutoscalingDelta(spec, current, status.ConcurrencyPercent, status.SkippedSearchPercent, now.Unix()-status.LastBusyTime)
+	if !scaleAutoscaledReplicas(&spec.AutoscalingSpec, &status.AutoscalingStatus, delta, minSearchHeadClusterReplicas, reason, now) {
+		return false, nil
+	}
+
+	mgr.log.Info("Autoscaling search head cluster members", "replicas", current, "desiredReplicas", status.DesiredReplicas, "reason", reason)
+	publishAutoscalingDecision(ctx, eventPublisher, mgr.cr, SplunkSearchHead, current, status.DesiredReplicas, reason)
+	return true, nil
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestValidateIndexerClusterAutoscalingSpec(t *testing.T) {
+	spec := enterpriseApi.IndexerClusterAutoscalingSpec{}
+	spec.Enabled = true
+	spec.MaxReplicas = 5
+	err := validateIndexerClusterAutoscalingSpec(&spec)
+	if err != nil {
+		t.Fatalf("validateIndexerClusterAutoscalingSpec returned error: %v", err)
+	}
+	if spec.MinReplicas != 1 || spec.ScaleUpCooldownSeconds != defaultScaleUpCooldownSeconds || spec.ScaleDownCooldownSeconds != defaultScaleDownCooldownSeconds ||
+		spec.ScaleUpQueueFillPercent != defaultScaleUpQueueFillPercent || spec.ScaleDownQueueFillPercent != defaultScaleDownQueueFillPercent {
+		t.Errorf("unexpected default values %+v", spec)
+	}
+
+	spec.MinReplicas = 6
+	if validateIndexerClusterAutoscalingSpec(&spec) == nil {
+		t.Errorf("validateIndexerClusterAutoscalingSpec should fail when maxReplicas < minReplicas")
+	}
+
+	spec.MinReplicas = 3
+	spec.ScaleDownQueueFillPercent = 80
+	if validateIndexerClusterAutoscalingSpec(&spec) == nil {
+		t.Errorf("validateIndexerClusterAutoscalingSpec should fail when scaleDownQueueFillPercent >= scaleUpQueueFillPercent")
+	}
+}
+
+func TestScaleAutoscaledReplicas(t *testing.T) {
+	spec := enterpriseApi.AutoscalingSpec{Enabled: true, MinReplicas: 2, MaxReplicas: 4, ScaleUpCooldownSeconds: 300, ScaleDownCooldownSeconds: 1800}
+	status := enterpriseApi.AutoscalingStatus{}
+	now := time.Unix(100000, 0)
+
+	if got := getAutoscaledReplicas(&spec, &status, 6); got != 4 {
+		t.Errorf("getAutoscaledReplicas() = %d; want 4", got)
+	}
+
+	// max replicas
+	if scaleAutoscaledReplicas(&spec, &status, 1, 0, "up", now) {
+		t.Errorf("scaleAutoscaledReplicas should not scale above maxReplicas")
+	}
+
+	if !scaleAutoscaledReplicas(&spec, &status, -1, 0, "down", now) || status.DesiredReplicas != 3 || status.LastScaleTime != now.Unix() || status.LastScaleReason != "down" {
+		t.Errorf("scaleAutoscaledReplicas should scale down, status %+v", status)
+	}
+
+	// scale up cooldown
+	if scaleAutoscaledReplicas(&spec, &status, 1, 0, "up", now.Add(time.Minute)) {
+		t.Errorf("scaleAutoscaledReplicas should not scale up during the cooldown period")
+	}
+	if !scaleAutoscaledReplicas(&spec, &status, 1, 0, "up", now.Add(5*time.Minute)) || status.DesiredReplicas != 4 {
+		t.Errorf("scaleAutoscaledReplicas should scale up, status %+v", status)
+	}
+
+	// replication factor floor
+	status.DesiredReplicas = 3
+	if scaleAutoscaledReplicas(&spec, &status, -1, 3, "down", now.Add(time.Hour)) {
+		t.Errorf("scaleAutoscaledReplicas should not scale below the replication factor")
+	}
+}
+
+func TestGetIndexerAutoscalingDelta(t *testing.T) {
+	spec := enterpriseApi.IndexerClusterAutoscalingSpec{ScaleUpQueueFillPercent: 70, ScaleDownQueueFillPercent: 20, ScaleUpThroughputKBps: 1000}
+
+	test := func(replicas, queueFillPercent, throughputKBps, want int32) {
+		got, reason := getIndexerAutoscalingDelta(&spec, replicas, queueFillPercent, throughputKBps)
+		if got != want {
+			t.Errorf("getIndexerAutoscalingDelta(%d,%d,%d) = %d (%s); want %d", replicas, queueFillPercent, throughputKBps, got, reason, want)
+		}
+	}
+
+	test(3, 75, 100, 1)
+	test(3, 50, 1200, 1)
+	test(3, 50, 100, 0)
+	test(3, 10, 100, -1)
+	// the throughput of the remaining indexers would be above the threshold
+	test(3, 10, 800, 0)
+	test(1, 10, 100, 0)
+}
+
+func TestIndexerClusterApplyAutoscaling(t *testing.T) {
+	ctx := context.TODO()
+	// the mock HTTP client returns the same response to both searches
+	searchHandler := spltest.MockHTTPHandler{
+		Method: "POST",
+		URL:    "https://splunk-master1-cluster-master-service.test.svc.cluster.local:8089/services/search/jobs",
+		Status: 200,
+		Body:   `{"results":[{"splunk_server":"splunk-stack1-indexer-0","fill_ratio":"0.9","average_KBps":"300"},{"splunk_server":"splunk-stack1-indexer-1","fill_ratio":"0.1","average_KBps":"100"},{"splunk_server":"splunk-other-indexer-0","fill_ratio":"0.1","average_KBps":"900"}]}`,
+	}
+	mockHandlers := []spltest.MockHTTPHandler{
+		searchHandler,
+		searchHandler,
+		{
+			Method: "GET",
+			URL:    splcommon.TestServiceURLClusterManagerClusterConfig,
+			Status: 200,
+			Body:   splcommon.TestVerifyRFPeers,
+		},
+	}
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	mockSplunkClient.AddHandlers(mockHandlers...)
+
+	mgr := getIndexerClusterPodManager("TestIndexerClusterApplyAutoscaling", mockHandlers, mockSplunkClient, 3)
+	mgr.c = spltest.NewMockClient()
+	mgr.cr.Status.Peers = []enterpriseApi.IndexerClusterMemberStatus{{Name: "splunk-stack1-indexer-0"}, {Name: "splunk-stack1-indexer-1"}}
+	mgr.cr.Spec.Autoscaling.Enabled = true
+	mgr.cr.Spec.Autoscaling.MaxReplicas = 5
+	err := validateIndexerClusterAutoscalingSpec(&mgr.cr.Spec.Autoscaling)
+	if err != nil {
+		t.Fatalf("validateIndexerClusterAutoscalingSpec returned error: %v", err)
+	}
+	getAutoscaledReplicas(&mgr.cr.Spec.Autoscaling.AutoscalingSpec, &mgr.cr.Status.Autoscaling.AutoscalingStatus, mgr.cr.Spec.Replicas)
+
+	scaled, err := mgr.applyAutoscaling(ctx, nil)
+	if err != nil || !scaled {
+		t.Fatalf("applyAutoscaling should scale up, scaled: %t, error: %v", scaled, err)
+	}
+
+	status := mgr.cr.Status.Autoscaling
+	if status.DesiredReplicas != 4 || status.QueueFillPercent != 90 || status.ThroughputKBps != 200 || status.LastScaleReason == "" {
+		t.Errorf("unexpected autoscaling status %+v", status)
+	}
+	mockSplunkClient.CheckRequests(t, "TestIndexerClusterApplyAutoscaling")
+}
+
+func TestValidateSearchHeadClusterAutoscalingSpec(t *testing.T) {
+	spec := enterpriseApi.SearchHeadClusterAutoscalingSpec{}
+	spec.Enabled = true
+	spec.MaxReplicas = 5
+	err := validateSearchHeadClusterAutoscalingSpec(&spec)
+	if err != nil {
+		t.Fatalf("validateSearchHeadClusterAutoscalingSpec returned error: %v", err)
+	}
+	if spec.MinReplicas != 3 || spec.ScaleUpConcurrencyPercent != defaultScaleUpConcurrencyPercent || spec.ScaleDownConcurrencyPercent != defaultScaleDownConcurrencyPercent ||
+		spec.ScaleUpSkippedSearchPercent != defaultScaleUpSkippedSearchPercent || spec.ScaleDownCooldownSeconds != defaultScaleDownCooldownSeconds {
+		t.Errorf("unexpected default values %+v", spec)
+	}
+
+	spec.MinReplicas = 2
+	if validateSearchHeadClusterAutoscalingSpec(&spec) == nil {
+		t.Errorf("validateSearchHeadClusterAutoscalingSpec should fail when minReplicas < 3")
+	}
+
+	spec.MinReplicas = 3
+	spec.ScaleDownConcurrencyPercent = 90
+	if validateSearchHeadClusterAutoscalingSpec(&spec) == nil {
+		t.Errorf("validateSearchHeadClusterAutoscalingSpec should fail when scaleDownConcurrencyPercent >= scaleUpConcurrencyPercent")
+	}
+}
+
+func TestGetSearchHeadAutoscalingDelta(t *testing.T) {
+	spec := enterpriseApi.SearchHeadClusterAutoscalingSpec{ScaleUpConcurrencyPercent: 80, ScaleDownConcurrencyPercent: 20, ScaleUpSkippedSearchPercent: 5}
+	spec.ScaleDownCooldownSeconds = 1800
+
+	test := func(replicas, concurrencyPercent, skippedSearchPercent int32, quietSeconds int64, want int32) {
+		got, reason := getSearchHeadAutoscalingDelta(&spec, replicas, concurrencyPercent, skippedSearchPercent, quietSeconds)
+		if got != want {
+			t.Errorf("getSearchHeadAutoscalingDelta(%d,%d,%d,%d) = %d (%s); want %d", replicas, concurrencyPercent, skippedSearchPercent, quietSeconds, got, reason, want)
+		}
+	}
+
+	test(3, 85, 0, 0, 1)
+	test(3, 50, 10, 0, 1)
+	test(3, 50, 2, 0, 0)
+	test(4, 10, 0, 3600, -1)
+	// not quiet for long enough
+	test(4, 10, 0, 600, 0)
+	// skipped searches prevent the scale down
+	test(4, 10, 5, 3600, 1)
+}
+
+func TestGetSearchConcurrencyPercent(t *testing.T) {
+	members := []enterpriseApi.SearchHeadClusterMemberStatus{
+		{ActiveHistoricalSearchCount: 4, ActiveRealtimeSearchCount: 1},
+		{ActiveHistoricalSearchCount: 2, ActiveRealtimeSearchCount: 5},
+	}
+	if got := getSearchConcurrencyPercent(members, 10, 10); got != 30 {
+		t.Errorf("getSearchConcurrencyPercent() = %d; want 30", got)
+	}
+	if got := getSearchConcurrencyPercent(members, 10, 4); got != 75 {
+		t.Errorf("getSearchConcurrencyPercent() = %d; want 75", got)
+	}
+}
+
+func TestGetAutoscalingResult(t *testing.T) {
+	result := getAutoscalingResult(reconcile.Result{}, false)
+	if !result.Requeue || result.RequeueAfter != autoscalingPollInterval {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	// an earlier requeue is kept
+	result = getAutoscalingResult(reconcile.Result{Requeue: true, RequeueAfter: time.Second}, false)
+	if result.RequeueAfter != time.Second {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	result = getAutoscalingResult(reconcile.Result{Requeue: true, RequeueAfter: time.Hour}, true)
+	if result.RequeueAfter != time.Second*5 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestSearchHeadClusterApplyAutoscaling(t *testing.T) {
+	ctx := context.TODO()
+	memberURL := "https://splunk-stack1-search-head-0.splunk-stack1-search-head-headless.test.svc.cluster.local:8089"
+	mockHandlers := []spltest.MockHTTPHandler{
+		{
+			Method: "GET",
+			URL:    memberURL + "/services/server/status/limits/search-concurrency?count=0&output_mode=json",
+			Status: 200,
+			Body:   `{"entry":[{"content":{"max_hist_searches":10,"max_rt_searches":10}}]}`,
+		},
+		{
+			Method: "POST",
+			URL:    memberURL + "/services/search/jobs",
+			Status: 200,
+			Body:   `{"results":[{"skipped":"0","total":"20","skipped_ratio":"0"}]}`,
+		},
+	}
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	mockSplunkClient.AddHandlers(mockHandlers...)
+
+	cr := enterpriseApi.SearchHeadCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "SearchHeadCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	cr.Spec.Replicas = 3
+	cr.Spec.Autoscaling.Enabled = true
+	cr.Spec.Autoscaling.MaxReplicas = 5
+	err := validateSearchHeadClusterAutoscalingSpec(&cr.Spec.Autoscaling)
+	if err != nil {
+		t.Fatalf("validateSearchHeadClusterAutoscalingSpec returned error: %v", err)
+	}
+	getAutoscaledReplicas(&cr.Spec.Autoscaling.AutoscalingSpec, &cr.Status.Autoscaling.AutoscalingStatus, cr.Spec.Replicas)
+	cr.Status.Members = []enterpriseApi.SearchHeadClusterMemberStatus{
+		{Name: "splunk-stack1-search-head-0", ActiveHistoricalSearchCount: 9},
+		{Name: "splunk-stack1-search-head-1", ActiveHistoricalSearchCount: 9},
+		{Name: "splunk-stack1-search-head-2", ActiveHistoricalSearchCount: 9},
+	}
+
+	mgr := &searchHeadClusterPodManager{
+		c:   spltest.NewMockClient(),
+		log: logt.WithName("TestSearchHeadClusterApplyAutoscaling"),
+		cr:  &cr,
+		newSplunkClient: func(managementURI, username, password string) *splclient.SplunkClient {
+			c := splclient.NewSplunkClient(managementURI, username, password)
+			c.Client = mockSplunkClient
+			return c
+		},
+	}
+
+	scaled, err := mgr.applyAutoscaling(ctx, nil)
+	if err != nil || !scaled {
+		t.Fatalf("applyAutoscaling should scale up, scaled: %t, error: %v", scaled, err)
+	}
+
+	status := cr.Status.Autoscaling
+	if status.DesiredReplicas != 4 || status.ConcurrencyPercent != 90 || status.SkippedSearchPercent != 0 || status.LastBusyTime == 0 {
+		t.Errorf("unexpected autoscaling status %+v", status)
+	}
+	mockSplunkClient.CheckRequests(t, "TestSearchHeadClusterApplyAutoscaling")
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+)
+
+// ApplyV4ClusterManager reconciles the state of a ClusterManager custom resource, like a ClusterMaster custom resource.
+// A ClusterManager with the name of a ClusterMaster in the same namespace is rejected
+func ApplyV4ClusterManager(ctx context.Context, client splcommon.ControllerClient, cr *enterpriseApiV4.ClusterManager) (reconcile.Result, error) {
+	crCopy, err := newV4ManagerCopy(cr)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	err = checkV4ManagerName(ctx, client, crCopy)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	return ApplyClusterManager(ctx, &v4ManagerClient{ControllerClient: client}, crCopy.(*enterpriseApi.ClusterMaster))
+}
+
+// getV4ClusterManager returns the ClusterMaster copy of a ClusterManager custom resource
+func getV4ClusterManager(ctx context.Context, client splcommon.ControllerClient, namespacedName types.NamespacedName) (*enterpriseApi.ClusterMaster, error) {
+	cr := &enterpriseApiV4.ClusterManager{}
+	err := client.Get(ctx, namespacedName, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	crCopy, err := newV4ManagerCopy(cr)
+	if err != nil {
+		return nil, err
+	}
+	return crCopy.(*enterpriseApi.ClusterMaster), nil
+}
+
+// getClusterManagerCR returns the cluster manager referred by clusterMasterRef or clusterManagerRef, which is either a
+// ClusterMaster or a ClusterManager custom resource. A ClusterManager is returned as a ClusterMaster copy
+func getClusterManagerCR(ctx context.Context, client splcommon.ControllerClient, namespacedName types.NamespacedName) (*enterpriseApi.ClusterMaster, error) {
+	cr := &enterpriseApi.ClusterMaster{}
+	err := client.Get(ctx, namespacedName, cr)
+	if !k8serrors.IsNotFound(err) {
+		return cr, err
+	}
+
+	crCopy, v4Err := getV4ClusterManager(ctx, client, namespacedName)
+	if v4Err != nil {
+		return cr, err
+	}
+	return crCopy, nil
+}
@@ -25,7 +25,9 @@ import (
 	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
 	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/concurrency"
 	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -37,6 +39,8 @@ import (
 
 // ApplyClusterManager reconciles the state of a Splunk Enterprise cluster manager.
 func ApplyClusterManager(ctx context.Context, client splcommon.ControllerClient, cr *enterpriseApi.ClusterMaster) (reconcile.Result, error) {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplyClusterManager", cr)
+	defer span.End()
 
 	// unless modified, reconcile for this object will be requeued after 5 seconds
 	result := reconcile.Result{
@@ -86,6 +90,11 @@ func ApplyClusterManager(ctx context.Context, client splcommon.ControllerClient,
 	// Update the CR Status
 	defer updateCRStatus(ctx, client, cr)
 
+	// detect the drift of the objects applied below, and report it in the status before it is updated
+	driftRecorder := splctrl.NewDriftRecorder(cr.Spec.DriftPolicy)
+	ctx = splctrl.WithDriftRecorder(ctx, driftRecorder)
+	defer reportDrift(ctx, eventPublisher, driftRecorder, cr.GetGeneration(), &cr.Status.Conditions)
+
 	// If needed, Migrate the app framework status
 	err = checkAndMigrateAppDeployStatus(ctx, client, cr, &cr.Status.AppContext, &cr.Spec.AppFrameworkConfig, false)
 	if err != nil {
@@ -116,7 +125,7 @@ func ApplyClusterManager(ctx context.Context, client splcommon.ControllerClient,
 	if cr.ObjectMeta.DeletionTimestamp != nil {
 		if cr.Spec.MonitoringConsoleRef.Name != "" {
 			extraEnv, err := VerifyCMisMultisite(ctx, cr, namespaceScopedSecret)
-			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), cr.Spec.MonitoringConsoleRef.Name, extraEnv, false)
+			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), getMonitoringConsoleRefKey(cr.GetNamespace(), cr.Spec.MonitoringConsoleRef), extraEnv, false)
 			if err != nil {
 				return result, err
 			}
@@ -157,6 +166,20 @@ func ApplyClusterManager(ctx context.Context, client splcommon.ControllerClient,
 		return result, err
 	}
 
+	// create or update the objects exposing Splunk Web and HEC
+	err = ApplySplunkExpose(ctx, client, cr, &cr.Spec.CommonSplunkSpec, SplunkClusterManager, &cr.Status.Expose)
+	if err != nil {
+		eventPublisher.Warning(ctx, "ApplySplunkExpose", fmt.Sprintf("create/update expose objects failed %s", err.Error()))
+		return result, err
+	}
+
+	// create or update the network policies of the Splunk instances
+	err = ApplySplunkNetworkPolicies(ctx, client, cr, &cr.Spec.CommonSplunkSpec, &cr.Status.NetworkPolicies, SplunkClusterManager)
+	if err != nil {
+		eventPublisher.Warning(ctx, "ApplySplunkNetworkPolicies", fmt.Sprintf("create/update network policies failed %s", err.Error()))
+		return result, err
+	}
+
 	// create or update statefulset for the cluster manager
 	statefulSet, err := getClusterManagerStatefulSet(ctx, client, cr)
 	if err != nil {
@@ -170,12 +193,23 @@ func ApplyClusterManager(ctx context.Context, client splcommon.ControllerClient,
 		return result, err
 	}
 
+	// wait for the previous stages of an ordered upgrade of the stack
+	upgradeReady, err := checkOrderedUpgrade(ctx, client, cr, &cr.Spec.CommonSplunkSpec, SplunkClusterManager, &cr.Status.UpgradeStage)
+	if err != nil {
+		return result, err
+	}
+	if !upgradeReady {
+		cr.Status.Phase = enterpriseApi.PhasePending
+		return result, nil
+	}
+
 	clusterMasterManager := splctrl.DefaultStatefulSetPodManager{}
 	phase, err := clusterMasterManager.Update(ctx, client, statefulSet, 1)
 	if err != nil {
 		return result, err
 	}
 	cr.Status.Phase = phase
+	updateUpgradeStage(&cr.Status.UpgradeStage, SplunkClusterManager, phase)
 
 	// no need to requeue if everything is ready
 	if cr.Status.Phase == enterpriseApi.PhaseReady {
@@ -187,7 +221,11 @@ func ApplyClusterManager(ctx context.Context, client splcommon.ControllerClient,
 		}
 		//Update MC configmap
 		if cr.Spec.MonitoringConsoleRef.Name != "" {
-			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), cr.Spec.MonitoringConsoleRef.Name, extraEnv, true)
+			err = addMonitoringConsoleRefFinalizer(ctx, client, cr, cr.Spec.MonitoringConsoleRef)
+			if err != nil {
+				return result, err
+			}
+			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), getMonitoringConsoleRefKey(cr.GetNamespace(), cr.Spec.MonitoringConsoleRef), extraEnv, true)
 			if err != nil {
 				return result, err
 			}
@@ -366,8 +404,14 @@ func PushManagerAppsBundle(ctx context.Context, c splcommon.ControllerClient, cr
 	managerIdxcName := cr.GetName()
 	fqdnName := splcommon.GetServiceFQDN(cr.GetNamespace(), GetSplunkServiceName(SplunkClusterManager, managerIdxcName, false))
 
+	ctx, release, err := concurrency.AcquireHeavyOperation(ctx, concurrency.OperationBundlePush)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Get a Splunk client to execute the REST call
-	splunkClient := splclient.NewSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(adminPwd))
+	splunkClient := splclient.NewSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(adminPwd)).WithContext(ctx)
 
 	return splunkClient.BundlePush(true)
 }
@@ -396,7 +440,7 @@ func VerifyCMisMultisite(ctx context.Context, cr *enterpriseApi.ClusterMaster, n
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("Verify if Multisite Indexer Cluster").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
 	mgr := clusterManagerPodManager{log: scopedLog, cr: cr, secrets: namespaceScopedSecret, newSplunkClient: splclient.NewSplunkClient}
-	cm := mgr.getClusterManagerClient(cr)
+	cm := mgr.getClusterManagerClient(cr).WithContext(ctx)
 	clusterInfo, err := cm.GetClusterInfo(false)
 	if err != nil {
 		return nil, err
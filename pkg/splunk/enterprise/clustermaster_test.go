@@ -80,7 +80,6 @@ func TestApplyClusterManager(t *testing.T) {
 		{MetaName: "*v1.ConfigMap-test-splunk-stack1-clustermaster-smartstore"},
 		{MetaName: "*v1." + splcommon.TestStack1ClusterManagerStatefulSet},
 		{MetaName: "*v1." + splcommon.TestStack1ClusterManagerStatefulSet},
-		{MetaName: "*v1." + splcommon.TestStack1ClusterManagerStatefulSet},
 		{MetaName: "*v3.ClusterMaster-test-stack1"},
 		{MetaName: "*v3.ClusterMaster-test-stack1"},
 	}
@@ -95,8 +94,8 @@ func TestApplyClusterManager(t *testing.T) {
 	}
 	listmockCall := []spltest.MockFuncCall{
 		{ListOpts: listOpts}}
-	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": {funcCalls[0], funcCalls[3], funcCalls[4], funcCalls[7], funcCalls[5]}, "List": {listmockCall[0]}, "Update": {funcCalls[0]}}
-	updateCalls := map[string][]spltest.MockFuncCall{"Get": updateFuncCalls, "Update": {funcCalls[5]}, "List": {listmockCall[0]}}
+	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": {funcCalls[0], funcCalls[7]}, "Patch": {funcCalls[3], funcCalls[4], funcCalls[5]}, "List": {listmockCall[0]}, "Update": {funcCalls[0]}}
+	updateCalls := map[string][]spltest.MockFuncCall{"Get": updateFuncCalls, "Patch": {funcCalls[5]}, "List": {listmockCall[0]}}
 
 	current := enterpriseApi.ClusterMaster{
 		TypeMeta: metav1.TypeMeta{
@@ -197,7 +196,6 @@ func TestApplyClusterManagerWithSmartstore(t *testing.T) {
 		{MetaName: "*v1.Secret-test-splunk-test-secret"},
 		{MetaName: "*v1." + splcommon.TestStack1ClusterManagerConfigMapSmartStore},
 		{MetaName: "*v1." + splcommon.TestStack1ClusterManagerConfigMapSmartStore},
-		{MetaName: "*v1." + splcommon.TestStack1ClusterManagerConfigMapSmartStore},
 		{MetaName: "*v1.Secret-test-splunk-test-secret"},
 		{MetaName: "*v1.Secret-test-splunk-test-secret"},
 		{MetaName: "*v1.Service-test-splunk-stack1-indexer-service"},
@@ -234,7 +232,6 @@ func TestApplyClusterManagerWithSmartstore(t *testing.T) {
 		{MetaName: "*v1.ConfigMap-test-splunk-stack1-clustermaster-smartstore"},
 		{MetaName: "*v1.StatefulSet-test-splunk-stack1-cluster-master"},
 		{MetaName: "*v1.StatefulSet-test-splunk-stack1-cluster-master"},
-		{MetaName: "*v1.StatefulSet-test-splunk-stack1-cluster-master"},
 		{MetaName: "*v3.ClusterMaster-test-stack1"},
 		{MetaName: "*v3.ClusterMaster-test-stack1"},
 	}
@@ -249,8 +246,8 @@ func TestApplyClusterManagerWithSmartstore(t *testing.T) {
 	}
 	listmockCall := []spltest.MockFuncCall{
 		{ListOpts: listOpts}}
-	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": {funcCalls[7], funcCalls[8], funcCalls[11]}, "List": {listmockCall[0], listmockCall[0]}, "Update": {funcCalls[0], funcCalls[3], funcCalls[12]}}
-	updateCalls := map[string][]spltest.MockFuncCall{"Get": updateFuncCalls, "Update": {funcCalls[9]}, "List": {listmockCall[0]}}
+	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": {funcCalls[10]}, "List": {listmockCall[0], listmockCall[0]}, "Update": {funcCalls[0]}, "Patch": {funcCalls[6], funcCalls[7]}}
+	updateCalls := map[string][]spltest.MockFuncCall{"Get": updateFuncCalls, "Patch": {funcCalls[8]}, "List": {listmockCall[0]}}
 
 	current := enterpriseApi.ClusterMaster{
 		TypeMeta: metav1.TypeMeta{
@@ -307,12 +304,10 @@ func TestApplyClusterManagerWithSmartstore(t *testing.T) {
 		t.Errorf(err.Error())
 	}
 
-	smartstoreConfigMap := corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      splcommon.TestStack1ClusterManagerSmartStore,
-			Namespace: "test",
-		},
-		Data: map[string]string{"a": "b"},
+	// the smartstore configMap is up to date, so that the bundle isn't pushed again
+	smartstoreConfigMap, _, err := ApplySmartstoreConfigMap(ctx, client, &current, &current.Spec.SmartStore)
+	if err != nil {
+		t.Errorf(err.Error())
 	}
 
 	revised := current.DeepCopy()
@@ -322,7 +317,6 @@ func TestApplyClusterManagerWithSmartstore(t *testing.T) {
 		return err
 	}
 
-	client.AddObject(&smartstoreConfigMap)
 	ss, _ := getClusterManagerStatefulSet(ctx, client, &current)
 	ss.Status.ReadyReplicas = 1
 
@@ -342,7 +336,7 @@ func TestApplyClusterManagerWithSmartstore(t *testing.T) {
 		},
 	}
 
-	spltest.ReconcileTesterWithoutRedundantCheck(t, "TestApplyClusterManagerWithSmartstore-0", &current, revised, createCalls, updateCalls, reconcile, true, secret, &smartstoreConfigMap, ss, pod)
+	spltest.ReconcileTesterWithoutRedundantCheck(t, "TestApplyClusterManagerWithSmartstore-0", &current, revised, createCalls, updateCalls, reconcile, true, secret, smartstoreConfigMap, ss, pod)
 
 	current.Status.BundlePushTracker.NeedToPushMasterApps = true
 	if _, err = ApplyClusterManager(context.Background(), client, &current); err != nil {
@@ -1100,7 +1094,7 @@ func TestClusterMasterWitReadyState(t *testing.T) {
 	}
 
 	builder := fake.NewClientBuilder()
-	c := builder.Build()
+	c := spltest.NewFakeApplyClient(builder.Build())
 	utilruntime.Must(enterpriseApi.AddToScheme(clientgoscheme.Scheme))
 	ctx := context.TODO()
 
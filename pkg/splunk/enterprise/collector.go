@@ -0,0 +1,352 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+	"github.com/splunk/splunk-operator/pkg/config"
+)
+
+// collectTimeout is the maximum time spent listing the custom resources on a scrape
+const collectTimeout = 10 * time.Second
+
+var (
+	crPhaseDesc = prometheus.NewDesc("splunk_operator_cr_phase",
+		"Set to 1 for the current phase of a custom resource",
+		[]string{"namespace", "name", "kind", "phase"}, nil)
+
+	indexerPeerStatusDesc = prometheus.NewDesc("splunk_operator_indexer_peer_status",
+		"Set to 1 for the status of an indexer cluster peer reported by the cluster manager",
+		[]string{"namespace", "name", "peer", "status"}, nil)
+
+	indexerPeerBucketsDesc = prometheus.NewDesc("splunk_operator_indexer_peer_buckets",
+		"The number of buckets on an indexer cluster peer, across all indexes",
+		[]string{"namespace", "name", "peer"}, nil)
+
+	indexerPeerSearchableDesc = prometheus.NewDesc("splunk_operator_indexer_peer_searchable",
+		"Set to 1 when an indexer cluster peer belongs to the committed generation and is searchable",
+		[]string{"namespace", "name", "peer"}, nil)
+
+	indexerClusterFlagDesc = prometheus.NewDesc("splunk_operator_indexer_cluster_flag",
+		"State flags of an indexer cluster reported by the cluster manager: initialized, indexing_ready, service_ready, maintenance_mode and rolling_restart",
+		[]string{"namespace", "name", "flag"}, nil)
+
+	searchHeadCaptainDesc = prometheus.NewDesc("splunk_operator_search_head_captain_ready",
+		"Set to 1 when the captain of a search head cluster is ready to service requests",
+		[]string{"namespace", "name", "captain"}, nil)
+
+	searchHeadMemberStatusDesc = prometheus.NewDesc("splunk_operator_search_head_member_status",
+		"Set to 1 for the status of a search head cluster member reported by the captain",
+		[]string{"namespace", "name", "member", "status"}, nil)
+
+	searchHeadMemberRegisteredDesc = prometheus.NewDesc("splunk_operator_search_head_member_registered",
+		"Set to 1 when a search head cluster member is registered with the captain",
+		[]string{"namespace", "name", "member"}, nil)
+
+	searchHeadMemberSearchesDesc = prometheus.NewDesc("splunk_operator_search_head_member_active_searches",
+		"The number of searches running on a search head cluster member, by type",
+		[]string{"namespace", "name", "member", "type"}, nil)
+
+	searchHeadClusterFlagDesc = prometheus.NewDesc("splunk_operator_search_head_cluster_flag",
+		"State flags of a search head cluster reported by the captain: initialized, min_peers_joined and maintenance_mode",
+		[]string{"namespace", "name", "flag"}, nil)
+
+	appDeploymentAppsDesc = prometheus.NewDesc("splunk_operator_app_deployment_apps",
+		"The number of apps of the App Framework of a custom resource, by deployment state: total, completed, pending and failed",
+		[]string{"namespace", "name", "kind", "state"}, nil)
+
+	appDeploymentInProgressDesc = prometheus.NewDesc("splunk_operator_app_deployment_in_progress",
+		"Set to 1 while an App Framework deployment is in progress for a custom resource",
+		[]string{"namespace", "name", "kind"}, nil)
+
+	appBundlePushStageDesc = prometheus.NewDesc("splunk_operator_app_bundle_push_stage",
+		"The App Framework bundle push stage of a cluster manager or deployer: 0 uninitialized, 1 pending, 2 in progress and 3 complete",
+		[]string{"namespace", "name", "kind"}, nil)
+
+	clusterManagerBundlePushPendingDesc = prometheus.NewDesc("splunk_operator_cluster_manager_bundle_push_pending",
+		"Set to 1 when the apps of a cluster manager need to be pushed to its peers",
+		[]string{"namespace", "name", "kind"}, nil)
+)
+
+// ClusterHealthCollector exports the health of the Splunk deployments learnt by the operator, read from the status of the
+// custom resources on each scrape. Only the custom resources of the Namespaces selected for this operator instance are
+// collected, so that the operator instances sharing a cluster don't report the same deployments
+type ClusterHealthCollector struct {
+	client    client.Reader
+	selection config.NamespaceSelection
+}
+
+// NewClusterHealthCollector returns a collector reading the custom resources of the Namespaces of selection with c, usually
+// the cached client of the manager
+func NewClusterHealthCollector(c client.Reader, selection config.NamespaceSelection) *ClusterHealthCollector {
+	return &ClusterHealthCollector{client: c, selection: selection}
+}
+
+// Describe implements prometheus.Collector
+func (c *ClusterHealthCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		crPhaseDesc,
+		indexerPeerStatusDesc,
+		indexerPeerBucketsDesc,
+		indexerPeerSearchableDesc,
+		indexerClusterFlagDesc,
+		searchHeadCaptainDesc,
+		searchHeadMemberStatusDesc,
+		searchHeadMemberRegisteredDesc,
+		searchHeadMemberSearchesDesc,
+		searchHeadClusterFlagDesc,
+		appDeploymentAppsDesc,
+		appDeploymentInProgressDesc,
+		appBundlePushStageDesc,
+		clusterManagerBundlePushPendingDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *ClusterHealthCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	scopedLog := log.FromContext(ctx).WithName("ClusterHealthCollector")
+	selected := c.namespaceFilter(ctx)
+
+	standaloneList := &enterpriseApi.StandaloneList{}
+	if err := c.client.List(ctx, standaloneList); err != nil {
+		scopedLog.Error(err, "Unable to list the standalones")
+	}
+	for i := range standaloneList.Items {
+		cr := &standaloneList.Items[i]
+		if !selected(cr.GetNamespace()) {
+			continue
+		}
+		collectPhase(ch, cr.GetNamespace(), cr.GetName(), "Standalone", cr.Status.Phase)
+		collectAppContext(ch, cr.GetNamespace(), cr.GetName(), "Standalone", &cr.Status.AppContext, false)
+	}
+
+	lmList := &enterpriseApi.LicenseMasterList{}
+	if err := c.client.List(ctx, lmList); err != nil {
+		scopedLog.Error(err, "Unable to list the license managers")
+	}
+	for i := range lmList.Items {
+		cr := &lmList.Items[i]
+		if !selected(cr.GetNamespace()) {
+			continue
+		}
+		collectPhase(ch, cr.GetNamespace(), cr.GetName(), "LicenseMaster", cr.Status.Phase)
+		collectAppContext(ch, cr.GetNamespace(), cr.GetName(), "LicenseMaster", &cr.Status.AppContext, false)
+	}
+
+	v4LmList := &enterpriseApiV4.LicenseManagerList{}
+	if err := c.client.List(ctx, v4LmList); err != nil {
+		scopedLog.Error(err, "Unable to list the v4 license managers")
+	}
+	for i := range v4LmList.Items {
+		cr := &v4LmList.Items[i]
+		if !selected(cr.GetNamespace()) {
+			continue
+		}
+		collectPhase(ch, cr.GetNamespace(), cr.GetName(), "LicenseManager", cr.Status.Phase)
+		collectAppContext(ch, cr.GetNamespace(), cr.GetName(), "LicenseManager", &cr.Status.AppContext, false)
+	}
+
+	cmList := &enterpriseApi.ClusterMasterList{}
+	if err := c.client.List(ctx, cmList); err != nil {
+		scopedLog.Error(err, "Unable to list the cluster managers")
+	}
+	for i := range cmList.Items {
+		cr := &cmList.Items[i]
+		if !selected(cr.GetNamespace()) {
+			continue
+		}
+		collectClusterManager(ch, cr.GetNamespace(), cr.GetName(), "ClusterMaster", &cr.Status)
+	}
+
+	v4CmList := &enterpriseApiV4.ClusterManagerList{}
+	if err := c.client.List(ctx, v4CmList); err != nil {
+		scopedLog.Error(err, "Unable to list the v4 cluster managers")
+	}
+	for i := range v4CmList.Items {
+		cr := &v4CmList.Items[i]
+		if !selected(cr.GetNamespace()) {
+			continue
+		}
+		collectClusterManager(ch, cr.GetNamespace(), cr.GetName(), "ClusterManager", &cr.Status)
+	}
+
+	idxcList := &enterpriseApi.IndexerClusterList{}
+	if err := c.client.List(ctx, idxcList); err != nil {
+		scopedLog.Error(err, "Unable to list the indexer clusters")
+	}
+	for i := range idxcList.Items {
+		cr := &idxcList.Items[i]
+		if !selected(cr.GetNamespace()) {
+			continue
+		}
+		collectIndexerCluster(ch, cr)
+	}
+
+	shcList := &enterpriseApi.SearchHeadClusterList{}
+	if err := c.client.List(ctx, shcList); err != nil {
+		scopedLog.Error(err, "Unable to list the search head clusters")
+	}
+	for i := range shcList.Items {
+		cr := &shcList.Items[i]
+		if !selected(cr.GetNamespace()) {
+			continue
+		}
+		collectSearchHeadCluster(ch, cr)
+	}
+
+	mcList := &enterpriseApi.MonitoringConsoleList{}
+	if err := c.client.List(ctx, mcList); err != nil {
+		scopedLog.Error(err, "Unable to list the monitoring consoles")
+	}
+	for i := range mcList.Items {
+		cr := &mcList.Items[i]
+		if !selected(cr.GetNamespace()) {
+			continue
+		}
+		collectPhase(ch, cr.GetNamespace(), cr.GetName(), "MonitoringConsole", cr.Status.Phase)
+		collectAppContext(ch, cr.GetNamespace(), cr.GetName(), "MonitoringConsole", &cr.Status.AppContext, false)
+	}
+}
+
+// namespaceFilter returns a function telling whether the custom resources of a Namespace are collected. The Namespaces
+// selected by label are read once per scrape
+func (c *ClusterHealthCollector) namespaceFilter(ctx context.Context) func(namespace string) bool {
+	selected := map[string]bool{}
+	return func(namespace string) bool {
+		if c.selection.Selector == nil {
+			return c.selection.Selects(namespace, nil)
+		}
+		if value, ok := selected[namespace]; ok {
+			return value
+		}
+		ns := &corev1.Namespace{}
+		err := c.client.Get(ctx, types.NamespacedName{Name: namespace}, ns)
+		if err != nil {
+			log.FromContext(ctx).WithName("ClusterHealthCollector").Error(err, "Unable to get the namespace", "namespace", namespace)
+		}
+		selected[namespace] = err == nil && c.selection.Selects(ns.GetName(), ns.GetLabels())
+		return selected[namespace]
+	}
+}
+
+// collectClusterManager collects the metrics of a ClusterMaster or ClusterManager custom resource
+func collectClusterManager(ch chan<- prometheus.Metric, namespace string, name string, kind string, status *enterpriseApi.ClusterMasterStatus) {
+	collectPhase(ch, namespace, name, kind, status.Phase)
+	collectAppContext(ch, namespace, name, kind, &status.AppContext, true)
+	collectGauge(ch, clusterManagerBundlePushPendingDesc, boolToGaugeValue(status.BundlePushTracker.NeedToPushMasterApps), namespace, name, kind)
+}
+
+// collectIndexerCluster collects the metrics of an indexer cluster and of its peers
+func collectIndexerCluster(ch chan<- prometheus.Metric, cr *enterpriseApi.IndexerCluster) {
+	namespace, name := cr.GetNamespace(), cr.GetName()
+	collectPhase(ch, namespace, name, "IndexerCluster", cr.Status.Phase)
+
+	flags := map[string]bool{
+		"initialized":      cr.Status.Initialized,
+		"indexing_ready":   cr.Status.IndexingReady,
+		"service_ready":    cr.Status.ServiceReady,
+		"maintenance_mode": cr.Status.MaintenanceMode,
+		"rolling_restart":  cr.Status.RollingRestart,
+	}
+	for flag, value := range flags {
+		collectGauge(ch, indexerClusterFlagDesc, boolToGaugeValue(value), namespace, name, flag)
+	}
+
+	for _, peer := range cr.Status.Peers {
+		collectGauge(ch, indexerPeerStatusDesc, 1, namespace, name, peer.Name, peer.Status)
+		collectGauge(ch, indexerPeerBucketsDesc, float64(peer.BucketCount), namespace, name, peer.Name)
+		collectGauge(ch, indexerPeerSearchableDesc, boolToGaugeValue(peer.Searchable), namespace, name, peer.Name)
+	}
+}
+
+// collectSearchHeadCluster collects the metrics of a search head cluster and of its members
+func collectSearchHeadCluster(ch chan<- prometheus.Metric, cr *enterpriseApi.SearchHeadCluster) {
+	namespace, name := cr.GetNamespace(), cr.GetName()
+	collectPhase(ch, namespace, name, "SearchHeadCluster", cr.Status.Phase)
+	collectAppContext(ch, namespace, name, "SearchHeadCluster", &cr.Status.AppContext, true)
+
+	if cr.Status.Captain != "" {
+		collectGauge(ch, searchHeadCaptainDesc, boolToGaugeValue(cr.Status.CaptainReady), namespace, name, cr.Status.Captain)
+	}
+
+	flags := map[string]bool{
+		"initialized":      cr.Status.Initialized,
+		"min_peers_joined": cr.Status.MinPeersJoined,
+		"maintenance_mode": cr.Status.MaintenanceMode,
+	}
+	for flag, value := range flags {
+		collectGauge(ch, searchHeadClusterFlagDesc, boolToGaugeValue(value), namespace, name, flag)
+	}
+
+	for _, member := range cr.Status.Members {
+		collectGauge(ch, searchHeadMemberStatusDesc, 1, namespace, name, member.Name, member.Status)
+		collectGauge(ch, searchHeadMemberRegisteredDesc, boolToGaugeValue(member.Registered), namespace, name, member.Name)
+		collectGauge(ch, searchHeadMemberSearchesDesc, float64(member.ActiveHistoricalSearchCount), namespace, name, member.Name, "historical")
+		collectGauge(ch, searchHeadMemberSearchesDesc, float64(member.ActiveRealtimeSearchCount), namespace, name, member.Name, "realtime")
+	}
+}
+
+// collectPhase collects the phase of a custom resource
+func collectPhase(ch chan<- prometheus.Metric, namespace string, name string, kind string, phase enterpriseApi.Phase) {
+	if phase == "" {
+		return
+	}
+	collectGauge(ch, crPhaseDesc, 1, namespace, name, kind, string(phase))
+}
+
+// collectAppContext collects the App Framework deployment of a custom resource. The bundle push stage is only collected for
+// the cluster managers and the deployers
+func collectAppContext(ch chan<- prometheus.Metric, namespace string, name string, kind string, appContext *enterpriseApi.AppDeploymentContext, bundlePush bool) {
+	if len(appContext.AppFrameworkConfig.AppSources) == 0 {
+		return
+	}
+
+	summary := &appContext.Summary
+	collectGauge(ch, appDeploymentAppsDesc, float64(summary.TotalApps), namespace, name, kind, "total")
+	collectGauge(ch, appDeploymentAppsDesc, float64(summary.CompletedApps), namespace, name, kind, "completed")
+	collectGauge(ch, appDeploymentAppsDesc, float64(summary.PendingApps), namespace, name, kind, "pending")
+	collectGauge(ch, appDeploymentAppsDesc, float64(summary.FailedApps), namespace, name, kind, "failed")
+	collectGauge(ch, appDeploymentInProgressDesc, boolToGaugeValue(appContext.IsDeploymentInProgress), namespace, name, kind)
+	if bundlePush {
+		collectGauge(ch, appBundlePushStageDesc, float64(appContext.BundlePushStatus.BundlePushStage), namespace, name, kind)
+	}
+}
+
+// collectGauge sends a gauge metric to ch
+func collectGauge(ch chan<- prometheus.Metric, desc *prometheus.Desc, value float64, labelValues ...string) {
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labelValues...)
+}
+
+// boolToGaugeValue returns 1 for true and 0 for false
+func boolToGaugeValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
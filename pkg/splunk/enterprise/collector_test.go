@@ -0,0 +1,172 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+	"github.com/splunk/splunk-operator/pkg/config"
+)
+
+func TestClusterHealthCollector(t *testing.T) {
+	utilruntime.Must(enterpriseApi.AddToScheme(clientgoscheme.Scheme))
+	utilruntime.Must(enterpriseApiV4.AddToScheme(clientgoscheme.Scheme))
+
+	idxc := &enterpriseApi.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+		Status: enterpriseApi.IndexerClusterStatus{
+			Phase:           enterpriseApi.PhaseReady,
+			IndexingReady:   true,
+			MaintenanceMode: true,
+			Peers: []enterpriseApi.IndexerClusterMemberStatus{
+				{Name: "splunk-stack1-indexer-0", Status: "Up", BucketCount: 42, Searchable: true},
+			},
+		},
+	}
+	shc := &enterpriseApi.SearchHeadCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+		Status: enterpriseApi.SearchHeadClusterStatus{
+			Phase:        enterpriseApi.PhaseUpdating,
+			Captain:      "splunk-stack1-search-head-0",
+			CaptainReady: true,
+			Members: []enterpriseApi.SearchHeadClusterMemberStatus{
+				{Name: "splunk-stack1-search-head-0", Status: "Up", Registered: true, ActiveHistoricalSearchCount: 3},
+			},
+			AppContext: enterpriseApi.AppDeploymentContext{
+				AppFrameworkConfig: enterpriseApi.AppFrameworkSpec{
+					AppSources: []enterpriseApi.AppSourceSpec{{Name: "apps"}},
+				},
+				Summary: enterpriseApi.AppDeploymentSummary{TotalApps: 2, CompletedApps: 1, FailedApps: 1},
+				BundlePushStatus: enterpriseApi.BundlePushTracker{
+					BundlePushStage: enterpriseApi.BundlePushInProgress,
+				},
+			},
+		},
+	}
+	cm := &enterpriseApiV4.ClusterManager{
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+		Status: enterpriseApi.ClusterMasterStatus{
+			Phase:             enterpriseApi.PhaseReady,
+			BundlePushTracker: enterpriseApi.BundlePushInfo{NeedToPushMasterApps: true},
+		},
+	}
+	c := fake.NewClientBuilder().WithObjects(idxc, shc, cm).Build()
+
+	want := `
+# HELP splunk_operator_cr_phase Set to 1 for the current phase of a custom resource
+# TYPE splunk_operator_cr_phase gauge
+splunk_operator_cr_phase{kind="ClusterManager",name="stack1",namespace="test",phase="Ready"} 1
+splunk_operator_cr_phase{kind="IndexerCluster",name="stack1",namespace="test",phase="Ready"} 1
+splunk_operator_cr_phase{kind="SearchHeadCluster",name="stack1",namespace="test",phase="Updating"} 1
+# HELP splunk_operator_indexer_peer_buckets The number of buckets on an indexer cluster peer, across all indexes
+# TYPE splunk_operator_indexer_peer_buckets gauge
+splunk_operator_indexer_peer_buckets{name="stack1",namespace="test",peer="splunk-stack1-indexer-0"} 42
+# HELP splunk_operator_indexer_peer_searchable Set to 1 when an indexer cluster peer belongs to the committed generation and is searchable
+# TYPE splunk_operator_indexer_peer_searchable gauge
+splunk_operator_indexer_peer_searchable{name="stack1",namespace="test",peer="splunk-stack1-indexer-0"} 1
+# HELP splunk_operator_search_head_member_active_searches The number of searches running on a search head cluster member, by type
+# TYPE splunk_operator_search_head_member_active_searches gauge
+splunk_operator_search_head_member_active_searches{member="splunk-stack1-search-head-0",name="stack1",namespace="test",type="historical"} 3
+splunk_operator_search_head_member_active_searches{member="splunk-stack1-search-head-0",name="stack1",namespace="test",type="realtime"} 0
+# HELP splunk_operator_app_deployment_apps The number of apps of the App Framework of a custom resource, by deployment state: total, completed, pending and failed
+# TYPE splunk_operator_app_deployment_apps gauge
+splunk_operator_app_deployment_apps{kind="SearchHeadCluster",name="stack1",namespace="test",state="completed"} 1
+splunk_operator_app_deployment_apps{kind="SearchHeadCluster",name="stack1",namespace="test",state="failed"} 1
+splunk_operator_app_deployment_apps{kind="SearchHeadCluster",name="stack1",namespace="test",state="pending"} 0
+splunk_operator_app_deployment_apps{kind="SearchHeadCluster",name="stack1",namespace="test",state="total"} 2
+# HELP splunk_operator_app_bundle_push_stage The App Framework bundle push stage of a cluster manager or deployer: 0 uninitialized, 1 pending, 2 in progress and 3 complete
+# TYPE splunk_operator_app_bundle_push_stage gauge
+splunk_operator_app_bundle_push_stage{kind="SearchHeadCluster",name="stack1",namespace="test"} 2
+# HELP splunk_operator_cluster_manager_bundle_push_pending Set to 1 when the apps of a cluster manager need to be pushed to its peers
+# TYPE splunk_operator_cluster_manager_bundle_push_pending gauge
+splunk_operator_cluster_manager_bundle_push_pending{kind="ClusterManager",name="stack1",namespace="test"} 1
+`
+	err := testutil.CollectAndCompare(NewClusterHealthCollector(c, config.NamespaceSelection{}), strings.NewReader(want),
+		"splunk_operator_cr_phase",
+		"splunk_operator_indexer_peer_buckets",
+		"splunk_operator_indexer_peer_searchable",
+		"splunk_operator_search_head_member_active_searches",
+		"splunk_operator_app_deployment_apps",
+		"splunk_operator_app_bundle_push_stage",
+		"splunk_operator_cluster_manager_bundle_push_pending",
+	)
+	if err != nil {
+		t.Errorf("CollectAndCompare() returned error: %v", err)
+	}
+
+	// the flags are reported as 0 or 1
+	want = `
+# HELP splunk_operator_indexer_cluster_flag State flags of an indexer cluster reported by the cluster manager: initialized, indexing_ready, service_ready, maintenance_mode and rolling_restart
+# TYPE splunk_operator_indexer_cluster_flag gauge
+splunk_operator_indexer_cluster_flag{flag="indexing_ready",name="stack1",namespace="test"} 1
+splunk_operator_indexer_cluster_flag{flag="initialized",name="stack1",namespace="test"} 0
+splunk_operator_indexer_cluster_flag{flag="maintenance_mode",name="stack1",namespace="test"} 1
+splunk_operator_indexer_cluster_flag{flag="rolling_restart",name="stack1",namespace="test"} 0
+splunk_operator_indexer_cluster_flag{flag="service_ready",name="stack1",namespace="test"} 0
+`
+	err = testutil.CollectAndCompare(NewClusterHealthCollector(c, config.NamespaceSelection{}), strings.NewReader(want), "splunk_operator_indexer_cluster_flag")
+	if err != nil {
+		t.Errorf("CollectAndCompare() returned error: %v", err)
+	}
+}
+
+func TestClusterHealthCollectorNamespaceSelection(t *testing.T) {
+	utilruntime.Must(enterpriseApi.AddToScheme(clientgoscheme.Scheme))
+
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant1", Labels: map[string]string{"tenant": "splunk"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant2"}},
+		&enterpriseApi.Standalone{
+			ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "tenant1"},
+			Status:     enterpriseApi.StandaloneStatus{Phase: enterpriseApi.PhaseReady},
+		},
+		&enterpriseApi.Standalone{
+			ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "tenant2"},
+			Status:     enterpriseApi.StandaloneStatus{Phase: enterpriseApi.PhaseReady},
+		},
+	).Build()
+
+	// the custom resources of the namespaces left out by the selector aren't collected
+	selector, _ := labels.Parse("tenant=splunk")
+	want := `
+# HELP splunk_operator_cr_phase Set to 1 for the current phase of a custom resource
+# TYPE splunk_operator_cr_phase gauge
+splunk_operator_cr_phase{kind="Standalone",name="s1",namespace="tenant1",phase="Ready"} 1
+`
+	err := testutil.CollectAndCompare(NewClusterHealthCollector(c, config.NamespaceSelection{Selector: selector}), strings.NewReader(want), "splunk_operator_cr_phase")
+	if err != nil {
+		t.Errorf("CollectAndCompare() returned error: %v", err)
+	}
+
+	// each custom resource is collected by the operator instance of its shard only
+	collected := 0
+	for shard := 0; shard < 2; shard++ {
+		collected += testutil.CollectAndCount(NewClusterHealthCollector(c, config.NamespaceSelection{ShardIndex: shard, ShardCount: 2}), "splunk_operator_cr_phase")
+	}
+	if collected != 2 {
+		t.Errorf("the shards collected %d phases; want 2", collected)
+	}
+}
@@ -34,6 +34,7 @@ import (
 	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
 	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -137,7 +138,7 @@ func getSplunkService(ctx context.Context, cr splcommon.MetaObject, spec *enterp
 		}
 	}
 	service.Spec.Selector = getSplunkLabels(instanceIdentifier, instanceType, partOfIdentifier)
-	service.Spec.Ports = append(service.Spec.Ports, splcommon.SortServicePorts(getSplunkServicePorts(instanceType))...) // note that port order is important for tests
+	service.Spec.Ports = append(service.Spec.Ports, splcommon.SortServicePorts(getSplunkServicePorts(instanceType, spec))...) // note that port order is important for tests
 
 	// ensure labels and annotations are not nil
 	if service.ObjectMeta.Labels == nil {
@@ -307,8 +308,18 @@ func validateCommonSplunkSpec(ctx context.Context, c splcommon.ControllerClient,
 		return fmt.Errorf("negative value (%d) is not allowed for Readiness probe intial delay", spec.ReadinessInitialDelaySeconds)
 	}
 
+	err := validateExposeSpec(&spec.Expose)
+	if err != nil {
+		return err
+	}
+
+	err = validateSplunkPorts(spec)
+	if err != nil {
+		return err
+	}
+
 	// if not provided, set default values for imagePullSecrets
-	err := ValidateImagePullSecrets(ctx, c, cr, spec)
+	err = ValidateImagePullSecrets(ctx, c, cr, spec)
 	if err != nil {
 		return err
 	}
@@ -354,8 +365,8 @@ func getSplunkDefaults(identifier, namespace string, instanceType InstanceType,
 	}
 }
 
-// getSplunkPorts returns a map of ports to use for Splunk instances.
-func getSplunkPorts(instanceType InstanceType) map[string]int {
+// getSplunkPorts returns a map of ports to use for Splunk instances, including the additional ports and overrides of the spec.
+func getSplunkPorts(instanceType InstanceType, spec *enterpriseApi.CommonSplunkSpec) map[string]int {
 	result := map[string]int{
 		GetPortName(splunkwebPort, protoHTTP): 8000,
 		GetPortName(splunkdPort, protoHTTPS):  8089,
@@ -373,36 +384,95 @@ func getSplunkPorts(instanceType InstanceType) map[string]int {
 		result[GetPortName(s2sPort, protoTCP)] = 9997
 	}
 
+	for _, port := range spec.Ports {
+		if port.Disabled {
+			delete(result, port.Name)
+		} else if port.Port != 0 {
+			result[port.Name] = int(port.Port)
+		}
+	}
+
 	return result
 }
 
+// getSplunkPortProtocol returns the protocol of a port of the Splunk instances, TCP unless set in the spec.
+func getSplunkPortProtocol(spec *enterpriseApi.CommonSplunkSpec, name string) corev1.Protocol {
+	for _, port := range spec.Ports {
+		if port.Name == name && port.Protocol != "" {
+			return port.Protocol
+		}
+	}
+	return corev1.ProtocolTCP
+}
+
 // getSplunkContainerPorts returns a list of Kubernetes ContainerPort objects for Splunk instances.
-func getSplunkContainerPorts(instanceType InstanceType) []corev1.ContainerPort {
+func getSplunkContainerPorts(instanceType InstanceType, spec *enterpriseApi.CommonSplunkSpec) []corev1.ContainerPort {
 	l := []corev1.ContainerPort{}
-	for key, value := range getSplunkPorts(instanceType) {
+	for key, value := range getSplunkPorts(instanceType, spec) {
 		l = append(l, corev1.ContainerPort{
 			Name:          key,
 			ContainerPort: int32(value),
-			Protocol:      corev1.ProtocolTCP,
+			Protocol:      getSplunkPortProtocol(spec, key),
 		})
 	}
 	return l
 }
 
 // getSplunkServicePorts returns a list of Kubernetes ServicePort objects for Splunk instances.
-func getSplunkServicePorts(instanceType InstanceType) []corev1.ServicePort {
+func getSplunkServicePorts(instanceType InstanceType, spec *enterpriseApi.CommonSplunkSpec) []corev1.ServicePort {
 	l := []corev1.ServicePort{}
-	for key, value := range getSplunkPorts(instanceType) {
+	for key, value := range getSplunkPorts(instanceType, spec) {
 		l = append(l, corev1.ServicePort{
 			Name:       key,
 			Port:       int32(value),
 			TargetPort: intstr.FromInt(value),
-			Protocol:   corev1.ProtocolTCP,
+			Protocol:   getSplunkPortProtocol(spec, key),
 		})
 	}
 	return l
 }
 
+// validateSplunkPorts checks the additional ports and the overrides of the default ports of a CommonSplunkSpec
+func validateSplunkPorts(spec *enterpriseApi.CommonSplunkSpec) error {
+	defaultPorts := map[string]bool{}
+	for _, instanceType := range []InstanceType{SplunkStandalone, SplunkClusterManager, SplunkIndexer, SplunkSearchHead, SplunkDeployer, SplunkLicenseManager, SplunkMonitoringConsole} {
+		for name := range getSplunkPorts(instanceType, &enterpriseApi.CommonSplunkSpec{}) {
+			defaultPorts[name] = true
+		}
+	}
+
+	names := map[string]bool{}
+	numbers := map[string]bool{}
+	for _, port := range spec.Ports {
+		if port.Name == "" {
+			return fmt.Errorf("name is required for the ports")
+		}
+		if names[port.Name] {
+			return fmt.Errorf("port %s is defined more than once", port.Name)
+		}
+		names[port.Name] = true
+
+		// the operator and the Splunk instances rely on the management port
+		if port.Name == GetPortName(splunkdPort, protoHTTPS) && (port.Disabled || (port.Port != 0 && port.Port != 8089)) {
+			return fmt.Errorf("port %s can't be disabled or changed", port.Name)
+		}
+		if port.Disabled {
+			continue
+		}
+		if port.Port == 0 && !defaultPorts[port.Name] {
+			return fmt.Errorf("port number is required for the additional port %s", port.Name)
+		}
+		if port.Port != 0 {
+			number := fmt.Sprintf("%d/%s", port.Port, getSplunkPortProtocol(spec, port.Name))
+			if numbers[number] {
+				return fmt.Errorf("port number %s is used more than once", number)
+			}
+			numbers[number] = true
+		}
+	}
+	return nil
+}
+
 // addSplunkVolumeToTemplate modifies the podTemplateSpec object to incorporate an additional VolumeSource.
 func addSplunkVolumeToTemplate(podTemplateSpec *corev1.PodTemplateSpec, name string, mountPath string, volumeSource corev1.VolumeSource) {
 	podTemplateSpec.Spec.Volumes = append(podTemplateSpec.Spec.Volumes, corev1.Volume{
@@ -493,7 +563,7 @@ func addStorageVolumes(cr splcommon.MetaObject, spec *enterpriseApi.CommonSplunk
 func getSplunkStatefulSet(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, spec *enterpriseApi.CommonSplunkSpec, instanceType InstanceType, replicas int32, extraEnv []corev1.EnvVar) (*appsv1.StatefulSet, error) {
 
 	// prepare misc values
-	ports := splcommon.SortContainerPorts(getSplunkContainerPorts(instanceType)) // note that port order is important for tests
+	ports := splcommon.SortContainerPorts(getSplunkContainerPorts(instanceType, spec)) // note that port order is important for tests
 	annotations := splcommon.GetIstioAnnotations(ports)
 	selectLabels := getSplunkLabels(cr.GetName(), instanceType, spec.ClusterMasterRef.Name)
 	affinity := splcommon.AppendPodAntiAffinity(&spec.Affinity, cr.GetName(), instanceType.ToString())
@@ -508,24 +578,24 @@ func getSplunkStatefulSet(ctx context.Context, client splcommon.ControllerClient
 		Namespace: cr.GetNamespace(),
 		Name:      GetSplunkStatefulsetName(instanceType, cr.GetName()),
 	}
-	statefulSet := &appsv1.StatefulSet{}
-	err := client.Get(ctx, namespacedName, statefulSet)
+	current := &appsv1.StatefulSet{}
+	err := client.Get(ctx, namespacedName, current)
 	if err != nil && !k8serrors.IsNotFound(err) {
 		return nil, err
 	}
 
-	if k8serrors.IsNotFound(err) {
-		// create statefulset configuration
-		statefulSet = &appsv1.StatefulSet{
-			TypeMeta: metav1.TypeMeta{
-				Kind:       "StatefulSet",
-				APIVersion: "apps/v1",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      GetSplunkStatefulsetName(instanceType, cr.GetName()),
-				Namespace: cr.GetNamespace(),
-			},
-		}
+	// create statefulset configuration, which only has the fields of the operator as it is applied to the existing
+	// statefulset. The status of the existing statefulset is kept for the callers
+	statefulSet := &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "StatefulSet",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetSplunkStatefulsetName(instanceType, cr.GetName()),
+			Namespace: cr.GetNamespace(),
+		},
+		Status: current.Status,
 	}
 
 	statefulSet.Spec = appsv1.StatefulSetSpec{
@@ -776,8 +846,7 @@ func updateSplunkPodTemplateWithConfig(ctx context.Context, client splcommon.Con
 				Namespace: cr.GetNamespace(),
 				Name:      spec.ClusterMasterRef.Name,
 			}
-			managerIdxCluster := &enterpriseApi.ClusterMaster{}
-			err := client.Get(ctx, namespacedName, managerIdxCluster)
+			managerIdxCluster, err := getClusterManagerCR(ctx, client, namespacedName)
 			if err != nil {
 				scopedLog.Error(err, "Unable to get ClusterManager")
 			}
@@ -806,7 +875,7 @@ func updateSplunkPodTemplateWithConfig(ctx context.Context, client splcommon.Con
 	if spec.MonitoringConsoleRef.Name != "" {
 		extraEnv = append(extraEnv, corev1.EnvVar{
 			Name:  "SPLUNK_MONITORING_CONSOLE_REF",
-			Value: spec.MonitoringConsoleRef.Name,
+			Value: getMonitoringConsoleRefKey(cr.GetNamespace(), spec.MonitoringConsoleRef),
 		})
 	}
 
@@ -961,6 +1030,8 @@ func AreRemoteVolumeKeysChanged(ctx context.Context, client splcommon.Controller
 
 // ApplyManualAppUpdateConfigMap applies the manual app update config map
 func ApplyManualAppUpdateConfigMap(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, crKindMap map[string]string) (*corev1.ConfigMap, error) {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplyManualAppUpdateConfigMap", cr)
+	defer span.End()
 
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("ApplyManualAppUpdateConfigMap").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
@@ -1053,9 +1124,8 @@ func createOrUpdateAppUpdateConfigMap(ctx context.Context, client splcommon.Cont
 	configMapName := GetSplunkManualAppUpdateConfigMapName(cr.GetNamespace())
 	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: configMapName}
 
-	mux := getResourceMutex(configMapName)
-	mux.Lock()
-	defer mux.Unlock()
+	unlock := lockResourceMutex(configMapName, cr, "ApplyManualAppUpdateConfigMap")
+	defer unlock()
 	configMap, err = splctrl.GetConfigMap(ctx, client, namespacedName)
 	if err == nil {
 		// If this CR is already an owner reference, then do nothing.
@@ -1347,12 +1417,16 @@ func validateRemoteVolumeSpec(ctx context.Context, volList []enterpriseApi.Volum
 		// For now, Smartstore supports only S3, which is by default.
 		if isAppFramework {
 			if !isValidStorageType(volume.Type) {
-				return fmt.Errorf("remote volume type is invalid. Only storageType=s3 is supported")
+				return fmt.Errorf("remote volume type is invalid. Supported storageTypes: s3, http, oci")
 			}
 
 			if !isValidProvider(volume.Provider) {
 				return fmt.Errorf("s3 Provider is invalid")
 			}
+
+			if !isProviderApplicableToStorageType(volume.Type, volume.Provider) {
+				return fmt.Errorf("provider %s is not applicable to the storageType %s", volume.Provider, volume.Type)
+			}
 		}
 	}
 	return nil
@@ -1360,12 +1434,21 @@ func validateRemoteVolumeSpec(ctx context.Context, volList []enterpriseApi.Volum
 
 // isValidStorageType checks if the storage type specified is valid and supported
 func isValidStorageType(storage string) bool {
-	return storage != "" && storage == "s3"
+	return storage != "" && (storage == "s3" || storage == "http" || storage == "oci")
 }
 
 // isValidProvider checks if the provider specified is valid and supported
 func isValidProvider(provider string) bool {
-	return provider != "" && (provider == "aws" || provider == "minio")
+	return provider != "" && (provider == "aws" || provider == "minio" || provider == "http" || provider == "oci")
+}
+
+// isProviderApplicableToStorageType checks if the provider can be used with the storage type.
+// http and oci storage types have the provider with the same name
+func isProviderApplicableToStorageType(storage string, provider string) bool {
+	if storage == "s3" {
+		return provider == "aws" || provider == "minio"
+	}
+	return storage == provider
 }
 
 // validateSplunkIndexesSpec validates the smartstore index spec
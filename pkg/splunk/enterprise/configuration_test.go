@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -90,6 +91,59 @@ func TestGetSplunkService(t *testing.T) {
 	test(SplunkSearchHead, true, `{"kind":"Service","apiVersion":"v1","metadata":{"name":"splunk-stack1-search-head-headless","namespace":"test","creationTimestamp":null,"labels":{"app.kubernetes.io/component":"search-head","app.kubernetes.io/instance":"splunk-stack1-search-head","app.kubernetes.io/managed-by":"splunk-operator","app.kubernetes.io/name":"search-head","app.kubernetes.io/part-of":"splunk-stack1-search-head","one":"two"},"annotations":{"a":"b"},"ownerReferences":[{"apiVersion":"","kind":"","name":"stack1","uid":"","controller":true}]},"spec":{"ports":[{"name":"http-splunkweb","protocol":"TCP","port":8000,"targetPort":8000},{"name":"https-splunkd","protocol":"TCP","port":8089,"targetPort":8089}],"selector":{"app.kubernetes.io/component":"search-head","app.kubernetes.io/instance":"splunk-stack1-search-head","app.kubernetes.io/managed-by":"splunk-operator","app.kubernetes.io/name":"search-head","app.kubernetes.io/part-of":"splunk-stack1-search-head"},"clusterIP":"None","type":"ClusterIP","publishNotReadyAddresses":true},"status":{"loadBalancer":{}}}`)
 }
 
+func TestGetSplunkPorts(t *testing.T) {
+	spec := enterpriseApi.CommonSplunkSpec{
+		Ports: []enterpriseApi.SplunkPort{
+			{Name: "http-splunkweb", Disabled: true},
+			{Name: "http-hec", Port: 18088},
+			{Name: "udp-syslog", Port: 514, Protocol: corev1.ProtocolUDP},
+			{Name: "tcp-s2s", Disabled: true},
+		},
+	}
+
+	got := getSplunkPorts(SplunkIndexer, &spec)
+	want := map[string]int{"https-splunkd": 8089, "http-hec": 18088, "udp-syslog": 514}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getSplunkPorts() = %v; want %v", got, want)
+	}
+
+	// hec is not added to the instances which are not listening on it
+	got = getSplunkPorts(SplunkSearchHead, &enterpriseApi.CommonSplunkSpec{Ports: []enterpriseApi.SplunkPort{{Name: "http-hec"}}})
+	want = map[string]int{"http-splunkweb": 8000, "https-splunkd": 8089}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getSplunkPorts() = %v; want %v", got, want)
+	}
+
+	servicePorts := splcommon.SortServicePorts(getSplunkServicePorts(SplunkIndexer, &spec))
+	if len(servicePorts) != 3 || servicePorts[0].Port != 514 || servicePorts[0].Protocol != corev1.ProtocolUDP || servicePorts[1].TargetPort.IntValue() != 8089 || servicePorts[2].Name != "http-hec" {
+		t.Errorf("unexpected service ports %+v", servicePorts)
+	}
+
+	containerPorts := splcommon.SortContainerPorts(getSplunkContainerPorts(SplunkIndexer, &spec))
+	annotations := splcommon.GetIstioAnnotations(containerPorts)
+	if annotations["traffic.sidecar.istio.io/includeInboundPorts"] != "18088" {
+		t.Errorf("unexpected istio annotations %v", annotations)
+	}
+}
+
+func TestValidateSplunkPorts(t *testing.T) {
+	test := func(ports []enterpriseApi.SplunkPort, wantErr bool) {
+		err := validateSplunkPorts(&enterpriseApi.CommonSplunkSpec{Ports: ports})
+		if (err != nil) != wantErr {
+			t.Errorf("validateSplunkPorts(%+v) error = %v; want error %t", ports, err, wantErr)
+		}
+	}
+
+	test([]enterpriseApi.SplunkPort{{Name: "http-splunkweb", Disabled: true}, {Name: "tcp-syslog", Port: 1514}}, false)
+	test([]enterpriseApi.SplunkPort{{Name: "tcp-syslog", Port: 514}, {Name: "udp-syslog", Port: 514, Protocol: corev1.ProtocolUDP}}, false)
+	test([]enterpriseApi.SplunkPort{{Name: "https-splunkd", Disabled: true}}, true)
+	test([]enterpriseApi.SplunkPort{{Name: "https-splunkd", Port: 18089}}, true)
+	test([]enterpriseApi.SplunkPort{{Name: "tcp-syslog"}}, true)
+	test([]enterpriseApi.SplunkPort{{Name: "tcp-syslog", Port: 514}, {Name: "tcp-syslog", Port: 1514}}, true)
+	test([]enterpriseApi.SplunkPort{{Name: "tcp-syslog", Port: 9997}, {Name: "tcp-s2s", Port: 9997}}, true)
+	test([]enterpriseApi.SplunkPort{{Port: 514}}, true)
+}
+
 func TestGetSplunkDefaults(t *testing.T) {
 	cr := enterpriseApi.IndexerCluster{
 		ObjectMeta: metav1.ObjectMeta{
@@ -910,16 +964,38 @@ func TestValidateAppFrameworkSpec(t *testing.T) {
 	// Invalid remote volume type should return error.
 	AppFramework.VolList[0].Type = "s4"
 	err = ValidateAppFrameworkSpec(ctx, &AppFramework, &appFrameworkContext, false)
-	if err == nil || !strings.Contains(err.Error(), "remote volume type is invalid. Only storageType=s3 is supported") {
+	if err == nil || !strings.Contains(err.Error(), "remote volume type is invalid. Supported storageTypes: s3, http, oci") {
 		t.Errorf("ValidateAppFrameworkSpec with invalid remote volume type should have returned error.")
 	}
 
 	AppFramework.VolList[0].Provider = "invalid-provider"
 	err = ValidateAppFrameworkSpec(ctx, &AppFramework, &appFrameworkContext, false)
-	if err == nil || !strings.Contains(err.Error(), "remote volume type is invalid. Only storageType=s3 is supported") {
+	if err == nil || !strings.Contains(err.Error(), "remote volume type is invalid. Supported storageTypes: s3, http, oci") {
 		t.Errorf("ValidateAppFrameworkSpec with invalid provider should have returned error.")
 	}
 
+	// http and oci storage types should be accepted with the matching provider
+	for _, storageType := range []string{"http", "oci"} {
+		AppFramework.VolList[0].Type = storageType
+		AppFramework.VolList[0].Provider = storageType
+		if err = validateRemoteVolumeSpec(ctx, AppFramework.VolList, true); err != nil {
+			t.Errorf("storageType %s should be valid, error: %v", storageType, err)
+		}
+	}
+
+	AppFramework.VolList[0].Type = "oci"
+	AppFramework.VolList[0].Provider = "aws"
+	err = validateRemoteVolumeSpec(ctx, AppFramework.VolList, true)
+	if err == nil || !strings.Contains(err.Error(), "provider aws is not applicable to the storageType oci") {
+		t.Errorf("provider not applicable to the storageType should have returned error.")
+	}
+
+	AppFramework.VolList[0].Type = "s3"
+	AppFramework.VolList[0].Provider = "http"
+	err = validateRemoteVolumeSpec(ctx, AppFramework.VolList, true)
+	if err == nil {
+		t.Errorf("http provider with s3 storageType should have returned error.")
+	}
 }
 
 func TestGetSmartstoreIndexesConfig(t *testing.T) {
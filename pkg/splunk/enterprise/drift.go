@@ -0,0 +1,76 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"strings"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// driftReasonReverted is the reason of the DriftDetected condition when the drift was reverted
+	driftReasonReverted = "DriftReverted"
+
+	// driftReasonReported is the reason of the DriftDetected condition when the drift was kept
+	driftReasonReported = "DriftReported"
+
+	// driftReasonNone is the reason of the DriftDetected condition when there is no drift
+	driftReasonNone = "NoDrift"
+)
+
+// reportDrift sets the DriftDetected condition of conditions from the drift recorded during a reconcile, and publishes
+// a warning event for each drifted object which wasn't already reported by the condition. The condition is left
+// unchanged when the reconcile didn't get to apply any object
+func reportDrift(ctx context.Context, eventPublisher *K8EventPublisher, recorder *splctrl.DriftRecorder, generation int64, conditions *[]metav1.Condition) {
+	if recorder.Checked() == 0 {
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               enterpriseApi.ConditionDriftDetected,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             driftReasonNone,
+		Message:            "The objects managed by the operator match the custom resource",
+	}
+	drifts := recorder.Drifts()
+	if len(drifts) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = driftReasonReported
+		if recorder.Enforce() {
+			condition.Reason = driftReasonReverted
+		}
+		messages := make([]string, 0, len(drifts))
+		for _, drift := range drifts {
+			messages = append(messages, drift.String())
+		}
+		condition.Message = strings.Join(messages, "; ")
+	}
+
+	// the drift kept by the report-only policy is detected again on every reconcile, and only published once
+	previous := meta.FindStatusCondition(*conditions, enterpriseApi.ConditionDriftDetected)
+	for _, drift := range drifts {
+		if previous == nil || previous.Status != metav1.ConditionTrue || !strings.Contains(previous.Message, drift.String()) {
+			eventPublisher.Warning(ctx, enterpriseApi.ConditionDriftDetected, drift.String())
+		}
+	}
+
+	meta.SetStatusCondition(conditions, condition)
+}
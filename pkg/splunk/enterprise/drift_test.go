@@ -0,0 +1,85 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+func TestReportDrift(t *testing.T) {
+	ctx := context.TODO()
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-standalone-service",
+			Namespace: "test",
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kubectl-edit", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:type":{}}}`)}},
+			},
+		},
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort},
+	}
+	c := spltest.NewFakeApplyClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(service).Build())
+	cr := &enterpriseApi.Standalone{ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test", Generation: 2}}
+	eventPublisher, _ := newK8EventPublisher(c, cr)
+
+	// reconciles with the report-only policy, which keeps the service type
+	reconcileService := func() {
+		recorder := splctrl.NewDriftRecorder(enterpriseApi.DriftPolicyReportOnly)
+		desired := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: service.GetName(), Namespace: "test"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP},
+		}
+		err := splctrl.ApplyService(splctrl.WithDriftRecorder(ctx, recorder), c, desired)
+		if err != nil {
+			t.Fatalf("ApplyService() returned error: %v", err)
+		}
+		reportDrift(ctx, eventPublisher, recorder, cr.GetGeneration(), &cr.Status.Conditions)
+	}
+	reconcileService()
+	reconcileService()
+
+	condition := meta.FindStatusCondition(cr.Status.Conditions, enterpriseApi.ConditionDriftDetected)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != driftReasonReported || condition.ObservedGeneration != 2 {
+		t.Fatalf("DriftDetected condition = %v; want True with reason %s", condition, driftReasonReported)
+	}
+	wantMessage := "Service splunk-stack1-standalone-service: spec.type (by kubectl-edit) kept"
+	if condition.Message != wantMessage {
+		t.Errorf("DriftDetected message = %s; want %s", condition.Message, wantMessage)
+	}
+
+	// the drift is published once
+	events := corev1.EventList{}
+	_ = c.List(ctx, &events)
+	if len(events.Items) != 1 || events.Items[0].Reason != enterpriseApi.ConditionDriftDetected {
+		t.Errorf("published %d events; want one %s event", len(events.Items), enterpriseApi.ConditionDriftDetected)
+	}
+
+	// no change of the condition when no object was applied
+	reportDrift(ctx, eventPublisher, splctrl.NewDriftRecorder(enterpriseApi.DriftPolicyEnforce), cr.GetGeneration(), &cr.Status.Conditions)
+	if !meta.IsStatusConditionTrue(cr.Status.Conditions, enterpriseApi.ConditionDriftDetected) {
+		t.Errorf("DriftDetected condition changed without applying any object")
+	}
+}
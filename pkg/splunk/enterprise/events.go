@@ -74,6 +74,13 @@ func (k *K8EventPublisher) publishEvent(ctx context.Context, eventType, reason,
 		return
 	}
 
+	// the events of the ClusterManager and LicenseManager kinds are published on their ClusterMaster and LicenseMaster copy
+	if cr, ok := k.instance.(splcommon.MetaObject); ok && isV4ManagerKind(cr) {
+		gvk := cr.GetObjectKind().GroupVersionKind()
+		event.InvolvedObject.Kind = gvk.Kind
+		event.InvolvedObject.APIVersion = gvk.GroupVersion().String()
+	}
+
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("PublishEvent")
 	scopedLog.Info("publishing event", "reason", event.Reason, "message", event.Message)
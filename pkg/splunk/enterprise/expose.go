@@ -0,0 +1,370 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
+)
+
+var (
+	// kinds of the objects generated to expose the Splunk ports
+	ingressGVK   = networkingv1.SchemeGroupVersion.WithKind("Ingress")
+	routeGVK     = schema.GroupVersionKind{Group: "route.openshift.io", Version: "v1", Kind: "Route"}
+	httpRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "HTTPRoute"}
+	tlsRouteGVK  = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Kind: "TLSRoute"}
+)
+
+// getExposeEndpoint returns the expose configuration of a Splunk port
+func getExposeEndpoint(spec *enterpriseApi.ExposeSpec, portName string) *enterpriseApi.ExposeEndpointSpec {
+	if portName == hecPort {
+		return &spec.HEC
+	}
+	return &spec.Web
+}
+
+// getExposePath returns the path prefix routed to a Splunk port
+func getExposePath(portName string) string {
+	if portName == hecPort {
+		return exposeHECPath
+	}
+	return "/"
+}
+
+// getExposeGVK returns the kind of the object generated to expose a Splunk port
+func getExposeGVK(spec *enterpriseApi.ExposeSpec, portName string) schema.GroupVersionKind {
+	switch spec.Type {
+	case exposeTypeRoute:
+		return routeGVK
+	case exposeTypeGateway:
+		if getExposeEndpoint(spec, portName).TLSPassthrough {
+			return tlsRouteGVK
+		}
+		return httpRouteGVK
+	}
+	return ingressGVK
+}
+
+// getExposeAnnotations returns the annotations of the object exposing a Splunk port. Splunk Web of the
+// search head cluster gets session affinity, and HEC is load balanced across the indexers or standalones
+func getExposeAnnotations(spec *enterpriseApi.ExposeSpec, instanceType InstanceType, portName string) map[string]string {
+	endpoint := getExposeEndpoint(spec, portName)
+	annotations := make(map[string]string)
+	switch spec.Type {
+	case exposeTypeIngress:
+		if portName == splunkwebPort && instanceType == SplunkSearchHead {
+			annotations["nginx.ingress.kubernetes.io/affinity"] = "cookie"
+			annotations["nginx.ingress.kubernetes.io/affinity-mode"] = "persistent"
+		}
+		// HEC is served over TLS by Splunk
+		if portName == hecPort || endpoint.TLSPassthrough {
+			annotations["nginx.ingress.kubernetes.io/backend-protocol"] = "HTTPS"
+		}
+		if endpoint.TLSPassthrough {
+			annotations["nginx.ingress.kubernetes.io/ssl-passthrough"] = "true"
+		}
+	case exposeTypeRoute:
+		if portName == hecPort {
+			annotations["haproxy.router.openshift.io/balance"] = "roundrobin"
+			annotations["haproxy.router.openshift.io/disable_cookies"] = "true"
+		} else if instanceType == SplunkSearchHead {
+			// cookies can't be set on the passthrough routes
+			if endpoint.TLSPassthrough {
+				annotations["haproxy.router.openshift.io/balance"] = "source"
+			} else {
+				annotations["router.openshift.io/cookie_name"] = "splunkweb"
+			}
+		}
+	}
+
+	// annotations from the spec override the defaults
+	for k, v := range spec.Annotations {
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// getSplunkIngress returns an Ingress exposing a Splunk port
+func getSplunkIngress(cr splcommon.MetaObject, spec *enterpriseApi.CommonSplunkSpec, instanceType InstanceType, portName string, port int) *networkingv1.Ingress {
+	endpoint := getExposeEndpoint(&spec.Expose, portName)
+	pathType := networkingv1.PathTypePrefix
+
+	ingress := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       ingressGVK.Kind,
+			APIVersion: ingressGVK.GroupVersion().String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        GetSplunkExposeName(instanceType, cr.GetName(), portName),
+			Namespace:   cr.GetNamespace(),
+			Labels:      getSplunkLabels(cr.GetName(), instanceType, ""),
+			Annotations: getExposeAnnotations(&spec.Expose, instanceType, portName),
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: endpoint.Host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path:     getExposePath(portName),
+									PathType: &pathType,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: GetSplunkServiceName(instanceType, cr.GetName(), false),
+											Port: networkingv1.ServiceBackendPort{Number: int32(port)},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if spec.Expose.IngressClassName != "" {
+		ingressClassName := spec.Expose.IngressClassName
+		ingress.Spec.IngressClassName = &ingressClassName
+	}
+
+	if endpoint.TLSSecretName != "" && !endpoint.TLSPassthrough {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{
+				Hosts:      []string{endpoint.Host},
+				SecretName: endpoint.TLSSecretName,
+			},
+		}
+	}
+
+	ingress.SetOwnerReferences(append(ingress.GetOwnerReferences(), splcommon.AsOwner(cr, true)))
+	return ingress
+}
+
+// newExposeObject returns an unstructured object of a Gateway API or OpenShift kind, which have no Go types in the operator
+func newExposeObject(cr splcommon.MetaObject, spec *enterpriseApi.CommonSplunkSpec, instanceType InstanceType, portName string, gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(GetSplunkExposeName(instanceType, cr.GetName(), portName))
+	obj.SetNamespace(cr.GetNamespace())
+	obj.SetLabels(getSplunkLabels(cr.GetName(), instanceType, ""))
+	obj.SetAnnotations(getExposeAnnotations(&spec.Expose, instanceType, portName))
+	obj.SetOwnerReferences([]metav1.OwnerReference{splcommon.AsOwner(cr, true)})
+	return obj
+}
+
+// getSplunkRoute returns an OpenShift Route exposing a Splunk port. The route terminates TLS with the default certificate
+// of the router, or the TLS secret referred as an external certificate, and re-encrypts the HEC traffic. The private
+// key of the TLS secret is never copied to the route, which is readable by anyone allowed to read routes
+func getSplunkRoute(cr splcommon.MetaObject, spec *enterpriseApi.CommonSplunkSpec, instanceType InstanceType, portName string, port int) *unstructured.Unstructured {
+	endpoint := getExposeEndpoint(&spec.Expose, portName)
+	route := newExposeObject(cr, spec, instanceType, portName, routeGVK)
+
+	tls := map[string]interface{}{}
+	switch {
+	case endpoint.TLSPassthrough:
+		tls["termination"] = "passthrough"
+	case portName == hecPort:
+		tls["termination"] = "reencrypt"
+	default:
+		tls["termination"] = "edge"
+		tls["insecureEdgeTerminationPolicy"] = "Redirect"
+	}
+
+	if endpoint.TLSSecretName != "" && !endpoint.TLSPassthrough {
+		tls["externalCertificate"] = map[string]interface{}{
+			"name": endpoint.TLSSecretName,
+		}
+	}
+
+	routeSpec := map[string]interface{}{
+		"host": endpoint.Host,
+		"to": map[string]interface{}{
+			"kind": "Service",
+			"name": GetSplunkServiceName(instanceType, cr.GetName(), false),
+		},
+		"port": map[string]interface{}{
+			"targetPort": int64(port),
+		},
+		"tls": tls,
+	}
+	if !endpoint.TLSPassthrough {
+		routeSpec["path"] = getExposePath(portName)
+	}
+	route.Object["spec"] = routeSpec
+
+	return route
+}
+
+// getSplunkGatewayRoute returns a Gateway API HTTPRoute, or TLSRoute for TLS passthrough, exposing a Splunk port
+func getSplunkGatewayRoute(cr splcommon.MetaObject, spec *enterpriseApi.CommonSplunkSpec, instanceType InstanceType, portName string, port int) *unstructured.Unstructured {
+	endpoint := getExposeEndpoint(&spec.Expose, portName)
+	gvk := getExposeGVK(&spec.Expose, portName)
+	route := newExposeObject(cr, spec, instanceType, portName, gvk)
+
+	parentRef := map[string]interface{}{
+		"name": spec.Expose.GatewayRef.Name,
+	}
+	if spec.Expose.GatewayRef.Namespace != "" {
+		parentRef["namespace"] = spec.Expose.GatewayRef.Namespace
+	}
+	if spec.Expose.GatewayRef.SectionName != "" {
+		parentRef["sectionName"] = spec.Expose.GatewayRef.SectionName
+	}
+
+	rule := map[string]interface{}{
+		"backendRefs": []interface{}{
+			map[string]interface{}{
+				"name": GetSplunkServiceName(instanceType, cr.GetName(), false),
+				"port": int64(port),
+			},
+		},
+	}
+	if gvk == httpRouteGVK {
+		rule["matches"] = []interface{}{
+			map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":  "PathPrefix",
+					"value": getExposePath(portName),
+				},
+			},
+		}
+	}
+
+	route.Object["spec"] = map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"hostnames":  []interface{}{endpoint.Host},
+		"rules":      []interface{}{rule},
+	}
+	return route
+}
+
+// deleteSplunkExposeObject deletes an object previously generated to expose a Splunk port
+func deleteSplunkExposeObject(ctx context.Context, c splcommon.ControllerClient, cr splcommon.MetaObject, exposed *enterpriseApi.ExposedEndpoint) error {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("deleteSplunkExposeObject").WithValues("kind", exposed.Kind, "name", exposed.Name, "namespace", cr.GetNamespace())
+
+	var obj client.Object
+	if exposed.APIVersion == ingressGVK.GroupVersion().String() && exposed.Kind == ingressGVK.Kind {
+		obj = &networkingv1.Ingress{}
+	} else {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(exposed.APIVersion)
+		u.SetKind(exposed.Kind)
+		obj = u
+	}
+	obj.SetName(exposed.Name)
+	obj.SetNamespace(cr.GetNamespace())
+
+	scopedLog.Info("Deleting the object no longer exposing the port")
+	err := c.Delete(ctx, obj)
+	// nothing to delete, when the kind isn't installed in the cluster anymore
+	if k8serrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return nil
+	}
+	return err
+}
+
+// ApplySplunkExpose creates or updates the Ingresses, OpenShift Routes or Gateway API routes exposing the Splunk Web
+// and HEC ports of the Splunk instances, and deletes the ones no longer required. The generated objects are tracked in status
+func ApplySplunkExpose(ctx context.Context, c splcommon.ControllerClient, cr splcommon.MetaObject, spec *enterpriseApi.CommonSplunkSpec, instanceType InstanceType, status *[]enterpriseApi.ExposedEndpoint) error {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplySplunkExpose", cr)
+	defer span.End()
+
+	ports := getSplunkPorts(instanceType, spec)
+	var exposedEndpoints []enterpriseApi.ExposedEndpoint
+	for _, portName := range []string{splunkwebPort, hecPort} {
+		// ports are exposed only when the host is set, and the instances are listening on them
+		port, ok := ports[GetPortName(portName, protoHTTP)]
+		host := getExposeEndpoint(&spec.Expose, portName).Host
+		if !ok || spec.Expose.Type == "" || host == "" {
+			continue
+		}
+
+		gvk := getExposeGVK(&spec.Expose, portName)
+		var err error
+		switch spec.Expose.Type {
+		case exposeTypeIngress:
+			err = splctrl.ApplyIngress(ctx, c, getSplunkIngress(cr, spec, instanceType, portName, port))
+		case exposeTypeRoute:
+			err = splctrl.ApplyUnstructuredObject(ctx, c, getSplunkRoute(cr, spec, instanceType, portName, port))
+		case exposeTypeGateway:
+			err = splctrl.ApplyUnstructuredObject(ctx, c, getSplunkGatewayRoute(cr, spec, instanceType, portName, port))
+		}
+		if err != nil {
+			return fmt.Errorf("unable to expose the %s port with a %s: %v", portName, gvk.Kind, err)
+		}
+
+		exposedEndpoints = append(exposedEndpoints, enterpriseApi.ExposedEndpoint{
+			Port:       portName,
+			Host:       host,
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Name:       GetSplunkExposeName(instanceType, cr.GetName(), portName),
+		})
+	}
+
+	// delete the objects left over by a previous expose configuration
+	for i := range *status {
+		previous := &(*status)[i]
+		stale := true
+		for _, exposed := range exposedEndpoints {
+			if exposed.APIVersion == previous.APIVersion && exposed.Kind == previous.Kind && exposed.Name == previous.Name {
+				stale = false
+			}
+		}
+		if !stale {
+			continue
+		}
+
+		err := deleteSplunkExposeObject(ctx, c, cr, previous)
+		if err != nil {
+			return err
+		}
+	}
+
+	*status = exposedEndpoints
+	return nil
+}
+
+// validateExposeSpec checks the expose configuration
+func validateExposeSpec(spec *enterpriseApi.ExposeSpec) error {
+	switch spec.Type {
+	case "", exposeTypeIngress, exposeTypeRoute:
+	case exposeTypeGateway:
+		if spec.GatewayRef.Name == "" && (spec.Web.Host != "" || spec.HEC.Host != "") {
+			return fmt.Errorf("gatewayRef.name is required to expose the ports with the Gateway API")
+		}
+	default:
+		return fmt.Errorf("unsupported expose type %s", spec.Type)
+	}
+	return nil
+}
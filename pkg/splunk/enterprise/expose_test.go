@@ -0,0 +1,241 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+func TestApplySplunkExposeIngress(t *testing.T) {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+	cr := enterpriseApi.SearchHeadCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "SearchHeadCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	cr.Spec.Expose = enterpriseApi.ExposeSpec{
+		Type:             "Ingress",
+		IngressClassName: "nginx",
+		Annotations:      map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt"},
+		Web:              enterpriseApi.ExposeEndpointSpec{Host: "shc.example.com", TLSSecretName: "shc-tls"},
+		HEC:              enterpriseApi.ExposeEndpointSpec{Host: "hec.example.com"},
+	}
+
+	err := ApplySplunkExpose(ctx, c, &cr, &cr.Spec.CommonSplunkSpec, SplunkSearchHead, &cr.Status.Expose)
+	if err != nil {
+		t.Fatalf("ApplySplunkExpose should not return an error, error: %v", err)
+	}
+
+	// search heads are not listening on the HEC port
+	if len(cr.Status.Expose) != 1 || cr.Status.Expose[0].Kind != "Ingress" || cr.Status.Expose[0].Name != "splunk-stack1-search-head-splunkweb" {
+		t.Fatalf("unexpected expose status %+v", cr.Status.Expose)
+	}
+
+	var ingress networkingv1.Ingress
+	err = c.Get(ctx, types.NamespacedName{Name: "splunk-stack1-search-head-splunkweb", Namespace: "test"}, &ingress)
+	if err != nil {
+		t.Fatalf("unable to get the ingress, error: %v", err)
+	}
+
+	backend := ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service
+	if *ingress.Spec.IngressClassName != "nginx" || ingress.Spec.Rules[0].Host != "shc.example.com" || backend.Name != "splunk-stack1-search-head-service" || backend.Port.Number != 8000 {
+		t.Errorf("unexpected ingress spec %+v", ingress.Spec)
+	}
+
+	if len(ingress.Spec.TLS) != 1 || ingress.Spec.TLS[0].SecretName != "shc-tls" {
+		t.Errorf("unexpected ingress tls %+v", ingress.Spec.TLS)
+	}
+
+	if ingress.Annotations["nginx.ingress.kubernetes.io/affinity"] != "cookie" || ingress.Annotations["cert-manager.io/cluster-issuer"] != "letsencrypt" {
+		t.Errorf("unexpected ingress annotations %v", ingress.Annotations)
+	}
+
+	if len(ingress.OwnerReferences) != 1 || ingress.OwnerReferences[0].Kind != "SearchHeadCluster" {
+		t.Errorf("ingress should be owned by the CR")
+	}
+
+	// ingress is deleted, when the type is changed
+	cr.Spec.Expose.Type = "Route"
+	err = ApplySplunkExpose(ctx, c, &cr, &cr.Spec.CommonSplunkSpec, SplunkSearchHead, &cr.Status.Expose)
+	if err != nil {
+		t.Fatalf("ApplySplunkExpose should not return an error, error: %v", err)
+	}
+
+	if len(cr.Status.Expose) != 1 || cr.Status.Expose[0].Kind != "Route" {
+		t.Errorf("unexpected expose status %+v", cr.Status.Expose)
+	}
+
+	err = c.Get(ctx, types.NamespacedName{Name: "splunk-stack1-search-head-splunkweb", Namespace: "test"}, &networkingv1.Ingress{})
+	if err == nil {
+		t.Errorf("ingress should be deleted, when the type is changed")
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(routeGVK)
+	err = c.Get(ctx, types.NamespacedName{Name: "splunk-stack1-search-head-splunkweb", Namespace: "test"}, route)
+	if err != nil {
+		t.Fatalf("unable to get the route, error: %v", err)
+	}
+
+	// the TLS secret is referred as an external certificate, its key is never copied to the route
+	termination, _, _ := unstructured.NestedString(route.Object, "spec", "tls", "termination")
+	certificate, _, _ := unstructured.NestedString(route.Object, "spec", "tls", "externalCertificate", "name")
+	if termination != "edge" || certificate != "shc-tls" || route.GetAnnotations()["router.openshift.io/cookie_name"] == "" {
+		t.Errorf("unexpected route %v", route.Object)
+	}
+	if _, ok, _ := unstructured.NestedFieldNoCopy(route.Object, "spec", "tls", "key"); ok {
+		t.Errorf("route should not inline the TLS key, got: %v", route.Object)
+	}
+
+	// route is deleted, when the expose section is removed
+	cr.Spec.Expose = enterpriseApi.ExposeSpec{}
+	err = ApplySplunkExpose(ctx, c, &cr, &cr.Spec.CommonSplunkSpec, SplunkSearchHead, &cr.Status.Expose)
+	if err != nil || len(cr.Status.Expose) != 0 {
+		t.Errorf("expose status should be empty, error: %v", err)
+	}
+
+	err = c.Get(ctx, types.NamespacedName{Name: "splunk-stack1-search-head-splunkweb", Namespace: "test"}, route)
+	if err == nil {
+		t.Errorf("route should be deleted, when the expose section is removed")
+	}
+}
+
+func TestApplySplunkExposeGateway(t *testing.T) {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+	cr := enterpriseApi.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	cr.Spec.Expose = enterpriseApi.ExposeSpec{
+		Type:       "Gateway",
+		GatewayRef: enterpriseApi.ExposeGatewayRef{Name: "gateway", Namespace: "infra"},
+		Web:        enterpriseApi.ExposeEndpointSpec{Host: "splunk.example.com"},
+		HEC:        enterpriseApi.ExposeEndpointSpec{Host: "hec.example.com", TLSPassthrough: true},
+	}
+
+	err := ApplySplunkExpose(ctx, c, &cr, &cr.Spec.CommonSplunkSpec, SplunkStandalone, &cr.Status.Expose)
+	if err != nil {
+		t.Fatalf("ApplySplunkExpose should not return an error, error: %v", err)
+	}
+
+	if len(cr.Status.Expose) != 2 || cr.Status.Expose[0].Kind != "HTTPRoute" || cr.Status.Expose[1].Kind != "TLSRoute" {
+		t.Fatalf("unexpected expose status %+v", cr.Status.Expose)
+	}
+
+	httpRoute := &unstructured.Unstructured{}
+	httpRoute.SetGroupVersionKind(httpRouteGVK)
+	err = c.Get(ctx, types.NamespacedName{Name: "splunk-stack1-standalone-splunkweb", Namespace: "test"}, httpRoute)
+	if err != nil {
+		t.Fatalf("unable to get the HTTPRoute, error: %v", err)
+	}
+
+	parentRefs, _, _ := unstructured.NestedSlice(httpRoute.Object, "spec", "parentRefs")
+	rules, _, _ := unstructured.NestedSlice(httpRoute.Object, "spec", "rules")
+	if len(parentRefs) != 1 || parentRefs[0].(map[string]interface{})["namespace"] != "infra" || len(rules) != 1 {
+		t.Fatalf("unexpected HTTPRoute %v", httpRoute.Object)
+	}
+
+	backendRef := rules[0].(map[string]interface{})["backendRefs"].([]interface{})[0].(map[string]interface{})
+	if backendRef["name"] != "splunk-stack1-standalone-service" || backendRef["port"] != int64(8000) {
+		t.Errorf("unexpected HTTPRoute backend %v", backendRef)
+	}
+
+	tlsRoute := &unstructured.Unstructured{}
+	tlsRoute.SetGroupVersionKind(tlsRouteGVK)
+	err = c.Get(ctx, types.NamespacedName{Name: "splunk-stack1-standalone-hec", Namespace: "test"}, tlsRoute)
+	if err != nil {
+		t.Fatalf("unable to get the TLSRoute, error: %v", err)
+	}
+
+	hostnames, _, _ := unstructured.NestedStringSlice(tlsRoute.Object, "spec", "hostnames")
+	if len(hostnames) != 1 || hostnames[0] != "hec.example.com" {
+		t.Errorf("unexpected TLSRoute hostnames %v", hostnames)
+	}
+
+	// HEC is no longer exposed, when its host is removed
+	cr.Spec.Expose.HEC.Host = ""
+	err = ApplySplunkExpose(ctx, c, &cr, &cr.Spec.CommonSplunkSpec, SplunkStandalone, &cr.Status.Expose)
+	if err != nil || len(cr.Status.Expose) != 1 {
+		t.Errorf("unexpected expose status %+v, error: %v", cr.Status.Expose, err)
+	}
+
+	err = c.Get(ctx, types.NamespacedName{Name: "splunk-stack1-standalone-hec", Namespace: "test"}, tlsRoute)
+	if err == nil {
+		t.Errorf("TLSRoute should be deleted, when the host is removed")
+	}
+}
+
+func TestGetExposeAnnotations(t *testing.T) {
+	spec := enterpriseApi.ExposeSpec{Type: "Route"}
+
+	annotations := getExposeAnnotations(&spec, SplunkIndexer, hecPort)
+	if annotations["haproxy.router.openshift.io/balance"] != "roundrobin" || annotations["haproxy.router.openshift.io/disable_cookies"] != "true" {
+		t.Errorf("HEC should be load balanced, got: %v", annotations)
+	}
+
+	spec.Web.TLSPassthrough = true
+	annotations = getExposeAnnotations(&spec, SplunkSearchHead, splunkwebPort)
+	if annotations["haproxy.router.openshift.io/balance"] != "source" {
+		t.Errorf("passthrough route of the search heads should use source affinity, got: %v", annotations)
+	}
+
+	spec = enterpriseApi.ExposeSpec{Type: "Ingress", Annotations: map[string]string{"nginx.ingress.kubernetes.io/backend-protocol": "HTTP"}}
+	annotations = getExposeAnnotations(&spec, SplunkStandalone, hecPort)
+	if annotations["nginx.ingress.kubernetes.io/backend-protocol"] != "HTTP" {
+		t.Errorf("annotations from the spec should override the defaults, got: %v", annotations)
+	}
+
+	annotations = getExposeAnnotations(&spec, SplunkStandalone, splunkwebPort)
+	if _, ok := annotations["nginx.ingress.kubernetes.io/affinity"]; ok {
+		t.Errorf("session affinity is only required for the search heads, got: %v", annotations)
+	}
+}
+
+func TestValidateExposeSpec(t *testing.T) {
+	spec := enterpriseApi.ExposeSpec{Type: "Gateway", Web: enterpriseApi.ExposeEndpointSpec{Host: "splunk.example.com"}}
+	if validateExposeSpec(&spec) == nil {
+		t.Errorf("gatewayRef should be required for the Gateway type")
+	}
+
+	spec.GatewayRef.Name = "gateway"
+	if validateExposeSpec(&spec) != nil {
+		t.Errorf("expose spec should be valid")
+	}
+
+	spec.Type = "LoadBalancer"
+	if validateExposeSpec(&spec) == nil {
+		t.Errorf("unsupported type should return an error")
+	}
+}
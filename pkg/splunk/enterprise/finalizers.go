@@ -29,6 +29,7 @@ import (
 
 func init() {
 	splctrl.SplunkFinalizerRegistry["enterprise.splunk.com/delete-pvc"] = DeleteSplunkPvc
+	splctrl.SplunkFinalizerRegistry[monitoringConsoleRefFinalizer] = DeleteMonitoringConsolePeerURLs
 }
 
 // DeleteSplunkPvc removes all corresponding PersistentVolumeClaims that are associated with a custom resource.
@@ -43,13 +44,13 @@ func DeleteSplunkPvc(ctx context.Context, cr splcommon.MetaObject, c splcommon.C
 	switch objectKind {
 	case "Standalone":
 		components = append(components, "standalone")
-	case "LicenseMaster":
+	case "LicenseMaster", "LicenseManager":
 		components = append(components, splcommon.LicenseManager)
 	case "SearchHeadCluster":
 		components = append(components, "search-head", "deployer")
 	case "IndexerCluster":
 		components = append(components, "indexer")
-	case "ClusterMaster":
+	case "ClusterMaster", "ClusterManager":
 		components = append(components, splcommon.ClusterManager)
 	case "MonitoringConsole":
 		components = append(components, "monitoring-console")
@@ -175,6 +175,7 @@ func splunkDeletionTester(t *testing.T, cr splcommon.MetaObject, delete func(spl
 				{MetaName: "*v1.Secret-test-splunk-test-secret"},
 				{MetaName: "*v1.Secret-test-splunk-test-secret"},
 				{MetaName: "*v3.ClusterMaster-test-master1"},
+				{MetaName: "*v4.ClusterManager-test-master1"},
 				{MetaName: "*v1.Secret-test-splunk-test-secret"},
 				{MetaName: "*v3.IndexerCluster-test-stack1"},
 				{MetaName: "*v3.IndexerCluster-test-stack1"},
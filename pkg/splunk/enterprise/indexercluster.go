@@ -17,7 +17,6 @@ package enterprise
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -35,6 +34,7 @@ import (
 	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
 	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -44,6 +44,8 @@ type NewSplunkClientFunc func(managementURI, username, password string) *splclie
 
 // ApplyIndexerCluster reconciles the state of a Splunk Enterprise indexer cluster.
 func ApplyIndexerCluster(ctx context.Context, client splcommon.ControllerClient, cr *enterpriseApi.IndexerCluster) (reconcile.Result, error) {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplyIndexerCluster", cr)
+	defer span.End()
 
 	// unless modified, reconcile for this object will be requeued after 5 seconds
 	result := reconcile.Result{
@@ -60,6 +62,13 @@ func ApplyIndexerCluster(ctx context.Context, client splcommon.ControllerClient,
 		return result, err
 	}
 
+	// the number of indexers is decided by the autoscaling when enabled
+	if cr.Spec.Autoscaling.Enabled {
+		cr.Spec.Replicas = getAutoscaledReplicas(&cr.Spec.Autoscaling.AutoscalingSpec, &cr.Status.Autoscaling.AutoscalingStatus, cr.Spec.Replicas)
+	} else {
+		cr.Status.Autoscaling = enterpriseApi.IndexerClusterAutoscalingStatus{}
+	}
+
 	// updates status after function completes
 	cr.Status.Phase = enterpriseApi.PhaseError
 	cr.Status.ClusterMasterPhase = enterpriseApi.PhaseError
@@ -78,6 +87,11 @@ func ApplyIndexerCluster(ctx context.Context, client splcommon.ControllerClient,
 	// Update the CR Status
 	defer updateCRStatus(ctx, client, cr)
 
+	// detect the drift of the objects applied below, and report it in the status before it is updated
+	driftRecorder := splctrl.NewDriftRecorder(cr.Spec.DriftPolicy)
+	ctx = splctrl.WithDriftRecorder(ctx, driftRecorder)
+	defer reportDrift(ctx, eventPublisher, driftRecorder, cr.GetGeneration(), &cr.Status.Conditions)
+
 	// create or update general config resources
 	namespaceScopedSecret, err := ApplySplunkConfig(ctx, client, cr, cr.Spec.CommonSplunkSpec, SplunkIndexer)
 	if err != nil {
@@ -90,8 +104,7 @@ func ApplyIndexerCluster(ctx context.Context, client splcommon.ControllerClient,
 		Namespace: cr.GetNamespace(),
 		Name:      cr.Spec.ClusterMasterRef.Name,
 	}
-	managerIdxCluster := &enterpriseApi.ClusterMaster{}
-	err = client.Get(context.TODO(), namespacedName, managerIdxCluster)
+	managerIdxCluster, err := getClusterManagerCR(ctx, client, namespacedName)
 	if err == nil {
 		// when user creates both cluster manager and index cluster yaml file at the same time
 		// cluser master status is not yet set so it will be blank
@@ -144,6 +157,20 @@ func ApplyIndexerCluster(ctx context.Context, client splcommon.ControllerClient,
 		return result, err
 	}
 
+	// create or update the objects exposing Splunk Web and HEC
+	err = ApplySplunkExpose(ctx, client, cr, &cr.Spec.CommonSplunkSpec, SplunkIndexer, &cr.Status.Expose)
+	if err != nil {
+		eventPublisher.Warning(ctx, "ApplySplunkExpose", fmt.Sprintf("create/update expose objects failed %s", err.Error()))
+		return result, err
+	}
+
+	// create or update the network policies of the Splunk instances
+	err = ApplySplunkNetworkPolicies(ctx, client, cr, &cr.Spec.CommonSplunkSpec, &cr.Status.NetworkPolicies, SplunkIndexer)
+	if err != nil {
+		eventPublisher.Warning(ctx, "ApplySplunkNetworkPolicies", fmt.Sprintf("create/update network policies failed %s", err.Error()))
+		return result, err
+	}
+
 	// create or update statefulset for the indexers
 	statefulSet, err := getIndexerStatefulSet(ctx, client, cr)
 	if err != nil {
@@ -165,6 +192,16 @@ func ApplyIndexerCluster(ctx context.Context, client splcommon.ControllerClient,
 	// As a temporary fix for 9.0.0 , if the image version do not  match with pod image version we delete the
 	// splunk statefulset for indexer
 
+	// wait for the previous stages of an ordered upgrade of the stack
+	upgradeReady, err := checkOrderedUpgrade(ctx, client, cr, &cr.Spec.CommonSplunkSpec, SplunkIndexer, &cr.Status.UpgradeStage)
+	if err != nil {
+		return result, err
+	}
+	if !upgradeReady {
+		cr.Status.Phase = enterpriseApi.PhasePending
+		return result, nil
+	}
+
 	var phase enterpriseApi.Phase
 	versionUpgrade := false
 	// get all the pods in the namespace
@@ -200,6 +237,13 @@ func ApplyIndexerCluster(ctx context.Context, client splcommon.ControllerClient,
 			return result, err
 		}
 	} else {
+		// Keep the cluster in maintenance mode while all the peers are recreated
+		err = beginClusterMaintenance(ctx, client, cr, maintenanceReasonUpgrade, splutil.GetPodExecClient(client, cr, ""))
+		if err != nil {
+			eventPublisher.Warning(ctx, "SetClusterMaintenanceMode", fmt.Sprintf("set cluster maintainance mode failed %s", err.Error()))
+			return result, err
+		}
+
 		// Delete the statefulset and recreate new one
 		err = client.Delete(ctx, statefulSet)
 		if err != nil {
@@ -217,6 +261,13 @@ func ApplyIndexerCluster(ctx context.Context, client splcommon.ControllerClient,
 		}
 	}
 	cr.Status.Phase = phase
+	updateUpgradeStage(&cr.Status.UpgradeStage, SplunkIndexer, phase)
+
+	// clear the maintenance mode enabled by the operator when the operation is stuck, or when a reconcile crashed before its end
+	_, err = checkClusterMaintenanceWatchdog(ctx, client, cr, splutil.GetPodExecClient(client, cr, ""), time.Now())
+	if err != nil {
+		eventPublisher.Warning(ctx, "MaintenanceModeWatchdog", fmt.Sprintf("clear stuck maintenance mode failed %s", err.Error()))
+	}
 
 	// no need to requeue if everything is ready
 	if cr.Status.Phase == enterpriseApi.PhaseReady {
@@ -228,32 +279,27 @@ func ApplyIndexerCluster(ctx context.Context, client splcommon.ControllerClient,
 			return result, err
 		}
 		if cmMonitoringConsoleConfigRef != "" {
-			namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: GetSplunkStatefulsetName(SplunkMonitoringConsole, cmMonitoringConsoleConfigRef)}
+			mcNamespace, mcName := splitMonitoringConsoleRefKey(cr.GetNamespace(), cmMonitoringConsoleConfigRef)
+			namespacedName := types.NamespacedName{Namespace: mcNamespace, Name: GetSplunkStatefulsetName(SplunkMonitoringConsole, mcName)}
 			_, err := splctrl.GetStatefulSetByName(ctx, client, namespacedName)
 			//if MC pod already exists
 			if err == nil {
-				c := mgr.getMonitoringConsoleClient(cr, cmMonitoringConsoleConfigRef)
-				err := c.AutomateMCApplyChanges()
+				c, err := mgr.getMonitoringConsoleClient(ctx, client, cr, cmMonitoringConsoleConfigRef)
+				if err == nil {
+					err = c.AutomateMCApplyChanges()
+				}
 				if err != nil {
 					eventPublisher.Warning(ctx, "AutomateMCApplyChanges", fmt.Sprintf("get monitoring console client failed %s", err.Error()))
 					return result, err
 				}
 			}
-			if len(cr.Spec.MonitoringConsoleRef.Name) > 0 && (cr.Spec.MonitoringConsoleRef.Name != cmMonitoringConsoleConfigRef) {
+			if len(cr.Spec.MonitoringConsoleRef.Name) > 0 && (getMonitoringConsoleRefKey(cr.GetNamespace(), cr.Spec.MonitoringConsoleRef) != cmMonitoringConsoleConfigRef) {
 				scopedLog.Info("Indexer Cluster CR should not specify monitoringConsoleRef and if specified, should be similar to cluster manager spec")
 			}
 		}
-		if len(cr.Status.IndexerSecretChanged) > 0 {
-			var managerIdxcName string
-			if len(cr.Spec.ClusterMasterRef.Name) > 0 {
-				managerIdxcName = cr.Spec.ClusterMasterRef.Name
-			} else {
-				return result, errors.New("empty cluster manager reference")
-			}
-			cmPodName := fmt.Sprintf(splcommon.TestClusterManagerID, managerIdxcName, "0")
-			podExecClient := splutil.GetPodExecClient(client, cr, cmPodName)
-			// Disable maintenance mode
-			err = SetClusterMaintenanceMode(ctx, client, cr, false, cmPodName, podExecClient)
+		// Disable the maintenance mode once the disruptive operations are over
+		if len(cr.Status.IndexerSecretChanged) > 0 || cr.Status.OperatorMaintenance.Reason != "" {
+			err = endClusterMaintenance(ctx, client, cr, splutil.GetPodExecClient(client, cr, ""))
 			if err != nil {
 				eventPublisher.Warning(ctx, "SetClusterMaintenanceMode", fmt.Sprintf("set cluster maintainance mode failed %s", err.Error()))
 				return result, err
@@ -275,6 +321,31 @@ func ApplyIndexerCluster(ctx context.Context, client splcommon.ControllerClient,
 			result.Requeue = true
 			return result, err
 		}
+
+		// poll the autoscaling metrics periodically, and apply the scaling decisions right away
+		if cr.Spec.Autoscaling.Enabled {
+			scaled, err := mgr.applyAutoscaling(ctx, eventPublisher)
+			if err != nil {
+				eventPublisher.Warning(ctx, "applyAutoscaling", fmt.Sprintf("autoscaling of indexers failed %s", err.Error()))
+			}
+			result = getAutoscalingResult(result, scaled)
+		}
+
+		// rebalance the buckets once the new peers are searchable
+		if cr.Spec.Rebalance.Enabled {
+			inProgress, err := mgr.applyRebalance(ctx, eventPublisher)
+			if err != nil {
+				eventPublisher.Warning(ctx, "applyRebalance", fmt.Sprintf("rebalance of indexer cluster failed %s", err.Error()))
+			}
+			if inProgress || err != nil {
+				result = requeueNoLaterThan(result, rebalancePollInterval)
+			}
+		} else {
+			err = mgr.stopRebalance(ctx)
+			if err != nil {
+				eventPublisher.Warning(ctx, "stopRebalance", fmt.Sprintf("stop rebalance of indexer cluster failed %s", err.Error()))
+			}
+		}
 	}
 	// RequeueAfter if greater than 0, tells the Controller to requeue the reconcile key after the Duration.
 	// Implies that Requeue is true, there is no need to set Requeue to true at the same time as RequeueAfter.
@@ -309,9 +380,21 @@ var newIndexerClusterPodManager = func(log logr.Logger, cr *enterpriseApi.Indexe
 }
 
 //getMonitoringConsoleClient for indexerClusterPodManager returns a SplunkClient for monitoring console
-func (mgr *indexerClusterPodManager) getMonitoringConsoleClient(cr *enterpriseApi.IndexerCluster, cmMonitoringConsoleConfigRef string) *splclient.SplunkClient {
-	fqdnName := splcommon.GetServiceFQDN(cr.GetNamespace(), GetSplunkServiceName(SplunkMonitoringConsole, cmMonitoringConsoleConfigRef, false))
-	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(mgr.secrets.Data["password"]))
+func (mgr *indexerClusterPodManager) getMonitoringConsoleClient(ctx context.Context, c splcommon.ControllerClient, cr *enterpriseApi.IndexerCluster, cmMonitoringConsoleConfigRef string) (*splclient.SplunkClient, error) {
+	mcNamespace, mcName := splitMonitoringConsoleRefKey(cr.GetNamespace(), cmMonitoringConsoleConfigRef)
+	secrets := mgr.secrets
+
+	// monitoring console in another namespace uses the namespace scoped secret of its own namespace
+	if mcNamespace != cr.GetNamespace() {
+		var err error
+		secrets, err = splutil.GetNamespaceScopedSecret(ctx, c, mcNamespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fqdnName := splcommon.GetServiceFQDN(mcNamespace, GetSplunkServiceName(SplunkMonitoringConsole, mcName, false))
+	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(secrets.Data["password"])).WithContext(ctx), nil
 }
 
 // SetClusterMaintenanceMode enables/disables cluster maintenance mode
@@ -347,6 +430,9 @@ func SetClusterMaintenanceMode(ctx context.Context, c splcommon.ControllerClient
 
 // ApplyIdxcSecret checks if any of the indexer's have a different idxc_secret from namespace scoped secret and changes it
 func ApplyIdxcSecret(ctx context.Context, mgr *indexerClusterPodManager, replicas int32, podExecClient splutil.PodExecClientImpl) error {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplyIdxcSecret", mgr.cr)
+	defer span.End()
+
 	var indIdxcSecret string
 	// Get namespace scoped secret
 	namespaceSecret, err := splutil.ApplyNamespaceScopedSecretObject(ctx, mgr.c, mgr.cr.GetNamespace())
@@ -395,17 +481,9 @@ func ApplyIdxcSecret(ctx context.Context, mgr *indexerClusterPodManager, replica
 		if indIdxcSecret != nsIdxcSecret {
 			scopedLog.Info("idxc Secret different from namespace scoped secret")
 
-			// Enable maintenance mode
+			// Enable maintenance mode until all the peers are restarted
 			if len(mgr.cr.Status.IndexerSecretChanged) == 0 && !mgr.cr.Status.MaintenanceMode {
-				var managerIdxcName string
-				if len(mgr.cr.Spec.ClusterMasterRef.Name) > 0 {
-					managerIdxcName = mgr.cr.Spec.ClusterMasterRef.Name
-				} else {
-					return errors.New("empty cluster manager reference")
-				}
-				cmPodName := fmt.Sprintf(splcommon.TestClusterManagerID, managerIdxcName, "0")
-				podExecClient.SetTargetPodName(ctx, cmPodName)
-				err = SetClusterMaintenanceMode(ctx, mgr.c, mgr.cr, true, cmPodName, podExecClient)
+				err = beginClusterMaintenance(ctx, mgr.c, mgr.cr, maintenanceReasonSecretRotation, podExecClient)
 				if err != nil {
 					return err
 				}
@@ -609,7 +687,7 @@ func (mgr *indexerClusterPodManager) getClient(ctx context.Context, n int32) *sp
 		scopedLog.Error(err, "Couldn't retrieve the admin password from pod")
 	}
 
-	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", adminPwd)
+	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", adminPwd).WithContext(ctx)
 }
 
 // getClusterManagerClient for indexerClusterPodManager returns a SplunkClient for cluster manager
@@ -635,7 +713,7 @@ func (mgr *indexerClusterPodManager) getClusterManagerClient(ctx context.Context
 		scopedLog.Error(err, "Couldn't retrieve the admin password from pod")
 	}
 
-	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", adminPwd)
+	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", adminPwd).WithContext(ctx)
 }
 
 // getSiteRepFactorOriginCount gets the origin count of the site_replication_factor
@@ -646,23 +724,31 @@ func getSiteRepFactorOriginCount(siteRepFactor string) int32 {
 	return int32(siteRF)
 }
 
+// getReplicationFactor for indexerClusterPodManager returns the minimum number of peers required by the replication factor
+// of the cluster manager, the origin count of the site_replication_factor for multisite indexer clusters
+func (mgr *indexerClusterPodManager) getReplicationFactor(ctx context.Context) (int32, error) {
+	cm := mgr.getClusterManagerClient(ctx)
+	clusterInfo, err := cm.GetClusterInfo(false)
+	if err != nil {
+		return 0, fmt.Errorf("could not get cluster info from cluster manager")
+	}
+	// if it is a multisite indexer cluster, check site_replication_factor
+	if clusterInfo.MultiSite == "true" {
+		return getSiteRepFactorOriginCount(clusterInfo.SiteReplicationFactor), nil
+	}
+	// for single site, check replication factor
+	return clusterInfo.ReplicationFactor, nil
+}
+
 // verifyRFPeers verifies the number of peers specified in the replicas section
 // of IndexerClsuster CR. If it is less than RF, than we set it to RF.
 func (mgr *indexerClusterPodManager) verifyRFPeers(ctx context.Context, c splcommon.ControllerClient) error {
 	if mgr.c == nil {
 		mgr.c = c
 	}
-	cm := mgr.getClusterManagerClient(ctx)
-	clusterInfo, err := cm.GetClusterInfo(false)
+	replicationFactor, err := mgr.getReplicationFactor(ctx)
 	if err != nil {
-		return fmt.Errorf("could not get cluster info from cluster manager")
-	}
-	var replicationFactor int32
-	// if it is a multisite indexer cluster, check site_replication_factor
-	if clusterInfo.MultiSite == "true" {
-		replicationFactor = getSiteRepFactorOriginCount(clusterInfo.SiteReplicationFactor)
-	} else { // for single site, check replication factor
-		replicationFactor = clusterInfo.ReplicationFactor
+		return err
 	}
 
 	if mgr.cr.Spec.Replicas < replicationFactor {
@@ -681,6 +767,7 @@ func (mgr *indexerClusterPodManager) updateStatus(ctx context.Context, statefulS
 		mgr.cr.Status.IndexingReady = false
 		mgr.cr.Status.ServiceReady = false
 		mgr.cr.Status.MaintenanceMode = false
+		mgr.cr.Status.RollingRestart = false
 		return fmt.Errorf("Waiting for cluster manager to become ready")
 	}
 
@@ -694,6 +781,7 @@ func (mgr *indexerClusterPodManager) updateStatus(ctx context.Context, statefulS
 	mgr.cr.Status.IndexingReady = clusterInfo.IndexingReady
 	mgr.cr.Status.ServiceReady = clusterInfo.ServiceReady
 	mgr.cr.Status.MaintenanceMode = clusterInfo.MaintenanceMode
+	mgr.cr.Status.RollingRestart = clusterInfo.RollingRestart
 
 	// get peer information from cluster manager
 	peers, err := c.GetClusterManagerPeers()
@@ -755,6 +843,11 @@ func validateIndexerClusterSpec(ctx context.Context, c splcommon.ControllerClien
 	if len(cr.Spec.ClusterMasterRef.Namespace) > 0 && cr.Spec.ClusterMasterRef.Namespace != cr.GetNamespace() {
 		return fmt.Errorf("multisite cluster does not support cluster manager to be located in a different namespace")
 	}
+
+	err := validateIndexerClusterAutoscalingSpec(&cr.Spec.Autoscaling)
+	if err != nil {
+		return err
+	}
 	return validateCommonSplunkSpec(ctx, c, &cr.Spec.CommonSplunkSpec, cr)
 }
 
@@ -780,12 +873,11 @@ func getIndexerClusterList(ctx context.Context, c splcommon.ControllerClient, cr
 func RetrieveCMSpec(ctx context.Context, client splcommon.ControllerClient, cr *enterpriseApi.IndexerCluster, clusterMasterRef string) (string, error) {
 
 	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: clusterMasterRef}
-	var cmCR enterpriseApi.ClusterMaster
 	var monitoringConsoleRef string = ""
 
-	err := client.Get(ctx, namespacedName, &cmCR)
+	cmCR, err := getClusterManagerCR(ctx, client, namespacedName)
 	if err == nil {
-		monitoringConsoleRef = cmCR.Spec.MonitoringConsoleRef.Name
+		monitoringConsoleRef = getMonitoringConsoleRefKey(cmCR.GetNamespace(), cmCR.Spec.MonitoringConsoleRef)
 		return monitoringConsoleRef, err
 	}
 	return "", err
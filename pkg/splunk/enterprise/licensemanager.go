@@ -0,0 +1,72 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+)
+
+// ApplyV4LicenseManager reconciles the state of a LicenseManager custom resource, like a LicenseMaster custom resource.
+// A LicenseManager with the name of a LicenseMaster in the same namespace is rejected
+func ApplyV4LicenseManager(ctx context.Context, client splcommon.ControllerClient, cr *enterpriseApiV4.LicenseManager) (reconcile.Result, error) {
+	crCopy, err := newV4ManagerCopy(cr)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	err = checkV4ManagerName(ctx, client, crCopy)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	return ApplyLicenseManager(ctx, &v4ManagerClient{ControllerClient: client}, crCopy.(*enterpriseApi.LicenseMaster))
+}
+
+// getV4LicenseManager returns the LicenseMaster copy of a LicenseManager custom resource
+func getV4LicenseManager(ctx context.Context, client splcommon.ControllerClient, namespacedName types.NamespacedName) (*enterpriseApi.LicenseMaster, error) {
+	cr := &enterpriseApiV4.LicenseManager{}
+	err := client.Get(ctx, namespacedName, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	crCopy, err := newV4ManagerCopy(cr)
+	if err != nil {
+		return nil, err
+	}
+	return crCopy.(*enterpriseApi.LicenseMaster), nil
+}
+
+// getLicenseManagerCR returns the license manager referred by licenseMasterRef or licenseManagerRef, which is either a
+// LicenseMaster or a LicenseManager custom resource. A LicenseManager is returned as a LicenseMaster copy
+func getLicenseManagerCR(ctx context.Context, client splcommon.ControllerClient, namespacedName types.NamespacedName) (*enterpriseApi.LicenseMaster, error) {
+	cr := &enterpriseApi.LicenseMaster{}
+	err := client.Get(ctx, namespacedName, cr)
+	if !k8serrors.IsNotFound(err) {
+		return cr, err
+	}
+
+	crCopy, v4Err := getV4LicenseManager(ctx, client, namespacedName)
+	if v4Err != nil {
+		return cr, err
+	}
+	return crCopy, nil
+}
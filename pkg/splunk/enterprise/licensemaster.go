@@ -17,24 +17,34 @@ package enterprise
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"reflect"
+	"sort"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
 	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
+	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
 )
 
 // ApplyLicenseManager reconciles the state for the Splunk Enterprise license manager.
 func ApplyLicenseManager(ctx context.Context, client splcommon.ControllerClient, cr *enterpriseApi.LicenseMaster) (reconcile.Result, error) {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplyLicenseManager", cr)
+	defer span.End()
 
 	// unless modified, reconcile for this object will be requeued after 5 seconds
 	result := reconcile.Result{
@@ -76,8 +86,13 @@ func ApplyLicenseManager(ctx context.Context, client splcommon.ControllerClient,
 	// Update the CR Status
 	defer updateCRStatus(ctx, client, cr)
 
+	// detect the drift of the objects applied below, and report it in the status before it is updated
+	driftRecorder := splctrl.NewDriftRecorder(cr.Spec.DriftPolicy)
+	ctx = splctrl.WithDriftRecorder(ctx, driftRecorder)
+	defer reportDrift(ctx, eventPublisher, driftRecorder, cr.GetGeneration(), &cr.Status.Conditions)
+
 	// create or update general config resources
-	_, err = ApplySplunkConfig(ctx, client, cr, cr.Spec.CommonSplunkSpec, SplunkLicenseManager)
+	namespaceScopedSecret, err := ApplySplunkConfig(ctx, client, cr, cr.Spec.CommonSplunkSpec, SplunkLicenseManager)
 	if err != nil {
 		scopedLog.Error(err, "create or update general config failed", "error", err.Error())
 		eventPublisher.Warning(ctx, "ApplySplunkConfig", fmt.Sprintf("create or update general config failed with error %s", err.Error()))
@@ -87,7 +102,7 @@ func ApplyLicenseManager(ctx context.Context, client splcommon.ControllerClient,
 	// check if deletion has been requested
 	if cr.ObjectMeta.DeletionTimestamp != nil {
 		if cr.Spec.MonitoringConsoleRef.Name != "" {
-			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), cr.Spec.MonitoringConsoleRef.Name, getLicenseManagerURL(ctx, cr, &cr.Spec.CommonSplunkSpec), false)
+			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), getMonitoringConsoleRefKey(cr.GetNamespace(), cr.Spec.MonitoringConsoleRef), getLicenseManagerURL(ctx, cr, &cr.Spec.CommonSplunkSpec), false)
 			if err != nil {
 				return result, err
 			}
@@ -103,6 +118,7 @@ func ApplyLicenseManager(ctx context.Context, client splcommon.ControllerClient,
 		}
 
 		DeleteOwnerReferencesForResources(ctx, client, cr, nil)
+		deleteLicenseMetrics(cr)
 		terminating, err := splctrl.CheckForDeletion(ctx, cr, client)
 
 		if terminating && err != nil { // don't bother if no error, since it will just be removed immmediately after
@@ -122,6 +138,20 @@ func ApplyLicenseManager(ctx context.Context, client splcommon.ControllerClient,
 		return result, err
 	}
 
+	// create or update the objects exposing Splunk Web and HEC
+	err = ApplySplunkExpose(ctx, client, cr, &cr.Spec.CommonSplunkSpec, SplunkLicenseManager, &cr.Status.Expose)
+	if err != nil {
+		eventPublisher.Warning(ctx, "ApplySplunkExpose", fmt.Sprintf("create/update expose objects failed %s", err.Error()))
+		return result, err
+	}
+
+	// create or update the network policies of the Splunk instances
+	err = ApplySplunkNetworkPolicies(ctx, client, cr, &cr.Spec.CommonSplunkSpec, &cr.Status.NetworkPolicies, SplunkLicenseManager)
+	if err != nil {
+		eventPublisher.Warning(ctx, "ApplySplunkNetworkPolicies", fmt.Sprintf("create/update network policies failed %s", err.Error()))
+		return result, err
+	}
+
 	// create or update statefulset
 	statefulSet, err := getLicenseManagerStatefulSet(ctx, client, cr)
 	if err != nil {
@@ -134,12 +164,23 @@ func ApplyLicenseManager(ctx context.Context, client splcommon.ControllerClient,
 		return result, err
 	}
 
+	// wait for the previous stages of an ordered upgrade of the stack
+	upgradeReady, err := checkOrderedUpgrade(ctx, client, cr, &cr.Spec.CommonSplunkSpec, SplunkLicenseManager, &cr.Status.UpgradeStage)
+	if err != nil {
+		return result, err
+	}
+	if !upgradeReady {
+		cr.Status.Phase = enterpriseApi.PhasePending
+		return result, nil
+	}
+
 	mgr := splctrl.DefaultStatefulSetPodManager{}
 	phase, err := mgr.Update(ctx, client, statefulSet, 1)
 	if err != nil {
 		return result, err
 	}
 	cr.Status.Phase = phase
+	updateUpgradeStage(&cr.Status.UpgradeStage, SplunkLicenseManager, phase)
 
 	// no need to requeue if everything is ready
 	if cr.Status.Phase == enterpriseApi.PhaseReady {
@@ -150,14 +191,42 @@ func ApplyLicenseManager(ctx context.Context, client splcommon.ControllerClient,
 			scopedLog.Error(err, "Error in deleting automated monitoring console resource")
 		}
 		if cr.Spec.MonitoringConsoleRef.Name != "" {
-			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), cr.Spec.MonitoringConsoleRef.Name, getLicenseManagerURL(ctx, cr, &cr.Spec.CommonSplunkSpec), true)
+			err = addMonitoringConsoleRefFinalizer(ctx, client, cr, cr.Spec.MonitoringConsoleRef)
+			if err != nil {
+				return result, err
+			}
+			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), getMonitoringConsoleRefKey(cr.GetNamespace(), cr.Spec.MonitoringConsoleRef), getLicenseManagerURL(ctx, cr, &cr.Spec.CommonSplunkSpec), true)
 			if err != nil {
 				return result, err
 			}
 		}
 
+		c := getLicenseManagerClient(cr, namespaceScopedSecret, splclient.NewSplunkClient).WithContext(ctx)
+
+		// install the licenses from the license secrets, failures shouldn't block the reconcile
+		if len(cr.Spec.LicenseSecrets) > 0 || len(cr.Status.InstalledLicenses) > 0 {
+			err = applyLicenseSecrets(ctx, client, cr, c)
+			if err != nil {
+				eventPublisher.Warning(ctx, "applyLicenseSecrets", fmt.Sprintf("install licenses from the license secrets failed %s", err.Error()))
+			}
+		}
+
+		// report the license usage and expiry, failures shouldn't block the reconcile
+		if time.Now().Unix()-cr.Status.License.LastCheckTime >= int64(licenseStatusCheckInterval.Seconds()) {
+			err = updateLicenseStatus(ctx, cr, c, eventPublisher)
+			if err != nil {
+				scopedLog.Error(err, "Unable to update the license status")
+			}
+		}
+
 		finalResult := handleAppFrameworkActivity(ctx, client, cr, &cr.Status.AppContext, &cr.Spec.AppFrameworkConfig)
 		result = *finalResult
+
+		// requeue to refresh the license status
+		if !result.Requeue || result.RequeueAfter > licenseStatusCheckInterval {
+			result.Requeue = true
+			result.RequeueAfter = licenseStatusCheckInterval
+		}
 	}
 	// RequeueAfter if greater than 0, tells the Controller to requeue the reconcile key after the Duration.
 	// Implies that Requeue is true, there is no need to set Requeue to true at the same time as RequeueAfter.
@@ -210,3 +279,291 @@ func getLicenseMasterList(ctx context.Context, c splcommon.ControllerClient, cr
 
 	return numOfObjects, nil
 }
+
+// getLicenseManagerClient returns a SplunkClient for the license manager
+func getLicenseManagerClient(cr *enterpriseApi.LicenseMaster, secret *corev1.Secret, newSplunkClient NewSplunkClientFunc) *splclient.SplunkClient {
+	fqdnName := splcommon.GetServiceFQDN(cr.GetNamespace(), GetSplunkServiceName(SplunkLicenseManager, cr.GetName(), false))
+	return newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(secret.Data["password"]))
+}
+
+// getLicenseBytes converts the license quota and usage reported by the license manager to bytes.
+// Unlimited quota is reported beyond the int64 range, so it is capped
+func getLicenseBytes(value float64) int64 {
+	if value >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(value)
+}
+
+// getLicenseStatus queries the license manager for the license expiry, quota, usage and violations
+func getLicenseStatus(c *splclient.SplunkClient) (*enterpriseApi.LicenseStatus, error) {
+	licenses, err := c.GetLicenses()
+	if err != nil {
+		return nil, err
+	}
+
+	pools, err := c.GetLicensePools()
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := c.GetLicenseUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := c.GetLicenseMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	status := enterpriseApi.LicenseStatus{
+		Quota:         getLicenseBytes(usage.Quota),
+		UsedBytes:     getLicenseBytes(usage.PeersUsageBytes),
+		LastCheckTime: time.Now().Unix(),
+	}
+
+	for _, license := range licenses {
+		if license.Status != "VALID" || license.ExpirationTime <= 0 {
+			continue
+		}
+		if status.ExpirationTime == 0 || license.ExpirationTime < status.ExpirationTime {
+			status.ExpirationTime = license.ExpirationTime
+		}
+	}
+
+	for _, pool := range pools {
+		status.Pools = append(status.Pools, enterpriseApi.LicensePoolUsage{
+			Name:      pool.Name,
+			Quota:     getLicenseBytes(pool.EffectiveQuota),
+			UsedBytes: getLicenseBytes(pool.UsedBytes),
+		})
+	}
+
+	for _, message := range messages {
+		if licenseViolationCategories[message.Category] {
+			status.ViolationCount++
+		}
+	}
+
+	return &status, nil
+}
+
+// getLicenseUsagePercent returns the usage of a license quota in percent
+func getLicenseUsagePercent(usedBytes, quota int64) float64 {
+	if quota <= 0 {
+		return 0
+	}
+	return float64(usedBytes) * 100 / float64(quota)
+}
+
+// getLicenseUsageOverThreshold returns the pools with the license usage over the threshold
+func getLicenseUsageOverThreshold(status *enterpriseApi.LicenseStatus, thresholdPercent int32) map[string]bool {
+	pools := make(map[string]bool)
+	for _, pool := range status.Pools {
+		if getLicenseUsagePercent(pool.UsedBytes, pool.Quota) >= float64(thresholdPercent) {
+			pools[pool.Name] = true
+		}
+	}
+	return pools
+}
+
+// setLicenseWarningGauge sets the license warning gauge to 1 when the warning is raised, and 0 otherwise
+func setLicenseWarningGauge(cr *enterpriseApi.LicenseMaster, warning string, raised bool) {
+	var value float64
+	if raised {
+		value = 1
+	}
+	licenseWarningGauge.WithLabelValues(cr.GetNamespace(), cr.GetName(), warning).Set(value)
+}
+
+// getLicenseDaysToExpiry returns the number of days until the earliest license expiry
+func getLicenseDaysToExpiry(status *enterpriseApi.LicenseStatus, now time.Time) float64 {
+	return time.Unix(status.ExpirationTime, 0).Sub(now).Hours() / 24
+}
+
+// isLicenseExpiring checks if the earliest license expires within the warning days
+func isLicenseExpiring(status *enterpriseApi.LicenseStatus, warningDays int32, now time.Time) bool {
+	return status.ExpirationTime > 0 && getLicenseDaysToExpiry(status, now) <= float64(warningDays)
+}
+
+// updateLicenseStatus refreshes the license status of the license manager, updates the license metrics and raises
+// warning events when the license usage crosses the threshold, a license is about to expire or new violations are reported
+func updateLicenseStatus(ctx context.Context, cr *enterpriseApi.LicenseMaster, c *splclient.SplunkClient, eventPublisher *K8EventPublisher) error {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("updateLicenseStatus").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
+
+	status, err := getLicenseStatus(c)
+	if err != nil {
+		return err
+	}
+
+	usageWarningPercent := cr.Spec.LicenseReporting.UsageWarningPercent
+	if usageWarningPercent <= 0 {
+		usageWarningPercent = defaultLicenseUsageWarningPercent
+	}
+	expiryWarningDays := cr.Spec.LicenseReporting.ExpiryWarningDays
+	if expiryWarningDays <= 0 {
+		expiryWarningDays = defaultLicenseExpiryWarningDays
+	}
+
+	now := time.Now()
+	prevStatus := cr.Status.License
+
+	// warn only when the usage crosses the threshold, not on every reconcile
+	prevPoolsOverThreshold := getLicenseUsageOverThreshold(&prevStatus, usageWarningPercent)
+	poolsOverThreshold := getLicenseUsageOverThreshold(status, usageWarningPercent)
+	for pool := range poolsOverThreshold {
+		if !prevPoolsOverThreshold[pool] {
+			scopedLog.Info("License usage crossed the threshold", "pool", pool, "threshold", usageWarningPercent)
+			eventPublisher.Warning(ctx, "LicenseUsage", fmt.Sprintf("license usage of pool %s crossed %d%% of the quota", pool, usageWarningPercent))
+		}
+	}
+
+	if isLicenseExpiring(status, expiryWarningDays, now) && !isLicenseExpiring(&prevStatus, expiryWarningDays, now) {
+		expirationTime := time.Unix(status.ExpirationTime, 0).UTC().Format(time.RFC3339)
+		scopedLog.Info("License is about to expire", "expirationTime", expirationTime)
+		eventPublisher.Warning(ctx, "LicenseExpiry", fmt.Sprintf("license expires on %s, within %d days", expirationTime, expiryWarningDays))
+	}
+
+	if status.ViolationCount > prevStatus.ViolationCount {
+		eventPublisher.Warning(ctx, "LicenseViolation", fmt.Sprintf("license manager reported %d license violations", status.ViolationCount))
+	}
+
+	// pools removed from the license manager are no longer reported
+	for _, pool := range prevStatus.Pools {
+		licenseUsagePercentGauge.DeleteLabelValues(cr.GetNamespace(), cr.GetName(), pool.Name)
+	}
+	for _, pool := range status.Pools {
+		licenseUsagePercentGauge.WithLabelValues(cr.GetNamespace(), cr.GetName(), pool.Name).Set(getLicenseUsagePercent(pool.UsedBytes, pool.Quota))
+	}
+
+	if status.ExpirationTime > 0 {
+		licenseDaysToExpiryGauge.WithLabelValues(cr.GetNamespace(), cr.GetName()).Set(getLicenseDaysToExpiry(status, now))
+	}
+
+	setLicenseWarningGauge(cr, "usage", len(poolsOverThreshold) > 0)
+	setLicenseWarningGauge(cr, "expiry", isLicenseExpiring(status, expiryWarningDays, now))
+	setLicenseWarningGauge(cr, "violation", status.ViolationCount > 0)
+
+	cr.Status.License = *status
+	return nil
+}
+
+// deleteLicenseMetrics removes the license metrics of a deleted license manager
+func deleteLicenseMetrics(cr *enterpriseApi.LicenseMaster) {
+	for _, pool := range cr.Status.License.Pools {
+		licenseUsagePercentGauge.DeleteLabelValues(cr.GetNamespace(), cr.GetName(), pool.Name)
+	}
+	licenseDaysToExpiryGauge.DeleteLabelValues(cr.GetNamespace(), cr.GetName())
+	for _, warning := range []string{"usage", "expiry", "violation"} {
+		licenseWarningGauge.DeleteLabelValues(cr.GetNamespace(), cr.GetName(), warning)
+	}
+}
+
+// getLicenseSecretFiles returns the license files from the license secrets, with their installed license status.
+// Licenses of the deleted secrets are left out, so that they are removed from the license manager
+func getLicenseSecretFiles(ctx context.Context, client splcommon.ControllerClient, cr *enterpriseApi.LicenseMaster) ([]enterpriseApi.InstalledLicense, map[string][]byte, error) {
+	var licenses []enterpriseApi.InstalledLicense
+	payloads := make(map[string][]byte)
+	for _, licenseSecret := range cr.Spec.LicenseSecrets {
+		secret, err := splutil.GetSecretByName(ctx, client, cr.GetNamespace(), cr.GetName(), licenseSecret.Name)
+		if k8serrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		var keys []string
+		for key := range secret.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			hash := sha256.Sum256(secret.Data[key])
+			license := enterpriseApi.InstalledLicense{
+				SecretName: licenseSecret.Name,
+				Key:        key,
+				Hash:       hex.EncodeToString(hash[:]),
+			}
+			licenses = append(licenses, license)
+			payloads[getInstalledLicenseID(&license)] = secret.Data[key]
+		}
+	}
+	return licenses, payloads, nil
+}
+
+// getInstalledLicenseID returns the secret and key of a license file
+func getInstalledLicenseID(license *enterpriseApi.InstalledLicense) string {
+	return license.SecretName + "/" + license.Key
+}
+
+// applyLicenseSecrets installs the new or changed licenses from the license secrets through the licenser REST API,
+// and removes the licenses whose secret or key is deleted
+func applyLicenseSecrets(ctx context.Context, client splcommon.ControllerClient, cr *enterpriseApi.LicenseMaster, c *splclient.SplunkClient) error {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("applyLicenseSecrets").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
+
+	licenses, payloads, err := getLicenseSecretFiles(ctx, client, cr)
+	if err != nil {
+		return err
+	}
+
+	installedLicenses := make(map[string]enterpriseApi.InstalledLicense)
+	for _, license := range cr.Status.InstalledLicenses {
+		installedLicenses[getInstalledLicenseID(&license)] = license
+	}
+
+	// failed installs keep the previously installed license, and are retried on the next reconcile
+	var newInstalledLicenses []enterpriseApi.InstalledLicense
+	var installErr error
+	for _, license := range licenses {
+		id := getInstalledLicenseID(&license)
+		installedLicense, ok := installedLicenses[id]
+		if ok && installedLicense.Hash == license.Hash {
+			newInstalledLicenses = append(newInstalledLicenses, installedLicense)
+			continue
+		}
+
+		scopedLog.Info("Installing license", "license", id, "hash", license.Hash)
+		license.LicenseName, err = c.InstallLicense(string(payloads[id]))
+		if err != nil {
+			scopedLog.Error(err, "Unable to install license", "license", id)
+			installErr = fmt.Errorf("unable to install license %s: %v", id, err)
+			if ok {
+				newInstalledLicenses = append(newInstalledLicenses, installedLicense)
+			}
+			continue
+		}
+		newInstalledLicenses = append(newInstalledLicenses, license)
+	}
+
+	// remove the licenses which are changed or deleted, unless another license file still refers to them
+	licenseNames := make(map[string]bool)
+	for _, license := range newInstalledLicenses {
+		licenseNames[license.LicenseName] = true
+	}
+	for _, license := range cr.Status.InstalledLicenses {
+		if licenseNames[license.LicenseName] {
+			continue
+		}
+
+		scopedLog.Info("Removing license", "license", getInstalledLicenseID(&license), "licenseName", license.LicenseName)
+		err = c.RemoveLicense(license.LicenseName)
+		if err != nil {
+			scopedLog.Error(err, "Unable to remove license", "license", getInstalledLicenseID(&license))
+			installErr = fmt.Errorf("unable to remove license %s: %v", getInstalledLicenseID(&license), err)
+			newInstalledLicenses = append(newInstalledLicenses, license)
+			licenseNames[license.LicenseName] = true
+		}
+	}
+
+	// refresh the license status on the license changes
+	if !reflect.DeepEqual(newInstalledLicenses, cr.Status.InstalledLicenses) {
+		cr.Status.License.LastCheckTime = 0
+	}
+	cr.Status.InstalledLicenses = newInstalledLicenses
+
+	return installErr
+}
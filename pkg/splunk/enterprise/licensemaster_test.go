@@ -17,7 +17,11 @@ package enterprise
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -68,9 +72,9 @@ func TestApplyLicenseManager(t *testing.T) {
 	listmockCall := []spltest.MockFuncCall{
 		{ListOpts: listOpts}}
 
-	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": {funcCalls[0], funcCalls[3], funcCalls[6], funcCalls[8]}, "Update": {funcCalls[0]}, "List": {listmockCall[0]}}
-	updateFuncCalls := []spltest.MockFuncCall{funcCalls[0], funcCalls[1], funcCalls[3], funcCalls[4], funcCalls[5], funcCalls[6], funcCalls[7], funcCalls[8], funcCalls[8], funcCalls[9], funcCalls[10]}
-	updateCalls := map[string][]spltest.MockFuncCall{"Get": updateFuncCalls, "Update": {funcCalls[4]}, "List": {listmockCall[0]}}
+	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": {funcCalls[0], funcCalls[6]}, "Patch": {funcCalls[3], funcCalls[8]}, "Update": {funcCalls[0]}, "List": {listmockCall[0]}}
+	updateFuncCalls := []spltest.MockFuncCall{funcCalls[0], funcCalls[1], funcCalls[3], funcCalls[4], funcCalls[5], funcCalls[6], funcCalls[7], funcCalls[8], funcCalls[9], funcCalls[10]}
+	updateCalls := map[string][]spltest.MockFuncCall{"Get": updateFuncCalls, "Patch": {funcCalls[4]}, "List": {listmockCall[0]}}
 	current := enterpriseApi.LicenseMaster{
 		TypeMeta: metav1.TypeMeta{
 			Kind: "LicenseMaster",
@@ -761,7 +765,7 @@ func TestLicenseMasterWithReadyState(t *testing.T) {
 	}
 
 	builder := fake.NewClientBuilder()
-	c := builder.Build()
+	c := spltest.NewFakeApplyClient(builder.Build())
 	utilruntime.Must(enterpriseApi.AddToScheme(clientgoscheme.Scheme))
 	ctx := context.TODO()
 
@@ -1189,3 +1193,211 @@ func TestLicenseMasterWithReadyState(t *testing.T) {
 		debug.PrintStack()
 	}
 }
+
+func TestUpdateLicenseStatus(t *testing.T) {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+	cr := enterpriseApi.LicenseMaster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "LicenseMaster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	eventPublisher, _ := newK8EventPublisher(c, &cr)
+
+	secret := corev1.Secret{Data: map[string][]byte{"password": []byte("p@ssw0rd")}}
+	newSplunkClient := func(managementURI, username, password string) *splclient.SplunkClient {
+		if managementURI != "https://splunk-stack1-license-master-service.test.svc.cluster.local:8089" || password != "p@ssw0rd" {
+			t.Errorf("unexpected license manager client %s", managementURI)
+		}
+		return splclient.NewSplunkClient("https://localhost:8089", username, password)
+	}
+	splunkClient := getLicenseManagerClient(&cr, &secret, newSplunkClient)
+
+	// license expiring in 10 days, with the pool usage at 90%
+	expirationTime := time.Now().Add(10 * 24 * time.Hour).Unix()
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	wantRequest, _ := http.NewRequest("GET", splcommon.LocalURLLicenserGetLicenses, nil)
+	mockSplunkClient.AddHandler(wantRequest, 200, fmt.Sprintf(`{"entry":[{"name":"AAAA","content":{"status":"VALID","expiration_time":%d,"quota":1000}},{"name":"BBBB","content":{"status":"EXPIRED","expiration_time":1000,"quota":1000}}]}`, expirationTime), nil)
+	wantRequest, _ = http.NewRequest("GET", splcommon.LocalURLLicenserGetPools, nil)
+	mockSplunkClient.AddHandler(wantRequest, 200, `{"entry":[{"name":"pool1","content":{"effective_quota":1000,"used_bytes":900}}]}`, nil)
+	wantRequest, _ = http.NewRequest("GET", splcommon.LocalURLLicenserGetUsage, nil)
+	mockSplunkClient.AddHandler(wantRequest, 200, `{"entry":[{"name":"license_usage","content":{"quota":1000,"slaves_usage_bytes":900}}]}`, nil)
+	wantRequest, _ = http.NewRequest("GET", splcommon.LocalURLLicenserGetMessages, nil)
+	mockSplunkClient.AddHandler(wantRequest, 200, `{"entry":[{"content":{"category":"pool_over_quota","severity":"WARN"}},{"content":{"category":"orphan_peer","severity":"WARN"}}]}`, nil)
+	splunkClient.Client = mockSplunkClient
+
+	err := updateLicenseStatus(ctx, &cr, splunkClient, eventPublisher)
+	if err != nil {
+		t.Fatalf("updateLicenseStatus should not return an error, error: %v", err)
+	}
+
+	status := cr.Status.License
+	if status.ExpirationTime != expirationTime || status.Quota != 1000 || status.UsedBytes != 900 || status.ViolationCount != 1 || status.LastCheckTime == 0 {
+		t.Errorf("unexpected license status %+v", status)
+	}
+
+	if len(status.Pools) != 1 || status.Pools[0].Name != "pool1" || status.Pools[0].UsedBytes != 900 {
+		t.Errorf("unexpected license pools %+v", status.Pools)
+	}
+
+	if len(getLicenseUsageOverThreshold(&status, defaultLicenseUsageWarningPercent)) != 1 {
+		t.Errorf("pool usage should be over the default threshold")
+	}
+
+	if len(getLicenseUsageOverThreshold(&status, 95)) != 0 {
+		t.Errorf("pool usage should not be over the threshold")
+	}
+
+	if !isLicenseExpiring(&status, defaultLicenseExpiryWarningDays, time.Now()) || isLicenseExpiring(&status, 5, time.Now()) {
+		t.Errorf("unexpected license expiry check")
+	}
+
+	// license status is not changed on failure
+	mockSplunkClient = &spltest.MockHTTPClient{}
+	wantRequest, _ = http.NewRequest("GET", splcommon.LocalURLLicenserGetLicenses, nil)
+	mockSplunkClient.AddHandler(wantRequest, 500, "", nil)
+	splunkClient.Client = mockSplunkClient
+	err = updateLicenseStatus(ctx, &cr, splunkClient, eventPublisher)
+	if err == nil || cr.Status.License.Quota != 1000 {
+		t.Errorf("updateLicenseStatus should return an error without changing the status")
+	}
+
+	deleteLicenseMetrics(&cr)
+}
+
+func TestGetLicenseBytes(t *testing.T) {
+	if getLicenseBytes(1024) != 1024 {
+		t.Errorf("unexpected license bytes")
+	}
+
+	if getLicenseBytes(18446744073709551615) != math.MaxInt64 {
+		t.Errorf("unlimited quota should be capped")
+	}
+}
+
+func TestApplyLicenseSecrets(t *testing.T) {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+	cr := enterpriseApi.LicenseMaster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "LicenseMaster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Spec: enterpriseApi.LicenseMasterSpec{
+			LicenseSecrets: []corev1.LocalObjectReference{{Name: "licenses"}},
+		},
+	}
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "licenses",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			"enterprise.lic": []byte("<license>enterprise</license>"),
+			"itsi.lic":       []byte("<license>itsi</license>"),
+		},
+	}
+	err := c.Create(ctx, &secret)
+	if err != nil {
+		t.Fatalf("unable to create the license secret, error: %v", err)
+	}
+
+	// license secrets are installed through the REST API, and not mounted on the license manager
+	_, err = splutil.ApplyNamespaceScopedSecretObject(ctx, c, "test")
+	if err != nil {
+		t.Errorf("Failed to create namespace scoped object")
+	}
+	ss, err := getLicenseManagerStatefulSet(ctx, c, &cr)
+	if err != nil {
+		t.Fatalf("getLicenseManagerStatefulSet should not return an error, error: %v", err)
+	}
+	for _, volume := range ss.Spec.Template.Spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == "licenses" {
+			t.Errorf("license secret should not be mounted on the license manager")
+		}
+	}
+
+	splunkClient := splclient.NewSplunkClient("https://localhost:8089", "admin", "p@ssw0rd")
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/licenser/licenses?output_mode=json", nil)
+	mockSplunkClient.AddHandler(wantRequest, 201, `{"entry":[{"name":"AAAA"}]}`, nil)
+	splunkClient.Client = mockSplunkClient
+
+	cr.Status.License.LastCheckTime = 1000
+	err = applyLicenseSecrets(ctx, c, &cr, splunkClient)
+	if err != nil {
+		t.Fatalf("applyLicenseSecrets should not return an error, error: %v", err)
+	}
+
+	if len(cr.Status.InstalledLicenses) != 2 || cr.Status.InstalledLicenses[0].Key != "enterprise.lic" || cr.Status.InstalledLicenses[0].LicenseName != "AAAA" {
+		t.Errorf("unexpected installed licenses %+v", cr.Status.InstalledLicenses)
+	}
+
+	hash := sha256.Sum256([]byte("<license>enterprise</license>"))
+	if cr.Status.InstalledLicenses[0].Hash != hex.EncodeToString(hash[:]) {
+		t.Errorf("unexpected license hash %s", cr.Status.InstalledLicenses[0].Hash)
+	}
+
+	if cr.Status.License.LastCheckTime != 0 {
+		t.Errorf("license status should be refreshed after installing the licenses")
+	}
+
+	if len(mockSplunkClient.GotRequests) != 2 {
+		t.Errorf("expected two license installs, got: %d", len(mockSplunkClient.GotRequests))
+	}
+
+	// secret belongs to the user, so it should not be owned by the license manager
+	err = c.Get(ctx, types.NamespacedName{Name: "licenses", Namespace: "test"}, &secret)
+	if err != nil || len(secret.GetOwnerReferences()) != 0 {
+		t.Errorf("license secret should not be owned by the license manager")
+	}
+
+	// unchanged licenses are not installed again
+	mockSplunkClient.GotRequests = nil
+	err = applyLicenseSecrets(ctx, c, &cr, splunkClient)
+	if err != nil || len(mockSplunkClient.GotRequests) != 0 {
+		t.Errorf("unchanged licenses should not be installed again, error: %v", err)
+	}
+
+	// changed license is installed, and the old license of the changed file is removed
+	secret.Data["itsi.lic"] = []byte("<license>itsi renewed</license>")
+	delete(secret.Data, "enterprise.lic")
+	err = c.Update(ctx, &secret)
+	if err != nil {
+		t.Fatalf("unable to update the license secret, error: %v", err)
+	}
+	cr.Status.InstalledLicenses[1].LicenseName = "BBBB"
+	wantRequest, _ = http.NewRequest("DELETE", "https://localhost:8089/services/licenser/licenses/AAAA", nil)
+	mockSplunkClient.AddHandler(wantRequest, 200, "", nil)
+	wantRequest, _ = http.NewRequest("DELETE", "https://localhost:8089/services/licenser/licenses/BBBB", nil)
+	mockSplunkClient.AddHandler(wantRequest, 500, "", nil)
+
+	err = applyLicenseSecrets(ctx, c, &cr, splunkClient)
+	if err == nil {
+		t.Errorf("applyLicenseSecrets should return an error for the failed license removal")
+	}
+
+	// failed removal is kept in the status, to be retried
+	if len(cr.Status.InstalledLicenses) != 2 || cr.Status.InstalledLicenses[0].Key != "itsi.lic" || cr.Status.InstalledLicenses[1].LicenseName != "BBBB" {
+		t.Errorf("unexpected installed licenses %+v", cr.Status.InstalledLicenses)
+	}
+
+	// licenses are removed, when the secret is deleted
+	cr.Status.InstalledLicenses = cr.Status.InstalledLicenses[:1]
+	err = c.Delete(ctx, &secret)
+	if err != nil {
+		t.Fatalf("unable to delete the license secret, error: %v", err)
+	}
+	err = applyLicenseSecrets(ctx, c, &cr, splunkClient)
+	if err != nil || len(cr.Status.InstalledLicenses) != 0 {
+		t.Errorf("licenses of the deleted secret should be removed, error: %v, installed licenses: %+v", err, cr.Status.InstalledLicenses)
+	}
+}
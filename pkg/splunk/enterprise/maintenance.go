@@ -0,0 +1,135 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
+)
+
+const (
+	// maintenanceReasonUpgrade is the reason of the maintenance mode enabled while the peers are upgraded
+	maintenanceReasonUpgrade = "image upgrade"
+
+	// maintenanceReasonSecretRotation is the reason of the maintenance mode enabled while the idxc secret of the peers is changed
+	maintenanceReasonSecretRotation = "idxc secret rotation"
+
+	// defaultMaintenanceModeTimeoutSeconds is the default time after which the watchdog clears the maintenance mode enabled by the operator
+	defaultMaintenanceModeTimeoutSeconds = 7200
+)
+
+// getClusterManagerPodName returns the name of the cluster manager pod of an indexer cluster
+func getClusterManagerPodName(cr *enterpriseApi.IndexerCluster) (string, error) {
+	if len(cr.Spec.ClusterMasterRef.Name) == 0 {
+		return "", errors.New(splcommon.EmptyClusterMasterRef)
+	}
+	return fmt.Sprintf(splcommon.TestClusterManagerID, cr.Spec.ClusterMasterRef.Name, "0"), nil
+}
+
+// beginClusterMaintenance enables the maintenance mode of the cluster manager for the whole duration of a disruptive operation
+// on the peers, unless the operator already enabled it. The maintenance mode is recorded in the status of the indexer cluster
+// before it is enabled, so that the watchdog can clear it when the reconcile crashes before the end of the operation
+func beginClusterMaintenance(ctx context.Context, c splcommon.ControllerClient, cr *enterpriseApi.IndexerCluster, reason string, podExecClient splutil.PodExecClientImpl) error {
+	if cr.Status.OperatorMaintenance.Reason != "" && cr.Status.MaintenanceMode {
+		return nil
+	}
+
+	cmPodName, err := getClusterManagerPodName(cr)
+	if err != nil {
+		return err
+	}
+	podExecClient.SetTargetPodName(ctx, cmPodName)
+
+	// the start of an operation already recorded is kept, so that the watchdog still bounds it
+	recorded := cr.Status.OperatorMaintenance.Reason != ""
+	if !recorded {
+		cr.Status.OperatorMaintenance = enterpriseApi.IndexerClusterMaintenanceStatus{Reason: reason, StartTime: time.Now().Unix()}
+		updateCRStatus(ctx, c, cr)
+	}
+
+	err = SetClusterMaintenanceMode(ctx, c, cr, true, cmPodName, podExecClient)
+	if err != nil {
+		if !recorded {
+			cr.Status.OperatorMaintenance = enterpriseApi.IndexerClusterMaintenanceStatus{}
+		}
+		return err
+	}
+
+	eventPublisher, _ := newK8EventPublisher(c, cr)
+	eventPublisher.Normal(ctx, "MaintenanceMode", fmt.Sprintf("enabled maintenance mode for %s", cr.Status.OperatorMaintenance.Reason))
+	return nil
+}
+
+// endClusterMaintenance disables the maintenance mode of the cluster manager, and clears the maintenance mode recorded in
+// the status of the indexer cluster
+func endClusterMaintenance(ctx context.Context, c splcommon.ControllerClient, cr *enterpriseApi.IndexerCluster, podExecClient splutil.PodExecClientImpl) error {
+	cmPodName, err := getClusterManagerPodName(cr)
+	if err != nil {
+		return err
+	}
+	podExecClient.SetTargetPodName(ctx, cmPodName)
+
+	err = SetClusterMaintenanceMode(ctx, c, cr, false, cmPodName, podExecClient)
+	if err != nil {
+		return err
+	}
+
+	eventPublisher, _ := newK8EventPublisher(c, cr)
+	if cr.Status.OperatorMaintenance.Reason != "" {
+		eventPublisher.Normal(ctx, "MaintenanceMode", fmt.Sprintf("disabled maintenance mode after %s", cr.Status.OperatorMaintenance.Reason))
+	} else {
+		eventPublisher.Normal(ctx, "MaintenanceMode", "disabled maintenance mode")
+	}
+	cr.Status.OperatorMaintenance = enterpriseApi.IndexerClusterMaintenanceStatus{}
+	return nil
+}
+
+// checkClusterMaintenanceWatchdog clears the maintenance mode enabled by the operator when it is kept longer than the timeout
+// of the spec, which happens when an operation is stuck or when a reconcile crashed before its end. It returns true when the
+// maintenance mode was cleared
+func checkClusterMaintenanceWatchdog(ctx context.Context, c splcommon.ControllerClient, cr *enterpriseApi.IndexerCluster, podExecClient splutil.PodExecClientImpl, now time.Time) (bool, error) {
+	maintenance := cr.Status.OperatorMaintenance
+	if maintenance.Reason == "" {
+		return false, nil
+	}
+
+	timeout := int64(cr.Spec.MaintenanceModeTimeoutSeconds)
+	if timeout == 0 {
+		timeout = defaultMaintenanceModeTimeoutSeconds
+	}
+	if now.Unix()-maintenance.StartTime < timeout {
+		return false, nil
+	}
+
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("checkClusterMaintenanceWatchdog").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
+	scopedLog.Info("Clearing a stuck maintenance mode", "reason", maintenance.Reason, "startTime", maintenance.StartTime)
+
+	err := endClusterMaintenance(ctx, c, cr, podExecClient)
+	if err != nil {
+		return false, err
+	}
+	eventPublisher, _ := newK8EventPublisher(c, cr)
+	eventPublisher.Warning(ctx, "MaintenanceModeWatchdog", fmt.Sprintf("cleared maintenance mode enabled for %s more than %d seconds ago", maintenance.Reason, timeout))
+	return true, nil
+}
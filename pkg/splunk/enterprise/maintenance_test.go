@@ -0,0 +1,161 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+// getClusterMaintenanceTestCR returns an indexer cluster, and a mock client with the pod and the secrets of its cluster manager
+func getClusterMaintenanceTestCR() (*enterpriseApi.IndexerCluster, *spltest.MockClient) {
+	c := spltest.NewMockClient()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(splcommon.TestStack1ClusterManagerID, "0"),
+			Namespace: "test",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							MountPath: "/mnt/splunk-secrets",
+							Name:      "mnt-splunk-secrets",
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "mnt-splunk-secrets",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: "stack1-secrets",
+						},
+					},
+				},
+			},
+		},
+	}
+	secrets := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1-secrets",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			"password": {'1', '2', '3'},
+		},
+	}
+	c.AddObjects([]client.Object{pod, secrets})
+
+	cr := &enterpriseApi.IndexerCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "IndexerCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	cr.Spec.ClusterMasterRef.Name = "stack1"
+	return cr, c
+}
+
+func TestClusterMaintenance(t *testing.T) {
+	ctx := context.TODO()
+	cr, c := getClusterMaintenanceTestCR()
+
+	mockPodExecClient := &spltest.MockPodExecClient{}
+	mockPodExecClient.AddMockPodExecReturnContexts(ctx, []string{"maintenance-mode"}, &spltest.MockPodExecReturnContext{})
+
+	err := beginClusterMaintenance(ctx, c, cr, maintenanceReasonUpgrade, mockPodExecClient)
+	if err != nil {
+		t.Fatalf("beginClusterMaintenance returned error: %v", err)
+	}
+	if !cr.Status.MaintenanceMode || cr.Status.OperatorMaintenance.Reason != maintenanceReasonUpgrade || cr.Status.OperatorMaintenance.StartTime == 0 {
+		t.Errorf("unexpected maintenance status %+v", cr.Status.OperatorMaintenance)
+	}
+
+	// the operation already holding the maintenance mode keeps it
+	err = beginClusterMaintenance(ctx, c, cr, maintenanceReasonSecretRotation, mockPodExecClient)
+	if err != nil || cr.Status.OperatorMaintenance.Reason != maintenanceReasonUpgrade {
+		t.Errorf("beginClusterMaintenance should keep the first operation, error: %v, status: %+v", err, cr.Status.OperatorMaintenance)
+	}
+
+	err = endClusterMaintenance(ctx, c, cr, mockPodExecClient)
+	if err != nil {
+		t.Fatalf("endClusterMaintenance returned error: %v", err)
+	}
+	if cr.Status.MaintenanceMode || cr.Status.OperatorMaintenance.Reason != "" {
+		t.Errorf("unexpected maintenance status %+v", cr.Status.OperatorMaintenance)
+	}
+
+	// nothing is recorded when the maintenance mode can't be enabled
+	mockPodExecClient = &spltest.MockPodExecClient{}
+	mockPodExecClient.AddMockPodExecReturnContexts(ctx, []string{"maintenance-mode"}, &spltest.MockPodExecReturnContext{Err: fmt.Errorf("dummy error")})
+	err = beginClusterMaintenance(ctx, c, cr, maintenanceReasonUpgrade, mockPodExecClient)
+	if err == nil || cr.Status.OperatorMaintenance.Reason != "" {
+		t.Errorf("beginClusterMaintenance should fail, error: %v, status: %+v", err, cr.Status.OperatorMaintenance)
+	}
+
+	cr.Spec.ClusterMasterRef.Name = ""
+	err = beginClusterMaintenance(ctx, c, cr, maintenanceReasonUpgrade, mockPodExecClient)
+	if err == nil || err.Error() != splcommon.EmptyClusterMasterRef {
+		t.Errorf("beginClusterMaintenance should fail without cluster manager, error: %v", err)
+	}
+}
+
+func TestCheckClusterMaintenanceWatchdog(t *testing.T) {
+	ctx := context.TODO()
+	cr, c := getClusterMaintenanceTestCR()
+	now := time.Unix(100000, 0)
+
+	mockPodExecClient := &spltest.MockPodExecClient{}
+	mockPodExecClient.AddMockPodExecReturnContexts(ctx, []string{"maintenance-mode"}, &spltest.MockPodExecReturnContext{})
+
+	// no maintenance mode enabled by the operator
+	cleared, err := checkClusterMaintenanceWatchdog(ctx, c, cr, mockPodExecClient, now)
+	if err != nil || cleared {
+		t.Errorf("checkClusterMaintenanceWatchdog should not clear the maintenance mode, cleared: %t, error: %v", cleared, err)
+	}
+
+	cr.Status.MaintenanceMode = true
+	cr.Status.OperatorMaintenance = enterpriseApi.IndexerClusterMaintenanceStatus{Reason: maintenanceReasonUpgrade, StartTime: now.Unix() - 600}
+	cleared, err = checkClusterMaintenanceWatchdog(ctx, c, cr, mockPodExecClient, now)
+	if err != nil || cleared {
+		t.Errorf("checkClusterMaintenanceWatchdog should not clear a recent maintenance mode, cleared: %t, error: %v", cleared, err)
+	}
+
+	cr.Spec.MaintenanceModeTimeoutSeconds = 300
+	cleared, err = checkClusterMaintenanceWatchdog(ctx, c, cr, mockPodExecClient, now)
+	if err != nil || !cleared {
+		t.Errorf("checkClusterMaintenanceWatchdog should clear the stuck maintenance mode, cleared: %t, error: %v", cleared, err)
+	}
+	if cr.Status.MaintenanceMode || cr.Status.OperatorMaintenance.Reason != "" {
+		t.Errorf("unexpected maintenance status %+v", cr.Status.OperatorMaintenance)
+	}
+	mockPodExecClient.CheckPodExecCommands(t, "TestCheckClusterMaintenanceWatchdog")
+}
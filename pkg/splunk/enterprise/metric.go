@@ -0,0 +1,73 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var appPkgCacheHitCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "splunk_operator_app_cache_hit_total",
+	Help: "The number of times an app package was found in the App Framework download cache",
+})
+
+var appPkgCacheMissCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "splunk_operator_app_cache_miss_total",
+	Help: "The number of times an app package had to be downloaded from the remote storage",
+})
+
+var appPkgCacheEvictionCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "splunk_operator_app_cache_eviction_total",
+	Help: "The number of app packages evicted from the App Framework download cache",
+})
+
+var licenseUsagePercentGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "splunk_operator_license_usage_percent",
+	Help: "Today's license usage of a license pool in percent of its quota",
+}, []string{"namespace", "name", "pool"})
+
+var licenseDaysToExpiryGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "splunk_operator_license_days_to_expiry",
+	Help: "The number of days until the earliest license expiry on the license manager",
+}, []string{"namespace", "name"})
+
+var licenseWarningGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "splunk_operator_license_warning",
+	Help: "Set to 1 when the license usage, expiry or violation warning is raised on the license manager",
+}, []string{"namespace", "name", "warning"})
+
+var autoscalingDecisionCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "splunk_operator_autoscaling_decisions_total",
+	Help: "The number of scaling decisions of the autoscaling, by direction",
+}, []string{"namespace", "name", "type", "direction"})
+
+var autoscalingDesiredReplicasGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "splunk_operator_autoscaling_desired_replicas",
+	Help: "The number of replicas decided by the autoscaling",
+}, []string{"namespace", "name", "type"})
+
+func init() {
+	metrics.Registry.MustRegister(
+		appPkgCacheHitCounter,
+		appPkgCacheMissCounter,
+		appPkgCacheEvictionCounter,
+		licenseUsagePercentGauge,
+		licenseDaysToExpiryGauge,
+		licenseWarningGauge,
+		autoscalingDecisionCounter,
+		autoscalingDesiredReplicasGauge,
+	)
+}
@@ -26,6 +26,7 @@ import (
 	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
 	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -38,6 +39,8 @@ import (
 
 // ApplyMonitoringConsole reconciles the StatefulSet for N monitoring console instances of Splunk Enterprise.
 func ApplyMonitoringConsole(ctx context.Context, client splcommon.ControllerClient, cr *enterpriseApi.MonitoringConsole) (reconcile.Result, error) {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplyMonitoringConsole", cr)
+	defer span.End()
 
 	// unless modified, reconcile for this object will be requeued after 5 seconds
 	result := reconcile.Result{
@@ -84,6 +87,11 @@ func ApplyMonitoringConsole(ctx context.Context, client splcommon.ControllerClie
 	// Update the CR Status
 	defer updateCRStatus(ctx, client, cr)
 
+	// detect the drift of the objects applied below, and report it in the status before it is updated
+	driftRecorder := splctrl.NewDriftRecorder(cr.Spec.DriftPolicy)
+	ctx = splctrl.WithDriftRecorder(ctx, driftRecorder)
+	defer reportDrift(ctx, eventPublisher, driftRecorder, cr.GetGeneration(), &cr.Status.Conditions)
+
 	// create or update general config resources
 	_, err = ApplySplunkConfig(ctx, client, cr, cr.Spec.CommonSplunkSpec, SplunkMonitoringConsole)
 	if err != nil {
@@ -127,6 +135,20 @@ func ApplyMonitoringConsole(ctx context.Context, client splcommon.ControllerClie
 		return result, err
 	}
 
+	// create or update the objects exposing Splunk Web and HEC
+	err = ApplySplunkExpose(ctx, client, cr, &cr.Spec.CommonSplunkSpec, SplunkMonitoringConsole, &cr.Status.Expose)
+	if err != nil {
+		eventPublisher.Warning(ctx, "ApplySplunkExpose", fmt.Sprintf("create/update expose objects failed %s", err.Error()))
+		return result, err
+	}
+
+	// create or update the network policies of the Splunk instances
+	err = ApplySplunkNetworkPolicies(ctx, client, cr, &cr.Spec.CommonSplunkSpec, &cr.Status.NetworkPolicies, SplunkMonitoringConsole)
+	if err != nil {
+		eventPublisher.Warning(ctx, "ApplySplunkNetworkPolicies", fmt.Sprintf("create/update network policies failed %s", err.Error()))
+		return result, err
+	}
+
 	// create or update statefulset
 	statefulSet, err := getMonitoringConsoleStatefulSet(ctx, client, cr)
 	if err != nil {
@@ -134,6 +156,16 @@ func ApplyMonitoringConsole(ctx context.Context, client splcommon.ControllerClie
 		return result, err
 	}
 
+	// wait for the previous stages of an ordered upgrade of the stack
+	upgradeReady, err := checkOrderedUpgrade(ctx, client, cr, &cr.Spec.CommonSplunkSpec, SplunkMonitoringConsole, &cr.Status.UpgradeStage)
+	if err != nil {
+		return result, err
+	}
+	if !upgradeReady {
+		cr.Status.Phase = enterpriseApi.PhasePending
+		return result, nil
+	}
+
 	mgr := splctrl.DefaultStatefulSetPodManager{}
 	phase, err := mgr.Update(ctx, client, statefulSet, 1)
 	if err != nil {
@@ -141,6 +173,7 @@ func ApplyMonitoringConsole(ctx context.Context, client splcommon.ControllerClie
 		return result, err
 	}
 	cr.Status.Phase = phase
+	updateUpgradeStage(&cr.Status.UpgradeStage, SplunkMonitoringConsole, phase)
 
 	// no need to requeue if everything is ready
 	if cr.Status.Phase == enterpriseApi.PhaseReady {
@@ -199,13 +232,81 @@ func validateMonitoringConsoleSpec(ctx context.Context, c splcommon.ControllerCl
 	return validateCommonSplunkSpec(ctx, c, &cr.Spec.CommonSplunkSpec, cr)
 }
 
-//ApplyMonitoringConsoleEnvConfigMap creates or updates a Kubernetes ConfigMap for extra env for monitoring console pod
+// monitoringConsoleRefFinalizer removes the peer URLs of a CR from the monitoring console configMap in another namespace
+const monitoringConsoleRefFinalizer = "enterprise.splunk.com/monitoring-console-ref"
+
+// getMonitoringConsoleRefKey returns the monitoring console reference of a CR as name, when the monitoring console
+// is in the namespace of the CR, or as namespace/name when the monitoring console is in another namespace
+func getMonitoringConsoleRefKey(namespace string, monitoringConsoleRef corev1.ObjectReference) string {
+	if monitoringConsoleRef.Name == "" || monitoringConsoleRef.Namespace == "" || monitoringConsoleRef.Namespace == namespace {
+		return monitoringConsoleRef.Name
+	}
+	return fmt.Sprintf("%s/%s", monitoringConsoleRef.Namespace, monitoringConsoleRef.Name)
+}
+
+// splitMonitoringConsoleRefKey returns the namespace and name of the monitoring console from the reference key,
+// the namespace defaults to the namespace of the CR
+func splitMonitoringConsoleRefKey(namespace string, monitoringConsoleRefKey string) (string, string) {
+	parts := strings.SplitN(monitoringConsoleRefKey, "/", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return namespace, monitoringConsoleRefKey
+}
+
+// getPeerURLNamespace returns the namespace from the service FQDN of a peer URL, the namespace defaults to
+// the namespace of the monitoring console when the URL is a service name
+func getPeerURLNamespace(url string, mcNamespace string) string {
+	idx := strings.Index(url, ".svc.")
+	if idx < 0 {
+		return mcNamespace
+	}
+	host := url[:idx]
+	return host[strings.LastIndex(host, ".")+1:]
+}
+
+// isCRPeerURL checks if the peer URL in the monitoring console configMap belongs to the CR
+func isCRPeerURL(url string, crName string, crNamespace string, mcNamespace string) bool {
+	if mcNamespace == "" {
+		mcNamespace = crNamespace
+	}
+	return strings.Contains(url, crName) && getPeerURLNamespace(url, mcNamespace) == crNamespace
+}
+
+// getCrossNamespacePeerURLs returns the peer URLs with the service names replaced by the service FQDNs,
+// so that the monitoring console can reach the peers in the other namespace
+func getCrossNamespacePeerURLs(namespace string, newURLs []corev1.EnvVar) []corev1.EnvVar {
+	var peerURLs []corev1.EnvVar
+	for _, url := range newURLs {
+		if url.Name != "SPLUNK_SITE" {
+			hosts := strings.Split(url.Value, ",")
+			for i, host := range hosts {
+				if host != "" && !strings.Contains(host, ".") {
+					hosts[i] = splcommon.GetServiceFQDN(namespace, host)
+				}
+			}
+			url.Value = strings.Join(hosts, ",")
+		}
+		peerURLs = append(peerURLs, url)
+	}
+	return peerURLs
+}
+
+//ApplyMonitoringConsoleEnvConfigMap creates or updates a Kubernetes ConfigMap for extra env for monitoring console pod.
+//The monitoringConsoleRef is the monitoring console name, or namespace/name for the monitoring console in another namespace
 func ApplyMonitoringConsoleEnvConfigMap(ctx context.Context, client splcommon.ControllerClient, namespace string, crName string, monitoringConsoleRef string, newURLs []corev1.EnvVar, addNewURLs bool) (*corev1.ConfigMap, error) {
+	ctx, span := tracing.StartSpan(ctx, "ApplyMonitoringConsoleEnvConfigMap", tracing.AttributeObjectName.String(monitoringConsoleRef))
+	defer span.End()
 
 	var current corev1.ConfigMap
 
-	configMap := GetSplunkMonitoringconsoleConfigMapName(monitoringConsoleRef, SplunkMonitoringConsole)
-	namespacedName := types.NamespacedName{Namespace: namespace, Name: configMap}
+	mcNamespace, mcName := splitMonitoringConsoleRefKey(namespace, monitoringConsoleRef)
+	if mcNamespace != namespace {
+		newURLs = getCrossNamespacePeerURLs(namespace, newURLs)
+	}
+
+	configMap := GetSplunkMonitoringconsoleConfigMapName(mcName, SplunkMonitoringConsole)
+	namespacedName := types.NamespacedName{Namespace: mcNamespace, Name: configMap}
 	err := client.Get(ctx, namespacedName, &current)
 
 	if err == nil {
@@ -214,9 +315,9 @@ func ApplyMonitoringConsoleEnvConfigMap(ctx context.Context, client splcommon.Co
 			revised.Data = make(map[string]string)
 		}
 		if addNewURLs {
-			AddURLsConfigMap(revised, crName, newURLs)
+			AddURLsConfigMap(revised, crName, namespace, newURLs)
 		} else {
-			DeleteURLsConfigMap(revised, crName, newURLs, true)
+			DeleteURLsConfigMap(revised, crName, namespace, newURLs, true)
 		}
 		if !reflect.DeepEqual(revised.Data, current.Data) {
 			current.Data = revised.Data
@@ -238,7 +339,7 @@ func ApplyMonitoringConsoleEnvConfigMap(ctx context.Context, client splcommon.Co
 	current = corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      configMap,
-			Namespace: namespace,
+			Namespace: mcNamespace,
 		},
 		Data: make(map[string]string),
 	}
@@ -252,7 +353,7 @@ func ApplyMonitoringConsoleEnvConfigMap(ctx context.Context, client splcommon.Co
 
 	current.ObjectMeta = metav1.ObjectMeta{
 		Name:      configMap,
-		Namespace: namespace,
+		Namespace: mcNamespace,
 	}
 
 	err = splutil.CreateResource(ctx, client, &current)
@@ -264,7 +365,7 @@ func ApplyMonitoringConsoleEnvConfigMap(ctx context.Context, client splcommon.Co
 }
 
 //AddURLsConfigMap for adding new server peers to the monitoring console or scaling up
-func AddURLsConfigMap(revised *corev1.ConfigMap, crName string, newURLs []corev1.EnvVar) {
+func AddURLsConfigMap(revised *corev1.ConfigMap, crName string, crNamespace string, newURLs []corev1.EnvVar) {
 	for _, url := range newURLs {
 		_, ok := revised.Data[url.Name]
 		if !ok {
@@ -274,7 +375,7 @@ func AddURLsConfigMap(revised *corev1.ConfigMap, crName string, newURLs []corev1
 			currentURLs := strings.Split(revised.Data[url.Name], ",")
 			var crURLs string
 			for _, curr := range currentURLs {
-				if strings.Contains(curr, crName) {
+				if isCRPeerURL(curr, crName, crNamespace, revised.GetNamespace()) {
 					if crURLs == "" {
 						crURLs = curr
 					} else {
@@ -296,22 +397,23 @@ func AddURLsConfigMap(revised *corev1.ConfigMap, crName string, newURLs []corev1
 				}
 			} else {
 				//scaling DOWN pods
-				DeleteURLsConfigMap(revised, crName, newURLs, false)
+				DeleteURLsConfigMap(revised, crName, crNamespace, newURLs, false)
 			}
 		}
 	}
 }
 
 //DeleteURLsConfigMap for deleting server peers to the monitoring console or scaling down
-func DeleteURLsConfigMap(revised *corev1.ConfigMap, crName string, newURLs []corev1.EnvVar, deleteCR bool) {
+func DeleteURLsConfigMap(revised *corev1.ConfigMap, crName string, crNamespace string, newURLs []corev1.EnvVar, deleteCR bool) {
 	for _, url := range newURLs {
 		currentURLs := strings.Split(revised.Data[url.Name], ",")
 		sort.Strings(currentURLs)
 		for _, curr := range currentURLs {
 			//scale DOWN
-			if strings.Contains(curr, crName) && !strings.Contains(url.Value, curr) && !deleteCR {
+			isCRURL := isCRPeerURL(curr, crName, crNamespace, revised.GetNamespace())
+			if isCRURL && !strings.Contains(url.Value, curr) && !deleteCR {
 				revised.Data[url.Name] = strings.ReplaceAll(revised.Data[url.Name], curr, "")
-			} else if strings.Contains(curr, crName) && deleteCR {
+			} else if isCRURL && deleteCR {
 				revised.Data[url.Name] = strings.ReplaceAll(revised.Data[url.Name], url.Value, "")
 			}
 			//if deleting "SPLUNK_MULTISITE_MASTER" delete "SPLUNK_SITE"
@@ -336,3 +438,87 @@ func DeleteURLsConfigMap(revised *corev1.ConfigMap, crName string, newURLs []cor
 		}
 	}
 }
+
+// getMonitoringConsoleRefFromCR returns the monitoring console reference of the CRs adding peer URLs to the monitoring console
+func getMonitoringConsoleRefFromCR(cr splcommon.MetaObject) corev1.ObjectReference {
+	switch cr.GetObjectKind().GroupVersionKind().Kind {
+	case "Standalone":
+		return cr.(*enterpriseApi.Standalone).Spec.MonitoringConsoleRef
+	case "LicenseMaster", "LicenseManager":
+		return cr.(*enterpriseApi.LicenseMaster).Spec.MonitoringConsoleRef
+	case "SearchHeadCluster":
+		return cr.(*enterpriseApi.SearchHeadCluster).Spec.MonitoringConsoleRef
+	case "ClusterMaster", "ClusterManager":
+		return cr.(*enterpriseApi.ClusterMaster).Spec.MonitoringConsoleRef
+	default:
+		return corev1.ObjectReference{}
+	}
+}
+
+// addMonitoringConsoleRefFinalizer adds the finalizer to a CR referring to a monitoring console in another namespace.
+// The monitoring console configMap can't be owned by a CR in another namespace, so the finalizer removes the peer URLs instead
+func addMonitoringConsoleRefFinalizer(ctx context.Context, c splcommon.ControllerClient, cr splcommon.MetaObject, monitoringConsoleRef corev1.ObjectReference) error {
+	mcNamespace, _ := splitMonitoringConsoleRefKey(cr.GetNamespace(), getMonitoringConsoleRefKey(cr.GetNamespace(), monitoringConsoleRef))
+	if mcNamespace == cr.GetNamespace() {
+		return nil
+	}
+
+	for _, finalizer := range cr.GetFinalizers() {
+		if finalizer == monitoringConsoleRefFinalizer {
+			return nil
+		}
+	}
+
+	cr.SetFinalizers(append(cr.GetFinalizers(), monitoringConsoleRefFinalizer))
+	return splutil.UpdateResource(ctx, c, cr)
+}
+
+// DeleteMonitoringConsolePeerURLs removes all the peer URLs of a CR from the monitoring console configMap
+func DeleteMonitoringConsolePeerURLs(ctx context.Context, cr splcommon.MetaObject, c splcommon.ControllerClient) error {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("DeleteMonitoringConsolePeerURLs").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
+
+	monitoringConsoleRef := getMonitoringConsoleRefFromCR(cr)
+	if monitoringConsoleRef.Name == "" {
+		scopedLog.Info("Skipping peer URLs removal, monitoring console reference is not set")
+		return nil
+	}
+
+	mcNamespace, mcName := splitMonitoringConsoleRefKey(cr.GetNamespace(), getMonitoringConsoleRefKey(cr.GetNamespace(), monitoringConsoleRef))
+	var current corev1.ConfigMap
+	namespacedName := types.NamespacedName{Namespace: mcNamespace, Name: GetSplunkMonitoringconsoleConfigMapName(mcName, SplunkMonitoringConsole)}
+	err := c.Get(ctx, namespacedName, &current)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	revised := current.DeepCopy()
+	for name, value := range revised.Data {
+		var peerURLs []string
+		for _, url := range strings.Split(value, ",") {
+			if url != "" && !isCRPeerURL(url, cr.GetName(), cr.GetNamespace(), mcNamespace) {
+				peerURLs = append(peerURLs, url)
+			}
+		}
+		if len(peerURLs) == 0 {
+			delete(revised.Data, name)
+		} else {
+			revised.Data[name] = strings.Join(peerURLs, ",")
+		}
+	}
+
+	// site is only applicable along with the multisite cluster manager
+	if _, ok := revised.Data["SPLUNK_MULTISITE_MASTER"]; !ok {
+		delete(revised.Data, "SPLUNK_SITE")
+	}
+
+	if reflect.DeepEqual(revised.Data, current.Data) {
+		return nil
+	}
+
+	scopedLog.Info("Removing peer URLs from the monitoring console configMap", "configMap", namespacedName.Name, "mcNamespace", mcNamespace)
+	current.Data = revised.Data
+	return splutil.UpdateResource(ctx, c, &current)
+}
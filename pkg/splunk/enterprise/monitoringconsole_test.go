@@ -18,6 +18,7 @@ package enterprise
 import (
 	"context"
 	"os"
+	"reflect"
 	"runtime/debug"
 	"testing"
 	"time"
@@ -69,7 +70,6 @@ func TestApplyMonitoringConsole(t *testing.T) {
 		{MetaName: "*v1.ConfigMap-test-splunk-stack1-monitoring-console"},
 		{MetaName: "*v1.ConfigMap-test-splunk-stack1-monitoring-console"},
 		{MetaName: "*v1.StatefulSet-test-splunk-stack1-monitoring-console"},
-		{MetaName: "*v1.StatefulSet-test-splunk-stack1-monitoring-console"},
 		{MetaName: "*v3.MonitoringConsole-test-stack1"},
 	}
 
@@ -84,8 +84,8 @@ func TestApplyMonitoringConsole(t *testing.T) {
 	listmockCall := []spltest.MockFuncCall{
 		{ListOpts: listOpts}}
 
-	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": {funcCalls[0], funcCalls[3], funcCalls[4], funcCalls[7], funcCalls[9], funcCalls[5]}, "Update": {funcCalls[0], funcCalls[9]}, "List": {listmockCall[0]}}
-	updateCalls := map[string][]spltest.MockFuncCall{"Get": updateFuncCalls, "Update": {updateFuncCalls[4]}, "List": {listmockCall[0]}}
+	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": {funcCalls[0], funcCalls[7], funcCalls[9]}, "Patch": {funcCalls[3], funcCalls[4], funcCalls[5]}, "Update": {funcCalls[0], funcCalls[9]}, "List": {listmockCall[0]}}
+	updateCalls := map[string][]spltest.MockFuncCall{"Get": updateFuncCalls, "Patch": {updateFuncCalls[4]}, "List": {listmockCall[0]}}
 
 	current := enterpriseApi.MonitoringConsole{
 		TypeMeta: metav1.TypeMeta{
@@ -777,7 +777,7 @@ func TestMonitoringConsoleGetAppsListForAWSS3ClientShouldFail(t *testing.T) {
 func TestMonitoringConsoleWithReadyState(t *testing.T) {
 
 	builder := fake.NewClientBuilder()
-	c := builder.Build()
+	c := spltest.NewFakeApplyClient(builder.Build())
 	utilruntime.Must(enterpriseApi.AddToScheme(clientgoscheme.Scheme))
 	ctx := context.TODO()
 
@@ -1050,3 +1050,127 @@ func TestApplyMonitoringConsoleDeletion(t *testing.T) {
 		t.Errorf("ApplyMonitoringConsole should not have returned error here.")
 	}
 }
+
+func TestMonitoringConsoleRefKey(t *testing.T) {
+	key := getMonitoringConsoleRefKey("test", corev1.ObjectReference{Name: "mc"})
+	if key != "mc" {
+		t.Errorf("unexpected key %s for the monitoring console in the same namespace", key)
+	}
+
+	key = getMonitoringConsoleRefKey("test", corev1.ObjectReference{Name: "mc", Namespace: "test"})
+	if key != "mc" {
+		t.Errorf("unexpected key %s for the monitoring console in the same namespace", key)
+	}
+
+	key = getMonitoringConsoleRefKey("test", corev1.ObjectReference{Name: "mc", Namespace: "monitoring"})
+	if key != "monitoring/mc" {
+		t.Errorf("unexpected key %s for the monitoring console in another namespace", key)
+	}
+
+	namespace, name := splitMonitoringConsoleRefKey("test", key)
+	if namespace != "monitoring" || name != "mc" {
+		t.Errorf("unexpected namespace %s and name %s", namespace, name)
+	}
+
+	namespace, name = splitMonitoringConsoleRefKey("test", "mc")
+	if namespace != "test" || name != "mc" {
+		t.Errorf("unexpected namespace %s and name %s", namespace, name)
+	}
+}
+
+func TestApplyMonitoringConsoleEnvConfigMapCrossNamespace(t *testing.T) {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+
+	// peer URLs of the same CR name in the monitoring console namespace
+	current := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-mc-monitoring-console",
+			Namespace: "monitoring",
+		},
+		Data: map[string]string{"SPLUNK_CLUSTER_MASTER_URL": "splunk-stack1-cluster-manager-service"},
+	}
+	c.AddObject(&current)
+
+	env := []corev1.EnvVar{
+		{Name: "SPLUNK_CLUSTER_MASTER_URL", Value: "splunk-stack1-cluster-manager-service"},
+		{Name: "SPLUNK_SITE", Value: "site0"},
+	}
+	configMap, err := ApplyMonitoringConsoleEnvConfigMap(ctx, c, "tenant1", "stack1", "monitoring/mc", env, true)
+	if err != nil {
+		t.Fatalf("ApplyMonitoringConsoleEnvConfigMap should not return an error, error: %v", err)
+	}
+
+	want := "splunk-stack1-cluster-manager-service,splunk-stack1-cluster-manager-service.tenant1.svc.cluster.local"
+	if configMap.Data["SPLUNK_CLUSTER_MASTER_URL"] != want || configMap.Data["SPLUNK_SITE"] != "site0" {
+		t.Errorf("unexpected monitoring console configMap data %v", configMap.Data)
+	}
+
+	// deleting the CR should only remove its own peer URLs
+	configMap, err = ApplyMonitoringConsoleEnvConfigMap(ctx, c, "tenant1", "stack1", "monitoring/mc", env[:1], false)
+	if err != nil {
+		t.Fatalf("ApplyMonitoringConsoleEnvConfigMap should not return an error, error: %v", err)
+	}
+
+	if configMap.Data["SPLUNK_CLUSTER_MASTER_URL"] != "splunk-stack1-cluster-manager-service" {
+		t.Errorf("unexpected monitoring console configMap data %v", configMap.Data)
+	}
+}
+
+func TestMonitoringConsoleRefFinalizer(t *testing.T) {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+
+	cr := enterpriseApi.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "tenant1",
+		},
+	}
+	cr.Spec.MonitoringConsoleRef = corev1.ObjectReference{Name: "mc"}
+
+	// no finalizer for the monitoring console in the same namespace
+	err := addMonitoringConsoleRefFinalizer(ctx, c, &cr, cr.Spec.MonitoringConsoleRef)
+	if err != nil || len(cr.GetFinalizers()) != 0 {
+		t.Errorf("finalizer should not be added for the monitoring console in the same namespace")
+	}
+
+	cr.Spec.MonitoringConsoleRef.Namespace = "monitoring"
+	err = addMonitoringConsoleRefFinalizer(ctx, c, &cr, cr.Spec.MonitoringConsoleRef)
+	if err != nil || len(cr.GetFinalizers()) != 1 || cr.GetFinalizers()[0] != monitoringConsoleRefFinalizer {
+		t.Errorf("finalizer should be added for the monitoring console in another namespace, finalizers: %v", cr.GetFinalizers())
+	}
+
+	// missing configMap should not return an error
+	err = DeleteMonitoringConsolePeerURLs(ctx, &cr, c)
+	if err != nil {
+		t.Errorf("DeleteMonitoringConsolePeerURLs should not return an error, error: %v", err)
+	}
+
+	current := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-mc-monitoring-console",
+			Namespace: "monitoring",
+		},
+		Data: map[string]string{
+			"SPLUNK_STANDALONE_URL": "splunk-stack1-standalone-0.splunk-stack1-standalone-headless.tenant1.svc.cluster.local,splunk-stack1-standalone-0.splunk-stack1-standalone-headless.tenant2.svc.cluster.local",
+			"SPLUNK_DEPLOYER_URL":   "splunk-stack1-deployer-service.tenant1.svc.cluster.local",
+		},
+	}
+	c.AddObject(&current)
+
+	err = DeleteMonitoringConsolePeerURLs(ctx, &cr, c)
+	if err != nil {
+		t.Errorf("DeleteMonitoringConsolePeerURLs should not return an error, error: %v", err)
+	}
+
+	var revised corev1.ConfigMap
+	_ = c.Get(ctx, types.NamespacedName{Namespace: "monitoring", Name: "splunk-mc-monitoring-console"}, &revised)
+	want := map[string]string{"SPLUNK_STANDALONE_URL": "splunk-stack1-standalone-0.splunk-stack1-standalone-headless.tenant2.svc.cluster.local"}
+	if !reflect.DeepEqual(revised.Data, want) {
+		t.Errorf("unexpected monitoring console configMap data %v", revised.Data)
+	}
+}
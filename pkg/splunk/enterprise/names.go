@@ -36,6 +36,12 @@ const (
 	// identifier, instanceType, "headless" or "service"
 	serviceTemplateStr = "splunk-%s-%s-%s"
 
+	// identifier, instanceType, exposed port name ("splunkweb" or "hec")
+	exposeTemplateStr = "splunk-%s-%s-%s"
+
+	// identifier, instanceType
+	networkPolicyTemplateStr = "splunk-%s-%s-network-policy"
+
 	// identifier
 	defaultsTemplateStr = "splunk-%s-%s-defaults"
 
@@ -62,6 +68,11 @@ const (
 
 	manualAppUpdateCMStr = "splunk-%s-manual-app-update"
 
+	// identifier, CR kind
+	appFrameworkPlanTemplateStr = "splunk-%s-%s-app-plan"
+
+	appDeployStatusTemplateStr = "splunk-%s-%s-app-deploy-status"
+
 	applySHCBundleCmdStr = "/opt/splunk/bin/splunk apply shcluster-bundle -target https://%s:8089 -auth admin:`cat /mnt/splunk-secrets/password` --answer-yes -push-default-apps true &> %s &"
 
 	shcBundlePushCompleteStr = "Bundle has been pushed successfully to all the cluster members.\n"
@@ -154,6 +165,16 @@ func GetSplunkServiceName(instanceType InstanceType, identifier string, isHeadle
 	return result
 }
 
+// GetSplunkExposeName uses a template to name the Ingress, Route or Gateway API route exposing a port of Splunk instances.
+func GetSplunkExposeName(instanceType InstanceType, identifier string, portName string) string {
+	return fmt.Sprintf(exposeTemplateStr, identifier, instanceType, portName)
+}
+
+// GetSplunkNetworkPolicyName uses a template to name the NetworkPolicy of Splunk instances.
+func GetSplunkNetworkPolicyName(instanceType InstanceType, identifier string) string {
+	return fmt.Sprintf(networkPolicyTemplateStr, identifier, instanceType)
+}
+
 // GetSplunkDefaultsName uses a template to name a Kubernetes ConfigMap for a SplunkEnterprise resource.
 func GetSplunkDefaultsName(identifier string, instanceType InstanceType) string {
 	return fmt.Sprintf(defaultsTemplateStr, identifier, instanceType.ToKind())
@@ -174,6 +195,16 @@ func GetSplunkManualAppUpdateConfigMapName(namespace string) string {
 	return fmt.Sprintf(manualAppUpdateCMStr, namespace)
 }
 
+// GetSplunkAppFrameworkPlanConfigMapName returns the name of the configMap with the App Framework dry-run plan
+func GetSplunkAppFrameworkPlanConfigMapName(identifier string, crKind string) string {
+	return fmt.Sprintf(appFrameworkPlanTemplateStr, identifier, strings.ToLower(crKind))
+}
+
+// GetSplunkAppDeployStatusConfigMapName returns the name of the configMap with the App deployment status
+func GetSplunkAppDeployStatusConfigMapName(identifier string, crKind string) string {
+	return fmt.Sprintf(appDeployStatusTemplateStr, identifier, strings.ToLower(crKind))
+}
+
 // GetSplunkStatefulsetUrls returns a list of fully qualified domain names for all pods within a Splunk StatefulSet.
 func GetSplunkStatefulsetUrls(namespace string, instanceType InstanceType, identifier string, replicas int32, hostnameOnly bool) string {
 	urls := make([]string, replicas)
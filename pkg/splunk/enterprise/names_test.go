@@ -64,6 +64,20 @@ func TestGetSplunkServiceName(t *testing.T) {
 	test(splcommon.TestStack1LicenseManagerService, SplunkLicenseManager, LicenseMasterRefName, false)
 }
 
+func TestGetSplunkExposeName(t *testing.T) {
+	got := GetSplunkExposeName(SplunkSearchHead, "t1", splunkwebPort)
+	if got != "splunk-t1-search-head-splunkweb" {
+		t.Errorf("GetSplunkExposeName() = %s; want splunk-t1-search-head-splunkweb", got)
+	}
+}
+
+func TestGetSplunkNetworkPolicyName(t *testing.T) {
+	got := GetSplunkNetworkPolicyName(SplunkIndexer, "t1")
+	if got != "splunk-t1-indexer-network-policy" {
+		t.Errorf("GetSplunkNetworkPolicyName() = %s; want splunk-t1-indexer-network-policy", got)
+	}
+}
+
 func TestGetSplunkDefaultsName(t *testing.T) {
 	got := GetSplunkDefaultsName("t1", SplunkSearchHead)
 	want := "splunk-t1-search-head-defaults"
@@ -0,0 +1,247 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
+)
+
+// operatorPodLabels are the labels of the Splunk Operator pod, used to allow its access to the management port
+var operatorPodLabels = map[string]string{
+	"control-plane": "controller-manager",
+	"name":          "splunk-operator",
+}
+
+// getNetworkPolicyPort returns a NetworkPolicyPort
+func getNetworkPolicyPort(port int, protocol corev1.Protocol) networkingv1.NetworkPolicyPort {
+	policyPort := intstr.FromInt(port)
+	return networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &policyPort}
+}
+
+// getSplunkPodsPeer returns a NetworkPolicyPeer selecting the Splunk pods with the given labels and, when set, one of the
+// given instance types. Pods of all namespaces are selected when anyNamespace is true
+func getSplunkPodsPeer(matchLabels map[string]string, anyNamespace bool, instanceTypes ...InstanceType) networkingv1.NetworkPolicyPeer {
+	labelTypeMap := splcommon.GetLabelTypes()
+	selector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{labelTypeMap["manager"]: "splunk-operator"},
+	}
+	for k, v := range matchLabels {
+		selector.MatchLabels[k] = v
+	}
+
+	if len(instanceTypes) > 0 {
+		var names []string
+		for _, instanceType := range instanceTypes {
+			names = append(names, instanceType.ToString())
+		}
+		selector.MatchExpressions = []metav1.LabelSelectorRequirement{
+			{
+				Key:      labelTypeMap["name"],
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   names,
+			},
+		}
+	}
+
+	peer := networkingv1.NetworkPolicyPeer{PodSelector: selector}
+	if anyNamespace {
+		peer.NamespaceSelector = &metav1.LabelSelector{}
+	}
+	return peer
+}
+
+// getSplunkManagementPeers returns the sources allowed to access the management port of the Splunk instances: the operator,
+// the cluster manager, the deployer, the monitoring console and the search head cluster members
+func getSplunkManagementPeers(podLabels map[string]string, instanceType InstanceType) []networkingv1.NetworkPolicyPeer {
+	labelTypeMap := splcommon.GetLabelTypes()
+	peers := []networkingv1.NetworkPolicyPeer{
+		{
+			PodSelector:       &metav1.LabelSelector{MatchLabels: operatorPodLabels},
+			NamespaceSelector: &metav1.LabelSelector{},
+		},
+		// monitoring console can be in another namespace
+		getSplunkPodsPeer(nil, true, SplunkMonitoringConsole),
+		getSplunkPodsPeer(nil, false, SplunkClusterManager, SplunkDeployer, SplunkSearchHead),
+	}
+
+	switch instanceType {
+	case SplunkClusterManager:
+		// peers of the indexer cluster, and the standalones searching it
+		peers = append(peers, getSplunkPodsPeer(map[string]string{labelTypeMap["partof"]: podLabels[labelTypeMap["partof"]]}, false, SplunkIndexer))
+		peers = append(peers, getSplunkPodsPeer(nil, false, SplunkStandalone))
+	case SplunkLicenseManager:
+		// all the Splunk instances using the license manager, which can be in other namespaces
+		peers = append(peers, getSplunkPodsPeer(nil, true))
+	case SplunkIndexer:
+		// standalones searching the indexer cluster
+		peers = append(peers, getSplunkPodsPeer(nil, false, SplunkStandalone))
+	}
+	return peers
+}
+
+// isSplunkClusterPort returns true if the port is used for the replication between the members of the same cluster
+func isSplunkClusterPort(instanceType InstanceType, port int) bool {
+	switch instanceType {
+	case SplunkIndexer:
+		return port == splunkReplicationPort
+	case SplunkSearchHead:
+		return port == splunkReplicationPort || port == splunkKVStorePort
+	}
+	return false
+}
+
+// getSplunkNetworkPolicy returns the NetworkPolicy of the Splunk instances, derived from the ports and the labels of the instances
+func getSplunkNetworkPolicy(cr splcommon.MetaObject, spec *enterpriseApi.CommonSplunkSpec, instanceType InstanceType) *networkingv1.NetworkPolicy {
+	labelTypeMap := splcommon.GetLabelTypes()
+	podLabels := getSplunkLabels(cr.GetName(), instanceType, spec.ClusterMasterRef.Name)
+	ports := getSplunkPorts(instanceType, spec)
+
+	// Splunk Web is reachable from anywhere, unless the sources are set
+	var ingressRules []networkingv1.NetworkPolicyIngressRule
+	if port, ok := ports[GetPortName(splunkwebPort, protoHTTP)]; ok {
+		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{getNetworkPolicyPort(port, corev1.ProtocolTCP)},
+			From:  spec.NetworkPolicy.WebFrom,
+		})
+	}
+
+	ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
+		Ports: []networkingv1.NetworkPolicyPort{getNetworkPolicyPort(ports[GetPortName(splunkdPort, protoHTTPS)], corev1.ProtocolTCP)},
+		From:  getSplunkManagementPeers(podLabels, instanceType),
+	})
+
+	// Splunk instances of the namespace forward their internal logs over S2S, the additional ports are handled like S2S
+	var inputPorts []networkingv1.NetworkPolicyPort
+	if port, ok := ports[GetPortName(s2sPort, protoTCP)]; ok {
+		inputPorts = append(inputPorts, getNetworkPolicyPort(port, corev1.ProtocolTCP))
+	}
+	defaultPorts := getSplunkPorts(instanceType, &enterpriseApi.CommonSplunkSpec{})
+	for _, port := range spec.Ports {
+		_, isDefault := defaultPorts[port.Name]
+		if !isDefault && !port.Disabled && port.Port != 0 && !isSplunkClusterPort(instanceType, int(port.Port)) {
+			inputPorts = append(inputPorts, getNetworkPolicyPort(int(port.Port), getSplunkPortProtocol(spec, port.Name)))
+		}
+	}
+	if len(inputPorts) > 0 {
+		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
+			Ports: inputPorts,
+			From:  append([]networkingv1.NetworkPolicyPeer{getSplunkPodsPeer(nil, false)}, spec.NetworkPolicy.S2SFrom...),
+		})
+	}
+
+	if port, ok := ports[GetPortName(hecPort, protoHTTP)]; ok && len(spec.NetworkPolicy.HECFrom) > 0 {
+		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{getNetworkPolicyPort(port, corev1.ProtocolTCP)},
+			From:  spec.NetworkPolicy.HECFrom,
+		})
+	}
+
+	// replication is only accepted from the members of the same cluster
+	switch instanceType {
+	case SplunkIndexer:
+		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{getNetworkPolicyPort(splunkReplicationPort, corev1.ProtocolTCP)},
+			From:  []networkingv1.NetworkPolicyPeer{getSplunkPodsPeer(map[string]string{labelTypeMap["partof"]: podLabels[labelTypeMap["partof"]]}, false, SplunkIndexer)},
+		})
+	case SplunkSearchHead:
+		ingressRules = append(ingressRules, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{getNetworkPolicyPort(splunkReplicationPort, corev1.ProtocolTCP), getNetworkPolicyPort(splunkKVStorePort, corev1.ProtocolTCP)},
+			From:  []networkingv1.NetworkPolicyPeer{getSplunkPodsPeer(map[string]string{labelTypeMap["partof"]: podLabels[labelTypeMap["partof"]]}, false, SplunkSearchHead)},
+		})
+	}
+
+	networkPolicy := &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: networkingv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetSplunkNetworkPolicyName(instanceType, cr.GetName()),
+			Namespace: cr.GetNamespace(),
+			Labels:    podLabels,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: podLabels},
+			Ingress:     ingressRules,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+
+	networkPolicy.SetOwnerReferences(append(networkPolicy.GetOwnerReferences(), splcommon.AsOwner(cr, true)))
+	return networkPolicy
+}
+
+// ApplySplunkNetworkPolicies creates or updates the NetworkPolicies of the Splunk instances when they are enabled,
+// and deletes them otherwise. The generated NetworkPolicies are tracked in status
+func ApplySplunkNetworkPolicies(ctx context.Context, c splcommon.ControllerClient, cr splcommon.MetaObject, spec *enterpriseApi.CommonSplunkSpec, status *[]string, instanceTypes ...InstanceType) error {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplySplunkNetworkPolicies", cr)
+	defer span.End()
+
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("ApplySplunkNetworkPolicies").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
+
+	var networkPolicies []string
+	if spec.NetworkPolicy.Enabled {
+		for _, instanceType := range instanceTypes {
+			networkPolicy := getSplunkNetworkPolicy(cr, spec, instanceType)
+			err := splctrl.ApplyNetworkPolicy(ctx, c, networkPolicy)
+			if err != nil {
+				return fmt.Errorf("unable to apply the network policy %s: %v", networkPolicy.GetName(), err)
+			}
+			networkPolicies = append(networkPolicies, networkPolicy.GetName())
+		}
+	}
+
+	// delete the network policies which are disabled
+	for _, name := range *status {
+		found := false
+		for _, networkPolicy := range networkPolicies {
+			if networkPolicy == name {
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+
+		scopedLog.Info("Deleting the network policy", "networkPolicy", name)
+		networkPolicy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: cr.GetNamespace(),
+			},
+		}
+		err := c.Delete(ctx, networkPolicy)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	*status = networkPolicies
+	return nil
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+// findNetworkPolicyRule returns the ingress rule of the network policy allowing the given port
+func findNetworkPolicyRule(networkPolicy *networkingv1.NetworkPolicy, port int) *networkingv1.NetworkPolicyIngressRule {
+	for i, rule := range networkPolicy.Spec.Ingress {
+		for _, policyPort := range rule.Ports {
+			if policyPort.Port.IntValue() == port {
+				return &networkPolicy.Spec.Ingress[i]
+			}
+		}
+	}
+	return nil
+}
+
+func TestGetSplunkNetworkPolicy(t *testing.T) {
+	cr := enterpriseApi.IndexerCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "IndexerCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "idxc",
+			Namespace: "test",
+		},
+	}
+	cr.Spec.ClusterMasterRef.Name = "cm"
+	hecPeer := networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "logging"}}}
+	cr.Spec.NetworkPolicy = enterpriseApi.NetworkPolicySpec{Enabled: true, HECFrom: []networkingv1.NetworkPolicyPeer{hecPeer}}
+
+	networkPolicy := getSplunkNetworkPolicy(&cr, &cr.Spec.CommonSplunkSpec, SplunkIndexer)
+	if networkPolicy.GetName() != "splunk-idxc-indexer-network-policy" || networkPolicy.Spec.PodSelector.MatchLabels["app.kubernetes.io/part-of"] != "splunk-cm-indexer" {
+		t.Errorf("unexpected network policy %s, pod selector %v", networkPolicy.GetName(), networkPolicy.Spec.PodSelector)
+	}
+
+	// replication is only accepted from the peers of the same indexer cluster
+	rule := findNetworkPolicyRule(networkPolicy, splunkReplicationPort)
+	if rule == nil || len(rule.From) != 1 || rule.From[0].NamespaceSelector != nil || rule.From[0].PodSelector.MatchLabels["app.kubernetes.io/part-of"] != "splunk-cm-indexer" {
+		t.Errorf("unexpected replication rule %+v", rule)
+	}
+
+	// management port is not open to all the Splunk pods
+	rule = findNetworkPolicyRule(networkPolicy, 8089)
+	if rule == nil || len(rule.From) == 0 {
+		t.Fatalf("management port should be restricted, rule: %+v", rule)
+	}
+	for _, peer := range rule.From {
+		if peer.PodSelector == nil || (len(peer.PodSelector.MatchExpressions) == 0 && peer.PodSelector.MatchLabels["name"] != "splunk-operator") {
+			t.Errorf("unexpected management peer %+v", peer)
+		}
+	}
+
+	rule = findNetworkPolicyRule(networkPolicy, 8088)
+	if rule == nil || len(rule.From) != 1 || rule.From[0].NamespaceSelector.MatchLabels["team"] != "logging" {
+		t.Errorf("unexpected HEC rule %+v", rule)
+	}
+
+	// HEC is not reachable when no source is set
+	cr.Spec.NetworkPolicy.HECFrom = nil
+	networkPolicy = getSplunkNetworkPolicy(&cr, &cr.Spec.CommonSplunkSpec, SplunkIndexer)
+	if rule = findNetworkPolicyRule(networkPolicy, 8088); rule != nil {
+		t.Errorf("HEC rule should not be generated, got %+v", rule)
+	}
+
+	// cluster manager accepts the peers of its indexer cluster
+	cm := enterpriseApi.ClusterMaster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ClusterMaster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cm",
+			Namespace: "test",
+		},
+	}
+	networkPolicy = getSplunkNetworkPolicy(&cm, &cm.Spec.CommonSplunkSpec, SplunkClusterManager)
+	rule = findNetworkPolicyRule(networkPolicy, 8089)
+	found := false
+	for _, peer := range rule.From {
+		if peer.PodSelector.MatchLabels["app.kubernetes.io/part-of"] == "splunk-cm-indexer" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("cluster manager should accept its indexers, rule: %+v", rule)
+	}
+
+	if rule = findNetworkPolicyRule(networkPolicy, 9997); rule != nil {
+		t.Errorf("cluster manager should not accept S2S, got %+v", rule)
+	}
+
+	// additional ports are handled like S2S, and disabled ports are not allowed
+	cr.Spec.Ports = []enterpriseApi.SplunkPort{{Name: "http-splunkweb", Disabled: true}, {Name: "udp-syslog", Port: 514, Protocol: corev1.ProtocolUDP}}
+	cr.Spec.NetworkPolicy.S2SFrom = []networkingv1.NetworkPolicyPeer{hecPeer}
+	networkPolicy = getSplunkNetworkPolicy(&cr, &cr.Spec.CommonSplunkSpec, SplunkIndexer)
+	if rule = findNetworkPolicyRule(networkPolicy, 8000); rule != nil {
+		t.Errorf("disabled Splunk Web should not be allowed, got %+v", rule)
+	}
+	rule = findNetworkPolicyRule(networkPolicy, 514)
+	if rule == nil || len(rule.Ports) != 2 || *rule.Ports[1].Protocol != corev1.ProtocolUDP || len(rule.From) != 2 {
+		t.Errorf("unexpected additional port rule %+v", rule)
+	}
+}
+
+func TestApplySplunkNetworkPolicies(t *testing.T) {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+	cr := enterpriseApi.SearchHeadCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "SearchHeadCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+
+	// no client calls when network policies are not enabled
+	err := ApplySplunkNetworkPolicies(ctx, c, &cr, &cr.Spec.CommonSplunkSpec, &cr.Status.NetworkPolicies, SplunkSearchHead, SplunkDeployer)
+	if err != nil || len(c.Calls) != 0 {
+		t.Errorf("ApplySplunkNetworkPolicies should not call the client, error: %v, calls: %v", err, c.Calls)
+	}
+
+	cr.Spec.NetworkPolicy.Enabled = true
+	err = ApplySplunkNetworkPolicies(ctx, c, &cr, &cr.Spec.CommonSplunkSpec, &cr.Status.NetworkPolicies, SplunkSearchHead, SplunkDeployer)
+	if err != nil {
+		t.Fatalf("ApplySplunkNetworkPolicies should not return an error, error: %v", err)
+	}
+
+	if len(cr.Status.NetworkPolicies) != 2 || cr.Status.NetworkPolicies[0] != "splunk-stack1-search-head-network-policy" || cr.Status.NetworkPolicies[1] != "splunk-stack1-deployer-network-policy" {
+		t.Fatalf("unexpected network policies status %v", cr.Status.NetworkPolicies)
+	}
+
+	var networkPolicy networkingv1.NetworkPolicy
+	err = c.Get(ctx, types.NamespacedName{Name: "splunk-stack1-search-head-network-policy", Namespace: "test"}, &networkPolicy)
+	if err != nil {
+		t.Fatalf("unable to get the network policy, error: %v", err)
+	}
+
+	if rule := findNetworkPolicyRule(&networkPolicy, splunkKVStorePort); rule == nil {
+		t.Errorf("search heads should accept the KV store replication, got %+v", networkPolicy.Spec.Ingress)
+	}
+
+	if len(networkPolicy.OwnerReferences) != 1 || networkPolicy.OwnerReferences[0].Kind != "SearchHeadCluster" {
+		t.Errorf("unexpected owner references %+v", networkPolicy.OwnerReferences)
+	}
+
+	// network policies are deleted when disabled
+	cr.Spec.NetworkPolicy.Enabled = false
+	err = ApplySplunkNetworkPolicies(ctx, c, &cr, &cr.Spec.CommonSplunkSpec, &cr.Status.NetworkPolicies, SplunkSearchHead, SplunkDeployer)
+	if err != nil || len(cr.Status.NetworkPolicies) != 0 {
+		t.Fatalf("ApplySplunkNetworkPolicies should delete the network policies, error: %v, status: %v", err, cr.Status.NetworkPolicies)
+	}
+
+	err = c.Get(ctx, types.NamespacedName{Name: "splunk-stack1-deployer-network-policy", Namespace: "test"}, &networkPolicy)
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("network policy should be deleted, error: %v", err)
+	}
+}
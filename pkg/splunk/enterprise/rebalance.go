@@ -0,0 +1,154 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+)
+
+const (
+	// rebalancePhasePending is the phase of a rebalance waiting for the new peers to be searchable
+	rebalancePhasePending = "Pending"
+
+	// rebalancePhaseDeferred is the phase of a rebalance waiting for the end of the maintenance mode or of the rolling restart
+	rebalancePhaseDeferred = "Deferred"
+
+	// rebalancePhaseRunning is the phase of a running rebalance
+	rebalancePhaseRunning = "Running"
+
+	// rebalancePhaseCompleted is the phase of a completed rebalance
+	rebalancePhaseCompleted = "Completed"
+
+	// rebalancePhaseStopped is the phase of a rebalance stopped before its completion
+	rebalancePhaseStopped = "Stopped"
+
+	// rebalancePollInterval is the interval between two checks of a pending or running rebalance
+	rebalancePollInterval = time.Second * 30
+)
+
+// isRebalanceInProgress returns true when a rebalance is waiting to start or running
+func isRebalanceInProgress(status *enterpriseApi.IndexerClusterRebalanceStatus) bool {
+	return status.Phase == rebalancePhasePending || status.Phase == rebalancePhaseDeferred || status.Phase == rebalancePhaseRunning
+}
+
+// getRebalanceDeferReason returns the reason why a rebalance can't run on the indexer cluster, or an empty string
+func getRebalanceDeferReason(cr *enterpriseApi.IndexerCluster) string {
+	if cr.Status.MaintenanceMode {
+		return "the indexer cluster is in maintenance mode"
+	}
+	if cr.Status.RollingRestart {
+		return "the indexer cluster is in a rolling restart"
+	}
+	return ""
+}
+
+// applyRebalance for indexerClusterPodManager starts a data rebalance through the cluster manager once the peers added to
+// the indexer cluster are searchable, and tracks its progress. The rebalance is deferred, and stopped when running, while the
+// cluster is in maintenance mode or in a rolling restart. It returns true while a rebalance is pending or running
+func (mgr *indexerClusterPodManager) applyRebalance(ctx context.Context, eventPublisher *K8EventPublisher) (bool, error) {
+	spec := &mgr.cr.Spec.Rebalance
+	status := &mgr.cr.Status.Rebalance
+	replicas := int32(len(mgr.cr.Status.Peers))
+
+	if status.Phase != rebalancePhaseRunning {
+		// nothing to balance without new peers, the decommission moves the buckets of the removed peers
+		if status.BalancedReplicas == 0 || replicas <= status.BalancedReplicas {
+			status.BalancedReplicas = replicas
+			if isRebalanceInProgress(status) {
+				status.Phase = ""
+				status.Message = ""
+			}
+			return false, nil
+		}
+
+		for _, peer := range mgr.cr.Status.Peers {
+			if !peer.Searchable {
+				status.Phase = rebalancePhasePending
+				status.Message = fmt.Sprintf("waiting for peer %s to be searchable", peer.Name)
+				return true, nil
+			}
+		}
+
+		if reason := getRebalanceDeferReason(mgr.cr); reason != "" {
+			status.Phase = rebalancePhaseDeferred
+			status.Message = reason
+			return true, nil
+		}
+
+		err := mgr.getClusterManagerClient(ctx).StartIndexerClusterRebalance(spec.MaxRuntimeMinutes)
+		if err != nil {
+			return false, fmt.Errorf("could not start the rebalance: %v", err)
+		}
+		status.Phase = rebalancePhaseRunning
+		status.PercentComplete = 0
+		status.StartTime = time.Now().Unix()
+		status.Message = ""
+		mgr.log.Info("Started the rebalance of the indexer cluster", "balancedReplicas", status.BalancedReplicas, "replicas", replicas)
+		if eventPublisher != nil {
+			eventPublisher.Normal(ctx, "Rebalance", fmt.Sprintf("started the rebalance of the buckets over %d peers", replicas))
+		}
+		return true, nil
+	}
+
+	cm := mgr.getClusterManagerClient(ctx)
+	if reason := getRebalanceDeferReason(mgr.cr); reason != "" {
+		err := cm.StopIndexerClusterRebalance()
+		if err != nil {
+			return false, fmt.Errorf("could not stop the rebalance: %v", err)
+		}
+		status.Phase = rebalancePhaseDeferred
+		status.Message = reason
+		mgr.log.Info("Stopped the rebalance of the indexer cluster", "reason", reason)
+		return true, nil
+	}
+
+	rebalanceStatus, err := cm.GetIndexerClusterRebalanceStatus()
+	if err != nil {
+		return false, fmt.Errorf("could not get the rebalance status: %v", err)
+	}
+	status.Message = rebalanceStatus.Message
+	if rebalanceStatus.Running {
+		status.PercentComplete = int32(math.Round(rebalanceStatus.PercentComplete))
+		return true, nil
+	}
+
+	status.Phase = rebalancePhaseCompleted
+	status.PercentComplete = 100
+	status.BalancedReplicas = replicas
+	mgr.log.Info("Completed the rebalance of the indexer cluster", "replicas", replicas)
+	if eventPublisher != nil {
+		eventPublisher.Normal(ctx, "Rebalance", fmt.Sprintf("completed the rebalance of the buckets over %d peers", replicas))
+	}
+	return false, nil
+}
+
+// stopRebalance for indexerClusterPodManager stops the running rebalance when the automatic rebalance is disabled
+func (mgr *indexerClusterPodManager) stopRebalance(ctx context.Context) error {
+	status := &mgr.cr.Status.Rebalance
+	if status.Phase == rebalancePhaseRunning {
+		err := mgr.getClusterManagerClient(ctx).StopIndexerClusterRebalance()
+		if err != nil {
+			return fmt.Errorf("could not stop the rebalance: %v", err)
+		}
+		status.Phase = rebalancePhaseStopped
+		status.Message = "the automatic rebalance is disabled"
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"testing"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+const testRebalanceURL = "https://splunk-master1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/control/control/rebalance_buckets"
+
+func TestIndexerClusterApplyRebalance(t *testing.T) {
+	ctx := context.TODO()
+
+	// the first peers are considered balanced
+	mgr := getIndexerClusterPodManager("TestIndexerClusterApplyRebalance", nil, &spltest.MockHTTPClient{}, 2)
+	mgr.c = spltest.NewMockClient()
+	mgr.cr.Spec.Rebalance.Enabled = true
+	mgr.cr.Status.Peers = []enterpriseApi.IndexerClusterMemberStatus{{Name: "splunk-stack1-indexer-0", Searchable: true}, {Name: "splunk-stack1-indexer-1", Searchable: true}}
+	inProgress, err := mgr.applyRebalance(ctx, nil)
+	if err != nil || inProgress || mgr.cr.Status.Rebalance.BalancedReplicas != 2 {
+		t.Errorf("applyRebalance should not start a rebalance, in progress: %t, error: %v, status: %+v", inProgress, err, mgr.cr.Status.Rebalance)
+	}
+
+	// waits for the new peer to be searchable
+	mgr.cr.Status.Peers = append(mgr.cr.Status.Peers, enterpriseApi.IndexerClusterMemberStatus{Name: "splunk-stack1-indexer-2"})
+	inProgress, err = mgr.applyRebalance(ctx, nil)
+	if err != nil || !inProgress || mgr.cr.Status.Rebalance.Phase != rebalancePhasePending {
+		t.Errorf("applyRebalance should wait for the new peer, in progress: %t, error: %v, status: %+v", inProgress, err, mgr.cr.Status.Rebalance)
+	}
+
+	// deferred in maintenance mode
+	mgr.cr.Status.Peers[2].Searchable = true
+	mgr.cr.Status.MaintenanceMode = true
+	inProgress, err = mgr.applyRebalance(ctx, nil)
+	if err != nil || !inProgress || mgr.cr.Status.Rebalance.Phase != rebalancePhaseDeferred {
+		t.Errorf("applyRebalance should be deferred, in progress: %t, error: %v, status: %+v", inProgress, err, mgr.cr.Status.Rebalance)
+	}
+
+	// started once the maintenance mode is over
+	mgr.cr.Status.MaintenanceMode = false
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	mockSplunkClient.AddHandlers(spltest.MockHTTPHandler{Method: "POST", URL: testRebalanceURL, Status: 200, Body: `{"messages":[{"type":"INFO","text":"Data rebalance started"}]}`})
+	mgr = getIndexerClusterPodManager("TestIndexerClusterApplyRebalance", nil, mockSplunkClient, 3)
+	mgr.c = spltest.NewMockClient()
+	mgr.cr.Spec.Rebalance.Enabled = true
+	mgr.cr.Status.Peers = []enterpriseApi.IndexerClusterMemberStatus{{Name: "splunk-stack1-indexer-0", Searchable: true}, {Name: "splunk-stack1-indexer-1", Searchable: true}, {Name: "splunk-stack1-indexer-2", Searchable: true}}
+	mgr.cr.Status.Rebalance = enterpriseApi.IndexerClusterRebalanceStatus{Phase: rebalancePhaseDeferred, BalancedReplicas: 2}
+	inProgress, err = mgr.applyRebalance(ctx, nil)
+	if err != nil || !inProgress || mgr.cr.Status.Rebalance.Phase != rebalancePhaseRunning || mgr.cr.Status.Rebalance.StartTime == 0 {
+		t.Errorf("applyRebalance should start the rebalance, in progress: %t, error: %v, status: %+v", inProgress, err, mgr.cr.Status.Rebalance)
+	}
+	mockSplunkClient.CheckRequests(t, "TestIndexerClusterApplyRebalance")
+
+	// progress of the running rebalance
+	mockSplunkClient = &spltest.MockHTTPClient{}
+	mockSplunkClient.AddHandlers(spltest.MockHTTPHandler{Method: "POST", URL: testRebalanceURL, Status: 200, Body: `{"messages":[{"type":"INFO","text":"Data rebalance is running. 40% complete"}]}`})
+	runningMgr := getIndexerClusterPodManager("TestIndexerClusterApplyRebalance", nil, mockSplunkClient, 3)
+	runningMgr.c = spltest.NewMockClient()
+	runningMgr.cr = mgr.cr
+	inProgress, err = runningMgr.applyRebalance(ctx, nil)
+	if err != nil || !inProgress || mgr.cr.Status.Rebalance.PercentComplete != 40 {
+		t.Errorf("applyRebalance should report the progress, in progress: %t, error: %v, status: %+v", inProgress, err, mgr.cr.Status.Rebalance)
+	}
+	mockSplunkClient.CheckRequests(t, "TestIndexerClusterApplyRebalance")
+
+	// completion of the rebalance
+	mockSplunkClient = &spltest.MockHTTPClient{}
+	mockSplunkClient.AddHandlers(spltest.MockHTTPHandler{Method: "POST", URL: testRebalanceURL, Status: 200, Body: `{"messages":[{"type":"INFO","text":"Data rebalance is not running"}]}`})
+	runningMgr = getIndexerClusterPodManager("TestIndexerClusterApplyRebalance", nil, mockSplunkClient, 3)
+	runningMgr.c = spltest.NewMockClient()
+	runningMgr.cr = mgr.cr
+	inProgress, err = runningMgr.applyRebalance(ctx, nil)
+	if err != nil || inProgress || mgr.cr.Status.Rebalance.Phase != rebalancePhaseCompleted || mgr.cr.Status.Rebalance.BalancedReplicas != 3 {
+		t.Errorf("applyRebalance should complete the rebalance, in progress: %t, error: %v, status: %+v", inProgress, err, mgr.cr.Status.Rebalance)
+	}
+	mockSplunkClient.CheckRequests(t, "TestIndexerClusterApplyRebalance")
+}
+
+func TestIndexerClusterStopRebalance(t *testing.T) {
+	ctx := context.TODO()
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	mockSplunkClient.AddHandlers(spltest.MockHTTPHandler{Method: "POST", URL: testRebalanceURL, Status: 200, Body: `{"messages":[{"type":"INFO","text":"Data rebalance stopped"}]}`})
+	mgr := getIndexerClusterPodManager("TestIndexerClusterStopRebalance", nil, mockSplunkClient, 3)
+	mgr.c = spltest.NewMockClient()
+
+	// the running rebalance is stopped in a rolling restart
+	mgr.cr.Spec.Rebalance.Enabled = true
+	mgr.cr.Status.RollingRestart = true
+	mgr.cr.Status.Rebalance = enterpriseApi.IndexerClusterRebalanceStatus{Phase: rebalancePhaseRunning, BalancedReplicas: 2}
+	inProgress, err := mgr.applyRebalance(ctx, nil)
+	if err != nil || !inProgress || mgr.cr.Status.Rebalance.Phase != rebalancePhaseDeferred {
+		t.Errorf("applyRebalance should stop the rebalance, in progress: %t, error: %v, status: %+v", inProgress, err, mgr.cr.Status.Rebalance)
+	}
+	mockSplunkClient.CheckRequests(t, "TestIndexerClusterStopRebalance")
+
+	// no request when no rebalance is running
+	mockSplunkClient = &spltest.MockHTTPClient{}
+	mgr = getIndexerClusterPodManager("TestIndexerClusterStopRebalance", nil, mockSplunkClient, 3)
+	mgr.c = spltest.NewMockClient()
+	err = mgr.stopRebalance(ctx)
+	if err != nil {
+		t.Errorf("stopRebalance returned error: %v", err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestIndexerClusterStopRebalance")
+}
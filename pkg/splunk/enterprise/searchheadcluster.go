@@ -28,6 +28,7 @@ import (
 	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
 	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -40,6 +41,9 @@ import (
 
 // ApplySearchHeadCluster reconciles the state for a Splunk Enterprise search head cluster.
 func ApplySearchHeadCluster(ctx context.Context, client splcommon.ControllerClient, cr *enterpriseApi.SearchHeadCluster) (reconcile.Result, error) {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplySearchHeadCluster", cr)
+	defer span.End()
+
 	// unless modified, reconcile for this object will be requeued after 5 seconds
 	result := reconcile.Result{
 		Requeue:      true,
@@ -55,6 +59,13 @@ func ApplySearchHeadCluster(ctx context.Context, client splcommon.ControllerClie
 		return result, err
 	}
 
+	// the number of members is decided by the autoscaling when enabled
+	if cr.Spec.Autoscaling.Enabled {
+		cr.Spec.Replicas = getAutoscaledReplicas(&cr.Spec.Autoscaling.AutoscalingSpec, &cr.Status.Autoscaling.AutoscalingStatus, cr.Spec.Replicas)
+	} else {
+		cr.Status.Autoscaling = enterpriseApi.SearchHeadClusterAutoscalingStatus{}
+	}
+
 	// If needed, Migrate the app framework status
 	err = checkAndMigrateAppDeployStatus(ctx, client, cr, &cr.Status.AppContext, &cr.Spec.AppFrameworkConfig, false)
 	if err != nil {
@@ -94,6 +105,11 @@ func ApplySearchHeadCluster(ctx context.Context, client splcommon.ControllerClie
 	// Update the CR Status
 	defer updateCRStatus(ctx, client, cr)
 
+	// detect the drift of the objects applied below, and report it in the status before it is updated
+	driftRecorder := splctrl.NewDriftRecorder(cr.Spec.DriftPolicy)
+	ctx = splctrl.WithDriftRecorder(ctx, driftRecorder)
+	defer reportDrift(ctx, eventPublisher, driftRecorder, cr.GetGeneration(), &cr.Status.Conditions)
+
 	// create or update general config resources
 	namespaceScopedSecret, err := ApplySplunkConfig(ctx, client, cr, cr.Spec.CommonSplunkSpec, SplunkSearchHead)
 	if err != nil {
@@ -105,7 +121,7 @@ func ApplySearchHeadCluster(ctx context.Context, client splcommon.ControllerClie
 	// check if deletion has been requested
 	if cr.ObjectMeta.DeletionTimestamp != nil {
 		if cr.Spec.MonitoringConsoleRef.Name != "" {
-			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), cr.Spec.MonitoringConsoleRef.Name, getSearchHeadEnv(cr), false)
+			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), getMonitoringConsoleRefKey(cr.GetNamespace(), cr.Spec.MonitoringConsoleRef), getSearchHeadEnv(cr), false)
 			if err != nil {
 				return result, err
 			}
@@ -147,6 +163,20 @@ func ApplySearchHeadCluster(ctx context.Context, client splcommon.ControllerClie
 		return result, err
 	}
 
+	// create or update the objects exposing Splunk Web and HEC
+	err = ApplySplunkExpose(ctx, client, cr, &cr.Spec.CommonSplunkSpec, SplunkSearchHead, &cr.Status.Expose)
+	if err != nil {
+		eventPublisher.Warning(ctx, "ApplySplunkExpose", fmt.Sprintf("create/update expose objects failed %s", err.Error()))
+		return result, err
+	}
+
+	// create or update the network policies of the Splunk instances
+	err = ApplySplunkNetworkPolicies(ctx, client, cr, &cr.Spec.CommonSplunkSpec, &cr.Status.NetworkPolicies, SplunkSearchHead, SplunkDeployer)
+	if err != nil {
+		eventPublisher.Warning(ctx, "ApplySplunkNetworkPolicies", fmt.Sprintf("create/update network policies failed %s", err.Error()))
+		return result, err
+	}
+
 	// create or update a deployer service
 	err = splctrl.ApplyService(ctx, client, getSplunkService(ctx, cr, &cr.Spec.CommonSplunkSpec, SplunkDeployer, false))
 	if err != nil {
@@ -159,6 +189,17 @@ func ApplySearchHeadCluster(ctx context.Context, client splcommon.ControllerClie
 		return result, err
 	}
 
+	// wait for the previous stages of an ordered upgrade of the stack
+	upgradeReady, err := checkOrderedUpgrade(ctx, client, cr, &cr.Spec.CommonSplunkSpec, SplunkDeployer, &cr.Status.UpgradeStage)
+	if err != nil {
+		return result, err
+	}
+	if !upgradeReady {
+		cr.Status.Phase = enterpriseApi.PhasePending
+		cr.Status.DeployerPhase = enterpriseApi.PhasePending
+		return result, nil
+	}
+
 	deployerManager := splctrl.DefaultStatefulSetPodManager{}
 	phase, err := deployerManager.Update(ctx, client, statefulSet, 1)
 	if err != nil {
@@ -178,12 +219,23 @@ func ApplySearchHeadCluster(ctx context.Context, client splcommon.ControllerClie
 		return result, err
 	}
 
+	// the search heads are upgraded once the deployer is ready on the new image
+	upgradeReady, err = checkOrderedUpgrade(ctx, client, cr, &cr.Spec.CommonSplunkSpec, SplunkSearchHead, &cr.Status.UpgradeStage)
+	if err != nil {
+		return result, err
+	}
+	if !upgradeReady {
+		cr.Status.Phase = enterpriseApi.PhasePending
+		return result, nil
+	}
+
 	mgr := newSerachHeadClusterPodManager(client, scopedLog, cr, namespaceScopedSecret, splclient.NewSplunkClient)
 	phase, err = mgr.Update(ctx, client, statefulSet, cr.Spec.Replicas)
 	if err != nil {
 		return result, err
 	}
 	cr.Status.Phase = phase
+	updateUpgradeStage(&cr.Status.UpgradeStage, SplunkSearchHead, phase)
 
 	var finalResult *reconcile.Result
 	if cr.Status.DeployerPhase == enterpriseApi.PhaseReady {
@@ -199,7 +251,11 @@ func ApplySearchHeadCluster(ctx context.Context, client splcommon.ControllerClie
 			scopedLog.Error(err, "Error in deleting automated monitoring console resource")
 		}
 		if cr.Spec.MonitoringConsoleRef.Name != "" {
-			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), cr.Spec.MonitoringConsoleRef.Name, getSearchHeadEnv(cr), true)
+			err = addMonitoringConsoleRefFinalizer(ctx, client, cr, cr.Spec.MonitoringConsoleRef)
+			if err != nil {
+				return result, err
+			}
+			_, err = ApplyMonitoringConsoleEnvConfigMap(ctx, client, cr.GetNamespace(), cr.GetName(), getMonitoringConsoleRefKey(cr.GetNamespace(), cr.Spec.MonitoringConsoleRef), getSearchHeadEnv(cr), true)
 			if err != nil {
 				return result, err
 			}
@@ -215,6 +271,15 @@ func ApplySearchHeadCluster(ctx context.Context, client splcommon.ControllerClie
 		if finalResult != nil {
 			result = *finalResult
 		}
+
+		// poll the autoscaling metrics periodically, and apply the scaling decisions right away
+		if cr.Spec.Autoscaling.Enabled {
+			scaled, err := mgr.applyAutoscaling(ctx, eventPublisher)
+			if err != nil {
+				eventPublisher.Warning(ctx, "applyAutoscaling", fmt.Sprintf("autoscaling of search head cluster members failed %s", err.Error()))
+			}
+			result = getAutoscalingResult(result, scaled)
+		}
 	}
 	// RequeueAfter if greater than 0, tells the Controller to requeue the reconcile key after the Duration.
 	// Implies that Requeue is true, there is no need to set Requeue to true at the same time as RequeueAfter.
@@ -247,6 +312,9 @@ var newSerachHeadClusterPodManager = func(client splcommon.ControllerClient, log
 
 // ApplyShcSecret checks if any of the search heads have a different shc_secret from namespace scoped secret and changes it
 func ApplyShcSecret(ctx context.Context, mgr *searchHeadClusterPodManager, replicas int32, podExecClient splutil.PodExecClientImpl) error {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplyShcSecret", mgr.cr)
+	defer span.End()
+
 	// Get namespace scoped secret
 	namespaceSecret, err := splutil.ApplyNamespaceScopedSecretObject(ctx, mgr.c, mgr.cr.GetNamespace())
 	if err != nil {
@@ -529,7 +597,7 @@ func (mgr *searchHeadClusterPodManager) getClient(ctx context.Context, n int32)
 		scopedLog.Error(err, "Couldn't retrieve the admin password from Pod")
 	}
 
-	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", adminPwd)
+	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", adminPwd).WithContext(ctx)
 }
 
 // updateStatus for searchHeadClusterPodManager uses the REST API to update the status for a SearcHead custom resource
@@ -621,8 +689,13 @@ func validateSearchHeadClusterSpec(ctx context.Context, c splcommon.ControllerCl
 		cr.Spec.Replicas = 3
 	}
 
+	err := validateSearchHeadClusterAutoscalingSpec(&cr.Spec.Autoscaling)
+	if err != nil {
+		return err
+	}
+
 	if !reflect.DeepEqual(cr.Status.AppContext.AppFrameworkConfig, cr.Spec.AppFrameworkConfig) {
-		err := ValidateAppFrameworkSpec(ctx, &cr.Spec.AppFrameworkConfig, &cr.Status.AppContext, false)
+		err = ValidateAppFrameworkSpec(ctx, &cr.Spec.AppFrameworkConfig, &cr.Status.AppContext, false)
 		if err != nil {
 			return err
 		}
@@ -16,6 +16,7 @@
 package enterprise
 
 import (
+	"container/list"
 	"context"
 	"sync"
 	"time"
@@ -38,8 +39,24 @@ const (
 
 	// Max. number of retries to update the CR Status
 	maxRetryCountForCRStatusUpdate = 10
+
+	// Interval to refresh the license status from the license manager
+	licenseStatusCheckInterval = 5 * time.Minute
+
+	// Default percentage of the license quota for the license usage warning
+	defaultLicenseUsageWarningPercent = 80
+
+	// Default number of days before the license expiry for the license expiry warning
+	defaultLicenseExpiryWarningDays = 30
 )
 
+// licenseViolationCategories are the licenser message categories counted as license violations
+var licenseViolationCategories = map[string]bool{
+	"pool_over_quota":  true,
+	"stack_over_quota": true,
+	"license_window":   true,
+}
+
 // InstanceType is used to represent the type of Splunk instance (search head, indexer, etc).
 type InstanceType string
 
@@ -65,6 +82,20 @@ const (
 	// SplunkMonitoringConsole is a single instance of Splunk monitor for mc
 	SplunkMonitoringConsole InstanceType = "monitoring-console"
 
+	// types of the objects generated to expose the Splunk Web and HEC ports
+	exposeTypeIngress = "Ingress"
+	exposeTypeRoute   = "Route"
+	exposeTypeGateway = "Gateway"
+
+	// exposeHECPath is the path prefix routed to the HEC port
+	exposeHECPath = "/services/collector"
+
+	// replication port of the indexer and search head cluster members
+	splunkReplicationPort = 9887
+
+	// KV store port, replicated between the search head cluster members
+	splunkKVStorePort = 8191
+
 	// TmpAppDownloadDir is the Operator directory for app framework, when there is no explicit volume specified
 	TmpAppDownloadDir string = "/tmp/appframework/"
 )
@@ -75,18 +106,83 @@ type commonResourceTracker struct {
 
 	// map of resource name:mutex, so that we can serialize get/create/update to common resources such as secrets/configMaps
 	mutexMap map[string]*sync.Mutex
+
+	// map of resource name:current holder of the resource mutex
+	mutexHolders map[string]resourceMutexHolder
+}
+
+// resourceMutexHolder identifies the CR and the function holding a resource mutex
+type resourceMutexHolder struct {
+	cr       string
+	function string
+	since    time.Time
 }
 
 type globalResourceTracker struct {
 	storage *storageTracker
 
 	commonResourceTracker *commonResourceTracker
+
+	// app packages shared across the CRs
+	appPkgCache *appPkgCache
+}
+
+// appPkgCacheKey identifies an app package version on the remote storage
+type appPkgCacheKey struct {
+	endpoint string
+	bucket   string
+	key      string
+	etag     string
+}
+
+// appPkgCacheEntryState represents the state of an app package in the cache
+type appPkgCacheEntryState uint8
+
+const (
+	// app package download is in progress
+	appPkgCacheEntryDownloading appPkgCacheEntryState = iota + 1
+
+	// app package is available on the operator pod
+	appPkgCacheEntryReady
+)
+
+type appPkgCacheEntry struct {
+	key appPkgCacheKey
+
+	// current state of the app package
+	state appPkgCacheEntryState
+
+	// storage reserved for the app package
+	size uint64
+
+	// CR app sources currently referring to this app package
+	holders map[string]struct{}
+
+	// position in the LRU list
+	lruElem *list.Element
+}
+
+type appPkgCache struct {
+	// mutex to serialize the access to the cache
+	mutex sync.Mutex
+
+	// map of app package key:entry
+	entries map[appPkgCacheKey]*appPkgCacheEntry
+
+	// LRU list of entries, most recently used at the front
+	lru *list.List
+
+	// used to clean up the cache directory left behind by a previous operator run
+	cleanupOnce sync.Once
 }
 
 type storageTracker struct {
 	// represents the available disk space on operator pod
 	availableDiskSpace uint64
 
+	// disk space reserved by the app packages, being downloaded or available on the operator pod
+	reservedDiskSpace uint64
+
 	// mutex to serialize the access
 	mutex sync.Mutex
 }
@@ -130,6 +226,12 @@ type PipelinePhase struct {
 	q            []*PipelineWorker
 	msgChannel   chan *PipelineWorker
 	workerWaiter sync.WaitGroup
+
+	// install slots of each replica, used by the install phase only
+	installTracker []chan struct{}
+
+	// snapshot of the workers, published by the phase manager for the debug endpoint
+	debugWorkers []PipelineWorkerDebugInfo
 }
 
 // AppInstallPipeline defines the pipeline for the installation activity
@@ -146,6 +248,10 @@ type AppInstallPipeline struct {
 	// Reference to app deploy context
 	appDeployContext *enterpriseApi.AppDeploymentContext
 
+	// serializes the access to the app IDs, dependencies and dependency errors of the apps, updated by the
+	// download phase and validated by the install phase
+	dependencyMutex sync.Mutex
+
 	// Scheduler entry time
 	afwEntryTime int64
 
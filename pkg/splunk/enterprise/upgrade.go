@@ -0,0 +1,297 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+)
+
+// Stages of an ordered upgrade of a Splunk stack, in the order supported by Splunk
+const (
+	upgradeStageLicenseManager    = "LicenseManager"
+	upgradeStageClusterManager    = "ClusterManager"
+	upgradeStageSearchHeads       = "SearchHeads"
+	upgradeStageIndexers          = "Indexers"
+	upgradeStageMonitoringConsole = "MonitoringConsole"
+)
+
+// getUpgradeStage returns the stage of an instance type in an ordered upgrade
+func getUpgradeStage(instanceType InstanceType) string {
+	switch instanceType {
+	case SplunkLicenseManager:
+		return upgradeStageLicenseManager
+	case SplunkClusterManager:
+		return upgradeStageClusterManager
+	case SplunkIndexer:
+		return upgradeStageIndexers
+	case SplunkMonitoringConsole:
+		return upgradeStageMonitoringConsole
+	default:
+		// standalone instances, search head cluster deployers and members
+		return upgradeStageSearchHeads
+	}
+}
+
+// checkOrderedUpgrade returns true when the statefulset of a custom resource can be updated. With an ordered upgrade, a new
+// image is only applied once the custom resources of the previous stages are ready on it. stage is set to the stage the
+// custom resource is waiting for, or to its own stage while its statefulset is upgraded
+func checkOrderedUpgrade(ctx context.Context, c splcommon.ControllerClient, cr splcommon.MetaObject, spec *enterpriseApi.CommonSplunkSpec, instanceType InstanceType, stage *string) (bool, error) {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("checkOrderedUpgrade").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
+
+	ownStage := getUpgradeStage(instanceType)
+	if !spec.OrderedUpgrade {
+		*stage = ""
+		return true, nil
+	}
+
+	upgraded, err := isStatefulSetOnImage(ctx, c, cr.GetNamespace(), GetSplunkStatefulsetName(instanceType, cr.GetName()), spec.Image)
+	if err != nil {
+		return false, err
+	}
+	if upgraded {
+		// the own stage is cleared by updateUpgradeStage once the custom resource is ready
+		if *stage != ownStage {
+			*stage = ""
+		}
+		return true, nil
+	}
+
+	pendingStage, err := getPendingUpgradeStage(ctx, c, cr, spec, instanceType)
+	if err != nil {
+		return false, err
+	}
+	if pendingStage != "" {
+		scopedLog.Info("Waiting for the previous stage of the upgrade", "stage", pendingStage, "image", spec.Image)
+		*stage = pendingStage
+		return false, nil
+	}
+
+	*stage = ownStage
+	return true, nil
+}
+
+// updateUpgradeStage clears the stage of a custom resource once it is ready on its new image
+func updateUpgradeStage(stage *string, instanceType InstanceType, phase enterpriseApi.Phase) {
+	if *stage == getUpgradeStage(instanceType) && phase == enterpriseApi.PhaseReady {
+		*stage = ""
+	}
+}
+
+// isStatefulSetOnImage returns true when a statefulset doesn't exist yet, or when its pod template runs image
+func isStatefulSetOnImage(ctx context.Context, c splcommon.ControllerClient, namespace string, name string, image string) (bool, error) {
+	statefulSet := &appsv1.StatefulSet{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, statefulSet)
+	if k8serrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	containers := statefulSet.Spec.Template.Spec.Containers
+	return len(containers) == 0 || containers[0].Image == image, nil
+}
+
+// isUpgradedTo returns true when a custom resource is ready and its statefulset has rolled out image to all its pods
+func isUpgradedTo(ctx context.Context, c splcommon.ControllerClient, phase enterpriseApi.Phase, namespace string, statefulSetName string, image string) (bool, error) {
+	if phase != enterpriseApi.PhaseReady {
+		return false, nil
+	}
+	statefulSet := &appsv1.StatefulSet{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: statefulSetName}, statefulSet)
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	containers := statefulSet.Spec.Template.Spec.Containers
+	if len(containers) == 0 || containers[0].Image != image {
+		return false, nil
+	}
+	return isStatefulSetRolledOut(statefulSet), nil
+}
+
+// isStatefulSetRolledOut returns true when the statefulset controller has observed the latest spec of a statefulset, and
+// all its pods run the latest revision
+func isStatefulSetRolledOut(statefulSet *appsv1.StatefulSet) bool {
+	if statefulSet.Status.ObservedGeneration < statefulSet.GetGeneration() {
+		return false
+	}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	if statefulSet.Status.UpdatedReplicas != replicas {
+		return false
+	}
+	// the statefulset controller only moves the current revision to the update revision for the RollingUpdate strategy,
+	// with the OnDelete strategy of the operator the updated replicas tell that all the pods were recycled
+	if statefulSet.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return true
+	}
+	return statefulSet.Status.UpdateRevision == statefulSet.Status.CurrentRevision
+}
+
+// getRefNamespacedName returns the namespaced name of a reference, which defaults to the namespace of the custom resource
+func getRefNamespacedName(cr splcommon.MetaObject, ref corev1.ObjectReference) types.NamespacedName {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = cr.GetNamespace()
+	}
+	return types.NamespacedName{Namespace: namespace, Name: ref.Name}
+}
+
+// getPendingUpgradeStage returns the first stage before the one of the custom resource which isn't ready on the image of the
+// spec, or an empty string when the custom resource can be upgraded
+func getPendingUpgradeStage(ctx context.Context, c splcommon.ControllerClient, cr splcommon.MetaObject, spec *enterpriseApi.CommonSplunkSpec, instanceType InstanceType) (string, error) {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("getPendingUpgradeStage").WithValues("name", cr.GetName(), "namespace", cr.GetNamespace())
+	image := spec.Image
+
+	// license manager
+	if instanceType != SplunkLicenseManager && spec.LicenseMasterRef.Name != "" {
+		namespacedName := getRefNamespacedName(cr, spec.LicenseMasterRef)
+		lm, err := getLicenseManagerCR(ctx, c, namespacedName)
+		if k8serrors.IsNotFound(err) {
+			// a missing license manager has nothing to upgrade first, so it doesn't hold the upgrade
+			scopedLog.Info("Skipping the upgrade stage of the missing license manager", "licenseManager", namespacedName.String())
+		} else if err != nil {
+			return "", err
+		} else {
+			ready, err := isUpgradedTo(ctx, c, lm.Status.Phase, namespacedName.Namespace, GetSplunkStatefulsetName(SplunkLicenseManager, lm.GetName()), image)
+			if err != nil || !ready {
+				return upgradeStageLicenseManager, err
+			}
+		}
+	}
+
+	// cluster manager
+	if instanceType != SplunkLicenseManager && instanceType != SplunkClusterManager && spec.ClusterMasterRef.Name != "" {
+		namespacedName := getRefNamespacedName(cr, spec.ClusterMasterRef)
+		cm, err := getClusterManagerCR(ctx, c, namespacedName)
+		if k8serrors.IsNotFound(err) {
+			scopedLog.Info("Skipping the upgrade stage of the missing cluster manager", "clusterManager", namespacedName.String())
+		} else if err != nil {
+			return "", err
+		} else {
+			ready, err := isUpgradedTo(ctx, c, cm.Status.Phase, namespacedName.Namespace, GetSplunkStatefulsetName(SplunkClusterManager, cm.GetName()), image)
+			if err != nil || !ready {
+				return upgradeStageClusterManager, err
+			}
+		}
+	}
+
+	switch instanceType {
+	case SplunkSearchHead:
+		// the members of a search head cluster are upgraded after the deployer
+		shc := cr.(*enterpriseApi.SearchHeadCluster)
+		ready, err := isUpgradedTo(ctx, c, shc.Status.DeployerPhase, shc.GetNamespace(), GetSplunkStatefulsetName(SplunkDeployer, shc.GetName()), image)
+		if err != nil || !ready {
+			return upgradeStageSearchHeads, err
+		}
+
+	case SplunkIndexer:
+		// the indexers are upgraded after the search heads of their cluster manager
+		if spec.ClusterMasterRef.Name == "" {
+			return "", nil
+		}
+		return getPendingStackStage(ctx, c, cr.GetNamespace(), image, false, func(obj splcommon.MetaObject, objSpec *enterpriseApi.CommonSplunkSpec) bool {
+			return objSpec.ClusterMasterRef.Name == spec.ClusterMasterRef.Name
+		})
+
+	case SplunkMonitoringConsole:
+		// the monitoring console is upgraded after the search heads and the indexers it monitors
+		return getPendingStackStage(ctx, c, cr.GetNamespace(), image, true, func(obj splcommon.MetaObject, objSpec *enterpriseApi.CommonSplunkSpec) bool {
+			ref := getRefNamespacedName(obj, objSpec.MonitoringConsoleRef)
+			return ref.Name == cr.GetName() && ref.Namespace == cr.GetNamespace()
+		})
+	}
+
+	return "", nil
+}
+
+// getPendingStackStage returns the stage of the first search head cluster, standalone or indexer cluster of a namespace
+// selected by filter, which isn't ready on image. Indexer clusters are only checked when withIndexers is true
+func getPendingStackStage(ctx context.Context, c splcommon.ControllerClient, namespace string, image string, withIndexers bool, filter func(splcommon.MetaObject, *enterpriseApi.CommonSplunkSpec) bool) (string, error) {
+	listOpts := []client.ListOption{client.InNamespace(namespace)}
+
+	shcList := &enterpriseApi.SearchHeadClusterList{}
+	err := c.List(ctx, shcList, listOpts...)
+	if err != nil {
+		return "", err
+	}
+	for i := range shcList.Items {
+		shc := &shcList.Items[i]
+		if !filter(shc, &shc.Spec.CommonSplunkSpec) {
+			continue
+		}
+		ready, err := isUpgradedTo(ctx, c, shc.Status.DeployerPhase, namespace, GetSplunkStatefulsetName(SplunkDeployer, shc.GetName()), image)
+		if err == nil && ready {
+			ready, err = isUpgradedTo(ctx, c, shc.Status.Phase, namespace, GetSplunkStatefulsetName(SplunkSearchHead, shc.GetName()), image)
+		}
+		if err != nil || !ready {
+			return upgradeStageSearchHeads, err
+		}
+	}
+
+	standaloneList := &enterpriseApi.StandaloneList{}
+	err = c.List(ctx, standaloneList, listOpts...)
+	if err != nil {
+		return "", err
+	}
+	for i := range standaloneList.Items {
+		standalone := &standaloneList.Items[i]
+		if !filter(standalone, &standalone.Spec.CommonSplunkSpec) {
+			continue
+		}
+		ready, err := isUpgradedTo(ctx, c, standalone.Status.Phase, namespace, GetSplunkStatefulsetName(SplunkStandalone, standalone.GetName()), image)
+		if err != nil || !ready {
+			return upgradeStageSearchHeads, err
+		}
+	}
+
+	if !withIndexers {
+		return "", nil
+	}
+
+	idxcList := &enterpriseApi.IndexerClusterList{}
+	err = c.List(ctx, idxcList, listOpts...)
+	if err != nil {
+		return "", err
+	}
+	for i := range idxcList.Items {
+		idxc := &idxcList.Items[i]
+		if !filter(idxc, &idxc.Spec.CommonSplunkSpec) {
+			continue
+		}
+		ready, err := isUpgradedTo(ctx, c, idxc.Status.Phase, namespace, GetSplunkStatefulsetName(SplunkIndexer, idxc.GetName()), image)
+		if err != nil || !ready {
+			return upgradeStageIndexers, err
+		}
+	}
+
+	return "", nil
+}
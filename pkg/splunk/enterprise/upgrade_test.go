@@ -0,0 +1,232 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+)
+
+func newUpgradeTestStatefulSet(name string, image string) *appsv1.StatefulSet {
+	replicas := int32(1)
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  "test",
+			Generation: 1,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "splunk", Image: image}},
+				},
+			},
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType},
+		},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 1,
+			Replicas:           replicas,
+			ReadyReplicas:      replicas,
+			UpdatedReplicas:    replicas,
+			CurrentRevision:    "v1",
+			UpdateRevision:     "v1",
+		},
+	}
+}
+
+func setUpgradeTestImage(ctx context.Context, t *testing.T, c client.Client, name string, image string) {
+	statefulSet := &appsv1.StatefulSet{}
+	err := c.Get(ctx, client.ObjectKey{Namespace: "test", Name: name}, statefulSet)
+	if err != nil {
+		t.Fatalf("Get(%s) returned error: %v", name, err)
+	}
+	statefulSet.Spec.Template.Spec.Containers[0].Image = image
+	err = c.Update(ctx, statefulSet)
+	if err != nil {
+		t.Fatalf("Update(%s) returned error: %v", name, err)
+	}
+}
+
+func setUpgradeTestUpdatedReplicas(ctx context.Context, t *testing.T, c client.Client, name string, updatedReplicas int32) {
+	statefulSet := &appsv1.StatefulSet{}
+	err := c.Get(ctx, client.ObjectKey{Namespace: "test", Name: name}, statefulSet)
+	if err != nil {
+		t.Fatalf("Get(%s) returned error: %v", name, err)
+	}
+	statefulSet.Status.UpdatedReplicas = updatedReplicas
+	err = c.Status().Update(ctx, statefulSet)
+	if err != nil {
+		t.Fatalf("Update(%s) returned error: %v", name, err)
+	}
+}
+
+func TestCheckOrderedUpgrade(t *testing.T) {
+	ctx := context.TODO()
+	utilruntime.Must(enterpriseApi.AddToScheme(clientgoscheme.Scheme))
+	utilruntime.Must(enterpriseApiV4.AddToScheme(clientgoscheme.Scheme))
+
+	lm := &enterpriseApi.LicenseMaster{
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+		Status:     enterpriseApi.LicenseMasterStatus{Phase: enterpriseApi.PhaseReady},
+	}
+	cm := &enterpriseApi.ClusterMaster{
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+		Spec: enterpriseApi.ClusterMasterSpec{
+			CommonSplunkSpec: enterpriseApi.CommonSplunkSpec{
+				Spec:             enterpriseApi.Spec{Image: "splunk/splunk:new"},
+				LicenseMasterRef: corev1.ObjectReference{Name: "stack1"},
+				OrderedUpgrade:   true,
+			},
+		},
+		Status: enterpriseApi.ClusterMasterStatus{Phase: enterpriseApi.PhaseReady},
+	}
+	shc := &enterpriseApi.SearchHeadCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+		Spec: enterpriseApi.SearchHeadClusterSpec{
+			CommonSplunkSpec: enterpriseApi.CommonSplunkSpec{
+				ClusterMasterRef: corev1.ObjectReference{Name: "stack1"},
+			},
+		},
+		Status: enterpriseApi.SearchHeadClusterStatus{Phase: enterpriseApi.PhaseReady, DeployerPhase: enterpriseApi.PhaseReady},
+	}
+	idxc := &enterpriseApi.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+		Spec: enterpriseApi.IndexerClusterSpec{
+			CommonSplunkSpec: enterpriseApi.CommonSplunkSpec{
+				Spec:             enterpriseApi.Spec{Image: "splunk/splunk:new"},
+				ClusterMasterRef: corev1.ObjectReference{Name: "stack1"},
+				OrderedUpgrade:   true,
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithObjects(
+		lm, cm, shc, idxc,
+		newUpgradeTestStatefulSet(GetSplunkStatefulsetName(SplunkLicenseManager, "stack1"), "splunk/splunk:old"),
+		newUpgradeTestStatefulSet(GetSplunkStatefulsetName(SplunkClusterManager, "stack1"), "splunk/splunk:old"),
+		newUpgradeTestStatefulSet(GetSplunkStatefulsetName(SplunkDeployer, "stack1"), "splunk/splunk:old"),
+		newUpgradeTestStatefulSet(GetSplunkStatefulsetName(SplunkSearchHead, "stack1"), "splunk/splunk:old"),
+		newUpgradeTestStatefulSet(GetSplunkStatefulsetName(SplunkIndexer, "stack1"), "splunk/splunk:old"),
+	).Build()
+
+	// the cluster manager waits for the license manager
+	stage := ""
+	upgradeReady, err := checkOrderedUpgrade(ctx, c, cm, &cm.Spec.CommonSplunkSpec, SplunkClusterManager, &stage)
+	if err != nil || upgradeReady || stage != upgradeStageLicenseManager {
+		t.Errorf("checkOrderedUpgrade() = %t, %q, %v; want false, %q, nil", upgradeReady, stage, err, upgradeStageLicenseManager)
+	}
+
+	// the cluster manager waits for the pods of the license manager to be recycled on the new image
+	setUpgradeTestImage(ctx, t, c, GetSplunkStatefulsetName(SplunkLicenseManager, "stack1"), "splunk/splunk:new")
+	setUpgradeTestUpdatedReplicas(ctx, t, c, GetSplunkStatefulsetName(SplunkLicenseManager, "stack1"), 0)
+	upgradeReady, err = checkOrderedUpgrade(ctx, c, cm, &cm.Spec.CommonSplunkSpec, SplunkClusterManager, &stage)
+	if err != nil || upgradeReady || stage != upgradeStageLicenseManager {
+		t.Errorf("checkOrderedUpgrade() = %t, %q, %v; want false, %q, nil", upgradeReady, stage, err, upgradeStageLicenseManager)
+	}
+
+	// the cluster manager is upgraded once the license manager is ready on the new image
+	setUpgradeTestUpdatedReplicas(ctx, t, c, GetSplunkStatefulsetName(SplunkLicenseManager, "stack1"), 1)
+	upgradeReady, err = checkOrderedUpgrade(ctx, c, cm, &cm.Spec.CommonSplunkSpec, SplunkClusterManager, &stage)
+	if err != nil || !upgradeReady || stage != upgradeStageClusterManager {
+		t.Errorf("checkOrderedUpgrade() = %t, %q, %v; want true, %q, nil", upgradeReady, stage, err, upgradeStageClusterManager)
+	}
+	updateUpgradeStage(&stage, SplunkClusterManager, enterpriseApi.PhaseUpdating)
+	if stage != upgradeStageClusterManager {
+		t.Errorf("updateUpgradeStage() = %q; want %q", stage, upgradeStageClusterManager)
+	}
+	updateUpgradeStage(&stage, SplunkClusterManager, enterpriseApi.PhaseReady)
+	if stage != "" {
+		t.Errorf("updateUpgradeStage() = %q; want \"\"", stage)
+	}
+
+	// the indexers wait for the cluster manager, then for the search heads of the cluster manager
+	stage = ""
+	upgradeReady, err = checkOrderedUpgrade(ctx, c, idxc, &idxc.Spec.CommonSplunkSpec, SplunkIndexer, &stage)
+	if err != nil || upgradeReady || stage != upgradeStageClusterManager {
+		t.Errorf("checkOrderedUpgrade() = %t, %q, %v; want false, %q, nil", upgradeReady, stage, err, upgradeStageClusterManager)
+	}
+	setUpgradeTestImage(ctx, t, c, GetSplunkStatefulsetName(SplunkClusterManager, "stack1"), "splunk/splunk:new")
+	upgradeReady, err = checkOrderedUpgrade(ctx, c, idxc, &idxc.Spec.CommonSplunkSpec, SplunkIndexer, &stage)
+	if err != nil || upgradeReady || stage != upgradeStageSearchHeads {
+		t.Errorf("checkOrderedUpgrade() = %t, %q, %v; want false, %q, nil", upgradeReady, stage, err, upgradeStageSearchHeads)
+	}
+	setUpgradeTestImage(ctx, t, c, GetSplunkStatefulsetName(SplunkDeployer, "stack1"), "splunk/splunk:new")
+	setUpgradeTestImage(ctx, t, c, GetSplunkStatefulsetName(SplunkSearchHead, "stack1"), "splunk/splunk:new")
+	upgradeReady, err = checkOrderedUpgrade(ctx, c, idxc, &idxc.Spec.CommonSplunkSpec, SplunkIndexer, &stage)
+	if err != nil || !upgradeReady || stage != upgradeStageIndexers {
+		t.Errorf("checkOrderedUpgrade() = %t, %q, %v; want true, %q, nil", upgradeReady, stage, err, upgradeStageIndexers)
+	}
+
+	// a dangling reference to a missing license manager or cluster manager doesn't hold the upgrade
+	idxc.Spec.LicenseMasterRef.Name = "missing"
+	idxc.Spec.ClusterMasterRef.Name = "missing"
+	stage = ""
+	upgradeReady, err = checkOrderedUpgrade(ctx, c, idxc, &idxc.Spec.CommonSplunkSpec, SplunkIndexer, &stage)
+	if err != nil || !upgradeReady || stage != upgradeStageIndexers {
+		t.Errorf("checkOrderedUpgrade() = %t, %q, %v; want true, %q, nil", upgradeReady, stage, err, upgradeStageIndexers)
+	}
+
+	// the statefulsets are updated as is without an ordered upgrade
+	idxc.Spec.OrderedUpgrade = false
+	idxc.Spec.Image = "splunk/splunk:next"
+	upgradeReady, err = checkOrderedUpgrade(ctx, c, idxc, &idxc.Spec.CommonSplunkSpec, SplunkIndexer, &stage)
+	if err != nil || !upgradeReady || stage != "" {
+		t.Errorf("checkOrderedUpgrade() = %t, %q, %v; want true, \"\", nil", upgradeReady, stage, err)
+	}
+}
+
+func TestIsStatefulSetRolledOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(statefulSet *appsv1.StatefulSet)
+		want   bool
+	}{
+		{"rolled out", func(statefulSet *appsv1.StatefulSet) {}, true},
+		{"spec not observed", func(statefulSet *appsv1.StatefulSet) { statefulSet.Generation = 2 }, false},
+		{"pods not updated", func(statefulSet *appsv1.StatefulSet) {
+			statefulSet.Status.UpdateRevision = "v2"
+			statefulSet.Status.UpdatedReplicas = 0
+		}, false},
+		{"pods updated on delete", func(statefulSet *appsv1.StatefulSet) {
+			statefulSet.Status.UpdateRevision = "v2"
+		}, true},
+		{"rolling update in progress", func(statefulSet *appsv1.StatefulSet) {
+			statefulSet.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+			statefulSet.Status.UpdateRevision = "v2"
+		}, false},
+		{"rolling update complete", func(statefulSet *appsv1.StatefulSet) {
+			statefulSet.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+		}, true},
+	}
+	for _, test := range tests {
+		statefulSet := newUpgradeTestStatefulSet("splunk-stack1-indexer", "splunk/splunk:new")
+		test.update(statefulSet)
+		if got := isStatefulSetRolledOut(statefulSet); got != test.want {
+			t.Errorf("isStatefulSetRolledOut() for %s = %t; want %t", test.name, got, test.want)
+		}
+	}
+}
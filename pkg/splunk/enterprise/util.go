@@ -41,7 +41,9 @@ import (
 	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
 	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/concurrency"
 	splctrl "github.com/splunk/splunk-operator/pkg/splunk/controller"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	splutil "github.com/splunk/splunk-operator/pkg/splunk/util"
 
 	// Used to move files between pods
@@ -67,11 +69,15 @@ func initGlobalResourceTracker() {
 
 	// initialize the resource tracker
 	initCommonResourceTracker()
+
+	// initialize the app package cache
+	operatorResourceTracker.appPkgCache = newAppPkgCache()
 }
 
 func initCommonResourceTracker() {
 	operatorResourceTracker.commonResourceTracker = &commonResourceTracker{
-		mutexMap: make(map[string]*sync.Mutex),
+		mutexMap:     make(map[string]*sync.Mutex),
+		mutexHolders: make(map[string]resourceMutexHolder),
 	}
 }
 
@@ -89,6 +95,32 @@ func getResourceMutex(resourceName string) *sync.Mutex {
 	return commonResourceTracker.mutexMap[resourceName]
 }
 
+// lockResourceMutex locks the mutex for the given K8s object on behalf of the function of a CR, and returns the function
+// unlocking it. The holder of the mutex is reported by the App Framework introspection endpoint
+func lockResourceMutex(resourceName string, cr splcommon.MetaObject, function string) func() {
+	mux := getResourceMutex(resourceName)
+	mux.Lock()
+
+	commonResourceTracker := operatorResourceTracker.commonResourceTracker
+	commonResourceTracker.mutex.Lock()
+	if commonResourceTracker.mutexHolders == nil {
+		commonResourceTracker.mutexHolders = make(map[string]resourceMutexHolder)
+	}
+	commonResourceTracker.mutexHolders[resourceName] = resourceMutexHolder{
+		cr:       fmt.Sprintf("%s/%s", cr.GetNamespace(), cr.GetName()),
+		function: function,
+		since:    time.Now(),
+	}
+	commonResourceTracker.mutex.Unlock()
+
+	return func() {
+		commonResourceTracker.mutex.Lock()
+		delete(commonResourceTracker.mutexHolders, resourceName)
+		commonResourceTracker.mutex.Unlock()
+		mux.Unlock()
+	}
+}
+
 func initStorageTracker() error {
 	ctx := context.TODO()
 	// For now, App framework is the only functionality using the storage space tracker
@@ -125,6 +157,21 @@ func updateStorageTracker(ctx context.Context) error {
 	}()
 }
 
+// getRemoteStorageAuthFromSecret returns the credentials for the http and oci remote storage from the secret.
+// Token is passed as the secret with an empty username
+func getRemoteStorageAuthFromSecret(secret *corev1.Secret) (string, string, error) {
+	if token := string(secret.Data["token"]); token != "" {
+		return "", token, nil
+	}
+
+	username := string(secret.Data["username"])
+	password := string(secret.Data["password"])
+	if username == "" || password == "" {
+		return "", "", fmt.Errorf("username and password, or token is missing")
+	}
+	return username, password, nil
+}
+
 // GetRemoteStorageClient returns the corresponding S3Client
 func GetRemoteStorageClient(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, appFrameworkRef *enterpriseApi.AppFrameworkSpec, vol *enterpriseApi.VolumeSpec, location string, fn splclient.GetInitFunc) (splclient.SplunkS3Client, error) {
 
@@ -151,18 +198,26 @@ func GetRemoteStorageClient(ctx context.Context, client splcommon.ControllerClie
 			return s3Client, err
 		}
 
-		// Get access keys
-		accessKeyID = string(s3ClientSecret.Data["s3_access_key"])
-		secretAccessKey = string(s3ClientSecret.Data["s3_secret_key"])
-
-		// Do we need to handle if IAM_ROLE is set in the secret as well?
-		if accessKeyID == "" {
-			err = fmt.Errorf("accessKey missing")
-			return s3Client, err
-		}
-		if secretAccessKey == "" {
-			err = fmt.Errorf("s3 Secret Key is missing")
-			return s3Client, err
+		if vol.Type == "http" || vol.Type == "oci" {
+			// http and oci remote storage use either the username and password, or the bearer token
+			accessKeyID, secretAccessKey, err = getRemoteStorageAuthFromSecret(s3ClientSecret)
+			if err != nil {
+				return s3Client, err
+			}
+		} else {
+			// Get access keys
+			accessKeyID = string(s3ClientSecret.Data["s3_access_key"])
+			secretAccessKey = string(s3ClientSecret.Data["s3_secret_key"])
+
+			// Do we need to handle if IAM_ROLE is set in the secret as well?
+			if accessKeyID == "" {
+				err = fmt.Errorf("accessKey missing")
+				return s3Client, err
+			}
+			if secretAccessKey == "" {
+				err = fmt.Errorf("s3 Secret Key is missing")
+				return s3Client, err
+			}
 		}
 	}
 
@@ -197,6 +252,9 @@ func GetRemoteStorageClient(ctx context.Context, client splcommon.ControllerClie
 
 // ApplySplunkConfig reconciles the state of Kubernetes Secrets, ConfigMaps and other general settings for Splunk Enterprise instances.
 func ApplySplunkConfig(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, spec enterpriseApi.CommonSplunkSpec, instanceType InstanceType) (*corev1.Secret, error) {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplySplunkConfig", cr)
+	defer span.End()
+
 	var err error
 
 	// Creates/updates the namespace scoped "splunk-secrets" K8S secret object
@@ -358,8 +416,8 @@ func getRemoteObjectFromS3Response(ctx context.Context, appName string, s3Respon
 	return nil
 }
 
-// appPhaseStatusAsStr converts the state enum to corresponding string
-func appPhaseStatusAsStr(status enterpriseApi.AppPhaseStatusType) string {
+// AppPhaseStatusAsStr converts the state enum to corresponding string
+func AppPhaseStatusAsStr(status enterpriseApi.AppPhaseStatusType) string {
 	switch status {
 	case enterpriseApi.AppPkgDownloadPending:
 		return "Download Pending"
@@ -385,6 +443,12 @@ func appPhaseStatusAsStr(status enterpriseApi.AppPhaseStatusType) string {
 		return "Install Complete"
 	case enterpriseApi.AppPkgInstallError:
 		return "Install Error"
+	case enterpriseApi.AppPkgMissingFromOperator:
+		return "App Package Missing From Operator"
+	case enterpriseApi.AppPkgDependencyError:
+		return "Dependency Error"
+	case enterpriseApi.AppPkgMissingOnPodError:
+		return "App Package Missing On Pod"
 	default:
 		return "Invalid Status"
 	}
@@ -553,6 +617,8 @@ func getAppPackageLocalPath(ctx context.Context, worker *PipelineWorker) string
 // ApplySmartstoreConfigMap creates the configMap with Smartstore config in INI format
 func ApplySmartstoreConfigMap(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject,
 	smartstore *enterpriseApi.SmartStoreSpec) (*corev1.ConfigMap, bool, error) {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplySmartstoreConfigMap", cr)
+	defer span.End()
 
 	var crKind string
 	var configMapDataChanged bool
@@ -756,6 +822,12 @@ func (s3mgr *S3ClientManager) DownloadApp(ctx context.Context, remoteFile string
 		return err
 	}
 
+	ctx, release, err := concurrency.AcquireHeavyOperation(ctx, concurrency.OperationAppDownload)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	_, err = c.Client.DownloadApp(ctx, remoteFile, localFile, etag)
 	if err != nil {
 		return err
@@ -1167,35 +1239,6 @@ func setupAppsStagingVolume(ctx context.Context, client splcommon.ControllerClie
 	}
 }
 
-// isAppAlreadyDownloaded checks if the app is already present on the operator pod
-func isAppAlreadyDownloaded(ctx context.Context, downloadWorker *PipelineWorker) bool {
-	reqLogger := log.FromContext(ctx)
-	scopedLog := reqLogger.WithName("isAppAlreadyDownloaded").WithValues("app name", downloadWorker.appDeployInfo.AppName)
-
-	scope := getAppSrcScope(ctx, downloadWorker.afwConfig, downloadWorker.appSrcName)
-	kind := downloadWorker.cr.GetObjectKind().GroupVersionKind().Kind
-
-	localPath := filepath.Join(splcommon.AppDownloadVolume, "downloadedApps", downloadWorker.cr.GetNamespace(), kind, downloadWorker.cr.GetName(), scope, downloadWorker.appSrcName) + "/"
-	localAppFileName := getLocalAppFileName(ctx, localPath, downloadWorker.appDeployInfo.AppName, downloadWorker.appDeployInfo.ObjectHash)
-
-	// check if the app is present on operator pod
-	fileInfo, err := os.Stat(localAppFileName)
-
-	if os.IsNotExist(err) {
-		scopedLog.Info("App not present on operator pod")
-		return false
-	}
-
-	localSize := fileInfo.Size()
-	remoteSize := int64(downloadWorker.appDeployInfo.Size)
-	if localSize != remoteSize {
-		err = fmt.Errorf("local size does not match with size on remote storage. localSize=%d, remoteSize=%d", localSize, remoteSize)
-		scopedLog.Error(err, "incorrect app size")
-		return false
-	}
-	return true
-}
-
 // SetLastAppInfoCheckTime sets the last check time to current time
 func SetLastAppInfoCheckTime(ctx context.Context, appInfoStatus *enterpriseApi.AppDeploymentContext) {
 	reqLogger := log.FromContext(ctx)
@@ -1290,9 +1333,8 @@ func updateManualAppUpdateConfigMapLocked(ctx context.Context, client splcommon.
 	configMapName := GetSplunkManualAppUpdateConfigMapName(cr.GetNamespace())
 	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: configMapName}
 
-	mux := getResourceMutex(configMapName)
-	mux.Lock()
-	defer mux.Unlock()
+	unlock := lockResourceMutex(configMapName, cr, "updateManualAppUpdateConfigMapLocked")
+	defer unlock()
 	configMap, err := splctrl.GetConfigMap(ctx, client, namespacedName)
 	if err != nil {
 		scopedLog.Error(err, "Unable to get configMap", "name", namespacedName.Name)
@@ -1330,6 +1372,29 @@ refCount: %d`, status, numOfObjects)
 	return nil
 }
 
+// cleanAppListObjectDigests cleans up the object digest values from the remote storage listing
+func cleanAppListObjectDigests(ctx context.Context, sourceToAppsList map[string]splclient.S3Response, appFrameworkConf *enterpriseApi.AppFrameworkSpec) error {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("cleanAppListObjectDigests")
+
+	for _, appSource := range appFrameworkConf.AppSources {
+		// Clean-up for the object digest value
+		for i := range sourceToAppsList[appSource.Name].Objects {
+			cleanDigest, err := getCleanObjectDigest(sourceToAppsList[appSource.Name].Objects[i].Etag)
+			if err != nil {
+				scopedLog.Error(err, "unable to fetch clean object digest value", "Object Hash", sourceToAppsList[appSource.Name].Objects[i].Etag)
+				return err
+			}
+
+			sourceToAppsList[appSource.Name].Objects[i].Etag = cleanDigest
+		}
+
+		scopedLog.Info("Apps List retrieved from remote storage", "App Source", appSource.Name, "Content", sourceToAppsList[appSource.Name].Objects)
+	}
+
+	return nil
+}
+
 // initAndCheckAppInfoStatus initializes the S3Clients and checks the status of apps on remote storage.
 func initAndCheckAppInfoStatus(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject,
 	appFrameworkConf *enterpriseApi.AppFrameworkSpec, appStatusContext *enterpriseApi.AppDeploymentContext) error {
@@ -1347,6 +1412,16 @@ func initAndCheckAppInfoStatus(ctx context.Context, client splcommon.ControllerC
 		return err
 	}
 
+	// In the dry-run mode, just compute the plan without changing the app deployment status
+	if isAppFrameworkDryRunEnabled(cr, appFrameworkConf) {
+		return checkAndUpdateAppFrameworkPlan(ctx, client, cr, appFrameworkConf, appStatusContext)
+	}
+
+	err = removeAppFrameworkPlan(ctx, client, cr, appStatusContext)
+	if err != nil {
+		scopedLog.Error(err, "Unable to remove the App Framework dry-run plan")
+	}
+
 	var turnOffManualChecking bool
 	kind := cr.GetObjectKind().GroupVersionKind().Kind
 
@@ -1370,19 +1445,9 @@ func initAndCheckAppInfoStatus(ctx context.Context, client splcommon.ControllerC
 		if len(sourceToAppsList) != len(appFrameworkConf.AppSources) {
 			scopedLog.Error(err, "Unable to get apps list, will retry in next reconcile...")
 		} else {
-			for _, appSource := range appFrameworkConf.AppSources {
-				// Clean-up for the object digest value
-				for i := range sourceToAppsList[appSource.Name].Objects {
-					cleanDigest, err := getCleanObjectDigest(sourceToAppsList[appSource.Name].Objects[i].Etag)
-					if err != nil {
-						scopedLog.Error(err, "unable to fetch clean object digest value", "Object Hash", sourceToAppsList[appSource.Name].Objects[i].Etag)
-						return err
-					}
-
-					sourceToAppsList[appSource.Name].Objects[i].Etag = cleanDigest
-				}
-
-				scopedLog.Info("Apps List retrieved from remote storage", "App Source", appSource.Name, "Content", sourceToAppsList[appSource.Name].Objects)
+			err = cleanAppListObjectDigests(ctx, sourceToAppsList, appFrameworkConf)
+			if err != nil {
+				return err
 			}
 
 			// Only handle the app repo changes if we were able to successfully get the apps list
@@ -1458,9 +1523,8 @@ func UpdateOrRemoveEntryFromConfigMapLocked(ctx context.Context, c splcommon.Con
 	configMapName := GetSplunkManualAppUpdateConfigMapName(cr.GetNamespace())
 	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: configMapName}
 
-	mux := getResourceMutex(configMapName)
-	mux.Lock()
-	defer mux.Unlock()
+	unlock := lockResourceMutex(configMapName, cr, "UpdateOrRemoveEntryFromConfigMapLocked")
+	defer unlock()
 	configMap, err := splctrl.GetConfigMap(ctx, c, namespacedName)
 	if err != nil {
 		scopedLog.Error(err, "Unable to get config map", "name", namespacedName.Name)
@@ -1768,6 +1832,7 @@ func reserveStorage(allocSize uint64) error {
 		}
 
 		sTracker.availableDiskSpace -= allocSize
+		sTracker.reservedDiskSpace += allocSize
 		return nil
 	}()
 }
@@ -1784,6 +1849,11 @@ func releaseStorage(releaseSize uint64) error {
 		defer sTracker.mutex.Unlock()
 
 		sTracker.availableDiskSpace += releaseSize
+		if sTracker.reservedDiskSpace < releaseSize {
+			sTracker.reservedDiskSpace = 0
+		} else {
+			sTracker.reservedDiskSpace -= releaseSize
+		}
 		return nil
 	}()
 }
@@ -1818,6 +1888,15 @@ func handleAppFrameworkActivity(ctx context.Context, client splcommon.Controller
 		RequeueAfter: maxRecDuration,
 	}
 
+	// Apps are not installed in the dry-run mode, just refresh the plan as per the polling interval
+	if isAppFrameworkDryRunEnabled(cr, appFrameworkConfig) {
+		if isAppRepoPollingEnabled(appDeployContext) && appDeployContext.DryRunPlan != nil {
+			requeueAfter := GetNextRequeueTime(ctx, appDeployContext.AppsRepoStatusPollInterval, appDeployContext.DryRunPlan.PlanTime)
+			updateReconcileRequeueTime(ctx, finalResult, requeueAfter, true)
+		}
+		return finalResult
+	}
+
 	// Consider the polling interval for next reconcile
 	if isAppRepoPollingEnabled(appDeployContext) {
 		requeueAfter := GetNextRequeueTime(ctx, appDeployContext.AppsRepoStatusPollInterval, appDeployContext.LastAppInfoCheckTime)
@@ -1839,10 +1918,16 @@ func handleAppFrameworkActivity(ctx context.Context, client splcommon.Controller
 
 // checkAndMigrateAppDeployStatus (if required) upgrades the appframework status context
 func checkAndMigrateAppDeployStatus(ctx context.Context, client splcommon.ControllerClient, cr splcommon.MetaObject, afwStatusContext *enterpriseApi.AppDeploymentContext, afwConf *enterpriseApi.AppFrameworkSpec, isLocalScope bool) error {
+	// App deployment status is kept in the configMap, rather than in the CR status
+	err := loadAppDeployStatus(ctx, client, cr, afwStatusContext)
+	if err != nil {
+		return err
+	}
+
 	// If needed, Migrate the app framework status
 	if isAppFrameworkMigrationNeeded(afwStatusContext) {
 		// Spec validation updates the status with some of the defaults, which may not be there in older app framework versions
-		err := ValidateAppFrameworkSpec(ctx, afwConf, afwStatusContext, isLocalScope)
+		err = ValidateAppFrameworkSpec(ctx, afwConf, afwStatusContext, isLocalScope)
 		if err != nil {
 			return err
 		}
@@ -1942,6 +2027,9 @@ func updateCRStatus(ctx context.Context, client splcommon.ControllerClient, orig
 	reqLogger := log.FromContext(ctx)
 	scopedLog := reqLogger.WithName("updateCRStatus").WithValues("original cr version", origCR.GetResourceVersion())
 
+	// Save the App deployment status in the configMap, so that the CR status stays within the limits
+	appDeployStatusOffloaded := origCR.GetDeletionTimestamp() == nil && offloadAppDeployStatus(ctx, client, origCR)
+
 	var tryCnt int
 	for tryCnt = 0; tryCnt < maxRetryCountForCRStatusUpdate; tryCnt++ {
 		latestCR, err := fetchCurrentCRWithStatusUpdate(ctx, client, origCR)
@@ -1953,6 +2041,10 @@ func updateCRStatus(ctx context.Context, client splcommon.ControllerClient, orig
 			continue
 		}
 
+		if appDeployStatusOffloaded {
+			getAppDeploymentContextFromCR(latestCR).AppsSrcDeployStatus = nil
+		}
+
 		scopedLog.Info("Trying to update", "count", tryCnt)
 		curCRVersion := latestCR.GetResourceVersion()
 		err = client.Status().Update(ctx, latestCR)
@@ -2015,6 +2107,14 @@ func fetchCurrentCRWithStatusUpdate(ctx context.Context, client splcommon.Contro
 		origCR.(*enterpriseApi.LicenseMaster).Status.DeepCopyInto(&latestLmCR.Status)
 		return latestLmCR, nil
 
+	case "LicenseManager":
+		latestLmCR, err := getV4LicenseManager(ctx, client, namespacedName)
+		if err != nil {
+			return nil, err
+		}
+		origCR.(*enterpriseApi.LicenseMaster).Status.DeepCopyInto(&latestLmCR.Status)
+		return latestLmCR, nil
+
 	case "SearchHeadCluster":
 		latestShcCR := &enterpriseApi.SearchHeadCluster{}
 		err = client.Get(ctx, namespacedName, latestShcCR)
@@ -2042,6 +2142,14 @@ func fetchCurrentCRWithStatusUpdate(ctx context.Context, client splcommon.Contro
 		origCR.(*enterpriseApi.ClusterMaster).Status.DeepCopyInto(&latestCmCR.Status)
 		return latestCmCR, nil
 
+	case "ClusterManager":
+		latestCmCR, err := getV4ClusterManager(ctx, client, namespacedName)
+		if err != nil {
+			return nil, err
+		}
+		origCR.(*enterpriseApi.ClusterMaster).Status.DeepCopyInto(&latestCmCR.Status)
+		return latestCmCR, nil
+
 	case "MonitoringConsole":
 		latestMcCR := &enterpriseApi.MonitoringConsole{}
 		err = client.Get(ctx, namespacedName, latestMcCR)
@@ -2054,3 +2162,12 @@ func fetchCurrentCRWithStatusUpdate(ctx context.Context, client splcommon.Contro
 
 	return nil, fmt.Errorf("Invalid CR Kind")
 }
+
+// requeueNoLaterThan returns the reconcile result requeued after requeueAfter, unless an earlier requeue is already set
+func requeueNoLaterThan(result reconcile.Result, requeueAfter time.Duration) reconcile.Result {
+	if !result.Requeue || result.RequeueAfter == 0 || result.RequeueAfter > requeueAfter {
+		result.Requeue = true
+		result.RequeueAfter = requeueAfter
+	}
+	return result
+}
@@ -58,7 +58,7 @@ func TestApplySplunkConfig(t *testing.T) {
 		{MetaName: "*v1.ConfigMap-test-splunk-stack1-search-head-defaults"},
 		{MetaName: "*v1.ConfigMap-test-splunk-stack1-search-head-defaults"},
 	}
-	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": {funcCalls[0], funcCalls[3]}, "Update": {funcCalls[0]}}
+	createCalls := map[string][]spltest.MockFuncCall{"Get": funcCalls, "Create": {funcCalls[0]}, "Patch": {funcCalls[3]}, "Update": {funcCalls[0]}}
 	updateCalls := map[string][]spltest.MockFuncCall{"Get": {funcCalls[0], funcCalls[1], funcCalls[3]}}
 	searchHeadCR := enterpriseApi.SearchHeadCluster{
 		TypeMeta: metav1.TypeMeta{
@@ -782,62 +782,62 @@ func TestHandleAppRepoChanges(t *testing.T) {
 
 func TestAppPhaseStatusAsStr(t *testing.T) {
 	var status string
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgDownloadPending)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgDownloadPending)
 	if status != "Download Pending" {
 		t.Errorf("Got wrong status. Expected status=Download Pending, Got = %s", status)
 	}
 
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgDownloadInProgress)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgDownloadInProgress)
 	if status != "Download In Progress" {
 		t.Errorf("Got wrong status. Expected status=\"Download In Progress\", Got = %s", status)
 	}
 
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgDownloadComplete)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgDownloadComplete)
 	if status != "Download Complete" {
 		t.Errorf("Got wrong status. Expected status=\"Download Complete\", Got = %s", status)
 	}
 
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgDownloadError)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgDownloadError)
 	if status != "Download Error" {
 		t.Errorf("Got wrong status. Expected status=\"Download Error\", Got = %s", status)
 	}
 
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgPodCopyPending)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgPodCopyPending)
 	if status != "Pod Copy Pending" {
 		t.Errorf("Got wrong status. Expected status=Pod Copy Pending, Got = %s", status)
 	}
 
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgPodCopyInProgress)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgPodCopyInProgress)
 	if status != "Pod Copy In Progress" {
 		t.Errorf("Got wrong status. Expected status=\"Pod Copy In Progress\", Got = %s", status)
 	}
 
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgPodCopyComplete)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgPodCopyComplete)
 	if status != "Pod Copy Complete" {
 		t.Errorf("Got wrong status. Expected status=\"Pod Copy Complete\", Got = %s", status)
 	}
 
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgPodCopyError)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgPodCopyError)
 	if status != "Pod Copy Error" {
 		t.Errorf("Got wrong status. Expected status=\"Pod Copy Error\", Got = %s", status)
 	}
 
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgInstallPending)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgInstallPending)
 	if status != "Install Pending" {
 		t.Errorf("Got wrong status. Expected status=Install Pending, Got = %s", status)
 	}
 
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgInstallInProgress)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgInstallInProgress)
 	if status != "Install In Progress" {
 		t.Errorf("Got wrong status. Expected status=\"Install In Progress\", Got = %s", status)
 	}
 
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgInstallComplete)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgInstallComplete)
 	if status != "Install Complete" {
 		t.Errorf("Got wrong status. Expected status=\"Install Complete\", Got = %s", status)
 	}
 
-	status = appPhaseStatusAsStr(enterpriseApi.AppPkgInstallError)
+	status = AppPhaseStatusAsStr(enterpriseApi.AppPkgInstallError)
 	if status != "Install Error" {
 		t.Errorf("Got wrong status. Expected status=\"Install Error\", Got = %s", status)
 	}
@@ -1537,7 +1537,7 @@ func TestSetInstallSetForClusterScopedApps(t *testing.T) {
 			if appSrc.Scope == enterpriseApi.ScopeCluster &&
 				(deployInfoList[i].PhaseInfo.Phase == enterpriseApi.PhaseInstall || deployInfoList[i].PhaseInfo.Status == enterpriseApi.AppPkgInstallComplete) {
 				t.Errorf("wrong install state for app: %s. Got(Phase=%s, PhaseStatus=%s), wanted(Phase=%s, PhaseStatus=%s)",
-					deployInfoList[i].AppName, deployInfoList[i].PhaseInfo.Phase, appPhaseStatusAsStr(deployInfoList[i].PhaseInfo.Status), enterpriseApi.PhaseInstall, appPhaseStatusAsStr(enterpriseApi.AppPkgInstallComplete))
+					deployInfoList[i].AppName, deployInfoList[i].PhaseInfo.Phase, AppPhaseStatusAsStr(deployInfoList[i].PhaseInfo.Status), enterpriseApi.PhaseInstall, AppPhaseStatusAsStr(enterpriseApi.AppPkgInstallComplete))
 			}
 		}
 	}
@@ -1563,7 +1563,7 @@ func TestSetInstallSetForClusterScopedApps(t *testing.T) {
 			if appSrc.Scope == enterpriseApi.ScopeCluster &&
 				(deployInfoList[i].PhaseInfo.Phase != enterpriseApi.PhaseInstall || deployInfoList[i].PhaseInfo.Status != enterpriseApi.AppPkgInstallComplete) {
 				t.Errorf("wrong install state for app: %s. Got(Phase=%s, PhaseStatus=%s), wanted(Phase=%s, PhaseStatus=%s)",
-					deployInfoList[i].AppName, deployInfoList[i].PhaseInfo.Phase, appPhaseStatusAsStr(deployInfoList[i].PhaseInfo.Status), enterpriseApi.PhaseInstall, appPhaseStatusAsStr(enterpriseApi.AppPkgInstallComplete))
+					deployInfoList[i].AppName, deployInfoList[i].PhaseInfo.Phase, AppPhaseStatusAsStr(deployInfoList[i].PhaseInfo.Status), enterpriseApi.PhaseInstall, AppPhaseStatusAsStr(enterpriseApi.AppPkgInstallComplete))
 			}
 		}
 	}
@@ -2247,7 +2247,7 @@ func TestUpdateReconcileRequeueTime(t *testing.T) {
 
 func TestUpdateCRStatus(t *testing.T) {
 	builder := fake.NewClientBuilder()
-	c := builder.Build()
+	c := spltest.NewFakeApplyClient(builder.Build())
 	utilruntime.Must(enterpriseApi.AddToScheme(clientgoscheme.Scheme))
 	ctx := context.TODO()
 
@@ -2296,7 +2296,7 @@ func TestUpdateCRStatus(t *testing.T) {
 
 func TestFetchCurrentCRWithStatusUpdate(t *testing.T) {
 	builder := fake.NewClientBuilder()
-	c := builder.Build()
+	c := spltest.NewFakeApplyClient(builder.Build())
 	utilruntime.Must(enterpriseApi.AddToScheme(clientgoscheme.Scheme))
 	ctx := context.TODO()
 
@@ -2522,3 +2522,30 @@ func TestFetchCurrentCRWithStatusUpdate(t *testing.T) {
 		t.Errorf("Failed to fetch the CR")
 	}
 }
+
+func TestGetRemoteStorageAuthFromSecret(t *testing.T) {
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			"username": []byte("user"),
+			"password": []byte("pass"),
+		},
+	}
+
+	username, password, err := getRemoteStorageAuthFromSecret(secret)
+	if err != nil || username != "user" || password != "pass" {
+		t.Errorf("unexpected credentials, username: %s, password: %s, error: %v", username, password, err)
+	}
+
+	// token takes the precedence, and is passed with an empty username
+	secret.Data["token"] = []byte("token1")
+	username, password, err = getRemoteStorageAuthFromSecret(secret)
+	if err != nil || username != "" || password != "token1" {
+		t.Errorf("unexpected credentials, username: %s, password: %s, error: %v", username, password, err)
+	}
+
+	secret.Data = map[string][]byte{"username": []byte("user")}
+	_, _, err = getRemoteStorageAuthFromSecret(secret)
+	if err == nil {
+		t.Errorf("missing password should return an error")
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+)
+
+// The ClusterManager and LicenseManager kinds of the v4 API are reconciled with the ClusterMaster and LicenseMaster logic,
+// on a ClusterMaster or LicenseMaster copy of the custom resource that keeps the group, version and kind of the v4 custom
+// resource. The owner references, the events and the status updates then refer to the v4 custom resource
+
+// isV4ManagerKind returns true when the custom resource is a copy of a ClusterManager or a LicenseManager
+func isV4ManagerKind(cr splcommon.MetaObject) bool {
+	kind := cr.GetObjectKind().GroupVersionKind().Kind
+	return kind == "ClusterManager" || kind == "LicenseManager"
+}
+
+// newV4ManagerCopy returns the ClusterMaster or LicenseMaster copy of a ClusterManager or LicenseManager custom resource
+func newV4ManagerCopy(cr client.Object) (splcommon.MetaObject, error) {
+	var crCopy splcommon.MetaObject
+	var kind string
+	switch cr.(type) {
+	case *enterpriseApiV4.ClusterManager:
+		crCopy, kind = &enterpriseApi.ClusterMaster{}, "ClusterManager"
+	case *enterpriseApiV4.LicenseManager:
+		crCopy, kind = &enterpriseApi.LicenseMaster{}, "LicenseManager"
+	default:
+		return nil, nil
+	}
+
+	err := enterpriseApi.ConvertFromV4(cr, crCopy)
+	if err != nil {
+		return nil, err
+	}
+	crCopy.SetGroupVersionKind(enterpriseApiV4.GroupVersion.WithKind(kind))
+	return crCopy, nil
+}
+
+// checkV4ManagerName returns an error when the ClusterMaster or LicenseMaster copy of a ClusterManager or LicenseManager
+// custom resource has the name of a ClusterMaster or LicenseMaster custom resource in the same namespace. Both custom
+// resources would manage the same resources, and the v3 custom resource takes precedence like for the
+// clusterManagerRef and licenseManagerRef lookups
+func checkV4ManagerName(ctx context.Context, c splcommon.ControllerClient, crCopy splcommon.MetaObject) error {
+	var v3CR client.Object
+	switch crCopy.(type) {
+	case *enterpriseApi.ClusterMaster:
+		v3CR = &enterpriseApi.ClusterMaster{}
+	case *enterpriseApi.LicenseMaster:
+		v3CR = &enterpriseApi.LicenseMaster{}
+	default:
+		return nil
+	}
+
+	namespacedName := types.NamespacedName{Namespace: crCopy.GetNamespace(), Name: crCopy.GetName()}
+	err := c.Get(ctx, namespacedName, v3CR)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	kind := crCopy.GetObjectKind().GroupVersionKind().Kind
+	v3Kind := "ClusterMaster"
+	if kind == "LicenseManager" {
+		v3Kind = "LicenseMaster"
+	}
+	err = fmt.Errorf("%s %s conflicts with %s %s in namespace %s", kind, crCopy.GetName(), v3Kind, crCopy.GetName(), crCopy.GetNamespace())
+	eventPublisher, _ := newK8EventPublisher(c, crCopy)
+	eventPublisher.Warning(ctx, "NameConflict", err.Error())
+	return err
+}
+
+// getV4ManagerFromCopy returns the ClusterManager or LicenseManager custom resource of a copy, or nil when the custom
+// resource isn't a copy
+func getV4ManagerFromCopy(obj client.Object) (client.Object, error) {
+	var cr client.Object
+	switch obj.GetObjectKind().GroupVersionKind().Kind {
+	case "ClusterManager":
+		if _, ok := obj.(*enterpriseApi.ClusterMaster); !ok {
+			return nil, nil
+		}
+		cr = &enterpriseApiV4.ClusterManager{}
+	case "LicenseManager":
+		if _, ok := obj.(*enterpriseApi.LicenseMaster); !ok {
+			return nil, nil
+		}
+		cr = &enterpriseApiV4.LicenseManager{}
+	default:
+		return nil, nil
+	}
+
+	cr.GetObjectKind().SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	err := enterpriseApi.ConvertToV4(obj, cr)
+	return cr, err
+}
+
+// v4ManagerClient is the client used to reconcile the ClusterManager and LicenseManager kinds. The updates of the copies
+// are applied to the v4 custom resources
+type v4ManagerClient struct {
+	splcommon.ControllerClient
+}
+
+// Update updates the v4 custom resource of a copy, or the object itself
+func (c *v4ManagerClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return updateV4Manager(obj, func(obj client.Object) error {
+		return c.ControllerClient.Update(ctx, obj, opts...)
+	})
+}
+
+// Status returns a status writer updating the status of the v4 custom resource of a copy, or of the object itself
+func (c *v4ManagerClient) Status() client.StatusWriter {
+	return &v4ManagerStatusWriter{StatusWriter: c.ControllerClient.Status()}
+}
+
+// v4ManagerStatusWriter is the status writer of v4ManagerClient
+type v4ManagerStatusWriter struct {
+	client.StatusWriter
+}
+
+// Update updates the status of the v4 custom resource of a copy, or of the object itself
+func (w *v4ManagerStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return updateV4Manager(obj, func(obj client.Object) error {
+		return w.StatusWriter.Update(ctx, obj, opts...)
+	})
+}
+
+// updateV4Manager applies update to the v4 custom resource of a copy, and keeps the resource version of the copy in sync
+func updateV4Manager(obj client.Object, update func(client.Object) error) error {
+	cr, err := getV4ManagerFromCopy(obj)
+	if err != nil {
+		return err
+	}
+	if cr == nil {
+		return update(obj)
+	}
+
+	err = update(cr)
+	if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(cr.GetResourceVersion())
+	return nil
+}
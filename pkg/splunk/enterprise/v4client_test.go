@@ -0,0 +1,243 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+func newTestV4ClusterManager() *enterpriseApiV4.ClusterManager {
+	return &enterpriseApiV4.ClusterManager{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ClusterManager",
+			APIVersion: enterpriseApiV4.APIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Spec: enterpriseApiV4.ClusterManagerSpec{
+			CommonSplunkSpec: enterpriseApiV4.CommonSplunkSpec{
+				LicenseManagerRef: corev1.ObjectReference{
+					Name: "lm",
+				},
+			},
+		},
+	}
+}
+
+func TestNewV4ManagerCopy(t *testing.T) {
+	cr := newTestV4ClusterManager()
+	crCopy, err := newV4ManagerCopy(cr)
+	if err != nil {
+		t.Errorf("newV4ManagerCopy() returned error: %v", err)
+	}
+
+	cm, ok := crCopy.(*enterpriseApi.ClusterMaster)
+	if !ok {
+		t.Fatalf("newV4ManagerCopy() returned %T; want *v3.ClusterMaster", crCopy)
+	}
+	if cm.GetName() != "stack1" || cm.GetNamespace() != "test" {
+		t.Errorf("newV4ManagerCopy() returned %s/%s; want test/stack1", cm.GetNamespace(), cm.GetName())
+	}
+	if cm.Spec.LicenseMasterRef.Name != "lm" {
+		t.Errorf("newV4ManagerCopy() licenseMasterRef = %q; want \"lm\"", cm.Spec.LicenseMasterRef.Name)
+	}
+	gvk := cm.GroupVersionKind()
+	if gvk.Kind != "ClusterManager" || gvk.GroupVersion() != enterpriseApiV4.GroupVersion {
+		t.Errorf("newV4ManagerCopy() kind = %s; want enterprise.splunk.com/v4 ClusterManager", gvk)
+	}
+	if !isV4ManagerKind(cm) {
+		t.Errorf("isV4ManagerKind() returned false for a ClusterManager copy")
+	}
+
+	// the copy converts back to the v4 custom resource
+	obj, err := getV4ManagerFromCopy(cm)
+	if err != nil {
+		t.Errorf("getV4ManagerFromCopy() returned error: %v", err)
+	}
+	v4CR, ok := obj.(*enterpriseApiV4.ClusterManager)
+	if !ok {
+		t.Fatalf("getV4ManagerFromCopy() returned %T; want *v4.ClusterManager", obj)
+	}
+	if v4CR.Spec.LicenseManagerRef.Name != "lm" {
+		t.Errorf("getV4ManagerFromCopy() licenseManagerRef = %q; want \"lm\"", v4CR.Spec.LicenseManagerRef.Name)
+	}
+
+	// a v3 custom resource isn't a copy
+	obj, err = getV4ManagerFromCopy(&enterpriseApi.ClusterMaster{TypeMeta: metav1.TypeMeta{Kind: "ClusterMaster"}})
+	if obj != nil || err != nil {
+		t.Errorf("getV4ManagerFromCopy() returned %v, %v for a ClusterMaster; want nil, nil", obj, err)
+	}
+	if isV4ManagerKind(&enterpriseApi.ClusterMaster{TypeMeta: metav1.TypeMeta{Kind: "ClusterMaster"}}) {
+		t.Errorf("isV4ManagerKind() returned true for a ClusterMaster")
+	}
+}
+
+func TestV4ManagerClientUpdate(t *testing.T) {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+	v4Client := &v4ManagerClient{ControllerClient: c}
+
+	crCopy, err := newV4ManagerCopy(newTestV4ClusterManager())
+	if err != nil {
+		t.Fatalf("newV4ManagerCopy() returned error: %v", err)
+	}
+	err = v4Client.Update(ctx, crCopy)
+	if err != nil {
+		t.Errorf("Update() returned error: %v", err)
+	}
+	if _, ok := c.State["*v4.ClusterManager-test-stack1"]; !ok {
+		t.Errorf("Update() didn't update the ClusterManager custom resource")
+	}
+	if _, ok := c.State["*v3.ClusterMaster-test-stack1"]; ok {
+		t.Errorf("Update() updated a ClusterMaster custom resource")
+	}
+
+	// other objects are updated as is
+	cm := &enterpriseApi.ClusterMaster{
+		TypeMeta:   metav1.TypeMeta{Kind: "ClusterMaster"},
+		ObjectMeta: metav1.ObjectMeta{Name: "stack2", Namespace: "test"},
+	}
+	err = v4Client.Update(ctx, cm)
+	if err != nil {
+		t.Errorf("Update() returned error: %v", err)
+	}
+	if _, ok := c.State["*v3.ClusterMaster-test-stack2"]; !ok {
+		t.Errorf("Update() didn't update the ClusterMaster custom resource")
+	}
+}
+
+func TestCheckV4ManagerName(t *testing.T) {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+
+	crCopy, err := newV4ManagerCopy(newTestV4ClusterManager())
+	if err != nil {
+		t.Fatalf("newV4ManagerCopy() returned error: %v", err)
+	}
+	err = checkV4ManagerName(ctx, c, crCopy)
+	if err != nil {
+		t.Errorf("checkV4ManagerName() returned error: %v", err)
+	}
+
+	// a ClusterMaster of another name doesn't conflict
+	c.AddObject(&enterpriseApi.ClusterMaster{
+		TypeMeta:   metav1.TypeMeta{Kind: "ClusterMaster"},
+		ObjectMeta: metav1.ObjectMeta{Name: "stack2", Namespace: "test"},
+	})
+	err = checkV4ManagerName(ctx, c, crCopy)
+	if err != nil {
+		t.Errorf("checkV4ManagerName() returned error: %v", err)
+	}
+
+	// a ClusterManager with the name of a ClusterMaster is rejected, with a warning event
+	c.AddObject(&enterpriseApi.ClusterMaster{
+		TypeMeta:   metav1.TypeMeta{Kind: "ClusterMaster"},
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+	})
+	err = checkV4ManagerName(ctx, c, crCopy)
+	if err == nil {
+		t.Errorf("checkV4ManagerName() didn't return an error for a ClusterMaster of the same name")
+	}
+	if len(c.Calls["Create"]) != 1 {
+		t.Fatalf("checkV4ManagerName() published %d events; want 1", len(c.Calls["Create"]))
+	}
+	event := c.Calls["Create"][0].Obj.(*corev1.Event)
+	if event.Reason != "NameConflict" || event.InvolvedObject.Kind != "ClusterManager" {
+		t.Errorf("checkV4ManagerName() published event %s on %s; want NameConflict on ClusterManager", event.Reason, event.InvolvedObject.Kind)
+	}
+
+	// the ClusterManager isn't reconciled
+	_, err = ApplyV4ClusterManager(ctx, c, newTestV4ClusterManager())
+	if err == nil {
+		t.Errorf("ApplyV4ClusterManager() didn't return an error for a ClusterMaster of the same name")
+	}
+
+	// same for a LicenseManager with the name of a LicenseMaster
+	lm := &enterpriseApiV4.LicenseManager{
+		TypeMeta:   metav1.TypeMeta{Kind: "LicenseManager", APIVersion: enterpriseApiV4.APIVersion},
+		ObjectMeta: metav1.ObjectMeta{Name: "lm", Namespace: "test"},
+	}
+	_, err = ApplyV4LicenseManager(ctx, c, lm)
+	if err != nil && err.Error() == "LicenseManager lm conflicts with LicenseMaster lm in namespace test" {
+		t.Errorf("ApplyV4LicenseManager() rejected a LicenseManager without a LicenseMaster of the same name")
+	}
+	c.AddObject(&enterpriseApi.LicenseMaster{
+		TypeMeta:   metav1.TypeMeta{Kind: "LicenseMaster"},
+		ObjectMeta: metav1.ObjectMeta{Name: "lm", Namespace: "test"},
+	})
+	_, err = ApplyV4LicenseManager(ctx, c, lm)
+	if err == nil || err.Error() != "LicenseManager lm conflicts with LicenseMaster lm in namespace test" {
+		t.Errorf("ApplyV4LicenseManager() returned %v; want a name conflict error", err)
+	}
+}
+
+func TestGetClusterManagerCR(t *testing.T) {
+	ctx := context.TODO()
+	c := spltest.NewMockClient()
+	namespacedName := types.NamespacedName{Name: "stack1", Namespace: "test"}
+
+	cr, err := getClusterManagerCR(ctx, c, namespacedName)
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("getClusterManagerCR() returned %v; want a not found error", err)
+	}
+	if cr == nil {
+		t.Errorf("getClusterManagerCR() returned a nil custom resource")
+	}
+
+	// a ClusterManager is returned as a ClusterMaster copy
+	c.AddObject(newTestV4ClusterManager())
+	cr, err = getClusterManagerCR(ctx, c, namespacedName)
+	if err != nil {
+		t.Errorf("getClusterManagerCR() returned error: %v", err)
+	}
+	if cr.GroupVersionKind().Kind != "ClusterManager" || cr.Spec.LicenseMasterRef.Name != "lm" {
+		t.Errorf("getClusterManagerCR() didn't return the ClusterManager copy")
+	}
+
+	// status updates keep the status of the copy
+	cr.Status.Phase = enterpriseApi.PhaseReady
+	latestCR, err := fetchCurrentCRWithStatusUpdate(ctx, c, cr)
+	if err != nil {
+		t.Errorf("fetchCurrentCRWithStatusUpdate() returned error: %v", err)
+	}
+	if latestCR.(*enterpriseApi.ClusterMaster).Status.Phase != enterpriseApi.PhaseReady {
+		t.Errorf("fetchCurrentCRWithStatusUpdate() didn't keep the status of the ClusterManager copy")
+	}
+
+	// a ClusterMaster takes precedence
+	c.AddObject(&enterpriseApi.ClusterMaster{
+		TypeMeta:   metav1.TypeMeta{Kind: "ClusterMaster"},
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+	})
+	cr, err = getClusterManagerCR(ctx, c, namespacedName)
+	if err != nil {
+		t.Errorf("getClusterManagerCR() returned error: %v", err)
+	}
+	if cr.GroupVersionKind().Kind != "ClusterMaster" {
+		t.Errorf("getClusterManagerCR() returned kind %s; want ClusterMaster", cr.GroupVersionKind().Kind)
+	}
+}
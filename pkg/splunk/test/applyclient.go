@@ -0,0 +1,89 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FakeApplyClient wraps a client, like the fake client of controller-runtime, which doesn't support server-side apply
+// patches. The applied objects are created when they don't exist, or merged into the existing objects: the maps are
+// merged field by field and the lists are replaced, which is close enough to apply for the objects of the operator
+type FakeApplyClient struct {
+	client.Client
+}
+
+// NewFakeApplyClient returns a FakeApplyClient wrapping c
+func NewFakeApplyClient(c client.Client) *FakeApplyClient {
+	return &FakeApplyClient{Client: c}
+}
+
+// Patch applies obj when patch is a server-side apply patch, and calls the wrapped client otherwise
+func (c *FakeApplyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	existing, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if k8serrors.IsNotFound(err) {
+		err = c.Client.Create(ctx, obj)
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	existingContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(existing)
+	if err != nil {
+		return err
+	}
+	appliedContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	mergeApplied(existingContent, appliedContent)
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(existingContent, obj)
+	if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	err = c.Client.Update(ctx, obj)
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	return err
+}
+
+// mergeApplied merges the fields of applied into existing
+func mergeApplied(existing, applied map[string]interface{}) {
+	for key, value := range applied {
+		appliedMap, isMap := value.(map[string]interface{})
+		existingMap, existingIsMap := existing[key].(map[string]interface{})
+		if isMap && existingIsMap {
+			mergeApplied(existingMap, appliedMap)
+			continue
+		}
+		existing[key] = value
+	}
+}
@@ -23,13 +23,17 @@ import (
 	"testing"
 
 	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+	enterpriseApiV4 "github.com/splunk/splunk-operator/api/v4"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -37,7 +41,7 @@ import (
 )
 
 func init() {
-	MockObjectCopiers = append(MockObjectCopiers, coreObjectCopier, appsObjectCopier, enterpriseObjCopier)
+	MockObjectCopiers = append(MockObjectCopiers, coreObjectCopier, appsObjectCopier, enterpriseObjCopier, networkingObjectCopier, unstructuredObjectCopier)
 	MockObjectListCopiers = append(MockObjectListCopiers, coreObjectListCopier, enterpriseObjListCopier)
 }
 
@@ -63,6 +67,10 @@ func enterpriseObjCopier(dst, src *client.Object) bool {
 		*dstP.(*enterpriseApi.Standalone) = *srcP.(*enterpriseApi.Standalone)
 	case *enterpriseApi.SearchHeadCluster:
 		*dstP.(*enterpriseApi.SearchHeadCluster) = *srcP.(*enterpriseApi.SearchHeadCluster)
+	case *enterpriseApiV4.ClusterManager:
+		*dstP.(*enterpriseApiV4.ClusterManager) = *srcP.(*enterpriseApiV4.ClusterManager)
+	case *enterpriseApiV4.LicenseManager:
+		*dstP.(*enterpriseApiV4.LicenseManager) = *srcP.(*enterpriseApiV4.LicenseManager)
 	default:
 		return false
 	}
@@ -116,6 +124,10 @@ func enterpriseObjListCopier(dst, src *client.ObjectList) bool {
 		*dstP.(*enterpriseApi.ClusterMasterList) = *srcP.(*enterpriseApi.ClusterMasterList)
 	case *enterpriseApi.StandaloneList:
 		*dstP.(*enterpriseApi.StandaloneList) = *srcP.(*enterpriseApi.StandaloneList)
+	case *enterpriseApiV4.ClusterManagerList:
+		*dstP.(*enterpriseApiV4.ClusterManagerList) = *srcP.(*enterpriseApiV4.ClusterManagerList)
+	case *enterpriseApiV4.LicenseManagerList:
+		*dstP.(*enterpriseApiV4.LicenseManagerList) = *srcP.(*enterpriseApiV4.LicenseManagerList)
 	default:
 		return false
 	}
@@ -154,6 +166,34 @@ func appsObjectCopier(dst, src *client.Object) bool {
 	return true
 }
 
+// networkingObjectCopier is used to copy networkingv1 client.Objects
+func networkingObjectCopier(dst, src *client.Object) bool {
+	srcP := *src
+	dstP := *dst
+	switch srcP.(type) {
+	case *networkingv1.Ingress:
+		*dstP.(*networkingv1.Ingress) = *srcP.(*networkingv1.Ingress)
+	case *networkingv1.NetworkPolicy:
+		*dstP.(*networkingv1.NetworkPolicy) = *srcP.(*networkingv1.NetworkPolicy)
+	default:
+		return false
+	}
+	return true
+}
+
+// unstructuredObjectCopier is used to copy the client.Objects of the kinds without Go types
+func unstructuredObjectCopier(dst, src *client.Object) bool {
+	srcP := *src
+	dstP := *dst
+	switch srcP.(type) {
+	case *unstructured.Unstructured:
+		srcP.(*unstructured.Unstructured).DeepCopyInto(dstP.(*unstructured.Unstructured))
+	default:
+		return false
+	}
+	return true
+}
+
 // copyMockObject uses the global MockObjectCopiers to perform the typed copy of a client.Object from src to dst
 func copyMockObject(dst, src *client.Object) {
 	for n := range MockObjectCopiers {
@@ -321,12 +361,35 @@ func (c MockClient) Update(ctx context.Context, obj client.Object, opts ...clien
 	return nil
 }
 
-// Patch returns mock client's Err field
+// Patch returns mock client's Err field. The objects of server-side apply patches are merged into the stored objects
 func (c MockClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
 	c.Calls["Patch"] = append(c.Calls["Patch"], MockFuncCall{
 		CTX: ctx,
 		Obj: obj,
 	})
+	if patch.Type() != types.ApplyPatchType {
+		return nil
+	}
+
+	// the applied fields are merged into the existing object, which keeps its status
+	if existing, ok := c.State[getStateKey(obj)].(client.Object); ok {
+		existingContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(existing)
+		if err != nil {
+			return err
+		}
+		appliedContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+		mergeApplied(existingContent, appliedContent)
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(existingContent, obj)
+		if err != nil {
+			return err
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	c.State[getStateKey(obj)] = obj
 	return nil
 }
 
@@ -431,8 +494,8 @@ func NewMockClient() *MockClient {
 // getStateKeyFromObject returns a lookup key for the MockClient's state map
 func getStateKey(obj client.Object) string {
 	key := client.ObjectKey{
-		Name:      obj.(splcommon.MetaObject).GetName(),
-		Namespace: obj.(splcommon.MetaObject).GetNamespace(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
 	}
 	return getStateKeyWithKey(key, obj)
 }
@@ -440,6 +503,9 @@ func getStateKey(obj client.Object) string {
 // getStateKey returns a lookup key for the MockClient's state map
 func getStateKeyWithKey(key client.ObjectKey, obj client.Object) string {
 	kind := reflect.TypeOf(obj).String()
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		kind = fmt.Sprintf("%s.%s", kind, u.GetKind())
+	}
 	return fmt.Sprintf("%s-%s-%s", kind, key.Namespace, key.Name)
 }
 
@@ -576,7 +642,7 @@ func PodManagerTester(t *testing.T, method string, mgr splcommon.StatefulSetPodM
 		{MetaName: "*v1.StatefulSet-test-splunk-stack1"},
 		{MetaName: "*v1.Pod-test-splunk-stack1-0"},
 	}
-	createCalls := map[string][]MockFuncCall{"Get": {funcCalls[0]}, "Create": {funcCalls[0]}}
+	createCalls := map[string][]MockFuncCall{"Get": {funcCalls[0]}, "Patch": {funcCalls[0]}}
 	var replicas int32 = 1
 	current := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -618,7 +684,7 @@ func PodManagerTester(t *testing.T, method string, mgr splcommon.StatefulSetPodM
 	// test update
 	revised := current.DeepCopy()
 	revised.Spec.Template.ObjectMeta.Labels = map[string]string{"one": "two"}
-	updateCalls := map[string][]MockFuncCall{"Get": {funcCalls[0], funcCalls[0]}, "Update": {funcCalls[0]}}
+	updateCalls := map[string][]MockFuncCall{"Get": {funcCalls[0]}, "Patch": {funcCalls[0]}}
 	methodPlus := fmt.Sprintf("%s(%s)", method, "Update StatefulSet")
 	PodManagerUpdateTester(t, methodPlus, mgr, 1, enterpriseApi.PhaseUpdating, revised, updateCalls, nil, current)
 
@@ -0,0 +1,135 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+
+	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+)
+
+const (
+	// tracerName is the name of the tracer used for all the spans of the operator
+	tracerName = "github.com/splunk/splunk-operator"
+
+	// serviceName is the service name reported with the spans of the operator
+	serviceName = "splunk-operator"
+)
+
+// Attributes of the spans of the operator
+const (
+	// AttributeCRName is the name of the custom resource being reconciled
+	AttributeCRName = attribute.Key("splunk.cr.name")
+
+	// AttributeCRNamespace is the namespace of the custom resource being reconciled
+	AttributeCRNamespace = attribute.Key("splunk.cr.namespace")
+
+	// AttributeCRKind is the kind of the custom resource being reconciled
+	AttributeCRKind = attribute.Key("splunk.cr.kind")
+
+	// AttributeObjectName is the name of the Kubernetes object applied by a span
+	AttributeObjectName = attribute.Key("k8s.object.name")
+
+	// AttributePodName is the name of the pod a span runs a command on
+	AttributePodName = attribute.Key("k8s.pod.name")
+
+	// AttributeAppName is the name of the app package handled by a span
+	AttributeAppName = attribute.Key("splunk.app.name")
+)
+
+// crAttributesKey is the context key of the attributes of the custom resource being reconciled
+type crAttributesKey struct{}
+
+// Tracer returns the tracer of the operator. Spans are dropped until a tracer provider is set up
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// WithCR returns a copy of ctx which adds the name, namespace and kind of cr to all the spans started from it. ctx is
+// returned as is when cr is nil
+func WithCR(ctx context.Context, cr splcommon.MetaObject) context.Context {
+	if cr == nil {
+		return ctx
+	}
+	attrs := []attribute.KeyValue{
+		AttributeCRName.String(cr.GetName()),
+		AttributeCRNamespace.String(cr.GetNamespace()),
+	}
+	if kind := cr.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		attrs = append(attrs, AttributeCRKind.String(kind))
+	}
+	return context.WithValue(ctx, crAttributesKey{}, attrs)
+}
+
+// StartSpan starts a span with the attributes of the custom resource of ctx, if any
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if crAttrs, ok := ctx.Value(crAttributesKey{}).([]attribute.KeyValue); ok {
+		attrs = append(attrs, crAttrs...)
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartCRSpan starts a span for cr, whose name, namespace and kind are added to all the spans started from the returned context
+func StartCRSpan(ctx context.Context, name string, cr splcommon.MetaObject, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return StartSpan(WithCR(ctx, cr), name, attrs...)
+}
+
+// RecordError records err on span, and marks the span as failed
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Setup exports the spans of the operator to an OTLP HTTP collector at endpoint (host:port), sampling sampleRatio of the
+// traces. Tracing is disabled when endpoint is empty. The returned function flushes the spans and stops the exporter
+func Setup(ctx context.Context, endpoint string, insecure bool, sampleRatio float64) (func(context.Context) error, error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if sampleRatio < 0 || sampleRatio > 1 {
+		return nil, fmt.Errorf("invalid tracing sample ratio %v, it must be between 0 and 1", sampleRatio)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the OTLP trace exporter, error: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
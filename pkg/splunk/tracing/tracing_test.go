@@ -0,0 +1,162 @@
+// Copyright (c) 2018-2022 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterpriseApi "github.com/splunk/splunk-operator/api/v3"
+)
+
+func newTestCR() *enterpriseApi.Standalone {
+	return &enterpriseApi.Standalone{
+		TypeMeta:   metav1.TypeMeta{Kind: "Standalone"},
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+	}
+}
+
+func getSpanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestStartCRSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	ctx, span := StartCRSpan(context.TODO(), "ApplyStandalone", newTestCR())
+	_, childSpan := StartSpan(ctx, "ApplyStatefulSet", AttributeObjectName.String("splunk-stack1-standalone"))
+	RecordError(childSpan, fmt.Errorf("statefulset update failed"))
+	childSpan.End()
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans; want 2", len(spans))
+	}
+
+	// the child span has the attributes of the custom resource of its parent
+	child := spans[0]
+	if child.Name() != "ApplyStatefulSet" || child.Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Errorf("span %q isn't a child of %q", child.Name(), spans[1].Name())
+	}
+	for key, want := range map[attribute.Key]string{
+		AttributeCRName:      "stack1",
+		AttributeCRNamespace: "test",
+		AttributeCRKind:      "Standalone",
+		AttributeObjectName:  "splunk-stack1-standalone",
+	} {
+		if got := getSpanAttribute(child, key); got != want {
+			t.Errorf("span attribute %s = %q; want %q", key, got, want)
+		}
+	}
+	if child.Status().Code != codes.Error || len(child.Events()) != 1 {
+		t.Errorf("RecordError() didn't record the error on the span")
+	}
+	if spans[1].Status().Code == codes.Error {
+		t.Errorf("span %q has an error status", spans[1].Name())
+	}
+}
+
+func TestSetup(t *testing.T) {
+	// tracing is disabled without an endpoint
+	shutdown, err := Setup(context.TODO(), "", false, 1)
+	if err != nil {
+		t.Errorf("Setup() returned error: %v", err)
+	}
+	if err = shutdown(context.TODO()); err != nil {
+		t.Errorf("shutdown() returned error: %v", err)
+	}
+
+	_, err = Setup(context.TODO(), "localhost:4318", false, 2)
+	if err == nil {
+		t.Errorf("Setup() didn't return an error for an invalid sample ratio")
+	}
+
+	// in-process OTLP HTTP collector
+	var mutex sync.Mutex
+	var received []*coltracepb.ExportTraceServiceRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil || r.URL.Path != "/v1/traces" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		request := &coltracepb.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(body, request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mutex.Lock()
+		received = append(received, request)
+		mutex.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	shutdown, err = Setup(context.TODO(), collector.Listener.Addr().String(), true, 1)
+	if err != nil {
+		t.Fatalf("Setup() returned error: %v", err)
+	}
+	_, span := StartCRSpan(context.TODO(), "ApplyStandalone", newTestCR())
+	span.End()
+	if err = shutdown(context.TODO()); err != nil {
+		t.Errorf("shutdown() returned error: %v", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("collector received %d requests; want 1", len(received))
+	}
+	resourceSpans := received[0].GetResourceSpans()
+	if len(resourceSpans) != 1 || len(resourceSpans[0].GetScopeSpans()) != 1 {
+		t.Fatalf("collector received %d resource spans; want 1", len(resourceSpans))
+	}
+	spans := resourceSpans[0].GetScopeSpans()[0].GetSpans()
+	if len(spans) != 1 || spans[0].GetName() != "ApplyStandalone" {
+		t.Fatalf("collector didn't receive the ApplyStandalone span")
+	}
+	attrs := map[string]string{}
+	for _, attr := range spans[0].GetAttributes() {
+		attrs[attr.GetKey()] = attr.GetValue().GetStringValue()
+	}
+	if attrs[string(AttributeCRName)] != "stack1" || attrs[string(AttributeCRNamespace)] != "test" {
+		t.Errorf("collector received span attributes %v; want the name and namespace of the custom resource", attrs)
+	}
+}
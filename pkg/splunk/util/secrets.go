@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -382,6 +383,9 @@ splunk:
 
 // ApplySplunkSecret creates/updates a secret using secretData(which HAS to be of ansible readable format) or namespace scoped secret data if not specified
 func ApplySplunkSecret(ctx context.Context, c splcommon.ControllerClient, cr splcommon.MetaObject, secretData map[string][]byte, secretName string, namespace string) (*corev1.Secret, error) {
+	ctx, span := tracing.StartCRSpan(ctx, "ApplySplunkSecret", cr, tracing.AttributeObjectName.String(secretName))
+	defer span.End()
+
 	var current corev1.Secret
 	var newSecretData map[string][]byte
 	var err error
@@ -438,6 +442,9 @@ func ApplySplunkSecret(ctx context.Context, c splcommon.ControllerClient, cr spl
 
 // ApplyNamespaceScopedSecretObject creates/updates the namespace scoped K8S secret object
 func ApplyNamespaceScopedSecretObject(ctx context.Context, client splcommon.ControllerClient, namespace string) (*corev1.Secret, error) {
+	ctx, span := tracing.StartSpan(ctx, "ApplyNamespaceScopedSecretObject")
+	defer span.End()
+
 	var current corev1.Secret
 
 	name := splcommon.GetNamespaceScopedSecretName(namespace)
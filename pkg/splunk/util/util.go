@@ -23,6 +23,8 @@ import (
 	"strings"
 
 	splcommon "github.com/splunk/splunk-operator/pkg/splunk/common"
+	"github.com/splunk/splunk-operator/pkg/splunk/concurrency"
+	"github.com/splunk/splunk-operator/pkg/splunk/tracing"
 	corev1 "k8s.io/api/core/v1"
 	errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -33,6 +35,7 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
@@ -113,6 +116,28 @@ func UpdateResource(ctx context.Context, client splcommon.ControllerClient, obj
 	return nil
 }
 
+// ApplyResource creates or updates a Kubernetes resource with server-side apply, under the field manager of the
+// operator. The operator owns the fields set in obj, and force takes over the fields owned by other field managers.
+func ApplyResource(ctx context.Context, c splcommon.ControllerClient, obj client.Object, force bool) error {
+	reqLogger := log.FromContext(ctx)
+	scopedLog := reqLogger.WithName("ApplyResource").WithValues(
+		"name", obj.GetName(),
+		"namespace", obj.GetNamespace())
+
+	opts := []client.PatchOption{client.FieldOwner(splcommon.OperatorFieldManager)}
+	if force {
+		opts = append(opts, client.ForceOwnership)
+	}
+	err := c.Patch(ctx, obj, client.Apply, opts...)
+	if err != nil {
+		scopedLog.Error(err, "Failed to apply resource", "kind", obj.GetObjectKind())
+		return err
+	}
+	scopedLog.Info("Applied resource", "kind", obj.GetObjectKind())
+
+	return nil
+}
+
 // DeleteResource deletes an existing Kubernetes resource using the REST API.
 func DeleteResource(ctx context.Context, client splcommon.ControllerClient, obj splcommon.MetaObject) error {
 	reqLogger := log.FromContext(ctx)
@@ -233,11 +258,21 @@ func GetPodExecClient(client splcommon.ControllerClient, cr splcommon.MetaObject
 
 // RunPodExecCommand runs the specific pod exec command
 func (podExecClient *PodExecClient) RunPodExecCommand(ctx context.Context, streamOptions *remotecommand.StreamOptions, baseCmd []string) (string, string, error) {
+	ctx, span := tracing.StartCRSpan(ctx, "RunPodExecCommand", podExecClient.cr, tracing.AttributePodName.String(podExecClient.targetPodName))
+	defer span.End()
+
 	reqLogger := log.FromContext(ctx)
+	ctx, release, err := concurrency.AcquireHeavyOperation(ctx, concurrency.OperationPodExec)
+	if err != nil {
+		return "", "", err
+	}
+	defer release()
+
 	errmsg := ""
 	stdOut, stdErr, err := PodExecCommand(ctx, podExecClient.client, podExecClient.targetPodName, podExecClient.cr.GetNamespace(), baseCmd, streamOptions, false, false)
 	if err != nil {
 		errmsg = err.Error()
+		tracing.RecordError(span, err)
 	}
 	reqLogger.Info("podexec call returned", "cmd", strings.Join(baseCmd, " "), "stdout", stdOut, "stderr", stdErr, "err", errmsg)
 	// Note: splunk 9.0 throws warning message "warning: server certificate hostname validation is disabled. please see server.conf/[sslconfig]/cliverifyservername for details.\n"